@@ -0,0 +1,67 @@
+package autodiff
+
+import (
+	"exprtree/expr"
+	"exprtree/value"
+	"fmt"
+)
+
+// Forward evaluates e at point and returns its value and its partial
+// derivatives with respect to the variables in wrt, in order
+// All partial derivatives are computed in a single pass by seeding one dual
+// direction per variable. Every variable of e must be bound in point.
+func Forward(e expr.Expr, point map[string]float64, wrt ...string) (float64, []float64, error) {
+	seeds := make(map[string][]float64, len(wrt))
+	for i, name := range wrt {
+		if _, ok := point[name]; !ok {
+			return 0, nil, fmt.Errorf("no value for variable: %s", name)
+		}
+		seed := make([]float64, len(wrt))
+		seed[i] = 1
+		seeds[name] = seed
+	}
+	return Directional(e, point, seeds, len(wrt))
+}
+
+// Derivative returns the value of e at point and its derivative with respect to x
+func Derivative(e expr.Expr, point map[string]float64, x string) (float64, float64, error) {
+	v, d, err := Forward(e, point, x)
+	if err != nil {
+		return 0, 0, err
+	}
+	return v, d[0], nil
+}
+
+// Directional evaluates e at point along arbitrary seed directions
+// seeds maps a variable to its tangent in each of the n directions;
+// variables without a seed are held constant. The i-th returned derivative
+// is the directional derivative along the i-th tangent.
+func Directional(e expr.Expr, point map[string]float64, seeds map[string][]float64, n int) (float64, []float64, error) {
+	env := make(map[string]value.Value, len(point))
+	for name, v := range point {
+		seed, ok := seeds[name]
+		if !ok {
+			seed = make([]float64, n)
+		}
+		if len(seed) != n {
+			return 0, nil, fmt.Errorf("seed for %s has %d directions, expected %d", name, len(seed), n)
+		}
+		env[name] = value.NewDualValue(v, seed...)
+	}
+
+	result, ok := expr.EvalWith(e, env)
+	if !ok {
+		return 0, nil, fmt.Errorf("evaluation failed")
+	}
+
+	switch r := result.(type) {
+	case *value.DualValue:
+		return r.Float64(), r.Derivatives(), nil
+	case *value.RealValue, *value.IntegerValue:
+		// e does not depend on any bound variable; 5! evaluates to an integer
+		realValue, _ := value.ToReal(r)
+		return realValue.Float64(), make([]float64, n), nil
+	default:
+		return 0, nil, fmt.Errorf("result is not a number (got %T)", result)
+	}
+}
@@ -0,0 +1,104 @@
+package autodiff_test
+
+import (
+	"exprtree/autodiff"
+	"exprtree/expr"
+	"exprtree/latex"
+	"math"
+	"testing"
+)
+
+func parseExpr(t *testing.T, input string) expr.Expr {
+	t.Helper()
+	parsed, err := latex.ParseLatex(input)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", input, err)
+	}
+	expression, ok := parsed.(expr.Expr)
+	if !ok {
+		t.Fatalf("expected Expression, got %T", parsed)
+	}
+	return expression
+}
+
+func assertClose(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s: expected %g, got %g", name, want, got)
+	}
+}
+
+func TestDerivative(t *testing.T) {
+	tests := []struct {
+		input    string
+		x        float64
+		value    float64
+		expected float64
+	}{
+		{"x^2", 3, 9, 6},
+		{"3x + 2", 1, 5, 3},
+		{"1 / x", 2, 0.5, -0.25},
+		{"\\sqrt{x}", 4, 2, 0.25},
+		{"\\sqrt[3]{x}", 8, 2, 1.0 / 12},
+		{"2^x", 3, 8, 8 * math.Ln2},
+		{"(x + 1)(x - 1)", 2, 3, 4},
+		{"-x^3", 2, -8, -12},
+		{"5", 2, 5, 0},
+		{"5!", 2, 120, 0},
+		{"\\binom{5}{2}", 2, 10, 0},
+		{"\\binom{5}{2} x", 2, 20, 10},
+		{"\\sin x^2", 1, math.Sin(1), 2 * math.Cos(1)},
+		{"\\ln x", 2, math.Ln2, 0.5},
+		{"\\log_{2} x", 4, 2, 1 / (4 * math.Ln2)},
+		{"\\exp(2x)", 0.5, math.E, 2 * math.E},
+		{"\\sum_{i=1}^{3} x^i", 2, 14, 17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			v, d, err := autodiff.Derivative(parseExpr(t, tt.input), map[string]float64{"x": tt.x}, "x")
+			if err != nil {
+				t.Fatalf("Derivative failed: %v", err)
+			}
+			assertClose(t, "value", v, tt.value)
+			assertClose(t, "derivative", d, tt.expected)
+		})
+	}
+}
+
+func TestForward_Gradient(t *testing.T) {
+	e := parseExpr(t, "x^2 y + \\sqrt{x^2 + y^2}")
+	point := map[string]float64{"x": 3, "y": 4}
+
+	v, grad, err := autodiff.Forward(e, point, "x", "y")
+	if err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+	assertClose(t, "value", v, 41)
+	assertClose(t, "d/dx", grad[0], 2*3*4+3.0/5)
+	assertClose(t, "d/dy", grad[1], 9+4.0/5)
+}
+
+func TestDirectional(t *testing.T) {
+	e := parseExpr(t, "x y")
+	point := map[string]float64{"x": 2, "y": 5}
+	seeds := map[string][]float64{"x": {1}, "y": {1}}
+
+	_, d, err := autodiff.Directional(e, point, seeds, 1)
+	if err != nil {
+		t.Fatalf("Directional failed: %v", err)
+	}
+	assertClose(t, "directional", d[0], 7)
+}
+
+func TestForward_Errors(t *testing.T) {
+	if _, _, err := autodiff.Forward(parseExpr(t, "x + y"), map[string]float64{"x": 1}, "x"); err == nil {
+		t.Errorf("expected error for unbound variable")
+	}
+	if _, _, err := autodiff.Forward(parseExpr(t, "1 / x"), map[string]float64{"x": 0}, "x"); err == nil {
+		t.Errorf("expected error for division by zero")
+	}
+	if _, _, err := autodiff.Forward(parseExpr(t, "x"), map[string]float64{}, "x"); err == nil {
+		t.Errorf("expected error for missing seed variable")
+	}
+}
@@ -0,0 +1,207 @@
+package autodiff
+
+import (
+	"exprtree/expr"
+	"exprtree/value"
+	"fmt"
+	"math"
+)
+
+// tapeNode is one recorded operation
+// Each node has at most two parents with the local partial derivative of
+// the node with respect to each parent.
+type tapeNode struct {
+	value    float64
+	parents  [2]int // -1 if absent
+	partials [2]float64
+}
+
+// Tape records the evaluation of Expression trees for reverse-mode
+// differentiation
+// Variables are shared between recordings, so several outputs recorded on
+// the same tape can be differentiated with respect to the same inputs.
+type Tape struct {
+	nodes []tapeNode
+	vars  map[string]int // variable name -> node index
+}
+
+// NewTape creates a new empty Tape
+func NewTape() *Tape {
+	return &Tape{
+		vars: map[string]int{},
+	}
+}
+
+// Record evaluates e at point, records every operation on the tape and
+// returns the index of the output node
+func (t *Tape) Record(e expr.Expr, point map[string]float64) (int, error) {
+	if e == nil {
+		return 0, fmt.Errorf("cannot record nil expression")
+	}
+
+	switch n := e.(type) {
+	case *expr.Constant:
+		realValue, ok := value.ToReal(n.Value())
+		if !ok {
+			return 0, fmt.Errorf("unsupported constant value type: %T", n.Value())
+		}
+		return t.push(realValue.Float64()), nil
+	case *expr.Variable:
+		return t.variable(n.Name(), point)
+	case *expr.Add:
+		return t.recordBinary(n.Left(), n.Right(), point, func(a, b float64) (float64, float64, float64, error) {
+			return a + b, 1, 1, nil
+		})
+	case *expr.Sub:
+		return t.recordBinary(n.Left(), n.Right(), point, func(a, b float64) (float64, float64, float64, error) {
+			return a - b, 1, -1, nil
+		})
+	case *expr.Mul:
+		return t.recordBinary(n.Left(), n.Right(), point, func(a, b float64) (float64, float64, float64, error) {
+			return a * b, b, a, nil
+		})
+	case *expr.Div:
+		return t.recordBinary(n.Left(), n.Right(), point, func(a, b float64) (float64, float64, float64, error) {
+			if b == 0 {
+				return 0, 0, 0, fmt.Errorf("division by zero")
+			}
+			return a / b, 1 / b, -a / (b * b), nil
+		})
+	case *expr.Power:
+		return t.recordBinary(n.Base(), n.Exponent(), point, func(a, b float64) (float64, float64, float64, error) {
+			v := math.Pow(a, b)
+			return v, b * math.Pow(a, b-1), v * math.Log(a), nil
+		})
+	case *expr.NthRoot:
+		return t.recordBinary(n.Radicand(), n.Degree(), point, func(a, b float64) (float64, float64, float64, error) {
+			if b == 0 {
+				return 0, 0, 0, fmt.Errorf("zero root degree")
+			}
+			v := math.Pow(a, 1/b)
+			return v, math.Pow(a, 1/b-1) / b, -v * math.Log(a) / (b * b), nil
+		})
+	case *expr.Function:
+		return t.recordUnary(n.Argument(), point, n.Kind().Apply)
+	case *expr.Neg:
+		return t.recordUnary(n.Operand(), point, value.Neg)
+	case *expr.Abs:
+		return t.recordUnary(n.Operand(), point, value.Abs)
+	case *expr.Floor:
+		return t.recordUnary(n.Operand(), point, value.Floor)
+	case *expr.Ceil:
+		return t.recordUnary(n.Operand(), point, value.Ceil)
+	case *expr.Log:
+		return t.recordBinary(n.Base(), n.Argument(), point, func(a, b float64) (float64, float64, float64, error) {
+			// partial derivatives from a dual evaluation with one direction per operand
+			result, ok := value.Log(value.NewDualValue(a, 1, 0), value.NewDualValue(b, 0, 1))
+			if !ok {
+				return 0, 0, 0, fmt.Errorf("logarithm out of domain")
+			}
+			dual := result.(*value.DualValue)
+			return dual.Float64(), dual.Derivative(0), dual.Derivative(1), nil
+		})
+	default:
+		return 0, fmt.Errorf("unsupported expression type: %T", e)
+	}
+}
+
+// recordBinary records both operands and the node combining them
+// op returns the value and the partial derivatives with respect to a and b.
+func (t *Tape) recordBinary(left, right expr.Expr, point map[string]float64, op func(a, b float64) (float64, float64, float64, error)) (int, error) {
+	l, err := t.Record(left, point)
+	if err != nil {
+		return 0, err
+	}
+	r, err := t.Record(right, point)
+	if err != nil {
+		return 0, err
+	}
+	v, da, db, err := op(t.nodes[l].value, t.nodes[r].value)
+	if err != nil {
+		return 0, err
+	}
+	t.nodes = append(t.nodes, tapeNode{value: v, parents: [2]int{l, r}, partials: [2]float64{da, db}})
+	return len(t.nodes) - 1, nil
+}
+
+// recordUnary records a unary function application
+// The local derivative is taken from a one-direction dual evaluation of f.
+func (t *Tape) recordUnary(operand expr.Expr, point map[string]float64, f func(value.Value) (value.Value, bool)) (int, error) {
+	arg, err := t.Record(operand, point)
+	if err != nil {
+		return 0, err
+	}
+	result, ok := f(value.NewDualValue(t.nodes[arg].value, 1))
+	if !ok {
+		return 0, fmt.Errorf("argument out of domain")
+	}
+	dual := result.(*value.DualValue)
+	t.nodes = append(t.nodes, tapeNode{value: dual.Float64(), parents: [2]int{arg, -1}, partials: [2]float64{dual.Derivative(0)}})
+	return len(t.nodes) - 1, nil
+}
+
+// variable returns the node of a variable, creating it on first use
+func (t *Tape) variable(name string, point map[string]float64) (int, error) {
+	if index, ok := t.vars[name]; ok {
+		return index, nil
+	}
+	v, ok := point[name]
+	if !ok {
+		return 0, fmt.Errorf("no value for variable: %s", name)
+	}
+	index := t.push(v)
+	t.vars[name] = index
+	return index, nil
+}
+
+// push appends a leaf node and returns its index
+func (t *Tape) push(v float64) int {
+	t.nodes = append(t.nodes, tapeNode{value: v, parents: [2]int{-1, -1}})
+	return len(t.nodes) - 1
+}
+
+// Value returns the value recorded for a node
+func (t *Tape) Value(node int) float64 {
+	return t.nodes[node].value
+}
+
+// Backward propagates adjoints from the output node back to the inputs and
+// returns the gradient of the output with respect to every variable
+func (t *Tape) Backward(output int) map[string]float64 {
+	adjoints := make([]float64, output+1)
+	adjoints[output] = 1
+
+	for i := output; i >= 0; i-- {
+		if adjoints[i] == 0 {
+			continue
+		}
+		node := t.nodes[i]
+		for k, parent := range node.parents {
+			if parent >= 0 {
+				adjoints[parent] += adjoints[i] * node.partials[k]
+			}
+		}
+	}
+
+	gradient := make(map[string]float64, len(t.vars))
+	for name, index := range t.vars {
+		if index <= output {
+			gradient[name] = adjoints[index]
+		} else {
+			gradient[name] = 0
+		}
+	}
+	return gradient
+}
+
+// Reverse evaluates e at point and returns its value and its gradient with
+// respect to every variable occurring in e
+// Unlike Forward, the cost does not grow with the number of variables.
+func Reverse(e expr.Expr, point map[string]float64) (float64, map[string]float64, error) {
+	tape := NewTape()
+	output, err := tape.Record(e, point)
+	if err != nil {
+		return 0, nil, err
+	}
+	return tape.Value(output), tape.Backward(output), nil
+}
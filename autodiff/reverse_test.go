@@ -0,0 +1,119 @@
+package autodiff_test
+
+import (
+	"exprtree/autodiff"
+	"exprtree/expr"
+	"exprtree/value"
+	"fmt"
+	"testing"
+)
+
+func TestReverse(t *testing.T) {
+	e := parseExpr(t, "x^2 y + \\sqrt{x^2 + y^2} / z")
+	point := map[string]float64{"x": 3, "y": 4, "z": 2}
+
+	v, grad, err := autodiff.Reverse(e, point)
+	if err != nil {
+		t.Fatalf("Reverse failed: %v", err)
+	}
+	assertClose(t, "value", v, 38.5)
+	assertClose(t, "d/dx", grad["x"], 24+3.0/10)
+	assertClose(t, "d/dy", grad["y"], 9+4.0/10)
+	assertClose(t, "d/dz", grad["z"], -5.0/4)
+}
+
+func TestReverse_ExactConstants(t *testing.T) {
+	// Scientific notation and long literals read as exact integer constants
+	e := parseExpr(t, "6.02 \\times 10^{23} x^2 + 12345678901234567890 y")
+	point := map[string]float64{"x": 2, "y": 1}
+
+	v, grad, err := autodiff.Reverse(e, point)
+	if err != nil {
+		t.Fatalf("Reverse failed: %v", err)
+	}
+	assertClose(t, "value", v, 4*6.02e23+12345678901234567890)
+	assertClose(t, "d/dx", grad["x"], 4*6.02e23)
+	assertClose(t, "d/dy", grad["y"], 12345678901234567890)
+}
+
+func TestReverse_MatchesForward(t *testing.T) {
+	tests := []string{
+		"x^3 - 2xy + y^2",
+		"(x + y) / (x - y)",
+		"\\sqrt[3]{x y}",
+		"x^y",
+		"\\sin(x y) + \\tanh x",
+		"\\log_{x} y",
+	}
+	point := map[string]float64{"x": 2.5, "y": 1.5}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			e := parseExpr(t, input)
+			_, forward, err := autodiff.Forward(e, point, "x", "y")
+			if err != nil {
+				t.Fatalf("Forward failed: %v", err)
+			}
+			_, reverse, err := autodiff.Reverse(e, point)
+			if err != nil {
+				t.Fatalf("Reverse failed: %v", err)
+			}
+			assertClose(t, "d/dx", reverse["x"], forward[0])
+			assertClose(t, "d/dy", reverse["y"], forward[1])
+		})
+	}
+}
+
+func TestReverse_ManyParameters(t *testing.T) {
+	// sum of p_i * i^2 over 200 parameters
+	point := map[string]float64{}
+	var e expr.Expr = expr.NewConstant(value.NewRealValue(0))
+	for i := 0; i < 200; i++ {
+		name := fmt.Sprintf("p%d", i)
+		point[name] = 1
+		term := expr.NewMul(expr.NewVariable(name), expr.NewConstant(value.NewRealValue(float64(i*i))))
+		e = expr.NewAdd(e, term)
+	}
+
+	_, grad, err := autodiff.Reverse(e, point)
+	if err != nil {
+		t.Fatalf("Reverse failed: %v", err)
+	}
+	if len(grad) != 200 {
+		t.Fatalf("expected 200 partial derivatives, got %d", len(grad))
+	}
+	for i := 0; i < 200; i++ {
+		assertClose(t, fmt.Sprintf("d/dp%d", i), grad[fmt.Sprintf("p%d", i)], float64(i*i))
+	}
+}
+
+func TestTape_SharedVariables(t *testing.T) {
+	tape := autodiff.NewTape()
+	point := map[string]float64{"x": 2, "y": 3}
+
+	first, err := tape.Record(parseExpr(t, "x y"), point)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	second, err := tape.Record(parseExpr(t, "x + x"), point)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	grad := tape.Backward(first)
+	assertClose(t, "d(xy)/dx", grad["x"], 3)
+	assertClose(t, "d(xy)/dy", grad["y"], 2)
+
+	grad = tape.Backward(second)
+	assertClose(t, "d(2x)/dx", grad["x"], 2)
+	assertClose(t, "d(2x)/dy", grad["y"], 0)
+}
+
+func TestReverse_Errors(t *testing.T) {
+	if _, _, err := autodiff.Reverse(parseExpr(t, "x / y"), map[string]float64{"x": 1, "y": 0}); err == nil {
+		t.Errorf("expected error for division by zero")
+	}
+	if _, _, err := autodiff.Reverse(parseExpr(t, "x + y"), map[string]float64{"x": 1}); err == nil {
+		t.Errorf("expected error for unbound variable")
+	}
+}
@@ -0,0 +1,462 @@
+package batch
+
+import (
+	"exprtree/expr"
+	"exprtree/prop"
+	"exprtree/value"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Evaluator evaluates an Expression over columns of data
+// The tree is walked once per chunk and every node is applied to whole
+// slices, instead of walking the tree once per row.
+type Evaluator struct {
+	// Workers is the number of goroutines the rows are split across.
+	// Values less than 2 evaluate on the calling goroutine.
+	Workers int
+}
+
+// NewEvaluator creates a new Evaluator instance
+func NewEvaluator() *Evaluator {
+	return &Evaluator{Workers: 1}
+}
+
+// Eval evaluates expression for every row of columns and returns the result column
+// All columns must have the same length, and every variable in expression must
+// have a column. Rows where evaluation fails (division by zero, zero root
+// degree) are NaN. Propositions yield 1 for true and 0 for false. An
+// expression without variables may be given no columns and yields one row.
+// Integrals, limits and calls of user-defined functions have no column-wise
+// form and are evaluated row by row through their Eval methods.
+func (ev *Evaluator) Eval(expression expr.Expr, columns map[string][]float64) ([]float64, error) {
+	if expression == nil {
+		return nil, fmt.Errorf("cannot evaluate nil expression")
+	}
+	n, err := columnLength(columns)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSupported(expression, columns); err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, n)
+	workers := ev.Workers
+	if workers > n {
+		workers = n
+	}
+	if workers < 2 {
+		copy(result, evalRange(expression, columns, 0, n))
+		return result, nil
+	}
+
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += chunk {
+		hi := min(lo+chunk, n)
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			copy(result[lo:hi], evalRange(expression, columns, lo, hi))
+		}(lo, hi)
+	}
+	wg.Wait()
+
+	return result, nil
+}
+
+// columnLength returns the common length of all columns, or 1 if there
+// are none
+// Without columns only a constant expression passes checkSupported, and
+// its single row is the constant.
+func columnLength(columns map[string][]float64) (int, error) {
+	if len(columns) == 0 {
+		return 1, nil
+	}
+	n := -1
+	for name, column := range columns {
+		if n >= 0 && len(column) != n {
+			return 0, fmt.Errorf("column %s has length %d, expected %d", name, len(column), n)
+		}
+		n = len(column)
+	}
+	return n, nil
+}
+
+// checkSupported reports unsupported nodes and variables without a column
+// evalRange relies on this check and does not report errors itself.
+func checkSupported(expression expr.Expr, columns map[string][]float64) error {
+	switch e := expression.(type) {
+	case *expr.Constant:
+		if _, ok := value.ToReal(e.Value()); !ok {
+			return fmt.Errorf("unsupported constant value type: %T", e.Value())
+		}
+		return nil
+	case *expr.Variable:
+		if _, ok := columns[e.Name()]; !ok {
+			return fmt.Errorf("no column for variable: %s", e.Name())
+		}
+		return nil
+	case *expr.Add, *expr.Sub, *expr.Mul, *expr.Div, *expr.Power:
+		binary := e.(expr.Binary)
+		if err := checkSupported(binary.Left(), columns); err != nil {
+			return err
+		}
+		return checkSupported(binary.Right(), columns)
+	case *expr.NthRoot:
+		if err := checkSupported(e.Radicand(), columns); err != nil {
+			return err
+		}
+		return checkSupported(e.Degree(), columns)
+	case *expr.Function:
+		return checkSupported(e.Argument(), columns)
+	case *expr.Neg, *expr.Abs, *expr.Floor, *expr.Ceil, *expr.Factorial:
+		return checkSupported(e.(expr.Unary).Operand(), columns)
+	case *expr.Binomial:
+		if err := checkSupported(e.N(), columns); err != nil {
+			return err
+		}
+		return checkSupported(e.K(), columns)
+	case *expr.Permutation:
+		if err := checkSupported(e.N(), columns); err != nil {
+			return err
+		}
+		return checkSupported(e.K(), columns)
+	case *expr.Sum, *expr.Product:
+		indexed := e.(expr.Indexed)
+		if err := checkSupported(indexed.Lower(), columns); err != nil {
+			return err
+		}
+		if err := checkSupported(indexed.Upper(), columns); err != nil {
+			return err
+		}
+		return checkSupported(indexed.Body(), withIndex(columns, indexed.Index(), nil))
+	case *expr.Log:
+		if err := checkSupported(e.Base(), columns); err != nil {
+			return err
+		}
+		return checkSupported(e.Argument(), columns)
+	case *prop.Equal:
+		if err := checkSupported(e.Left(), columns); err != nil {
+			return err
+		}
+		return checkSupported(e.Right(), columns)
+	case *prop.Inequality:
+		if err := checkSupported(e.Left(), columns); err != nil {
+			return err
+		}
+		return checkSupported(e.Right(), columns)
+	case *prop.And:
+		if err := checkSupported(e.Left(), columns); err != nil {
+			return err
+		}
+		return checkSupported(e.Right(), columns)
+	case *expr.Piecewise:
+		for _, branch := range e.Branches() {
+			if err := checkSupported(branch.Condition, columns); err != nil {
+				return err
+			}
+			if err := checkSupported(branch.Value, columns); err != nil {
+				return err
+			}
+		}
+		if e.Otherwise() == nil {
+			return nil
+		}
+		return checkSupported(e.Otherwise(), columns)
+	case *expr.Integral, *expr.Limit, *expr.Call:
+		// Evaluated row by row, so only the free variables need columns
+		for _, name := range expr.FreeVariables(e) {
+			if _, ok := columns[name]; !ok {
+				return fmt.Errorf("no column for variable: %s", name)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported expression type: %T", expression)
+	}
+}
+
+// evalRange evaluates expression for rows [lo, hi)
+// The returned slice is owned by the caller and may be modified.
+func evalRange(expression expr.Expr, columns map[string][]float64, lo, hi int) []float64 {
+	switch e := expression.(type) {
+	case *expr.Constant:
+		out := make([]float64, hi-lo)
+		realValue, _ := value.ToReal(e.Value())
+		v := realValue.Float64()
+		for i := range out {
+			out[i] = v
+		}
+		return out
+	case *expr.Variable:
+		out := make([]float64, hi-lo)
+		copy(out, columns[e.Name()][lo:hi])
+		return out
+	case *expr.Add:
+		return combine(e.Left(), e.Right(), columns, lo, hi, func(a, b float64) float64 { return a + b })
+	case *expr.Sub:
+		return combine(e.Left(), e.Right(), columns, lo, hi, func(a, b float64) float64 { return a - b })
+	case *expr.Mul:
+		return combine(e.Left(), e.Right(), columns, lo, hi, func(a, b float64) float64 { return a * b })
+	case *expr.Div:
+		return combine(e.Left(), e.Right(), columns, lo, hi, func(a, b float64) float64 {
+			if b == 0 {
+				return math.NaN()
+			}
+			return a / b
+		})
+	case *expr.Power:
+		return combine(e.Left(), e.Right(), columns, lo, hi, math.Pow)
+	case *expr.NthRoot:
+		return combine(e.Radicand(), e.Degree(), columns, lo, hi, func(a, b float64) float64 {
+			if b == 0 {
+				return math.NaN()
+			}
+			return math.Pow(a, 1.0/b)
+		})
+	case *expr.Function:
+		out := evalRange(e.Argument(), columns, lo, hi)
+		for i := range out {
+			result, ok := e.Kind().Float64(out[i])
+			if !ok {
+				result = math.NaN()
+			}
+			out[i] = result
+		}
+		return out
+	case *expr.Neg:
+		return apply(e.Operand(), columns, lo, hi, func(a float64) float64 { return -a })
+	case *expr.Abs:
+		return apply(e.Operand(), columns, lo, hi, math.Abs)
+	case *expr.Floor:
+		return apply(e.Operand(), columns, lo, hi, math.Floor)
+	case *expr.Ceil:
+		return apply(e.Operand(), columns, lo, hi, math.Ceil)
+	case *expr.Factorial:
+		return apply(e.Operand(), columns, lo, hi, func(a float64) float64 {
+			return orNaN(value.FactorialFloat64(a))
+		})
+	case *expr.Binomial:
+		return combine(e.N(), e.K(), columns, lo, hi, func(a, b float64) float64 {
+			return orNaN(value.BinomialFloat64(a, b))
+		})
+	case *expr.Permutation:
+		return combine(e.N(), e.K(), columns, lo, hi, func(a, b float64) float64 {
+			return orNaN(value.PermutationsFloat64(a, b))
+		})
+	case *expr.Sum:
+		return iterate(e, columns, lo, hi, 0, func(a, b float64) float64 { return a + b })
+	case *expr.Product:
+		return iterate(e, columns, lo, hi, 1, func(a, b float64) float64 { return a * b })
+	case *expr.Log:
+		return combine(e.Base(), e.Argument(), columns, lo, hi, func(a, b float64) float64 {
+			if a <= 0 || a == 1 || b <= 0 {
+				return math.NaN()
+			}
+			return math.Log(b) / math.Log(a)
+		})
+	case *prop.Equal:
+		return combine(e.Left(), e.Right(), columns, lo, hi, func(a, b float64) float64 {
+			return boolToFloat(a == b)
+		})
+	case *prop.Inequality:
+		return combine(e.Left(), e.Right(), columns, lo, hi, func(a, b float64) float64 {
+			return boolToFloat(holds(e.Relation(), a, b))
+		})
+	case *prop.And:
+		return combine(e.Left(), e.Right(), columns, lo, hi, func(a, b float64) float64 {
+			return boolToFloat(a != 0 && b != 0)
+		})
+	case *expr.Piecewise:
+		return choose(e, columns, lo, hi)
+	case *expr.Integral, *expr.Limit, *expr.Call:
+		return evalRows(e, columns, lo, hi)
+	default:
+		panic(fmt.Sprintf("unsupported expression type: %T", expression))
+	}
+}
+
+// choose evaluates a Piecewise for rows [lo, hi)
+// Each row takes the value of the first branch whose condition holds, or
+// of the otherwise branch; rows without either are NaN.
+func choose(piecewise *expr.Piecewise, columns map[string][]float64, lo, hi int) []float64 {
+	out := make([]float64, hi-lo)
+	chosen := make([]bool, hi-lo)
+	for _, branch := range piecewise.Branches() {
+		conditions := evalRange(branch.Condition, columns, lo, hi)
+		values := evalRange(branch.Value, columns, lo, hi)
+		for i := range out {
+			if !chosen[i] && conditions[i] == 1 {
+				out[i], chosen[i] = values[i], true
+			}
+		}
+	}
+	otherwise := make([]float64, hi-lo)
+	if piecewise.Otherwise() != nil {
+		otherwise = evalRange(piecewise.Otherwise(), columns, lo, hi)
+	} else {
+		for i := range otherwise {
+			otherwise[i] = math.NaN()
+		}
+	}
+	for i := range out {
+		if !chosen[i] {
+			out[i] = otherwise[i]
+		}
+	}
+	return out
+}
+
+// evalRows evaluates expression for rows [lo, hi) one row at a time, binding
+// its free variables to the values of their columns
+func evalRows(expression expr.Expr, columns map[string][]float64, lo, hi int) []float64 {
+	names := expr.FreeVariables(expression)
+	out := make([]float64, hi-lo)
+	for i := range out {
+		env := make(map[string]value.Value, len(names))
+		for _, name := range names {
+			env[name] = value.NewRealValue(columns[name][lo+i])
+		}
+		out[i] = math.NaN()
+		if result, ok := expr.EvalWith(expression, env); ok {
+			if real, ok := value.ToReal(result); ok {
+				out[i] = real.Float64()
+			}
+		}
+	}
+	return out
+}
+
+// holds reports whether a relation holds between a and b
+// NaN is not ordered, so no relation holds for it.
+func holds(relation prop.Relation, a, b float64) bool {
+	switch relation {
+	case prop.Less:
+		return a < b
+	case prop.LessEqual:
+		return a <= b
+	case prop.Greater:
+		return a > b
+	default:
+		return a >= b
+	}
+}
+
+// maxIterations bounds the number of index values of a Sum or Product, as
+// in their Eval methods
+const maxIterations = 1 << 20
+
+// iterate evaluates a Sum or Product for rows [lo, hi)
+// The body is evaluated over all rows once per index value, and each row
+// accumulates the terms within its own bounds. Rows with non-integer bounds
+// or too many terms are NaN.
+func iterate(indexed expr.Indexed, columns map[string][]float64, lo, hi int, identity float64, op func(a, b float64) float64) []float64 {
+	lower := evalRange(indexed.Lower(), columns, lo, hi)
+	upper := evalRange(indexed.Upper(), columns, lo, hi)
+	out := make([]float64, hi-lo)
+
+	first, last := math.Inf(1), math.Inf(-1)
+	for i := range out {
+		if !isIndexBound(lower[i]) || !isIndexBound(upper[i]) || upper[i]-lower[i] >= maxIterations {
+			out[i] = math.NaN()
+			continue
+		}
+		out[i] = identity
+		if upper[i] >= lower[i] {
+			first = min(first, lower[i])
+			last = max(last, upper[i])
+		}
+	}
+
+	index := make([]float64, hi)
+	accumulate := func(k float64, rowLo, rowHi int) {
+		for j := rowLo; j < rowHi; j++ {
+			index[j] = k
+		}
+		terms := evalRange(indexed.Body(), withIndex(columns, indexed.Index(), index), rowLo, rowHi)
+		for j, term := range terms {
+			i := rowLo - lo + j
+			if !math.IsNaN(out[i]) && lower[i] <= k && k <= upper[i] {
+				out[i] = op(out[i], term)
+			}
+		}
+	}
+
+	if last-first < maxIterations {
+		for k := first; k <= last; k++ {
+			accumulate(k, lo, hi)
+		}
+		return out
+	}
+	// The rows cover too wide a range together; iterate row by row
+	for i := range out {
+		if math.IsNaN(out[i]) {
+			continue
+		}
+		for k := lower[i]; k <= upper[i]; k++ {
+			accumulate(k, lo+i, lo+i+1)
+		}
+	}
+	return out
+}
+
+// isIndexBound reports whether x can be a bound of an index
+func isIndexBound(x float64) bool {
+	return !math.IsInf(x, 0) && x == math.Trunc(x)
+}
+
+// withIndex returns columns with an additional column for the index variable
+// The index column shadows a data column of the same name.
+func withIndex(columns map[string][]float64, name string, index []float64) map[string][]float64 {
+	result := make(map[string][]float64, len(columns)+1)
+	for k, v := range columns {
+		result[k] = v
+	}
+	result[name] = index
+	return result
+}
+
+// combine evaluates both operands over the range and applies op element-wise
+// The left buffer is reused for the result.
+func combine(left, right expr.Expr, columns map[string][]float64, lo, hi int, op func(a, b float64) float64) []float64 {
+	a := evalRange(left, columns, lo, hi)
+	b := evalRange(right, columns, lo, hi)
+	for i := range a {
+		a[i] = op(a[i], b[i])
+	}
+	return a
+}
+
+// apply evaluates the operand over the range and applies f element-wise
+func apply(operand expr.Expr, columns map[string][]float64, lo, hi int, f func(float64) float64) []float64 {
+	out := evalRange(operand, columns, lo, hi)
+	for i := range out {
+		out[i] = f(out[i])
+	}
+	return out
+}
+
+// orNaN maps a failed evaluation to NaN
+func orNaN(result float64, ok bool) float64 {
+	if !ok {
+		return math.NaN()
+	}
+	return result
+}
+
+// boolToFloat converts a truth value to 1 or 0
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Eval evaluates expression over columns on the calling goroutine
+func Eval(expression expr.Expr, columns map[string][]float64) ([]float64, error) {
+	evaluator := NewEvaluator()
+	return evaluator.Eval(expression, columns)
+}
@@ -0,0 +1,156 @@
+package batch_test
+
+import (
+	"exprtree/batch"
+	"exprtree/expr"
+	"exprtree/latex"
+	"exprtree/value"
+	"math"
+	"testing"
+)
+
+func parseExpr(t *testing.T, input string) expr.Expr {
+	t.Helper()
+	parsed, err := latex.ParseLatex(input)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", input, err)
+	}
+	expression, ok := parsed.(expr.Expr)
+	if !ok {
+		t.Fatalf("expected Expression, got %T", parsed)
+	}
+	return expression
+}
+
+func TestEval(t *testing.T) {
+	columns := map[string][]float64{
+		"x": {1, 2, 3, 4},
+		"y": {0, 1, 2, 0},
+	}
+
+	tests := []struct {
+		input    string
+		expected []float64
+	}{
+		{"2x + 1", []float64{3, 5, 7, 9}},
+		{"x^2 - y", []float64{1, 3, 7, 16}},
+		{"\\sqrt{x^2 + y^2}", []float64{1, math.Sqrt(5), math.Sqrt(13), 4}},
+		{"x / y", []float64{math.NaN(), 2, 1.5, math.NaN()}},
+		{"x = y + 1", []float64{1, 1, 1, 0}},
+		{"7", []float64{7, 7, 7, 7}},
+		{"x! / \\binom{x}{y}", []float64{1, 1, 2, 24}},
+		{"\\sum_{i=y}^{x} i x", []float64{1, 6, 15, 40}},
+		{"\\prod_{k=1}^{y} (x + k)", []float64{1, 3, 20, 1}},
+		{"6.02 \\times 10^{23} x", []float64{6.02e23, 1.204e24, 1.806e24, 2.408e24}},
+		{"12345678901234567890 y", []float64{0, 12345678901234567890, 24691357802469135780, 0}},
+		{"x < 3", []float64{1, 1, 0, 0}},
+		{"0 < y \\le 1", []float64{0, 1, 0, 0}},
+		{"\\begin{cases} x & y > 0 \\\\ -x & \\text{otherwise} \\end{cases}", []float64{-1, 2, 3, -4}},
+		{"\\begin{cases} x & y \\ge 1 \\end{cases}", []float64{math.NaN(), 2, 3, math.NaN()}},
+		{"\\int_{0}^{x} 2t \\, dt", []float64{1, 4, 9, 16}},
+		{"\\lim_{t \\to x} (t^2 + y)", []float64{1, 5, 11, 16}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := batch.Eval(parseExpr(t, tt.input), columns)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			assertColumn(t, result, tt.expected)
+		})
+	}
+}
+
+func TestEval_Call(t *testing.T) {
+	definitions := expr.NewDefinitions()
+	definitions.Define(expr.NewDefinition("f", []string{"t"}, parseExpr(t, "t^2 + 1")))
+	call := expr.NewCall(definitions, "f", []expr.Expr{parseExpr(t, "x + y")})
+
+	result, err := batch.Eval(call, map[string][]float64{"x": {1, 2, 3}, "y": {0, 1, -3}})
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	assertColumn(t, result, []float64{2, 10, 1})
+}
+
+func TestEval_Parallel(t *testing.T) {
+	n := 10007
+	x := make([]float64, n)
+	expected := make([]float64, n)
+	for i := range x {
+		x[i] = float64(i)
+		expected[i] = float64(i)*float64(i) + 1
+	}
+
+	evaluator := batch.NewEvaluator()
+	evaluator.Workers = 8
+	result, err := evaluator.Eval(parseExpr(t, "x^2 + 1"), map[string][]float64{"x": x})
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	assertColumn(t, result, expected)
+}
+
+func TestEval_DoesNotModifyColumns(t *testing.T) {
+	x := []float64{1, 2, 3}
+	_, err := batch.Eval(parseExpr(t, "x * 2"), map[string][]float64{"x": x})
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	assertColumn(t, x, []float64{1, 2, 3})
+}
+
+func TestEval_NoColumns(t *testing.T) {
+	// An expression without variables yields a single row
+	result, err := batch.Eval(expr.NewConstant(value.NewRealValue(2)), nil)
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	assertColumn(t, result, []float64{2})
+
+	result, err = batch.Eval(parseExpr(t, "\\sum_{i=1}^{4} i"), map[string][]float64{})
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	assertColumn(t, result, []float64{10})
+}
+
+func TestEval_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		columns map[string][]float64
+	}{
+		{"Missing column", "x + y", map[string][]float64{"x": {1}}},
+		{"Length mismatch", "x + y", map[string][]float64{"x": {1}, "y": {1, 2}}},
+		{"No columns", "x + 2", nil},
+		{"Missing column in integral", "\\int_{0}^{1} x y \\, dx", map[string][]float64{"x": {1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := batch.Eval(parseExpr(t, tt.input), tt.columns); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func assertColumn(t *testing.T, result, expected []float64) {
+	t.Helper()
+	if len(result) != len(expected) {
+		t.Fatalf("expected length %d, got %d", len(expected), len(result))
+	}
+	for i := range expected {
+		if math.IsNaN(expected[i]) {
+			if !math.IsNaN(result[i]) {
+				t.Errorf("row %d: expected NaN, got %f", i, result[i])
+			}
+			continue
+		}
+		if math.Abs(result[i]-expected[i]) > 1e-10 {
+			t.Errorf("row %d: expected %f, got %f", i, expected[i], result[i])
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package bytecode_test
+
+import (
+	"errors"
+	"exprtree/bytecode"
+	"exprtree/expr"
+	"exprtree/latex"
+	"math"
+	"strings"
+	"testing"
+)
+
+func compileLatex(t *testing.T, input string) *bytecode.Program {
+	t.Helper()
+	parsed, err := latex.ParseLatex(input)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", input, err)
+	}
+	expression, ok := parsed.(expr.Expr)
+	if !ok {
+		t.Fatalf("expected Expression, got %T", parsed)
+	}
+	program, err := bytecode.Compile(expression)
+	if err != nil {
+		t.Fatalf("Failed to compile %s: %v", input, err)
+	}
+	return program
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		input    string
+		vars     map[string]float64
+		expected float64
+	}{
+		{"2 + 3 * 4", nil, 14},
+		{"(2 + 3) * 4", nil, 20},
+		{"10 - 2 - 3", nil, 5},
+		{"100 / 4 / 5", nil, 5},
+		{"2^3^2", nil, 512},
+		{"\\sqrt{9} * 2", nil, 6},
+		{"\\sqrt[3]{8}", nil, 2},
+		{"-x + 1", map[string]float64{"x": 4}, -3},
+		{"-x^2 + (-x)^2", map[string]float64{"x": 3}, 0},
+		{"x^2 + y^2", map[string]float64{"x": 3, "y": 4}, 25},
+		{"\\sin^2 x + \\cos^2 x", map[string]float64{"x": 0.7}, 1},
+		{"\\log_{2} x", map[string]float64{"x": 32}, 5},
+		{"|x| + \\lfloor x \\rfloor + \\lceil x \\rceil", map[string]float64{"x": -2.5}, -2.5},
+		{"x! + \\binom{x}{2}", map[string]float64{"x": 5}, 130},
+		{"6.02 \\times 10^{23} / 10^{23}", nil, 6.02},
+		{"12345678901234567890 / 10^{19}", nil, 1.234567890123456789},
+		{"1 = 1", nil, 1},
+		{"0 \\le x < 1", map[string]float64{"x": 1}, 0},
+		{"x \\ge 2", map[string]float64{"x": 2}, 1},
+		{"\\begin{cases} x & x > 0 \\\\ -x & \\text{otherwise} \\end{cases}", map[string]float64{"x": -3}, 3},
+		{"\\begin{cases} 1 & x < 0 \\\\ 2 & x < 1 \\\\ 3 & \\text{otherwise} \\end{cases} * 10", map[string]float64{"x": 0.5}, 20},
+		{"\\begin{cases} 1 & x < 0 \\\\ 2 & x < 1 \\\\ 3 & \\text{otherwise} \\end{cases} * 10", map[string]float64{"x": 1}, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program := compileLatex(t, tt.input)
+			result, err := bytecode.Run(program, tt.vars)
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if math.Abs(result-tt.expected) > 1e-10 {
+				t.Errorf("expected %f, got %f", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bytecode.ErrorCode
+	}{
+		{"10 / 0", bytecode.ErrDivisionByZero},
+		{"\\sqrt[0]{8}", bytecode.ErrZeroRootDegree},
+		{"x + 1", bytecode.ErrUnboundVariable},
+		{"\\ln(0)", bytecode.ErrDomain},
+		{"(0 - 1)!", bytecode.ErrDomain},
+		{"\\begin{cases} 1 & 2 < 1 \\end{cases}", bytecode.ErrNoBranch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program := compileLatex(t, tt.input)
+			_, err := bytecode.Run(program, nil)
+			var vmErr *bytecode.Error
+			if !errors.As(err, &vmErr) {
+				t.Fatalf("expected *bytecode.Error, got %v", err)
+			}
+			if vmErr.Code != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, vmErr.Code)
+			}
+		})
+	}
+}
+
+func TestDisassemble_Piecewise(t *testing.T) {
+	program := compileLatex(t, "\\begin{cases} x & x > 0 \\end{cases}")
+	expected := "0000 LOAD  0 (x)\n0003 CONST 0 (0)\n0006 GT\n0007 JMPF  16 (to 0016)\n" +
+		"0010 LOAD  0 (x)\n0013 JMP   17 (to 0017)\n0016 NOBRANCH\n"
+	if result := bytecode.Disassemble(program); result != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
+
+func TestConstantPoolDeduplication(t *testing.T) {
+	program := compileLatex(t, "x * 2 + x * 2")
+	if len(program.Constants) != 1 {
+		t.Errorf("expected 1 constant, got %d", len(program.Constants))
+	}
+	if len(program.Names) != 1 {
+		t.Errorf("expected 1 name, got %d", len(program.Names))
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	program := compileLatex(t, "x + 2")
+	expected := "0000 LOAD  0 (x)\n0003 CONST 0 (2)\n0006 ADD\n"
+	if result := bytecode.Disassemble(program); result != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	program := compileLatex(t, "\\sqrt{x^2 + y^2} / 0.1")
+
+	data, err := program.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+
+	var decoded bytecode.Program
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+
+	vars := map[string]float64{"x": 3, "y": 4}
+	want, _ := bytecode.Run(program, vars)
+	got, err := bytecode.Run(&decoded, vars)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("expected %f, got %f", want, got)
+	}
+	if bytecode.Disassemble(&decoded) != bytecode.Disassemble(program) {
+		t.Errorf("disassembly differs after round trip")
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	program := compileLatex(t, "x + 1")
+	data, _ := program.MarshalBinary()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"Empty", nil},
+		{"Bad magic", append([]byte("XXXX"), data[4:]...)},
+		{"Truncated", data[:len(data)-1]},
+		// Two constants declared but only one present
+		{"Truncated constants", append([]byte("EXBC\x01\x02"), make([]byte, 10)...)},
+		{"Truncated header", []byte("EXB")},
+		{"Backward jump", append([]byte("EXBC\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x06"), byte(bytecode.OpConst), 0, 0, byte(bytecode.OpJump), 0, 0)},
+		{"Jump into an operand", append([]byte("EXBC\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x06"), byte(bytecode.OpJump), 4, 0, byte(bytecode.OpConst), 0, 0)},
+		{"Bad opcode", append(append([]byte{}, data[:len(data)-1]...), 0xff)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var decoded bytecode.Program
+			if err := decoded.UnmarshalBinary(tt.data); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestCompile_Unsupported(t *testing.T) {
+	// Nodes that bind variables or call definitions have no instructions
+	inputs := []string{
+		"\\sum_{i=1}^{n} i",
+		"\\prod_{i=1}^{n} i",
+		"\\int_{0}^{1} x \\, dx",
+		"\\lim_{x \\to 1} x",
+	}
+	definitions := expr.NewDefinitions()
+	definitions.Define(expr.NewDefinition("f", []string{"t"}, expr.NewVariable("t")))
+	expressions := []expr.Expr{expr.NewCall(definitions, "f", []expr.Expr{expr.NewVariable("x")})}
+	for _, input := range inputs {
+		parsed, err := latex.ParseLatex(input)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", input, err)
+		}
+		expressions = append(expressions, parsed.(expr.Expr))
+	}
+
+	for _, e := range expressions {
+		_, err := bytecode.Compile(e)
+		if err == nil || !strings.Contains(err.Error(), "unsupported") {
+			t.Errorf("%v: expected unsupported error, got %v", e, err)
+		}
+	}
+}
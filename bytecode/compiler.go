@@ -0,0 +1,254 @@
+package bytecode
+
+import (
+	"encoding/binary"
+	"exprtree/expr"
+	"exprtree/prop"
+	"exprtree/value"
+	"fmt"
+	"math"
+)
+
+// Compiler compiles Expression trees into bytecode programs
+type Compiler struct {
+	program   *Program
+	constants map[uint64]int // float bits -> constant index
+	names     map[string]int // variable name -> name index
+}
+
+// NewCompiler creates a new Compiler instance
+func NewCompiler() *Compiler {
+	return &Compiler{}
+}
+
+// Compile compiles an Expression into a Program
+func (c *Compiler) Compile(expression expr.Expr) (*Program, error) {
+	c.program = &Program{}
+	c.constants = map[uint64]int{}
+	c.names = map[string]int{}
+
+	if err := c.compile(expression); err != nil {
+		return nil, err
+	}
+	return c.program, nil
+}
+
+// compile emits code that leaves the value of expression on the stack
+func (c *Compiler) compile(expression expr.Expr) error {
+	if expression == nil {
+		return fmt.Errorf("cannot compile nil expression")
+	}
+
+	switch e := expression.(type) {
+	case *expr.Constant:
+		return c.compileConstant(e)
+	case *expr.Variable:
+		return c.emitOperand(OpLoad, c.nameIndex(e.Name()))
+	case *expr.Add:
+		return c.compileBinary(e, OpAdd)
+	case *expr.Sub:
+		return c.compileBinary(e, OpSub)
+	case *expr.Mul:
+		return c.compileBinary(e, OpMul)
+	case *expr.Div:
+		return c.compileBinary(e, OpDiv)
+	case *expr.Power:
+		return c.compileBinary(e, OpPow)
+	case *expr.NthRoot:
+		if err := c.compile(e.Radicand()); err != nil {
+			return err
+		}
+		if err := c.compile(e.Degree()); err != nil {
+			return err
+		}
+		c.emit(OpRoot)
+		return nil
+	case *expr.Function:
+		if err := c.compile(e.Argument()); err != nil {
+			return err
+		}
+		return c.emitOperand(OpFunc, int(e.Kind()))
+	case *expr.Log:
+		if err := c.compile(e.Base()); err != nil {
+			return err
+		}
+		if err := c.compile(e.Argument()); err != nil {
+			return err
+		}
+		c.emit(OpLog)
+		return nil
+	case *expr.Neg:
+		return c.compileUnary(e, OpNeg)
+	case *expr.Abs:
+		return c.compileUnary(e, OpAbs)
+	case *expr.Floor:
+		return c.compileUnary(e, OpFloor)
+	case *expr.Ceil:
+		return c.compileUnary(e, OpCeil)
+	case *expr.Factorial:
+		return c.compileUnary(e, OpFact)
+	case *expr.Binomial:
+		return c.compilePair(e.N(), e.K(), OpBinom)
+	case *expr.Permutation:
+		return c.compilePair(e.N(), e.K(), OpPerm)
+	case *prop.Equal:
+		return c.compilePair(e.Left(), e.Right(), OpEq)
+	case *prop.Inequality:
+		return c.compilePair(e.Left(), e.Right(), relationOpcodes[e.Relation()])
+	case *prop.And:
+		return c.compilePair(e.Left(), e.Right(), OpAnd)
+	case *expr.Piecewise:
+		return c.compilePiecewise(e)
+	default:
+		// Sums, products, integrals, limits and calls of user-defined
+		// functions have no instructions
+		return fmt.Errorf("unsupported expression type: %T", expression)
+	}
+}
+
+// compileConstant emits a CONST instruction for a real or integer constant
+func (c *Compiler) compileConstant(constant *expr.Constant) error {
+	realValue, ok := value.ToReal(constant.Value())
+	if !ok {
+		return fmt.Errorf("unsupported constant value type: %T", constant.Value())
+	}
+	return c.emitOperand(OpConst, c.constantIndex(realValue.Float64()))
+}
+
+// compileBinary emits code for both operands followed by op
+func (c *Compiler) compileBinary(binary expr.Binary, op Opcode) error {
+	if err := c.compile(binary.Left()); err != nil {
+		return err
+	}
+	if err := c.compile(binary.Right()); err != nil {
+		return err
+	}
+	c.emit(op)
+	return nil
+}
+
+// relationOpcodes maps the relations of inequalities to their opcodes
+var relationOpcodes = map[prop.Relation]Opcode{
+	prop.Less:         OpLess,
+	prop.LessEqual:    OpLessEq,
+	prop.Greater:      OpGreater,
+	prop.GreaterEqual: OpGreaterEq,
+}
+
+// compilePiecewise emits a conditional jump past each branch whose
+// condition is false, and NOBRANCH in place of a missing otherwise branch
+func (c *Compiler) compilePiecewise(piecewise *expr.Piecewise) error {
+	var ends []int // operands of the jumps to the end
+	for _, branch := range piecewise.Branches() {
+		if err := c.compile(branch.Condition); err != nil {
+			return err
+		}
+		next := c.emitJump(OpJumpIfFalse)
+		if err := c.compile(branch.Value); err != nil {
+			return err
+		}
+		ends = append(ends, c.emitJump(OpJump))
+		if err := c.patchJump(next); err != nil {
+			return err
+		}
+	}
+	if piecewise.Otherwise() != nil {
+		if err := c.compile(piecewise.Otherwise()); err != nil {
+			return err
+		}
+	} else {
+		c.emit(OpNoBranch)
+	}
+	for _, end := range ends {
+		if err := c.patchJump(end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitJump appends a jump whose target is set later by patchJump, and
+// returns the position of its operand
+func (c *Compiler) emitJump(op Opcode) int {
+	c.emit(op)
+	c.program.Code = append(c.program.Code, 0, 0)
+	return len(c.program.Code) - 2
+}
+
+// patchJump sets the target of the jump with the operand at position to the
+// end of the code
+func (c *Compiler) patchJump(position int) error {
+	target := len(c.program.Code)
+	if target > math.MaxUint16 {
+		return fmt.Errorf("program too long for jumps: %d bytes", target)
+	}
+	binary.LittleEndian.PutUint16(c.program.Code[position:], uint16(target))
+	return nil
+}
+
+// compilePair emits code for two operands followed by op
+func (c *Compiler) compilePair(a, b expr.Expr, op Opcode) error {
+	if err := c.compile(a); err != nil {
+		return err
+	}
+	if err := c.compile(b); err != nil {
+		return err
+	}
+	c.emit(op)
+	return nil
+}
+
+// compileUnary emits code for the operand followed by op
+func (c *Compiler) compileUnary(unary expr.Unary, op Opcode) error {
+	if err := c.compile(unary.Operand()); err != nil {
+		return err
+	}
+	c.emit(op)
+	return nil
+}
+
+// constantIndex returns the index of v in the constant pool, adding it if needed
+func (c *Compiler) constantIndex(v float64) int {
+	bits := math.Float64bits(v)
+	if index, ok := c.constants[bits]; ok {
+		return index
+	}
+	index := len(c.program.Constants)
+	c.program.Constants = append(c.program.Constants, v)
+	c.constants[bits] = index
+	return index
+}
+
+// nameIndex returns the index of name in the name table, adding it if needed
+func (c *Compiler) nameIndex(name string) int {
+	if index, ok := c.names[name]; ok {
+		return index
+	}
+	index := len(c.program.Names)
+	c.program.Names = append(c.program.Names, name)
+	c.names[name] = index
+	return index
+}
+
+// emit appends an instruction without operand
+func (c *Compiler) emit(op Opcode) {
+	c.program.Code = append(c.program.Code, byte(op))
+}
+
+// emitOperand appends an instruction with a 16-bit operand
+func (c *Compiler) emitOperand(op Opcode, operand int) error {
+	if operand > math.MaxUint16 {
+		return fmt.Errorf("too many operands for %s: %d", op, operand)
+	}
+	c.program.Code = append(c.program.Code, byte(op))
+	c.program.Code = binary.LittleEndian.AppendUint16(c.program.Code, uint16(operand))
+	return nil
+}
+
+// Compile compiles an Expression tree into a Program
+// Propositions yield 1 for true and 0 for false. Sums, products, integrals,
+// limits and calls of user-defined functions are not supported.
+func Compile(expression expr.Expr) (*Program, error) {
+	compiler := NewCompiler()
+	return compiler.Compile(expression)
+}
@@ -0,0 +1,50 @@
+package bytecode
+
+import (
+	"encoding/binary"
+	"exprtree/expr"
+	"fmt"
+	"strings"
+)
+
+// Disassemble returns a human-readable listing of the program
+// Each line contains the byte offset, the mnemonic and the resolved operand.
+func Disassemble(p *Program) string {
+	var sb strings.Builder
+
+	for pc := 0; pc < len(p.Code); {
+		op := Opcode(p.Code[pc])
+		width := operandWidth(op)
+		if width < 0 || pc+1+width > len(p.Code) {
+			fmt.Fprintf(&sb, "%04d %s\n", pc, op)
+			break
+		}
+
+		if width == 0 {
+			fmt.Fprintf(&sb, "%04d %s\n", pc, op)
+		} else {
+			operand := int(binary.LittleEndian.Uint16(p.Code[pc+1:]))
+			fmt.Fprintf(&sb, "%04d %-5s %d (%s)\n", pc, op, operand, p.describeOperand(op, operand))
+		}
+
+		pc += 1 + width
+	}
+
+	return sb.String()
+}
+
+// describeOperand returns the constant or name referred to by an operand
+func (p *Program) describeOperand(op Opcode, operand int) string {
+	switch {
+	case op == OpConst && operand < len(p.Constants):
+		return fmt.Sprintf("%g", p.Constants[operand])
+	case op == OpLoad && operand < len(p.Names):
+		return p.Names[operand]
+	case op == OpFunc:
+		return expr.FunctionKind(operand).String()
+	case isJump(op):
+		return fmt.Sprintf("to %04d", operand)
+	default:
+		return "?"
+	}
+}
@@ -0,0 +1,92 @@
+package bytecode
+
+import "fmt"
+
+// Opcode identifies a single VM instruction
+type Opcode byte
+
+const (
+	OpConst       Opcode = iota + 1 // push Constants[operand]
+	OpLoad                          // push the variable bound to Names[operand]
+	OpAdd                           // a + b
+	OpSub                           // a - b
+	OpMul                           // a * b
+	OpDiv                           // a / b
+	OpPow                           // a ^ b
+	OpRoot                          // b-th root of a
+	OpFunc                          // apply the elementary function expr.FunctionKind(operand)
+	OpLog                           // logarithm of b to base a
+	OpAbs                           // |a|
+	OpFloor                         // floor(a)
+	OpCeil                          // ceil(a)
+	OpFact                          // a!
+	OpBinom                         // a choose b
+	OpPerm                          // a!/(a-b)!
+	OpNeg                           // -a
+	OpEq                            // 1 if a = b, else 0
+	OpLess                          // 1 if a < b, else 0
+	OpLessEq                        // 1 if a ≤ b, else 0
+	OpGreater                       // 1 if a > b, else 0
+	OpGreaterEq                     // 1 if a ≥ b, else 0
+	OpAnd                           // 1 if a and b are both nonzero, else 0
+	OpJump                          // continue at offset operand
+	OpJumpIfFalse                   // pop a and continue at offset operand if a is 0
+	OpNoBranch                      // fail: no branch of a piecewise expression applies
+)
+
+// opcodeInfo describes the textual name and operand width of an opcode
+type opcodeInfo struct {
+	name         string
+	operandWidth int // operand size in bytes (0 or 2)
+}
+
+var opcodes = map[Opcode]opcodeInfo{
+	OpConst:       {"CONST", 2},
+	OpLoad:        {"LOAD", 2},
+	OpAdd:         {"ADD", 0},
+	OpSub:         {"SUB", 0},
+	OpMul:         {"MUL", 0},
+	OpDiv:         {"DIV", 0},
+	OpPow:         {"POW", 0},
+	OpRoot:        {"ROOT", 0},
+	OpFunc:        {"FUNC", 2},
+	OpLog:         {"LOG", 0},
+	OpAbs:         {"ABS", 0},
+	OpFloor:       {"FLOOR", 0},
+	OpCeil:        {"CEIL", 0},
+	OpFact:        {"FACT", 0},
+	OpBinom:       {"BINOM", 0},
+	OpPerm:        {"PERM", 0},
+	OpNeg:         {"NEG", 0},
+	OpEq:          {"EQ", 0},
+	OpLess:        {"LT", 0},
+	OpLessEq:      {"LE", 0},
+	OpGreater:     {"GT", 0},
+	OpGreaterEq:   {"GE", 0},
+	OpAnd:         {"AND", 0},
+	OpJump:        {"JMP", 2},
+	OpJumpIfFalse: {"JMPF", 2},
+	OpNoBranch:    {"NOBRANCH", 0},
+}
+
+// isJump reports whether op takes a code offset as its operand
+func isJump(op Opcode) bool {
+	return op == OpJump || op == OpJumpIfFalse
+}
+
+// String returns the mnemonic of the opcode
+func (op Opcode) String() string {
+	if info, ok := opcodes[op]; ok {
+		return info.name
+	}
+	return fmt.Sprintf("Opcode(%d)", byte(op))
+}
+
+// operandWidth returns the operand size of op, or -1 if op is unknown
+func operandWidth(op Opcode) int {
+	info, ok := opcodes[op]
+	if !ok {
+		return -1
+	}
+	return info.operandWidth
+}
@@ -0,0 +1,175 @@
+package bytecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"exprtree/expr"
+	"fmt"
+	"io"
+	"math"
+)
+
+// magic identifies serialized programs
+var magic = []byte("EXBC")
+
+// formatVersion is the version of the serialized program format
+const formatVersion = 1
+
+// Program is a compiled expression
+// Code is a sequence of opcodes, each optionally followed by a
+// little-endian uint16 operand indexing Constants or Names.
+type Program struct {
+	Code      []byte
+	Constants []float64
+	Names     []string
+}
+
+// MarshalBinary serializes the program
+func (p *Program) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(magic)
+	buf.WriteByte(formatVersion)
+
+	writeUvarint(&buf, uint64(len(p.Constants)))
+	for _, c := range p.Constants {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(c))
+		buf.Write(b[:])
+	}
+
+	writeUvarint(&buf, uint64(len(p.Names)))
+	for _, name := range p.Names {
+		writeUvarint(&buf, uint64(len(name)))
+		buf.WriteString(name)
+	}
+
+	writeUvarint(&buf, uint64(len(p.Code)))
+	buf.Write(p.Code)
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary deserializes a program produced by MarshalBinary
+// The decoded code is validated so that it can be run safely.
+func (p *Program) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.Equal(header[:len(magic)], magic) {
+		return fmt.Errorf("invalid program header")
+	}
+	if header[len(magic)] != formatVersion {
+		return fmt.Errorf("unsupported program version: %d", header[len(magic)])
+	}
+
+	numConstants, err := readLength(r, 8)
+	if err != nil {
+		return fmt.Errorf("failed to read constants: %w", err)
+	}
+	constants := make([]float64, numConstants)
+	for i := range constants {
+		var b [8]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return fmt.Errorf("failed to read constant %d: %w", i, err)
+		}
+		constants[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[:]))
+	}
+
+	numNames, err := readLength(r, 1)
+	if err != nil {
+		return fmt.Errorf("failed to read names: %w", err)
+	}
+	names := make([]string, numNames)
+	for i := range names {
+		n, err := readLength(r, 1)
+		if err != nil {
+			return fmt.Errorf("failed to read name %d: %w", i, err)
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return fmt.Errorf("failed to read name %d: %w", i, err)
+		}
+		names[i] = string(b)
+	}
+
+	codeLen, err := readLength(r, 1)
+	if err != nil {
+		return fmt.Errorf("failed to read code: %w", err)
+	}
+	code := make([]byte, codeLen)
+	if _, err := io.ReadFull(r, code); err != nil {
+		return fmt.Errorf("failed to read code: %w", err)
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("unexpected trailing data")
+	}
+
+	decoded := Program{Code: code, Constants: constants, Names: names}
+	if err := decoded.validate(); err != nil {
+		return err
+	}
+	*p = decoded
+	return nil
+}
+
+// validate checks that every instruction is known and its operand is in range
+// Jumps must go forward to the start of an instruction or the end of the
+// code, so that every program terminates.
+func (p *Program) validate() error {
+	starts := map[int]bool{len(p.Code): true}
+	var jumps []int
+	for pc := 0; pc < len(p.Code); {
+		starts[pc] = true
+		op := Opcode(p.Code[pc])
+		width := operandWidth(op)
+		if width < 0 {
+			return &Error{Code: ErrInvalidOpcode, Offset: pc}
+		}
+		if pc+1+width > len(p.Code) {
+			return &Error{Code: ErrInvalidOperand, Offset: pc}
+		}
+		if width > 0 {
+			operand := int(binary.LittleEndian.Uint16(p.Code[pc+1:]))
+			if (op == OpConst && operand >= len(p.Constants)) || (op == OpLoad && operand >= len(p.Names)) ||
+				(op == OpFunc && !isFunctionKind(operand)) || (isJump(op) && operand <= pc) {
+				return &Error{Code: ErrInvalidOperand, Offset: pc}
+			}
+			if isJump(op) {
+				jumps = append(jumps, pc)
+			}
+		}
+		pc += 1 + width
+	}
+	for _, pc := range jumps {
+		if !starts[int(binary.LittleEndian.Uint16(p.Code[pc+1:]))] {
+			return &Error{Code: ErrInvalidOperand, Offset: pc}
+		}
+	}
+	return nil
+}
+
+// isFunctionKind reports whether operand is a valid expr.FunctionKind
+func isFunctionKind(operand int) bool {
+	_, ok := expr.LookupFunction(expr.FunctionKind(operand).String())
+	return ok
+}
+
+// writeUvarint appends an unsigned varint to buf
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	buf.Write(b[:n])
+}
+
+// readLength reads a uvarint count of items of size bytes each, which
+// must fit in the remaining input
+func readLength(r *bytes.Reader, size int) (int, error) {
+	v, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, err
+	}
+	if v > uint64(r.Len()/size) {
+		return 0, fmt.Errorf("length %d exceeds input size", v)
+	}
+	return int(v), nil
+}
@@ -0,0 +1,254 @@
+package bytecode
+
+import (
+	"encoding/binary"
+	"exprtree/expr"
+	"exprtree/value"
+	"fmt"
+	"math"
+)
+
+// ErrorCode classifies runtime failures
+// The codes mirror the cases in which Eval() of the expression nodes fails.
+type ErrorCode int
+
+const (
+	ErrDivisionByZero   ErrorCode = iota + 1 // Div with a zero divisor
+	ErrZeroRootDegree                        // NthRoot with a zero degree
+	ErrUnboundVariable                       // LOAD of a variable missing from the bindings
+	ErrStackUnderflow                        // malformed program
+	ErrInvalidOpcode                         // unknown instruction
+	ErrInvalidOperand                        // operand out of range or truncated
+	ErrInvalidStackSize                      // program did not leave exactly one value
+	ErrDomain                                // function argument outside its domain
+	ErrNoBranch                              // Piecewise without a branch for the values
+)
+
+var errorCodeNames = map[ErrorCode]string{
+	ErrDivisionByZero:   "division by zero",
+	ErrZeroRootDegree:   "zero root degree",
+	ErrUnboundVariable:  "unbound variable",
+	ErrStackUnderflow:   "stack underflow",
+	ErrInvalidOpcode:    "invalid opcode",
+	ErrInvalidOperand:   "invalid operand",
+	ErrInvalidStackSize: "invalid stack size",
+	ErrDomain:           "argument out of domain",
+	ErrNoBranch:         "no branch applies",
+}
+
+// String returns a description of the error code
+func (c ErrorCode) String() string {
+	if name, ok := errorCodeNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErrorCode(%d)", int(c))
+}
+
+// Error is returned when a program fails to run
+type Error struct {
+	Code   ErrorCode
+	Offset int    // byte offset of the failing instruction
+	Name   string // variable name for ErrUnboundVariable
+}
+
+func (e *Error) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("%s at offset %d: %s", e.Code, e.Offset, e.Name)
+	}
+	return fmt.Sprintf("%s at offset %d", e.Code, e.Offset)
+}
+
+// VM executes bytecode programs
+// A VM reuses its stack between runs and must not be shared between goroutines.
+type VM struct {
+	stack []float64
+}
+
+// NewVM creates a new VM instance
+func NewVM() *VM {
+	return &VM{}
+}
+
+// Run executes the program with the given variable bindings and returns the result
+func (vm *VM) Run(p *Program, vars map[string]float64) (float64, error) {
+	vm.stack = vm.stack[:0]
+
+	for pc := 0; pc < len(p.Code); {
+		op := Opcode(p.Code[pc])
+		width := operandWidth(op)
+		if width < 0 {
+			return 0, &Error{Code: ErrInvalidOpcode, Offset: pc}
+		}
+		if pc+1+width > len(p.Code) {
+			return 0, &Error{Code: ErrInvalidOperand, Offset: pc}
+		}
+
+		switch op {
+		case OpConst:
+			index := int(binary.LittleEndian.Uint16(p.Code[pc+1:]))
+			if index >= len(p.Constants) {
+				return 0, &Error{Code: ErrInvalidOperand, Offset: pc}
+			}
+			vm.stack = append(vm.stack, p.Constants[index])
+		case OpLoad:
+			index := int(binary.LittleEndian.Uint16(p.Code[pc+1:]))
+			if index >= len(p.Names) {
+				return 0, &Error{Code: ErrInvalidOperand, Offset: pc}
+			}
+			v, ok := vars[p.Names[index]]
+			if !ok {
+				return 0, &Error{Code: ErrUnboundVariable, Offset: pc, Name: p.Names[index]}
+			}
+			vm.stack = append(vm.stack, v)
+		case OpFunc:
+			if len(vm.stack) < 1 {
+				return 0, &Error{Code: ErrStackUnderflow, Offset: pc}
+			}
+			kind := expr.FunctionKind(binary.LittleEndian.Uint16(p.Code[pc+1:]))
+			result, ok := kind.Float64(vm.stack[len(vm.stack)-1])
+			if !ok {
+				return 0, &Error{Code: ErrDomain, Offset: pc}
+			}
+			vm.stack[len(vm.stack)-1] = result
+		case OpNeg, OpAbs, OpFloor, OpCeil:
+			if len(vm.stack) < 1 {
+				return 0, &Error{Code: ErrStackUnderflow, Offset: pc}
+			}
+			vm.stack[len(vm.stack)-1] = applyUnary(op, vm.stack[len(vm.stack)-1])
+		case OpJump, OpJumpIfFalse:
+			// Only forward jumps are allowed, so every program terminates
+			target := int(binary.LittleEndian.Uint16(p.Code[pc+1:]))
+			if target <= pc || target > len(p.Code) {
+				return 0, &Error{Code: ErrInvalidOperand, Offset: pc}
+			}
+			if op == OpJump {
+				pc = target
+				continue
+			}
+			if len(vm.stack) < 1 {
+				return 0, &Error{Code: ErrStackUnderflow, Offset: pc}
+			}
+			condition := vm.stack[len(vm.stack)-1]
+			vm.stack = vm.stack[:len(vm.stack)-1]
+			if condition == 0 {
+				pc = target
+				continue
+			}
+		case OpNoBranch:
+			return 0, &Error{Code: ErrNoBranch, Offset: pc}
+		case OpFact:
+			if len(vm.stack) < 1 {
+				return 0, &Error{Code: ErrStackUnderflow, Offset: pc}
+			}
+			result, ok := value.FactorialFloat64(vm.stack[len(vm.stack)-1])
+			if !ok {
+				return 0, &Error{Code: ErrDomain, Offset: pc}
+			}
+			vm.stack[len(vm.stack)-1] = result
+		default:
+			if len(vm.stack) < 2 {
+				return 0, &Error{Code: ErrStackUnderflow, Offset: pc}
+			}
+			a, b := vm.stack[len(vm.stack)-2], vm.stack[len(vm.stack)-1]
+			result, code := apply(op, a, b)
+			if code != 0 {
+				return 0, &Error{Code: code, Offset: pc}
+			}
+			vm.stack = vm.stack[:len(vm.stack)-1]
+			vm.stack[len(vm.stack)-1] = result
+		}
+
+		pc += 1 + width
+	}
+
+	if len(vm.stack) != 1 {
+		return 0, &Error{Code: ErrInvalidStackSize, Offset: len(p.Code)}
+	}
+	return vm.stack[0], nil
+}
+
+// apply evaluates a binary opcode
+func apply(op Opcode, a, b float64) (float64, ErrorCode) {
+	switch op {
+	case OpAdd:
+		return a + b, 0
+	case OpSub:
+		return a - b, 0
+	case OpMul:
+		return a * b, 0
+	case OpDiv:
+		if b == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return a / b, 0
+	case OpPow:
+		return math.Pow(a, b), 0
+	case OpRoot:
+		if b == 0 {
+			return 0, ErrZeroRootDegree
+		}
+		return math.Pow(a, 1.0/b), 0
+	case OpLog:
+		if a <= 0 || b <= 0 {
+			return 0, ErrDomain
+		}
+		if a == 1 {
+			return 0, ErrDivisionByZero
+		}
+		return math.Log(b) / math.Log(a), 0
+	case OpBinom:
+		return combinatorial(value.BinomialFloat64(a, b))
+	case OpPerm:
+		return combinatorial(value.PermutationsFloat64(a, b))
+	case OpEq:
+		return boolToFloat(a == b), 0
+	case OpLess:
+		return boolToFloat(a < b), 0
+	case OpLessEq:
+		return boolToFloat(a <= b), 0
+	case OpGreater:
+		return boolToFloat(a > b), 0
+	case OpGreaterEq:
+		return boolToFloat(a >= b), 0
+	case OpAnd:
+		return boolToFloat(a != 0 && b != 0), 0
+	default:
+		return 0, ErrInvalidOpcode
+	}
+}
+
+// combinatorial converts the result of a combinatorial function to an opcode result
+func combinatorial(result float64, ok bool) (float64, ErrorCode) {
+	if !ok {
+		return 0, ErrDomain
+	}
+	return result, 0
+}
+
+// boolToFloat converts a truth value to 1 or 0
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// applyUnary evaluates a unary opcode without operand
+func applyUnary(op Opcode, a float64) float64 {
+	switch op {
+	case OpNeg:
+		return -a
+	case OpAbs:
+		return math.Abs(a)
+	case OpFloor:
+		return math.Floor(a)
+	default:
+		return math.Ceil(a)
+	}
+}
+
+// Run executes the program on a fresh VM
+func Run(p *Program, vars map[string]float64) (float64, error) {
+	vm := NewVM()
+	return vm.Run(p, vars)
+}
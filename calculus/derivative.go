@@ -0,0 +1,206 @@
+package calculus
+
+import (
+	"exprtree/expr"
+	"fmt"
+)
+
+// Derivative returns the derivative of e with respect to x
+// Floor and Ceil are differentiated almost everywhere (to 0), and piecewise
+// expressions branch by branch, ignoring the boundaries. Nodes without
+// a symbolic rule, such as factorials of expressions in x, give an error.
+func Derivative(e expr.Expr, x string) (expr.Expr, error) {
+	if !dependsOn(e, x) {
+		return constant(0), nil
+	}
+
+	switch n := e.(type) {
+	case *expr.Variable:
+		// n is x itself
+		return constant(1), nil
+	case *expr.Add:
+		l, r, err := derivatives(n.Left(), n.Right(), x)
+		if err != nil {
+			return nil, err
+		}
+		return add(l, r), nil
+	case *expr.Sub:
+		l, r, err := derivatives(n.Left(), n.Right(), x)
+		if err != nil {
+			return nil, err
+		}
+		return sub(l, r), nil
+	case *expr.Neg:
+		d, err := Derivative(n.Operand(), x)
+		if err != nil {
+			return nil, err
+		}
+		return neg(d), nil
+	case *expr.Mul:
+		// u'v + uv'
+		l, r, err := derivatives(n.Left(), n.Right(), x)
+		if err != nil {
+			return nil, err
+		}
+		return add(mul(l, n.Right()), mul(n.Left(), r)), nil
+	case *expr.Div:
+		// (u'v - uv') / v^2
+		l, r, err := derivatives(n.Left(), n.Right(), x)
+		if err != nil {
+			return nil, err
+		}
+		if !dependsOn(n.Right(), x) {
+			return div(l, n.Right()), nil
+		}
+		return div(sub(mul(l, n.Right()), mul(n.Left(), r)), pow(n.Right(), constant(2))), nil
+	case *expr.Power:
+		return powerDerivative(n.Left(), n.Right(), x)
+	case *expr.NthRoot:
+		return powerDerivative(n.Radicand(), div(constant(1), n.Degree()), x)
+	case *expr.Function:
+		return functionDerivative(n, x)
+	case *expr.Log:
+		// log_b(u) = ln(u) / ln(b)
+		return Derivative(div(expr.NewFunction(expr.Ln, n.Argument()), expr.NewFunction(expr.Ln, n.Base())), x)
+	case *expr.Abs:
+		// |u|' = u' u / |u|
+		d, err := Derivative(n.Operand(), x)
+		if err != nil {
+			return nil, err
+		}
+		return mul(d, div(n.Operand(), n)), nil
+	case *expr.Floor, *expr.Ceil:
+		return constant(0), nil
+	case *expr.Sum:
+		if dependsOn(n.Lower(), x) || dependsOn(n.Upper(), x) {
+			return nil, fmt.Errorf("cannot differentiate a sum whose bounds depend on %s", x)
+		}
+		if n.Index() == x {
+			return constant(0), nil
+		}
+		d, err := Derivative(n.Body(), x)
+		if err != nil {
+			return nil, err
+		}
+		return expr.NewSum(n.Index(), n.Lower(), n.Upper(), d), nil
+	case *expr.Integral:
+		return integralDerivative(n, x)
+	case *expr.Piecewise:
+		return piecewiseDerivative(n, x)
+	default:
+		return nil, fmt.Errorf("cannot differentiate %T", e)
+	}
+}
+
+// derivatives differentiates both operands of a binary node
+func derivatives(left, right expr.Expr, x string) (expr.Expr, expr.Expr, error) {
+	l, err := Derivative(left, x)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := Derivative(right, x)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, r, nil
+}
+
+// powerDerivative differentiates u^v
+func powerDerivative(u, v expr.Expr, x string) (expr.Expr, error) {
+	du, dv, err := derivatives(u, v, x)
+	if err != nil {
+		return nil, err
+	}
+	if !dependsOn(v, x) {
+		// v u^(v-1) u'
+		return mul(mul(v, pow(u, sub(v, constant(1)))), du), nil
+	}
+	power := pow(u, v)
+	if !dependsOn(u, x) {
+		// u^v ln(u) v'
+		return mul(mul(power, expr.NewFunction(expr.Ln, u)), dv), nil
+	}
+	// u^v (v' ln u + v u' / u)
+	return mul(power, add(mul(dv, expr.NewFunction(expr.Ln, u)), div(mul(v, du), u))), nil
+}
+
+// functionDerivative applies the chain rule to an elementary function
+func functionDerivative(function *expr.Function, x string) (expr.Expr, error) {
+	u := function.Argument()
+	du, err := Derivative(u, x)
+	if err != nil {
+		return nil, err
+	}
+
+	var outer expr.Expr
+	switch function.Kind() {
+	case expr.Sin:
+		outer = expr.NewFunction(expr.Cos, u)
+	case expr.Cos:
+		outer = neg(expr.NewFunction(expr.Sin, u))
+	case expr.Tan:
+		// 1 / cos^2 u
+		outer = div(constant(1), pow(expr.NewFunction(expr.Cos, u), constant(2)))
+	case expr.Arcsin:
+		outer = div(constant(1), expr.NewSqrt(oneMinusSquare(u)))
+	case expr.Arccos:
+		outer = div(constant(-1), expr.NewSqrt(oneMinusSquare(u)))
+	case expr.Arctan:
+		outer = div(constant(1), add(constant(1), pow(u, constant(2))))
+	case expr.Sinh:
+		outer = expr.NewFunction(expr.Cosh, u)
+	case expr.Cosh:
+		outer = expr.NewFunction(expr.Sinh, u)
+	case expr.Tanh:
+		outer = sub(constant(1), pow(function, constant(2)))
+	case expr.Exp:
+		outer = function
+	case expr.Ln:
+		outer = div(constant(1), u)
+	default:
+		return nil, fmt.Errorf("cannot differentiate \\%s", function.Kind())
+	}
+	return mul(outer, du), nil
+}
+
+// integralDerivative applies the Leibniz rule to a definite integral whose
+// integrand does not depend on x
+func integralDerivative(integral *expr.Integral, x string) (expr.Expr, error) {
+	if !integral.IsDefinite() {
+		return nil, fmt.Errorf("cannot differentiate an indefinite integral")
+	}
+	if integral.Variable() != x && dependsOn(integral.Integrand(), x) {
+		return nil, fmt.Errorf("cannot differentiate an integral whose integrand depends on %s", x)
+	}
+
+	dLower, dUpper, err := derivatives(integral.Lower(), integral.Upper(), x)
+	if err != nil {
+		return nil, err
+	}
+	at := func(bound expr.Expr) expr.Expr {
+		return expr.Substitute(integral.Integrand(), map[string]expr.Expr{integral.Variable(): bound})
+	}
+	// f(b) b' - f(a) a'
+	return sub(mul(at(integral.Upper()), dUpper), mul(at(integral.Lower()), dLower)), nil
+}
+
+// piecewiseDerivative differentiates each branch, keeping the conditions
+func piecewiseDerivative(piecewise *expr.Piecewise, x string) (expr.Expr, error) {
+	branches := piecewise.Branches()
+	for i, branch := range branches {
+		d, err := Derivative(branch.Value, x)
+		if err != nil {
+			return nil, err
+		}
+		branches[i].Value = d
+	}
+	var otherwise expr.Expr
+	if piecewise.Otherwise() != nil {
+		d, err := Derivative(piecewise.Otherwise(), x)
+		if err != nil {
+			return nil, err
+		}
+		otherwise = d
+	}
+	return expr.NewPiecewise(branches, otherwise), nil
+}
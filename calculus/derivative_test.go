@@ -0,0 +1,61 @@
+package calculus_test
+
+import (
+	"exprtree/calculus"
+	"math"
+	"testing"
+)
+
+func TestDerivative(t *testing.T) {
+	tests := []string{
+		"3",
+		"x^3 - 2x + 1",
+		"x / (x + 1)",
+		"\\sqrt{x} + \\sqrt[3]{x}",
+		"2^x + x^x",
+		"\\sin(x^2) \\cos x",
+		"\\tan x + \\arctan x + \\arcsin(x / 2) + \\arccos(x / 2)",
+		"\\sinh x + \\cosh x + \\tanh x",
+		"\\exp(2x) \\ln x",
+		"\\log_{2} x",
+		"|x - 1|",
+		"\\sum_{i=1}^{3} x^i",
+		"\\int_{0}^{x^2} t \\, dt",
+		"\\begin{cases} x^2 & x < 1 \\\\ \\sin x & \\text{otherwise} \\end{cases}",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			e := parseExpr(t, input)
+			derivative, err := calculus.Derivative(e, "x")
+			if err != nil {
+				t.Fatalf("Derivative failed: %v", err)
+			}
+			// Compared with a central difference
+			for _, x := range []float64{0.3, 0.8, 1.6} {
+				const h = 1e-6
+				slope := (evalAt(t, e, x+h) - evalAt(t, e, x-h)) / (2 * h)
+				if got := evalAt(t, derivative, x); math.Abs(got-slope) > 1e-5*math.Max(1, math.Abs(slope)) {
+					t.Errorf("x=%g: derivative %g, central difference %g", x, got, slope)
+				}
+			}
+		})
+	}
+}
+
+func TestDerivativeUnsupported(t *testing.T) {
+	tests := []string{
+		"x!",
+		"\\binom{x}{2}",
+		"\\sum_{i=1}^{x} i",
+		"\\int_{0}^{1} x t \\, dt",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if _, err := calculus.Derivative(parseExpr(t, input), "x"); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
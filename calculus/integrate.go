@@ -0,0 +1,379 @@
+package calculus
+
+import (
+	"exprtree/expr"
+	"exprtree/quadrature"
+	"exprtree/value"
+	"fmt"
+	"math"
+)
+
+// Result is the outcome of Integrate
+type Result struct {
+	// Expr is the symbolic result: an antiderivative for an indefinite
+	// integral, F(upper) - F(lower) for a definite one. It is nil when the
+	// integral was only evaluated numerically.
+	Expr expr.Expr
+	// Value is the value of a definite integral, NaN when the bounds are not
+	// numeric or the integral is indefinite
+	Value float64
+	// ErrorEstimate is the estimated absolute error of Value, 0 when Value
+	// was computed from an antiderivative
+	ErrorEstimate float64
+}
+
+// Integrate computes an integral symbolically where the antiderivative table
+// applies and by adaptive Gauss–Kronrod quadrature otherwise
+// With numeric bounds, an antiderivative is only applied once quadrature
+// confirms the integral exists, so a divergent integral such as that of
+// 1/x from -1 to 1 is an error rather than F(1) - F(-1).
+func Integrate(integral *expr.Integral) (*Result, error) {
+	antiderivative, found := Antiderivative(integral.Integrand(), integral.Variable())
+
+	if !integral.IsDefinite() {
+		if !found {
+			return nil, fmt.Errorf("no antiderivative found for the integrand")
+		}
+		return &Result{Expr: antiderivative, Value: math.NaN()}, nil
+	}
+
+	a, ok1 := evalFloat(integral.Lower())
+	b, ok2 := evalFloat(integral.Upper())
+	numericBounds := ok1 && ok2
+
+	if found {
+		if numericBounds {
+			if _, err := quadrature.Integrate(integral.Func(), a, b); err != nil {
+				return nil, fmt.Errorf("integral does not converge: %w", err)
+			}
+		}
+		result := expr.NewSub(
+			expr.Substitute(antiderivative, map[string]expr.Expr{integral.Variable(): integral.Upper()}),
+			expr.Substitute(antiderivative, map[string]expr.Expr{integral.Variable(): integral.Lower()}),
+		)
+		v := math.NaN()
+		if evaluated, ok := result.Eval(); ok {
+			if real, ok := value.ToReal(evaluated); ok {
+				v = real.Float64()
+			}
+		}
+		return &Result{Expr: result, Value: v}, nil
+	}
+
+	if !numericBounds {
+		return nil, fmt.Errorf("no antiderivative found and the bounds are not numeric")
+	}
+	numeric, err := quadrature.Integrate(integral.Func(), a, b)
+	if err != nil {
+		return nil, fmt.Errorf("numeric integration failed: %w", err)
+	}
+	return &Result{Value: numeric.Value, ErrorEstimate: numeric.Error}, nil
+}
+
+// Antiderivative returns an antiderivative of e with respect to x
+// It covers polynomials, powers and exponentials of linear arguments and the
+// elementary functions of linear arguments. ok is false when the table does
+// not apply. The constant of integration is omitted.
+func Antiderivative(e expr.Expr, x string) (expr.Expr, bool) {
+	if !dependsOn(e, x) {
+		return expr.NewMul(e, expr.NewVariable(x)), true
+	}
+
+	switch n := e.(type) {
+	case *expr.Variable:
+		// n is x itself
+		return expr.NewDiv(expr.NewPower(n, constant(2)), constant(2)), true
+	case *expr.Add:
+		return splitTerms(n.Left(), n.Right(), x, func(l, r expr.Expr) expr.Expr { return expr.NewAdd(l, r) })
+	case *expr.Sub:
+		return splitTerms(n.Left(), n.Right(), x, func(l, r expr.Expr) expr.Expr { return expr.NewSub(l, r) })
+	case *expr.Neg:
+		inner, ok := Antiderivative(n.Operand(), x)
+		if !ok {
+			return nil, false
+		}
+		return expr.NewNeg(inner), true
+	case *expr.Mul:
+		if !dependsOn(n.Left(), x) {
+			inner, ok := Antiderivative(n.Right(), x)
+			if !ok {
+				return nil, false
+			}
+			return expr.NewMul(n.Left(), inner), true
+		}
+		if !dependsOn(n.Right(), x) {
+			inner, ok := Antiderivative(n.Left(), x)
+			if !ok {
+				return nil, false
+			}
+			return expr.NewMul(inner, n.Right()), true
+		}
+		return nil, false
+	case *expr.Div:
+		if !dependsOn(n.Right(), x) {
+			inner, ok := Antiderivative(n.Left(), x)
+			if !ok {
+				return nil, false
+			}
+			return expr.NewDiv(inner, n.Right()), true
+		}
+		if !dependsOn(n.Left(), x) {
+			// c / (ax + b) = c ln|ax + b| / a
+			a, ok := linearCoefficient(n.Right(), x)
+			if !ok {
+				return nil, false
+			}
+			return expr.NewMul(n.Left(), expr.NewDiv(lnAbs(n.Right()), a)), true
+		}
+		return nil, false
+	case *expr.Power:
+		return powerAntiderivative(n, x)
+	case *expr.NthRoot:
+		// (ax + b)^(1/n)
+		if dependsOn(n.Degree(), x) {
+			return nil, false
+		}
+		return powerAntiderivative(expr.NewPower(n.Radicand(), expr.NewDiv(constant(1), n.Degree())), x)
+	case *expr.Function:
+		return functionAntiderivative(n, x)
+	case *expr.Log:
+		// log_b(u) = ln(u) / ln(b)
+		if dependsOn(n.Base(), x) {
+			return nil, false
+		}
+		inner, ok := Antiderivative(expr.NewFunction(expr.Ln, n.Argument()), x)
+		if !ok {
+			return nil, false
+		}
+		return expr.NewDiv(inner, expr.NewFunction(expr.Ln, n.Base())), true
+	case *expr.Abs:
+		// |u| = u|u| / (2a)
+		a, ok := linearCoefficient(n.Operand(), x)
+		if !ok {
+			return nil, false
+		}
+		return expr.NewDiv(expr.NewMul(n.Operand(), n), expr.NewMul(constant(2), a)), true
+	default:
+		return nil, false
+	}
+}
+
+// splitTerms integrates two terms separately and combines the results
+func splitTerms(left, right expr.Expr, x string, combine func(l, r expr.Expr) expr.Expr) (expr.Expr, bool) {
+	l, ok := Antiderivative(left, x)
+	if !ok {
+		return nil, false
+	}
+	r, ok := Antiderivative(right, x)
+	if !ok {
+		return nil, false
+	}
+	return combine(l, r), true
+}
+
+// powerAntiderivative integrates u^n and c^u for a linear u
+func powerAntiderivative(power *expr.Power, x string) (expr.Expr, bool) {
+	base, exponent := power.Left(), power.Right()
+
+	if !dependsOn(exponent, x) {
+		a, ok := linearCoefficient(base, x)
+		if !ok {
+			return nil, false
+		}
+		if n, ok := evalFloat(exponent); ok && n == -1 {
+			return expr.NewDiv(lnAbs(base), a), true
+		}
+		// u^(n+1) / ((n+1) a)
+		raised := expr.NewAdd(exponent, constant(1))
+		return expr.NewDiv(expr.NewPower(base, raised), simplifyProduct(raised, a)), true
+	}
+
+	if !dependsOn(base, x) {
+		// c^u / (a ln c)
+		a, ok := linearCoefficient(exponent, x)
+		if !ok {
+			return nil, false
+		}
+		return expr.NewDiv(power, expr.NewMul(a, expr.NewFunction(expr.Ln, base))), true
+	}
+	return nil, false
+}
+
+// functionAntiderivative integrates an elementary function of a linear argument
+func functionAntiderivative(function *expr.Function, x string) (expr.Expr, bool) {
+	u := function.Argument()
+	a, ok := linearCoefficient(u, x)
+	if !ok {
+		return nil, false
+	}
+
+	var result expr.Expr
+	switch function.Kind() {
+	case expr.Sin:
+		result = expr.NewNeg(expr.NewFunction(expr.Cos, u))
+	case expr.Cos:
+		result = expr.NewFunction(expr.Sin, u)
+	case expr.Tan:
+		result = expr.NewNeg(lnAbs(expr.NewFunction(expr.Cos, u)))
+	case expr.Exp:
+		result = function
+	case expr.Sinh:
+		result = expr.NewFunction(expr.Cosh, u)
+	case expr.Cosh:
+		result = expr.NewFunction(expr.Sinh, u)
+	case expr.Tanh:
+		result = expr.NewFunction(expr.Ln, expr.NewFunction(expr.Cosh, u))
+	case expr.Ln:
+		// u ln u - u
+		result = expr.NewSub(expr.NewMul(u, function), u)
+	case expr.Arcsin:
+		// u arcsin u + sqrt(1 - u^2)
+		result = expr.NewAdd(expr.NewMul(u, function), expr.NewSqrt(oneMinusSquare(u)))
+	case expr.Arccos:
+		// u arccos u - sqrt(1 - u^2)
+		result = expr.NewSub(expr.NewMul(u, function), expr.NewSqrt(oneMinusSquare(u)))
+	case expr.Arctan:
+		// u arctan u - ln(1 + u^2) / 2
+		result = expr.NewSub(
+			expr.NewMul(u, function),
+			expr.NewDiv(expr.NewFunction(expr.Ln, expr.NewAdd(constant(1), expr.NewPower(u, constant(2)))), constant(2)),
+		)
+	default:
+		return nil, false
+	}
+
+	if isConstant(a, 1) {
+		return result, true
+	}
+	return expr.NewDiv(result, a), true
+}
+
+// linearCoefficient returns a when e = a x + b with a and b free of x
+// Coefficients are only recognized through sums, differences, constant
+// factors and division by constants.
+func linearCoefficient(e expr.Expr, x string) (expr.Expr, bool) {
+	if !dependsOn(e, x) {
+		return constant(0), true
+	}
+	switch n := e.(type) {
+	case *expr.Variable:
+		return constant(1), true
+	case *expr.Add, *expr.Sub:
+		binary := n.(expr.Binary)
+		l, ok := linearCoefficient(binary.Left(), x)
+		if !ok {
+			return nil, false
+		}
+		r, ok := linearCoefficient(binary.Right(), x)
+		if !ok {
+			return nil, false
+		}
+		switch {
+		case isConstant(r, 0):
+			return l, true
+		case isConstant(l, 0) && !isSub(n):
+			return r, true
+		case isSub(n):
+			return expr.NewSub(l, r), true
+		default:
+			return expr.NewAdd(l, r), true
+		}
+	case *expr.Neg:
+		a, ok := linearCoefficient(n.Operand(), x)
+		if !ok {
+			return nil, false
+		}
+		return simplifyProduct(constant(-1), a), true
+	case *expr.Mul:
+		if !dependsOn(n.Left(), x) {
+			a, ok := linearCoefficient(n.Right(), x)
+			if !ok {
+				return nil, false
+			}
+			return simplifyProduct(n.Left(), a), true
+		}
+		if !dependsOn(n.Right(), x) {
+			a, ok := linearCoefficient(n.Left(), x)
+			if !ok {
+				return nil, false
+			}
+			return simplifyProduct(a, n.Right()), true
+		}
+		return nil, false
+	case *expr.Div:
+		if dependsOn(n.Right(), x) {
+			return nil, false
+		}
+		a, ok := linearCoefficient(n.Left(), x)
+		if !ok {
+			return nil, false
+		}
+		return expr.NewDiv(a, n.Right()), true
+	default:
+		return nil, false
+	}
+}
+
+// isSub reports whether e is a difference
+func isSub(e expr.Expr) bool {
+	_, ok := e.(*expr.Sub)
+	return ok
+}
+
+// simplifyProduct multiplies two coefficients, dropping factors of 1
+func simplifyProduct(a, b expr.Expr) expr.Expr {
+	if isConstant(a, 1) {
+		return b
+	}
+	if isConstant(b, 1) {
+		return a
+	}
+	return expr.NewMul(a, b)
+}
+
+// lnAbs returns ln|u|
+func lnAbs(u expr.Expr) expr.Expr {
+	return expr.NewFunction(expr.Ln, expr.NewAbs(u))
+}
+
+// oneMinusSquare returns 1 - u^2
+func oneMinusSquare(u expr.Expr) expr.Expr {
+	return expr.NewSub(constant(1), expr.NewPower(u, constant(2)))
+}
+
+// dependsOn reports whether x occurs free in e
+func dependsOn(e expr.Expr, x string) bool {
+	for _, name := range expr.FreeVariables(e) {
+		if name == x {
+			return true
+		}
+	}
+	return false
+}
+
+// isConstant reports whether e is a constant equal to v
+func isConstant(e expr.Expr, v float64) bool {
+	c, ok := e.(*expr.Constant)
+	if !ok {
+		return false
+	}
+	real, ok := value.ToReal(c.Value())
+	return ok && real.Float64() == v
+}
+
+// evalFloat evaluates e to a float64
+func evalFloat(e expr.Expr) (float64, bool) {
+	v, ok := e.Eval()
+	if !ok {
+		return 0, false
+	}
+	real, ok := value.ToReal(v)
+	if !ok {
+		return 0, false
+	}
+	return real.Float64(), true
+}
+
+func constant(v float64) expr.Expr {
+	return expr.NewConstant(value.NewRealValue(v))
+}
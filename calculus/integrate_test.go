@@ -0,0 +1,174 @@
+package calculus_test
+
+import (
+	"exprtree/calculus"
+	"exprtree/expr"
+	"exprtree/latex"
+	"exprtree/value"
+	"math"
+	"testing"
+)
+
+func parseExpr(t *testing.T, input string) expr.Expr {
+	t.Helper()
+	parsed, err := latex.ParseLatex(input)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", input, err)
+	}
+	expression, ok := parsed.(expr.Expr)
+	if !ok {
+		t.Fatalf("expected Expression, got %T", parsed)
+	}
+	return expression
+}
+
+func evalAt(t *testing.T, e expr.Expr, x float64) float64 {
+	t.Helper()
+	v, ok := expr.EvalWith(e, map[string]value.Value{"x": value.NewRealValue(x)})
+	if !ok {
+		t.Fatalf("evaluation failed at x=%g", x)
+	}
+	real, _ := value.ToReal(v)
+	return real.Float64()
+}
+
+func TestAntiderivative(t *testing.T) {
+	tests := []string{
+		"3",
+		"x",
+		"x^2 + 2x - 1",
+		"(2x + 1)^3",
+		"\\sqrt{x}",
+		"1 / x",
+		"4 / (3x - 1)",
+		"2^x",
+		"\\exp(2x)",
+		"\\sin x + \\cos(3x)",
+		"\\tan x",
+		"\\sinh x - \\cosh x + \\tanh x",
+		"\\ln x",
+		"\\log_{2} x",
+		"\\arctan x + \\arcsin(x / 2) + \\arccos(x / 2)",
+		"|x - 1|",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			integrand := parseExpr(t, input)
+			antiderivative, ok := calculus.Antiderivative(integrand, "x")
+			if !ok {
+				t.Fatalf("no antiderivative found")
+			}
+			// F' = f, checked with a central difference
+			for _, x := range []float64{0.4, 0.9, 1.7} {
+				const h = 1e-5
+				slope := (evalAt(t, antiderivative, x+h) - evalAt(t, antiderivative, x-h)) / (2 * h)
+				if want := evalAt(t, integrand, x); math.Abs(slope-want) > 1e-5*math.Max(1, math.Abs(want)) {
+					t.Errorf("x=%g: F' = %g, f = %g", x, slope, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAntiderivativeUnknown(t *testing.T) {
+	tests := []string{
+		"x \\sin x",
+		"\\exp(x^2)",
+		"1 / (x^2 + 1)",
+		"x^x",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if _, ok := calculus.Antiderivative(parseExpr(t, input), "x"); ok {
+				t.Errorf("expected no antiderivative")
+			}
+		})
+	}
+}
+
+func TestIntegrate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+		symbolic bool
+	}{
+		{"\\int_{0}^{1} x^2 \\, dx", 1.0 / 3, true},
+		{"\\int_{1}^{e} 1 / x \\, dx", 1, true},
+		{"\\int_{0}^{1} x^{-0.5} \\, dx", 2, true},
+		{"\\int_{0}^{1} \\exp(0 - x^2) \\, dx", 0.746824132812427, false},
+		{"\\int_{0}^{2} x \\sin x \\, dx", math.Sin(2) - 2*math.Cos(2), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			e := parseExpr(t, tt.input)
+			e = expr.Substitute(e, map[string]expr.Expr{"e": expr.NewConstant(value.NewRealValue(math.E))})
+			result, err := calculus.Integrate(e.(*expr.Integral))
+			if err != nil {
+				t.Fatalf("Integrate failed: %v", err)
+			}
+			if math.Abs(result.Value-tt.expected) > 1e-9 {
+				t.Errorf("expected %.12f, got %.12f", tt.expected, result.Value)
+			}
+			if (result.Expr != nil) != tt.symbolic {
+				t.Errorf("expected symbolic=%v, got Expr %v", tt.symbolic, result.Expr)
+			}
+			if !tt.symbolic && result.ErrorEstimate > 1e-9 {
+				t.Errorf("error estimate too large: %g", result.ErrorEstimate)
+			}
+		})
+	}
+}
+
+func TestIntegrate_Divergent(t *testing.T) {
+	// The antiderivatives exist, but the integrands are unbounded between the bounds
+	tests := []string{
+		"\\int_{-1}^{1} \\frac{1}{x} \\, dx",
+		"\\int_{0}^{1} \\frac{1}{x - 0.3} \\, dx",
+		"\\int_{-1}^{1} x^{-2} \\, dx",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			integral := parseExpr(t, input).(*expr.Integral)
+			if result, err := calculus.Integrate(integral); err == nil {
+				t.Errorf("expected error, got %g", result.Value)
+			}
+			if _, ok := integral.Eval(); ok {
+				t.Errorf("expected Integral.Eval to fail as well")
+			}
+		})
+	}
+}
+
+func TestAntiderivative_NoUnitFactor(t *testing.T) {
+	// x^3 integrates to x^(3+1) / (3+1), without a factor of 1
+	x := expr.NewVariable("x")
+	three := expr.NewConstant(value.NewRealValue(3))
+	raised := expr.NewAdd(three, expr.NewConstant(value.NewRealValue(1)))
+	expected := expr.NewDiv(expr.NewPower(x, raised), raised)
+
+	antiderivative, ok := calculus.Antiderivative(expr.NewPower(x, three), "x")
+	if !ok || !antiderivative.Equals(expected) {
+		t.Errorf("expected %v, got %v", expected, antiderivative)
+	}
+}
+
+func TestIntegrateIndefinite(t *testing.T) {
+	result, err := calculus.Integrate(parseExpr(t, "\\int 3x^2 \\, dx").(*expr.Integral))
+	if err != nil {
+		t.Fatalf("Integrate failed: %v", err)
+	}
+	if got := evalAt(t, result.Expr, 2) - evalAt(t, result.Expr, 1); math.Abs(got-7) > 1e-12 {
+		t.Errorf("expected F(2) - F(1) = 7, got %g", got)
+	}
+
+	if _, err := calculus.Integrate(parseExpr(t, "\\int \\exp(x^2) \\, dx").(*expr.Integral)); err == nil {
+		t.Errorf("expected error for an integrand without antiderivative")
+	}
+	if _, err := calculus.Integrate(parseExpr(t, "\\int_{0}^{a} \\exp(x^2) \\, dx").(*expr.Integral)); err == nil {
+		t.Errorf("expected error for symbolic bounds without antiderivative")
+	}
+}
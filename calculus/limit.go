@@ -0,0 +1,490 @@
+package calculus
+
+import (
+	"exprtree/expr"
+	"exprtree/value"
+	"fmt"
+	"math"
+)
+
+// LimitMethod identifies how a limit was determined
+type LimitMethod int
+
+const (
+	DirectSubstitution LimitMethod = iota // the body is continuous at the point
+	Cancellation                          // common factors of a rational function were cancelled
+	LHopital                              // L'Hôpital's rule on a 0/0 or ∞/∞ quotient
+	Numeric                               // extrapolation of samples approaching the point
+)
+
+func (m LimitMethod) String() string {
+	switch m {
+	case DirectSubstitution:
+		return "direct substitution"
+	case Cancellation:
+		return "cancellation"
+	case LHopital:
+		return "L'Hôpital's rule"
+	case Numeric:
+		return "numeric"
+	default:
+		return fmt.Sprintf("LimitMethod(%d)", int(m))
+	}
+}
+
+// LimitResult is the outcome of Limit
+type LimitResult struct {
+	// Value is the limit, possibly ±Inf
+	Value  float64
+	Method LimitMethod
+	// Confidence is 1 for symbolic methods. For numeric limits it is the
+	// number of digits on which the extrapolated samples agree, divided by
+	// 12 and capped at 1.
+	Confidence float64
+}
+
+// maxLHopital bounds the number of times L'Hôpital's rule is applied
+const maxLHopital = 4
+
+// Limit evaluates a limit by direct substitution, cancellation of rational
+// functions and L'Hôpital's rule, falling back to numeric extrapolation
+// The point must evaluate to a real number or ±Inf. A two-sided limit whose
+// sides differ is an error.
+func Limit(limit *expr.Limit) (*LimitResult, error) {
+	a, ok := evalFloat(limit.Point())
+	if !ok || math.IsNaN(a) {
+		return nil, fmt.Errorf("limit point is not a real number")
+	}
+	direction := limit.Direction()
+	// At infinity the direction is implied by the sign
+	if math.IsInf(a, 1) {
+		direction = expr.FromBelow
+	} else if math.IsInf(a, -1) {
+		direction = expr.FromAbove
+	}
+	return limitOf(limit.Body(), limit.Variable(), a, direction, maxLHopital)
+}
+
+// limitOf tries the methods in order; depth bounds L'Hôpital recursion
+func limitOf(body expr.Expr, x string, a float64, direction expr.LimitDirection, depth int) (*LimitResult, error) {
+	if v, ok := direct(body, x, a); ok {
+		return &LimitResult{Value: v, Method: DirectSubstitution, Confidence: 1}, nil
+	}
+
+	if v, ok, err := rationalLimit(body, x, a, direction); ok || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		return &LimitResult{Value: v, Method: Cancellation, Confidence: 1}, nil
+	}
+
+	if quotient, ok := body.(*expr.Div); ok && depth > 0 {
+		if result, ok := lHopital(quotient, x, a, direction, depth); ok {
+			return result, nil
+		}
+	}
+
+	return numericLimit(body, x, a, direction)
+}
+
+// direct evaluates the body at the point
+// It is skipped for bodies with discontinuous nodes, and at infinity for
+// powers whose base and exponent both vary (the 1^∞ form).
+func direct(body expr.Expr, x string, a float64) (float64, bool) {
+	if hasNode(body, func(e expr.Expr) bool {
+		switch n := e.(type) {
+		case *expr.Floor, *expr.Ceil, *expr.Piecewise:
+			return true
+		case *expr.Power:
+			return math.IsInf(a, 0) && dependsOn(n.Left(), x) && dependsOn(n.Right(), x)
+		}
+		return false
+	}) {
+		return 0, false
+	}
+
+	v, ok := evalAt(body, x, a)
+	if !ok || math.IsNaN(v) || (!math.IsInf(a, 0) && math.IsInf(v, 0)) {
+		return 0, false
+	}
+	return v, true
+}
+
+// lHopital applies L'Hôpital's rule when the quotient is 0/0 or ∞/∞
+func lHopital(quotient *expr.Div, x string, a float64, direction expr.LimitDirection, depth int) (*LimitResult, bool) {
+	num, err := limitOf(quotient.Left(), x, a, direction, depth-1)
+	if err != nil || num.Method == Numeric {
+		return nil, false
+	}
+	den, err := limitOf(quotient.Right(), x, a, direction, depth-1)
+	if err != nil || den.Method == Numeric {
+		return nil, false
+	}
+	zeros := num.Value == 0 && den.Value == 0
+	infinities := math.IsInf(num.Value, 0) && math.IsInf(den.Value, 0)
+	if !zeros && !infinities {
+		return nil, false
+	}
+
+	dNum, err := Derivative(quotient.Left(), x)
+	if err != nil {
+		return nil, false
+	}
+	dDen, err := Derivative(quotient.Right(), x)
+	if err != nil {
+		return nil, false
+	}
+	result, err := limitOf(div(dNum, dDen), x, a, direction, depth-1)
+	if err != nil || result.Method == Numeric {
+		return nil, false
+	}
+	result.Method = LHopital
+	return result, true
+}
+
+// rationalLimit handles quotients of polynomials in x
+// At a finite point common factors (x - a) are cancelled; at infinity the
+// degrees are compared. ok is false when the body is not rational.
+func rationalLimit(body expr.Expr, x string, a float64, direction expr.LimitDirection) (float64, bool, error) {
+	numerator, denominator := body, expr.Expr(constant(1))
+	if quotient, ok := body.(*expr.Div); ok {
+		numerator, denominator = quotient.Left(), quotient.Right()
+	}
+	p, ok1 := polynomialCoefficients(numerator, x)
+	q, ok2 := polynomialCoefficients(denominator, x)
+	if !ok1 || !ok2 {
+		return 0, false, nil
+	}
+	p, q = trimZeros(p), trimZeros(q)
+	if len(q) == 0 {
+		return 0, false, nil
+	}
+	if len(p) == 0 {
+		return 0, true, nil
+	}
+
+	if math.IsInf(a, 0) {
+		// The leading terms dominate
+		ratio := p[len(p)-1] / q[len(q)-1]
+		excess := len(p) - len(q)
+		switch {
+		case excess < 0:
+			return 0, true, nil
+		case excess == 0:
+			return ratio, true, nil
+		}
+		if math.IsInf(a, -1) && excess%2 == 1 {
+			ratio = -ratio
+		}
+		return math.Copysign(math.Inf(1), ratio), true, nil
+	}
+
+	p, mp := divideRoot(p, a)
+	q, mq := divideRoot(q, a)
+	ratio := evalPolynomial(p, a) / evalPolynomial(q, a)
+	switch {
+	case mp > mq:
+		return 0, true, nil
+	case mp == mq:
+		return ratio, true, nil
+	}
+
+	// The quotient behaves like ratio / (x - a)^(mq - mp)
+	odd := (mq-mp)%2 == 1
+	switch {
+	case !odd || direction == expr.FromAbove:
+		return math.Copysign(math.Inf(1), ratio), true, nil
+	case direction == expr.FromBelow:
+		return math.Copysign(math.Inf(1), -ratio), true, nil
+	default:
+		return 0, false, fmt.Errorf("limit does not exist: the one-sided limits are -Inf and +Inf")
+	}
+}
+
+// numericLimit extrapolates samples approaching the point
+// Finite points are approached with steps h = 0.1 / 2^k, infinite points
+// through x = ±1/h. The samples are extrapolated to h = 0 by Richardson's
+// method, and the change of the last extrapolation step gives the error.
+func numericLimit(body expr.Expr, x string, a float64, direction expr.LimitDirection) (*LimitResult, error) {
+	sides := []float64{1, -1}
+	switch {
+	case math.IsInf(a, 1), direction == expr.FromBelow:
+		sides = []float64{-1}
+	case math.IsInf(a, -1), direction == expr.FromAbove:
+		sides = []float64{1}
+	}
+
+	var values, errs []float64
+	for _, side := range sides {
+		v, e, err := extrapolate(body, x, a, side)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		errs = append(errs, e)
+	}
+
+	v, e := values[0], errs[0]
+	if len(values) == 2 {
+		if !agree(values[0], values[1], math.Max(errs[0], errs[1])) {
+			return nil, fmt.Errorf("limit does not exist: the one-sided limits are %g and %g", values[1], values[0])
+		}
+		if !math.IsInf(v, 0) {
+			v = (values[0] + values[1]) / 2
+			e = math.Max(math.Max(errs[0], errs[1]), math.Abs(values[0]-values[1]))
+		}
+	}
+
+	relative := e / math.Max(1, math.Abs(v))
+	if relative > 1e-2 {
+		return nil, fmt.Errorf("limit does not appear to converge")
+	}
+	confidence := 1.0
+	if relative > 0 {
+		confidence = math.Min(1, -math.Log10(relative)/12)
+	}
+	if math.IsInf(v, 0) {
+		// Divergence is only inferred from growing samples
+		confidence = 0.5
+	}
+	return &LimitResult{Value: v, Method: Numeric, Confidence: confidence}, nil
+}
+
+// samples is the number of steps sampled by extrapolate
+const samples = 10
+
+// extrapolate samples the body on one side of the point and extrapolates
+// It returns the limit and an error estimate.
+func extrapolate(body expr.Expr, x string, a float64, side float64) (float64, float64, error) {
+	ys := make([]float64, samples)
+	h := 0.1
+	for k := range ys {
+		point := a + side*h
+		if math.IsInf(a, 0) {
+			point = -side / h
+		}
+		y, ok := evalAt(body, x, point)
+		if !ok || math.IsNaN(y) {
+			return 0, 0, fmt.Errorf("body is undefined near the limit point")
+		}
+		ys[k] = y
+		h /= 2
+	}
+
+	if diverges(ys) {
+		return math.Copysign(math.Inf(1), ys[samples-1]), 0, nil
+	}
+
+	// The extrapolation from one sample fewer shows how far the expansion
+	// in powers of h can be trusted
+	estimate, change := richardson(ys)
+	previous, _ := richardson(ys[:samples-1])
+	return estimate, math.Max(change, math.Abs(estimate-previous)), nil
+}
+
+// richardson extrapolates samples taken at h, h/2, h/4, ... to h = 0,
+// assuming an expansion in powers of h
+// Each level removes one more power; levels stop once rounding makes the
+// estimates drift. It returns the estimate and the change of the last level.
+func richardson(samples []float64) (float64, float64) {
+	ys := append([]float64(nil), samples...)
+	n := len(ys)
+	estimate, change := ys[n-1], math.Abs(ys[n-1]-ys[n-2])
+	for level := 1; level < n; level++ {
+		factor := math.Pow(2, float64(level))
+		for i := n - 1; i >= level; i-- {
+			ys[i] = (factor*ys[i] - ys[i-1]) / (factor - 1)
+		}
+		difference := math.Abs(ys[n-1] - estimate)
+		if difference > change {
+			break
+		}
+		estimate, change = ys[n-1], difference
+	}
+	return estimate, change
+}
+
+// diverges reports whether the samples grow without bound with a fixed sign
+func diverges(ys []float64) bool {
+	last := ys[len(ys)-1]
+	if math.IsInf(last, 0) {
+		return true
+	}
+	if math.Abs(last) < 1e6 {
+		return false
+	}
+	for i := len(ys) - 4; i < len(ys); i++ {
+		if math.Abs(ys[i]) < 1.5*math.Abs(ys[i-1]) || math.Signbit(ys[i]) != math.Signbit(last) {
+			return false
+		}
+	}
+	return true
+}
+
+// agree reports whether two one-sided limits are equal within tolerance
+func agree(a, b, tolerance float64) bool {
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return a == b
+	}
+	return math.Abs(a-b) <= math.Max(1e3*tolerance, 1e-6*math.Max(1, math.Abs(a)))
+}
+
+// polynomialCoefficients returns the coefficients of e as a polynomial in x,
+// lowest degree first
+// Subexpressions free of x must evaluate to real numbers.
+func polynomialCoefficients(e expr.Expr, x string) ([]float64, bool) {
+	if !dependsOn(e, x) {
+		v, ok := evalFloat(e)
+		if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		return []float64{v}, true
+	}
+
+	switch n := e.(type) {
+	case *expr.Variable:
+		return []float64{0, 1}, true
+	case *expr.Add, *expr.Sub:
+		binary := n.(expr.Binary)
+		p, ok1 := polynomialCoefficients(binary.Left(), x)
+		q, ok2 := polynomialCoefficients(binary.Right(), x)
+		if !ok1 || !ok2 {
+			return nil, false
+		}
+		sign := 1.0
+		if isSub(n) {
+			sign = -1
+		}
+		result := make([]float64, max(len(p), len(q)))
+		copy(result, p)
+		for i, c := range q {
+			result[i] += sign * c
+		}
+		return result, true
+	case *expr.Neg:
+		p, ok := polynomialCoefficients(n.Operand(), x)
+		if !ok {
+			return nil, false
+		}
+		for i := range p {
+			p[i] = -p[i]
+		}
+		return p, true
+	case *expr.Mul:
+		p, ok1 := polynomialCoefficients(n.Left(), x)
+		q, ok2 := polynomialCoefficients(n.Right(), x)
+		if !ok1 || !ok2 {
+			return nil, false
+		}
+		return multiplyPolynomials(p, q), true
+	case *expr.Div:
+		if dependsOn(n.Right(), x) {
+			return nil, false
+		}
+		p, ok1 := polynomialCoefficients(n.Left(), x)
+		d, ok2 := evalFloat(n.Right())
+		if !ok1 || !ok2 || d == 0 {
+			return nil, false
+		}
+		for i := range p {
+			p[i] /= d
+		}
+		return p, true
+	case *expr.Power:
+		if dependsOn(n.Right(), x) {
+			return nil, false
+		}
+		exponent, ok := evalFloat(n.Right())
+		if !ok || exponent < 0 || exponent > 64 || exponent != math.Trunc(exponent) {
+			return nil, false
+		}
+		base, ok := polynomialCoefficients(n.Left(), x)
+		if !ok {
+			return nil, false
+		}
+		result := []float64{1}
+		for i := 0; i < int(exponent); i++ {
+			result = multiplyPolynomials(result, base)
+		}
+		return result, true
+	default:
+		return nil, false
+	}
+}
+
+func multiplyPolynomials(p, q []float64) []float64 {
+	result := make([]float64, len(p)+len(q)-1)
+	for i, a := range p {
+		for j, b := range q {
+			result[i+j] += a * b
+		}
+	}
+	return result
+}
+
+// trimZeros removes vanishing leading coefficients
+func trimZeros(p []float64) []float64 {
+	for len(p) > 0 && p[len(p)-1] == 0 {
+		p = p[:len(p)-1]
+	}
+	return p
+}
+
+// divideRoot divides p by (x - a) as long as a is a root, returning the
+// quotient and the multiplicity of the root
+func divideRoot(p []float64, a float64) ([]float64, int) {
+	multiplicity := 0
+	for len(p) > 1 {
+		// Synthetic division, highest degree first
+		quotient := make([]float64, len(p)-1)
+		carry := 0.0
+		scale := 0.0
+		for i := len(p) - 1; i >= 1; i-- {
+			carry = carry*a + p[i]
+			quotient[i-1] = carry
+			scale = math.Max(scale, math.Abs(p[i]*math.Pow(a, float64(i))))
+		}
+		remainder := carry*a + p[0]
+		if math.Abs(remainder) > 1e-12*math.Max(scale, math.Abs(p[0])) {
+			break
+		}
+		p = quotient
+		multiplicity++
+	}
+	return p, multiplicity
+}
+
+// evalPolynomial evaluates p at x by Horner's method
+func evalPolynomial(p []float64, x float64) float64 {
+	result := 0.0
+	for i := len(p) - 1; i >= 0; i-- {
+		result = result*x + p[i]
+	}
+	return result
+}
+
+// hasNode reports whether any node of e satisfies match
+func hasNode(e expr.Expr, match func(expr.Expr) bool) bool {
+	if match(e) {
+		return true
+	}
+	for _, child := range e.Children() {
+		if c, ok := child.(expr.Expr); ok && hasNode(c, match) {
+			return true
+		}
+	}
+	return false
+}
+
+// evalAt evaluates e with x bound to v
+func evalAt(e expr.Expr, x string, v float64) (float64, bool) {
+	result, ok := expr.EvalWith(e, map[string]value.Value{x: value.NewRealValue(v)})
+	if !ok {
+		return 0, false
+	}
+	real, ok := value.ToReal(result)
+	if !ok {
+		return 0, false
+	}
+	return real.Float64(), true
+}
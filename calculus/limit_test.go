@@ -0,0 +1,143 @@
+package calculus_test
+
+import (
+	"exprtree/calculus"
+	"exprtree/expr"
+	"exprtree/value"
+	"math"
+	"testing"
+)
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+		method   calculus.LimitMethod
+	}{
+		{"\\lim_{x \\to 2} (x^2 + 1)", 5, calculus.DirectSubstitution},
+		{"\\lim_{x \\to 1} (x^2 - 1) / (x - 1)", 2, calculus.Cancellation},
+		{"\\lim_{x \\to 2} (x^3 - 8) / (x^2 - 4)", 3, calculus.Cancellation},
+		{"\\lim_{x \\to 0^+} 1 / x", math.Inf(1), calculus.Cancellation},
+		{"\\lim_{x \\to 0^-} 1 / x", math.Inf(-1), calculus.Cancellation},
+		{"\\lim_{x \\to 1} 1 / (x - 1)^2", math.Inf(1), calculus.Cancellation},
+		{"\\lim_{x \\to \\infty} (2x^2 + 1) / (x^2 - 3)", 2, calculus.Cancellation},
+		{"\\lim_{x \\to -\\infty} x^3 / (x + 1)", math.Inf(1), calculus.Cancellation},
+		{"\\lim_{x \\to \\infty} 1 / x", 0, calculus.DirectSubstitution},
+		{"\\lim_{x \\to -\\infty} \\exp x", 0, calculus.DirectSubstitution},
+		{"\\lim_{x \\to 0} \\sin x / x", 1, calculus.LHopital},
+		{"\\lim_{x \\to 0} (1 - \\cos x) / x^2", 0.5, calculus.LHopital},
+		{"\\lim_{x \\to 0} (\\exp x - 1 - x) / x^2", 0.5, calculus.LHopital},
+		{"\\lim_{x \\to \\infty} \\ln x / x", 0, calculus.LHopital},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := calculus.Limit(parseLimit(t, tt.input))
+			if err != nil {
+				t.Fatalf("Limit failed: %v", err)
+			}
+			if result.Value != tt.expected && math.Abs(result.Value-tt.expected) > 1e-12 {
+				t.Errorf("expected %g, got %g", tt.expected, result.Value)
+			}
+			if result.Method != tt.method {
+				t.Errorf("expected method %v, got %v", tt.method, result.Method)
+			}
+			if result.Confidence != 1 {
+				t.Errorf("expected confidence 1, got %g", result.Confidence)
+			}
+		})
+	}
+}
+
+func TestLimitNumeric(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      float64
+		tolerance     float64
+		minConfidence float64
+	}{
+		{"\\lim_{x \\to \\infty} (1 + 1 / x)^x", math.E, 1e-8, 0.6},
+		{"\\lim_{x \\to 0} (1 + x)^{1 / x}", math.E, 1e-8, 0.6},
+		{"\\lim_{x \\to 0^+} x \\ln x", 0, 1e-3, 0.1},
+		{"\\lim_{x \\to 0} \\lfloor x^2 \\rfloor", 0, 1e-12, 0.9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := calculus.Limit(parseLimit(t, tt.input))
+			if err != nil {
+				t.Fatalf("Limit failed: %v", err)
+			}
+			if result.Method != calculus.Numeric {
+				t.Errorf("expected a numeric limit, got %v", result.Method)
+			}
+			if math.Abs(result.Value-tt.expected) > tt.tolerance {
+				t.Errorf("expected %g, got %g", tt.expected, result.Value)
+			}
+			if result.Confidence < tt.minConfidence || result.Confidence > 1 {
+				t.Errorf("confidence %g out of range", result.Confidence)
+			}
+		})
+	}
+}
+
+func TestLimitDoesNotExist(t *testing.T) {
+	tests := []string{
+		"\\lim_{x \\to 0} 1 / x",
+		"\\lim_{x \\to 0} |x| / x",
+		"\\lim_{x \\to 1} \\lfloor x \\rfloor",
+		"\\lim_{x \\to \\infty} \\sin x",
+		"\\lim_{x \\to a} x",
+		"\\lim_{x \\to 1} \\begin{cases} 0 & x < 1 \\\\ 1 & \\text{otherwise} \\end{cases}",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if result, err := calculus.Limit(parseLimit(t, input)); err == nil {
+				t.Errorf("expected error, got %g", result.Value)
+			}
+		})
+	}
+}
+
+func TestLimitEval_NoSubstitution(t *testing.T) {
+	// Substituting the point would give 0, 1 and 1 instead of the limits
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"\\lim_{x \\to 0^-} \\lfloor x \\rfloor", -1},
+		{"\\lim_{x \\to 1^-} \\lceil x - 1 \\rceil", 0},
+		{"\\lim_{x \\to 0^+} \\begin{cases} 1 & x \\le 0 \\\\ 2 & \\text{otherwise} \\end{cases}", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			limit := parseLimit(t, tt.input)
+			if v, ok := limit.Eval(); ok {
+				t.Errorf("expected Eval to fail, got %v", v)
+			}
+			result, err := calculus.Limit(limit)
+			if err != nil {
+				t.Fatalf("Limit failed: %v", err)
+			}
+			if math.Abs(result.Value-tt.expected) > 1e-9 {
+				t.Errorf("expected %g, got %g", tt.expected, result.Value)
+			}
+		})
+	}
+
+	// A two-sided limit of a continuous body is still substituted
+	if v, ok := parseLimit(t, "\\lim_{x \\to 2} x^2").Eval(); !ok || !v.Equals(value.NewRealValue(4)) {
+		t.Errorf("expected 4, got %v", v)
+	}
+}
+
+func parseLimit(t *testing.T, input string) *expr.Limit {
+	t.Helper()
+	limit, ok := parseExpr(t, input).(*expr.Limit)
+	if !ok {
+		t.Fatalf("expected Limit for %s", input)
+	}
+	return limit
+}
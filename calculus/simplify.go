@@ -0,0 +1,103 @@
+package calculus
+
+import (
+	"exprtree/expr"
+	"exprtree/value"
+)
+
+// The constructors below fold constant operands so that repeated
+// differentiation does not grow trees of zeros and ones.
+
+func add(a, b expr.Expr) expr.Expr {
+	if v, ok := foldBinary(a, b, value.Add); ok {
+		return v
+	}
+	if isConstant(a, 0) {
+		return b
+	}
+	if isConstant(b, 0) {
+		return a
+	}
+	return expr.NewAdd(a, b)
+}
+
+func sub(a, b expr.Expr) expr.Expr {
+	if v, ok := foldBinary(a, b, value.Sub); ok {
+		return v
+	}
+	if isConstant(b, 0) {
+		return a
+	}
+	if isConstant(a, 0) {
+		return neg(b)
+	}
+	return expr.NewSub(a, b)
+}
+
+func neg(a expr.Expr) expr.Expr {
+	if c, ok := a.(*expr.Constant); ok {
+		if negated, ok := value.Neg(c.Value()); ok {
+			return expr.NewConstant(negated)
+		}
+	}
+	if inner, ok := a.(*expr.Neg); ok {
+		return inner.Operand()
+	}
+	return expr.NewNeg(a)
+}
+
+func mul(a, b expr.Expr) expr.Expr {
+	if v, ok := foldBinary(a, b, value.Mul); ok {
+		return v
+	}
+	if isConstant(a, 0) || isConstant(b, 0) {
+		return constant(0)
+	}
+	if isConstant(a, 1) {
+		return b
+	}
+	if isConstant(b, 1) {
+		return a
+	}
+	return expr.NewMul(a, b)
+}
+
+func div(a, b expr.Expr) expr.Expr {
+	if isConstant(b, 1) {
+		return a
+	}
+	if isConstant(a, 0) && !isConstant(b, 0) {
+		return constant(0)
+	}
+	if v, ok := foldBinary(a, b, value.Div); ok {
+		return v
+	}
+	return expr.NewDiv(a, b)
+}
+
+func pow(a, b expr.Expr) expr.Expr {
+	if isConstant(b, 1) {
+		return a
+	}
+	if isConstant(b, 0) {
+		return constant(1)
+	}
+	if v, ok := foldBinary(a, b, value.Pow); ok {
+		return v
+	}
+	return expr.NewPower(a, b)
+}
+
+// foldBinary evaluates op when both operands are constants
+func foldBinary(a, b expr.Expr, op func(x, y value.Value) (value.Value, bool)) (expr.Expr, bool) {
+	x, ok1 := a.(*expr.Constant)
+	y, ok2 := b.(*expr.Constant)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	v, ok := op(x.Value(), y.Value())
+	if !ok {
+		return nil, false
+	}
+	return expr.NewConstant(v), true
+}
@@ -0,0 +1,45 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+)
+
+// Abs is the absolute value |x|
+type Abs struct {
+	Unary
+	operand Expr
+}
+
+func NewAbs(operand Expr) *Abs {
+	if operand == nil {
+		panic("operand is nil")
+	}
+	return &Abs{
+		operand: operand,
+	}
+}
+
+func (a *Abs) Operand() Expr {
+	return a.operand
+}
+
+func (a *Abs) Eval() (value.Value, bool) {
+	operandVal, ok := a.operand.Eval()
+	if !ok {
+		return nil, false
+	}
+	return value.Abs(operandVal)
+}
+
+func (a *Abs) Equals(other any) bool {
+	otherAbs, ok := other.(*Abs)
+	if !ok {
+		return false
+	}
+	return a.operand.Equals(otherAbs.operand)
+}
+
+func (a *Abs) Children() []ast.HasChildren {
+	return []ast.HasChildren{a.operand}
+}
@@ -43,14 +43,7 @@ func (a *Add) Eval() (value.Value, bool) {
 		return nil, false
 	}
 
-	leftReal, ok1 := leftVal.(*value.RealValue)
-	rightReal, ok2 := rightVal.(*value.RealValue)
-	if !ok1 || !ok2 {
-		return nil, false
-	}
-
-	result := leftReal.Float64() + rightReal.Float64()
-	return value.NewRealValue(result), true
+	return value.Add(leftVal, rightVal)
 }
 
 func (a *Add) Equals(other any) bool {
@@ -0,0 +1,54 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+)
+
+// Binomial is the binomial coefficient n choose k
+type Binomial struct {
+	Expr
+	n, k Expr
+}
+
+func NewBinomial(n, k Expr) *Binomial {
+	if n == nil || k == nil {
+		panic("n or k is nil")
+	}
+	return &Binomial{
+		n: n,
+		k: k,
+	}
+}
+
+func (b *Binomial) N() Expr {
+	return b.n
+}
+
+func (b *Binomial) K() Expr {
+	return b.k
+}
+
+func (b *Binomial) Eval() (value.Value, bool) {
+	nVal, ok := b.n.Eval()
+	if !ok {
+		return nil, false
+	}
+	kVal, ok := b.k.Eval()
+	if !ok {
+		return nil, false
+	}
+	return value.Binomial(nVal, kVal)
+}
+
+func (b *Binomial) Equals(other any) bool {
+	otherBinomial, ok := other.(*Binomial)
+	if !ok {
+		return false
+	}
+	return b.n.Equals(otherBinomial.n) && b.k.Equals(otherBinomial.k)
+}
+
+func (b *Binomial) Children() []ast.HasChildren {
+	return []ast.HasChildren{b.n, b.k}
+}
@@ -0,0 +1,164 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+)
+
+// Definition is a user-defined function such as f(x, y) = x^2 + y
+type Definition struct {
+	name   string
+	params []string
+	body   Expr
+}
+
+func NewDefinition(name string, params []string, body Expr) *Definition {
+	if body == nil {
+		panic("body is nil")
+	}
+	return &Definition{
+		name:   name,
+		params: append([]string(nil), params...),
+		body:   body,
+	}
+}
+
+func (d *Definition) Name() string {
+	return d.name
+}
+
+func (d *Definition) Params() []string {
+	return append([]string(nil), d.params...)
+}
+
+func (d *Definition) Body() Expr {
+	return d.body
+}
+
+// Apply returns the body with the parameters replaced by args
+// ok is false when the number of arguments does not match.
+func (d *Definition) Apply(args []Expr) (Expr, bool) {
+	if len(args) != len(d.params) {
+		return nil, false
+	}
+	bindings := make(map[string]Expr, len(args))
+	for i, arg := range args {
+		bindings[d.params[i]] = arg
+	}
+	return Substitute(d.body, bindings), true
+}
+
+// DefaultMaxDepth is the default limit on nested calls
+const DefaultMaxDepth = 256
+
+// Definitions is a set of user-defined functions by name
+// Calls look up their definition when they are evaluated, so a function may
+// call itself or functions defined later. Evaluation tracks the nesting of
+// calls and is not safe for concurrent use.
+type Definitions struct {
+	// MaxDepth limits nested calls; deeper evaluations fail
+	MaxDepth    int
+	definitions map[string]*Definition
+	depth       int
+}
+
+func NewDefinitions() *Definitions {
+	return &Definitions{
+		MaxDepth:    DefaultMaxDepth,
+		definitions: make(map[string]*Definition),
+	}
+}
+
+// Define adds a definition, replacing any previous one of the same name
+func (d *Definitions) Define(definition *Definition) {
+	d.definitions[definition.name] = definition
+}
+
+func (d *Definitions) Lookup(name string) (*Definition, bool) {
+	definition, ok := d.definitions[name]
+	return definition, ok
+}
+
+// Call is the application of a user-defined function to arguments
+type Call struct {
+	Expr
+	definitions *Definitions
+	name        string
+	args        []Expr
+}
+
+func NewCall(definitions *Definitions, name string, args []Expr) *Call {
+	if definitions == nil {
+		panic("definitions is nil")
+	}
+	for _, arg := range args {
+		if arg == nil {
+			panic("argument is nil")
+		}
+	}
+	return &Call{
+		definitions: definitions,
+		name:        name,
+		args:        append([]Expr(nil), args...),
+	}
+}
+
+func (c *Call) Name() string {
+	return c.name
+}
+
+func (c *Call) Args() []Expr {
+	return append([]Expr(nil), c.args...)
+}
+
+func (c *Call) Definitions() *Definitions {
+	return c.definitions
+}
+
+// Eval evaluates the arguments and substitutes their values into the body
+// It fails for undefined functions, a wrong number of arguments and calls
+// nested deeper than MaxDepth.
+func (c *Call) Eval() (value.Value, bool) {
+	definition, ok := c.definitions.Lookup(c.name)
+	if !ok || len(definition.params) != len(c.args) {
+		return nil, false
+	}
+	if c.definitions.depth >= c.definitions.MaxDepth {
+		return nil, false
+	}
+
+	args := make([]Expr, len(c.args))
+	for i, arg := range c.args {
+		argVal, ok := arg.Eval()
+		if !ok {
+			return nil, false
+		}
+		args[i] = NewConstant(argVal)
+	}
+	body, _ := definition.Apply(args)
+
+	c.definitions.depth++
+	defer func() { c.definitions.depth-- }()
+	return body.Eval()
+}
+
+func (c *Call) Equals(other any) bool {
+	otherCall, ok := other.(*Call)
+	if !ok || c.name != otherCall.name || c.definitions != otherCall.definitions || len(c.args) != len(otherCall.args) {
+		return false
+	}
+	for i, arg := range c.args {
+		if !arg.Equals(otherCall.args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Call) Children() []ast.HasChildren {
+	children := make([]ast.HasChildren, len(c.args))
+	for i, arg := range c.args {
+		children[i] = arg
+	}
+	return children
+}
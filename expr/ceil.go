@@ -0,0 +1,45 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+)
+
+// Ceil rounds up to an integer
+type Ceil struct {
+	Unary
+	operand Expr
+}
+
+func NewCeil(operand Expr) *Ceil {
+	if operand == nil {
+		panic("operand is nil")
+	}
+	return &Ceil{
+		operand: operand,
+	}
+}
+
+func (c *Ceil) Operand() Expr {
+	return c.operand
+}
+
+func (c *Ceil) Eval() (value.Value, bool) {
+	operandVal, ok := c.operand.Eval()
+	if !ok {
+		return nil, false
+	}
+	return value.Ceil(operandVal)
+}
+
+func (c *Ceil) Equals(other any) bool {
+	otherCeil, ok := other.(*Ceil)
+	if !ok {
+		return false
+	}
+	return c.operand.Equals(otherCeil.operand)
+}
+
+func (c *Ceil) Children() []ast.HasChildren {
+	return []ast.HasChildren{c.operand}
+}
@@ -35,13 +35,7 @@ func (d *Div) Eval() (value.Value, bool) {
 	if !ok1 || !ok2 {
 		return nil, false
 	}
-	leftReal, ok1 := leftVal.(*value.RealValue)
-	rightReal, ok2 := rightVal.(*value.RealValue)
-	if !ok1 || !ok2 || rightReal.Float64() == 0 {
-		return nil, false
-	}
-	result := leftReal.Float64() / rightReal.Float64()
-	return value.NewRealValue(result), true
+	return value.Div(leftVal, rightVal)
 }
 
 func (d *Div) Equals(other any) bool {
@@ -0,0 +1,46 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+)
+
+// Factorial is n!
+// Non-negative integers evaluate exactly; other reals use the Gamma function.
+type Factorial struct {
+	Unary
+	operand Expr
+}
+
+func NewFactorial(operand Expr) *Factorial {
+	if operand == nil {
+		panic("operand is nil")
+	}
+	return &Factorial{
+		operand: operand,
+	}
+}
+
+func (f *Factorial) Operand() Expr {
+	return f.operand
+}
+
+func (f *Factorial) Eval() (value.Value, bool) {
+	operandVal, ok := f.operand.Eval()
+	if !ok {
+		return nil, false
+	}
+	return value.Factorial(operandVal)
+}
+
+func (f *Factorial) Equals(other any) bool {
+	otherFactorial, ok := other.(*Factorial)
+	if !ok {
+		return false
+	}
+	return f.operand.Equals(otherFactorial.operand)
+}
+
+func (f *Factorial) Children() []ast.HasChildren {
+	return []ast.HasChildren{f.operand}
+}
@@ -0,0 +1,45 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+)
+
+// Floor rounds down to an integer
+type Floor struct {
+	Unary
+	operand Expr
+}
+
+func NewFloor(operand Expr) *Floor {
+	if operand == nil {
+		panic("operand is nil")
+	}
+	return &Floor{
+		operand: operand,
+	}
+}
+
+func (f *Floor) Operand() Expr {
+	return f.operand
+}
+
+func (f *Floor) Eval() (value.Value, bool) {
+	operandVal, ok := f.operand.Eval()
+	if !ok {
+		return nil, false
+	}
+	return value.Floor(operandVal)
+}
+
+func (f *Floor) Equals(other any) bool {
+	otherFloor, ok := other.(*Floor)
+	if !ok {
+		return false
+	}
+	return f.operand.Equals(otherFloor.operand)
+}
+
+func (f *Floor) Children() []ast.HasChildren {
+	return []ast.HasChildren{f.operand}
+}
@@ -0,0 +1,126 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+	"fmt"
+)
+
+// FunctionKind identifies an elementary function of one argument
+type FunctionKind int
+
+const (
+	Sin FunctionKind = iota
+	Cos
+	Tan
+	Arcsin
+	Arccos
+	Arctan
+	Sinh
+	Cosh
+	Tanh
+	Exp
+	Ln
+)
+
+// functionDef describes an elementary function
+type functionDef struct {
+	name  string // LaTeX command name without backslash
+	apply func(value.Value) (value.Value, bool)
+}
+
+var functionDefs = map[FunctionKind]functionDef{
+	Sin:    {"sin", value.Sin},
+	Cos:    {"cos", value.Cos},
+	Tan:    {"tan", value.Tan},
+	Arcsin: {"arcsin", value.Asin},
+	Arccos: {"arccos", value.Acos},
+	Arctan: {"arctan", value.Atan},
+	Sinh:   {"sinh", value.Sinh},
+	Cosh:   {"cosh", value.Cosh},
+	Tanh:   {"tanh", value.Tanh},
+	Exp:    {"exp", value.Exp},
+	Ln:     {"ln", value.Ln},
+}
+
+// String returns the LaTeX command name of the function (e.g. "sin")
+func (k FunctionKind) String() string {
+	if def, ok := functionDefs[k]; ok {
+		return def.name
+	}
+	return fmt.Sprintf("FunctionKind(%d)", int(k))
+}
+
+// Apply evaluates the function on v
+func (k FunctionKind) Apply(v value.Value) (value.Value, bool) {
+	def, ok := functionDefs[k]
+	if !ok {
+		return nil, false
+	}
+	return def.apply(v)
+}
+
+// Float64 evaluates the function on a real number
+// ok is false outside the domain of the function.
+func (k FunctionKind) Float64(x float64) (float64, bool) {
+	result, ok := k.Apply(value.NewRealValue(x))
+	if !ok {
+		return 0, false
+	}
+	return result.(*value.RealValue).Float64(), true
+}
+
+// LookupFunction returns the function with the given LaTeX command name
+func LookupFunction(name string) (FunctionKind, bool) {
+	for kind, def := range functionDefs {
+		if def.name == name {
+			return kind, true
+		}
+	}
+	return 0, false
+}
+
+// Function is the application of an elementary function to an argument
+type Function struct {
+	Expr
+	kind     FunctionKind
+	argument Expr
+}
+
+func NewFunction(kind FunctionKind, argument Expr) *Function {
+	if argument == nil {
+		panic("argument is nil")
+	}
+	return &Function{
+		kind:     kind,
+		argument: argument,
+	}
+}
+
+func (f *Function) Kind() FunctionKind {
+	return f.kind
+}
+
+func (f *Function) Argument() Expr {
+	return f.argument
+}
+
+func (f *Function) Eval() (value.Value, bool) {
+	argVal, ok := f.argument.Eval()
+	if !ok {
+		return nil, false
+	}
+	return f.kind.Apply(argVal)
+}
+
+func (f *Function) Equals(other any) bool {
+	otherFunction, ok := other.(*Function)
+	if !ok {
+		return false
+	}
+	return f.kind == otherFunction.kind && f.argument.Equals(otherFunction.argument)
+}
+
+func (f *Function) Children() []ast.HasChildren {
+	return []ast.HasChildren{f.argument}
+}
@@ -0,0 +1,55 @@
+package expr
+
+import "exprtree/value"
+
+// Indexed is implemented by operators that bind an index variable ranging
+// over the integers from Lower to Upper, such as Sum and Product
+type Indexed interface {
+	Expr
+	Index() string
+	Lower() Expr
+	Upper() Expr
+	Body() Expr
+}
+
+// maxIterations bounds the number of terms evaluated by Sum and Product
+const maxIterations = 1 << 20
+
+// iterate folds op over the body evaluated for each integer value of the
+// index from lower to upper
+// An empty range gives identity; non-integer bounds or too many terms fail.
+func iterate(index string, lower, upper, body Expr, identity value.Value, op func(a, b value.Value) (value.Value, bool)) (value.Value, bool) {
+	lowerVal, ok := lower.Eval()
+	if !ok {
+		return nil, false
+	}
+	upperVal, ok := upper.Eval()
+	if !ok {
+		return nil, false
+	}
+	lo, ok := value.ToInt64(lowerVal)
+	if !ok {
+		return nil, false
+	}
+	hi, ok := value.ToInt64(upperVal)
+	if !ok {
+		return nil, false
+	}
+	if hi >= lo && float64(hi)-float64(lo) >= maxIterations {
+		return nil, false
+	}
+
+	result := identity
+	for i := lo; i <= hi; i++ {
+		bindings := map[string]Expr{index: NewConstant(value.NewIntegerValue(i))}
+		term, ok := Substitute(body, bindings).Eval()
+		if !ok {
+			return nil, false
+		}
+		result, ok = op(result, term)
+		if !ok {
+			return nil, false
+		}
+	}
+	return result, true
+}
@@ -0,0 +1,128 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/quadrature"
+	"exprtree/value"
+)
+
+// Integral is the integral of integrand with respect to variable
+// The variable is bound within the integrand. Lower and Upper are nil for
+// an indefinite integral.
+type Integral struct {
+	Expr
+	variable     string
+	lower, upper Expr
+	integrand    Expr
+}
+
+// NewIntegral creates a definite integral from lower to upper
+func NewIntegral(variable string, lower, upper, integrand Expr) *Integral {
+	if lower == nil || upper == nil || integrand == nil {
+		panic("lower, upper or integrand is nil")
+	}
+	return &Integral{
+		variable:  variable,
+		lower:     lower,
+		upper:     upper,
+		integrand: integrand,
+	}
+}
+
+// NewIndefiniteIntegral creates an integral without bounds
+func NewIndefiniteIntegral(variable string, integrand Expr) *Integral {
+	if integrand == nil {
+		panic("integrand is nil")
+	}
+	return &Integral{
+		variable:  variable,
+		integrand: integrand,
+	}
+}
+
+func (i *Integral) Variable() string {
+	return i.variable
+}
+
+// Lower returns the lower bound, or nil for an indefinite integral
+func (i *Integral) Lower() Expr {
+	return i.lower
+}
+
+// Upper returns the upper bound, or nil for an indefinite integral
+func (i *Integral) Upper() Expr {
+	return i.upper
+}
+
+func (i *Integral) Integrand() Expr {
+	return i.integrand
+}
+
+func (i *Integral) IsDefinite() bool {
+	return i.lower != nil
+}
+
+// Eval integrates numerically by adaptive quadrature
+// Indefinite integrals, non-real bounds and integrands that are undefined
+// in the interval fail, as does a result that misses the default tolerance.
+func (i *Integral) Eval() (value.Value, bool) {
+	if !i.IsDefinite() {
+		return nil, false
+	}
+	a, ok := evalReal(i.lower)
+	if !ok {
+		return nil, false
+	}
+	b, ok := evalReal(i.upper)
+	if !ok {
+		return nil, false
+	}
+
+	result, err := quadrature.Integrate(i.Func(), a, b)
+	if err != nil {
+		return nil, false
+	}
+	return value.NewRealValue(result.Value), true
+}
+
+// Func returns the integrand as a function of the integration variable
+func (i *Integral) Func() quadrature.Func {
+	return func(x float64) (float64, bool) {
+		bindings := map[string]Expr{i.variable: NewConstant(value.NewRealValue(x))}
+		return evalReal(Substitute(i.integrand, bindings))
+	}
+}
+
+func (i *Integral) Equals(other any) bool {
+	otherIntegral, ok := other.(*Integral)
+	if !ok {
+		return false
+	}
+	if i.variable != otherIntegral.variable || !i.integrand.Equals(otherIntegral.integrand) {
+		return false
+	}
+	if !i.IsDefinite() || !otherIntegral.IsDefinite() {
+		return i.IsDefinite() == otherIntegral.IsDefinite()
+	}
+	return i.lower.Equals(otherIntegral.lower) && i.upper.Equals(otherIntegral.upper)
+}
+
+func (i *Integral) Children() []ast.HasChildren {
+	if !i.IsDefinite() {
+		return []ast.HasChildren{i.integrand}
+	}
+	return []ast.HasChildren{i.lower, i.upper, i.integrand}
+}
+
+// evalReal evaluates e to a float64
+func evalReal(e Expr) (float64, bool) {
+	v, ok := e.Eval()
+	if !ok {
+		return 0, false
+	}
+	real, ok := value.ToReal(v)
+	if !ok {
+		return 0, false
+	}
+	return real.Float64(), true
+}
@@ -0,0 +1,103 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+	"math"
+)
+
+// LimitDirection selects the side from which a limit approaches its point
+type LimitDirection int
+
+const (
+	TwoSided  LimitDirection = iota // x → a
+	FromAbove                       // x → a^+
+	FromBelow                       // x → a^-
+)
+
+// Limit is the limit of body as variable approaches point
+// The variable is bound within the body.
+type Limit struct {
+	Expr
+	variable  string
+	point     Expr
+	direction LimitDirection
+	body      Expr
+}
+
+func NewLimit(variable string, point Expr, direction LimitDirection, body Expr) *Limit {
+	if point == nil || body == nil {
+		panic("point or body is nil")
+	}
+	return &Limit{
+		variable:  variable,
+		point:     point,
+		direction: direction,
+		body:      body,
+	}
+}
+
+func (l *Limit) Variable() string {
+	return l.variable
+}
+
+func (l *Limit) Point() Expr {
+	return l.point
+}
+
+func (l *Limit) Direction() LimitDirection {
+	return l.direction
+}
+
+func (l *Limit) Body() Expr {
+	return l.body
+}
+
+// Eval evaluates a two-sided limit by direct substitution of a finite point
+// Substitution is refused for one-sided limits and for bodies with Floor,
+// Ceil or Piecewise nodes, whose value at the point may differ from the
+// limit, and indeterminate forms such as 0/0 fail. Use calculus.Limit for
+// those and for limits at infinity.
+func (l *Limit) Eval() (value.Value, bool) {
+	if l.direction != TwoSided || hasDiscontinuity(l.body) {
+		return nil, false
+	}
+	pointVal, ok := l.point.Eval()
+	if !ok {
+		return nil, false
+	}
+	point, ok := value.ToReal(pointVal)
+	if !ok || math.IsInf(point.Float64(), 0) || math.IsNaN(point.Float64()) {
+		return nil, false
+	}
+	return Substitute(l.body, map[string]Expr{l.variable: NewConstant(pointVal)}).Eval()
+}
+
+// hasDiscontinuity reports whether e contains a Floor, Ceil or Piecewise node
+func hasDiscontinuity(e Expr) bool {
+	switch e.(type) {
+	case *Floor, *Ceil, *Piecewise:
+		return true
+	}
+	for _, child := range e.Children() {
+		if c, ok := child.(Expr); ok && hasDiscontinuity(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Limit) Equals(other any) bool {
+	otherLimit, ok := other.(*Limit)
+	if !ok {
+		return false
+	}
+	return l.variable == otherLimit.variable &&
+		l.direction == otherLimit.direction &&
+		l.point.Equals(otherLimit.point) &&
+		l.body.Equals(otherLimit.body)
+}
+
+func (l *Limit) Children() []ast.HasChildren {
+	return []ast.HasChildren{l.point, l.body}
+}
@@ -0,0 +1,63 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+)
+
+// Log is the logarithm of argument to an arbitrary base
+type Log struct {
+	Expr
+	base     Expr
+	argument Expr
+}
+
+func NewLog(base, argument Expr) *Log {
+	if base == nil {
+		panic("base is nil")
+	}
+	if argument == nil {
+		panic("argument is nil")
+	}
+	return &Log{
+		base:     base,
+		argument: argument,
+	}
+}
+
+// NewLog10 creates the common logarithm written \log x
+func NewLog10(argument Expr) *Log {
+	return NewLog(NewConstant(value.NewRealValue(10)), argument)
+}
+
+func (l *Log) Base() Expr {
+	return l.base
+}
+
+func (l *Log) Argument() Expr {
+	return l.argument
+}
+
+func (l *Log) Eval() (value.Value, bool) {
+	baseVal, ok := l.base.Eval()
+	if !ok {
+		return nil, false
+	}
+	argVal, ok := l.argument.Eval()
+	if !ok {
+		return nil, false
+	}
+	return value.Log(baseVal, argVal)
+}
+
+func (l *Log) Equals(other any) bool {
+	otherLog, ok := other.(*Log)
+	if !ok {
+		return false
+	}
+	return l.base.Equals(otherLog.base) && l.argument.Equals(otherLog.argument)
+}
+
+func (l *Log) Children() []ast.HasChildren {
+	return []ast.HasChildren{l.base, l.argument}
+}
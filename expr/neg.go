@@ -0,0 +1,45 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+)
+
+// Neg is the negation -x
+type Neg struct {
+	Unary
+	operand Expr
+}
+
+func NewNeg(operand Expr) *Neg {
+	if operand == nil {
+		panic("operand is nil")
+	}
+	return &Neg{
+		operand: operand,
+	}
+}
+
+func (n *Neg) Operand() Expr {
+	return n.operand
+}
+
+func (n *Neg) Eval() (value.Value, bool) {
+	operandVal, ok := n.operand.Eval()
+	if !ok {
+		return nil, false
+	}
+	return value.Neg(operandVal)
+}
+
+func (n *Neg) Equals(other any) bool {
+	otherNeg, ok := other.(*Neg)
+	if !ok {
+		return false
+	}
+	return n.operand.Equals(otherNeg.operand)
+}
+
+func (n *Neg) Children() []ast.HasChildren {
+	return []ast.HasChildren{n.operand}
+}
@@ -0,0 +1,54 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+)
+
+// Permutation is the number of ordered selections of k out of n, n!/(n-k)!
+type Permutation struct {
+	Expr
+	n, k Expr
+}
+
+func NewPermutation(n, k Expr) *Permutation {
+	if n == nil || k == nil {
+		panic("n or k is nil")
+	}
+	return &Permutation{
+		n: n,
+		k: k,
+	}
+}
+
+func (p *Permutation) N() Expr {
+	return p.n
+}
+
+func (p *Permutation) K() Expr {
+	return p.k
+}
+
+func (p *Permutation) Eval() (value.Value, bool) {
+	nVal, ok := p.n.Eval()
+	if !ok {
+		return nil, false
+	}
+	kVal, ok := p.k.Eval()
+	if !ok {
+		return nil, false
+	}
+	return value.Permutations(nVal, kVal)
+}
+
+func (p *Permutation) Equals(other any) bool {
+	otherPermutation, ok := other.(*Permutation)
+	if !ok {
+		return false
+	}
+	return p.n.Equals(otherPermutation.n) && p.k.Equals(otherPermutation.k)
+}
+
+func (p *Permutation) Children() []ast.HasChildren {
+	return []ast.HasChildren{p.n, p.k}
+}
@@ -0,0 +1,93 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+)
+
+// Branch is a value of a Piecewise expression with its condition
+// The condition is a proposition, which evaluates to a boolean.
+type Branch struct {
+	Condition Expr
+	Value     Expr
+}
+
+// Piecewise takes the value of the first branch whose condition holds, or
+// the otherwise value when none does
+type Piecewise struct {
+	Expr
+	branches  []Branch
+	otherwise Expr
+}
+
+// NewPiecewise creates a Piecewise expression; otherwise may be nil
+func NewPiecewise(branches []Branch, otherwise Expr) *Piecewise {
+	if len(branches) == 0 {
+		panic("no branches")
+	}
+	for _, branch := range branches {
+		if branch.Condition == nil || branch.Value == nil {
+			panic("condition or value is nil")
+		}
+	}
+	return &Piecewise{
+		branches:  append([]Branch(nil), branches...),
+		otherwise: otherwise,
+	}
+}
+
+func (p *Piecewise) Branches() []Branch {
+	return append([]Branch(nil), p.branches...)
+}
+
+// Otherwise returns the value taken when no condition holds, or nil
+func (p *Piecewise) Otherwise() Expr {
+	return p.otherwise
+}
+
+// Eval checks the conditions in order
+// It fails when a condition before the matching branch cannot be evaluated,
+// and when no condition holds and there is no otherwise value.
+func (p *Piecewise) Eval() (value.Value, bool) {
+	for _, branch := range p.branches {
+		conditionVal, ok := branch.Condition.Eval()
+		if !ok || conditionVal.Kind() != value.BoolKind {
+			return nil, false
+		}
+		if conditionVal.(*value.BoolValue).Bool() {
+			return branch.Value.Eval()
+		}
+	}
+	if p.otherwise == nil {
+		return nil, false
+	}
+	return p.otherwise.Eval()
+}
+
+func (p *Piecewise) Equals(other any) bool {
+	otherPiecewise, ok := other.(*Piecewise)
+	if !ok || len(p.branches) != len(otherPiecewise.branches) {
+		return false
+	}
+	for i, branch := range p.branches {
+		otherBranch := otherPiecewise.branches[i]
+		if !branch.Condition.Equals(otherBranch.Condition) || !branch.Value.Equals(otherBranch.Value) {
+			return false
+		}
+	}
+	if p.otherwise == nil || otherPiecewise.otherwise == nil {
+		return p.otherwise == nil && otherPiecewise.otherwise == nil
+	}
+	return p.otherwise.Equals(otherPiecewise.otherwise)
+}
+
+func (p *Piecewise) Children() []ast.HasChildren {
+	var children []ast.HasChildren
+	for _, branch := range p.branches {
+		children = append(children, branch.Condition, branch.Value)
+	}
+	if p.otherwise != nil {
+		children = append(children, p.otherwise)
+	}
+	return children
+}
@@ -3,7 +3,6 @@ package expr
 import (
 	"exprtree/ast"
 	"exprtree/value"
-	"math"
 )
 
 type Power struct {
@@ -35,14 +34,7 @@ func (p *Power) Eval() (value.Value, bool) {
 		return nil, false
 	}
 
-	baseReal, ok1 := baseVal.(*value.RealValue)
-	exponentReal, ok2 := exponentVal.(*value.RealValue)
-	if !ok1 || !ok2 {
-		return nil, false
-	}
-
-	result := math.Pow(baseReal.Float64(), exponentReal.Float64())
-	return value.NewRealValue(result), true
+	return value.Pow(baseVal, exponentVal)
 }
 
 func (p *Power) Base() Expr {
@@ -0,0 +1,62 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+)
+
+// Product is the product of body over the integers index = lower..upper
+// The index is bound within the body; an empty range gives 1.
+type Product struct {
+	Indexed
+	index        string
+	lower, upper Expr
+	body         Expr
+}
+
+func NewProduct(index string, lower, upper, body Expr) *Product {
+	if lower == nil || upper == nil || body == nil {
+		panic("lower, upper or body is nil")
+	}
+	return &Product{
+		index: index,
+		lower: lower,
+		upper: upper,
+		body:  body,
+	}
+}
+
+func (p *Product) Index() string {
+	return p.index
+}
+
+func (p *Product) Lower() Expr {
+	return p.lower
+}
+
+func (p *Product) Upper() Expr {
+	return p.upper
+}
+
+func (p *Product) Body() Expr {
+	return p.body
+}
+
+func (p *Product) Eval() (value.Value, bool) {
+	return iterate(p.index, p.lower, p.upper, p.body, value.NewIntegerValue(1), value.Mul)
+}
+
+func (p *Product) Equals(other any) bool {
+	otherProduct, ok := other.(*Product)
+	if !ok {
+		return false
+	}
+	return p.index == otherProduct.index &&
+		p.lower.Equals(otherProduct.lower) &&
+		p.upper.Equals(otherProduct.upper) &&
+		p.body.Equals(otherProduct.body)
+}
+
+func (p *Product) Children() []ast.HasChildren {
+	return []ast.HasChildren{p.lower, p.upper, p.body}
+}
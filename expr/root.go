@@ -3,7 +3,6 @@ package expr
 import (
 	"exprtree/ast"
 	"exprtree/value"
-	"math"
 )
 
 // n-th root
@@ -44,13 +43,7 @@ func (n *NthRoot) Eval() (value.Value, bool) {
 	if !ok1 || !ok2 {
 		return nil, false
 	}
-	leftReal, ok1 := leftVal.(*value.RealValue)
-	rightReal, ok2 := rightVal.(*value.RealValue)
-	if !ok1 || !ok2 || rightReal.Float64() == 0 {
-		return nil, false
-	}
-	result := math.Pow(leftReal.Float64(), 1.0/rightReal.Float64())
-	return value.NewRealValue(result), true
+	return value.Root(leftVal, rightVal)
 }
 
 func (n *NthRoot) Equals(other any) bool {
@@ -38,14 +38,7 @@ func (s *Sub) Eval() (value.Value, bool) {
 		return nil, false
 	}
 
-	leftReal, ok1 := leftVal.(*value.RealValue)
-	rightReal, ok2 := rightVal.(*value.RealValue)
-	if !ok1 || !ok2 {
-		return nil, false
-	}
-
-	result := leftReal.Float64() - rightReal.Float64()
-	return value.NewRealValue(result), true
+	return value.Sub(leftVal, rightVal)
 }
 
 func (s *Sub) Equals(other any) bool {
@@ -0,0 +1,149 @@
+package expr
+
+import "exprtree/value"
+
+// Substituter is implemented by nodes defined outside this package
+// (e.g. propositions) so that Substitute can descend into them.
+type Substituter interface {
+	Substitute(bindings map[string]Expr) Expr
+}
+
+// Substitute returns a copy of e in which every variable named in bindings
+// is replaced by the bound expression
+func Substitute(e Expr, bindings map[string]Expr) Expr {
+	switch n := e.(type) {
+	case *Variable:
+		if replacement, ok := bindings[n.Name()]; ok {
+			return replacement
+		}
+		return n
+	case *Constant:
+		return n
+	case *Add:
+		return NewAdd(Substitute(n.left, bindings), Substitute(n.right, bindings))
+	case *Sub:
+		return NewSub(Substitute(n.left, bindings), Substitute(n.right, bindings))
+	case *Mul:
+		return NewMul(Substitute(n.left, bindings), Substitute(n.right, bindings))
+	case *Div:
+		return NewDiv(Substitute(n.left, bindings), Substitute(n.right, bindings))
+	case *Power:
+		return NewPower(Substitute(n.base, bindings), Substitute(n.exponent, bindings))
+	case *NthRoot:
+		return NewNthRoot(Substitute(n.radicand, bindings), Substitute(n.degree, bindings))
+	case *Function:
+		return NewFunction(n.kind, Substitute(n.argument, bindings))
+	case *Log:
+		return NewLog(Substitute(n.base, bindings), Substitute(n.argument, bindings))
+	case *Neg:
+		return NewNeg(Substitute(n.operand, bindings))
+	case *Abs:
+		return NewAbs(Substitute(n.operand, bindings))
+	case *Floor:
+		return NewFloor(Substitute(n.operand, bindings))
+	case *Ceil:
+		return NewCeil(Substitute(n.operand, bindings))
+	case *Factorial:
+		return NewFactorial(Substitute(n.operand, bindings))
+	case *Binomial:
+		return NewBinomial(Substitute(n.n, bindings), Substitute(n.k, bindings))
+	case *Permutation:
+		return NewPermutation(Substitute(n.n, bindings), Substitute(n.k, bindings))
+	case *Sum:
+		index, body := bindIndex(n.index, n.body, bindings)
+		return NewSum(index, Substitute(n.lower, bindings), Substitute(n.upper, bindings), body)
+	case *Product:
+		index, body := bindIndex(n.index, n.body, bindings)
+		return NewProduct(index, Substitute(n.lower, bindings), Substitute(n.upper, bindings), body)
+	case *Integral:
+		variable, integrand := bindIndex(n.variable, n.integrand, bindings)
+		if !n.IsDefinite() {
+			return NewIndefiniteIntegral(variable, integrand)
+		}
+		return NewIntegral(variable, Substitute(n.lower, bindings), Substitute(n.upper, bindings), integrand)
+	case *Limit:
+		variable, body := bindIndex(n.variable, n.body, bindings)
+		return NewLimit(variable, Substitute(n.point, bindings), n.direction, body)
+	case *Piecewise:
+		branches := make([]Branch, len(n.branches))
+		for i, branch := range n.branches {
+			branches[i] = Branch{Condition: Substitute(branch.Condition, bindings), Value: Substitute(branch.Value, bindings)}
+		}
+		var otherwise Expr
+		if n.otherwise != nil {
+			otherwise = Substitute(n.otherwise, bindings)
+		}
+		return NewPiecewise(branches, otherwise)
+	case *Call:
+		args := make([]Expr, len(n.args))
+		for i, arg := range n.args {
+			args[i] = Substitute(arg, bindings)
+		}
+		return NewCall(n.definitions, n.name, args)
+	case Substituter:
+		return n.Substitute(bindings)
+	default:
+		return e
+	}
+}
+
+// bindIndex substitutes into the body of an operator binding index, such as
+// the index of a Sum or the variable of an Integral or Limit
+// The index itself is never replaced. It is renamed when a replacement
+// mentions a variable of the same name, which would otherwise be captured.
+func bindIndex(index string, body Expr, bindings map[string]Expr) (string, Expr) {
+	inner := make(map[string]Expr, len(bindings))
+	captured := false
+	for _, name := range FreeVariables(body) {
+		replacement, ok := bindings[name]
+		if !ok || name == index {
+			continue
+		}
+		inner[name] = replacement
+		if containsString(FreeVariables(replacement), index) {
+			captured = true
+		}
+	}
+
+	if captured {
+		fresh := index
+		for {
+			fresh += "'"
+			if !containsString(FreeVariables(body), fresh) && !mentionedBy(inner, fresh) {
+				break
+			}
+		}
+		inner[index] = NewVariable(fresh)
+		index = fresh
+	}
+	return index, Substitute(body, inner)
+}
+
+// mentionedBy reports whether any of the bound expressions has a free variable name
+func mentionedBy(bindings map[string]Expr, name string) bool {
+	for _, replacement := range bindings {
+		if containsString(FreeVariables(replacement), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// EvalWith evaluates e with variables bound to the given values
+// Variables without a binding make the evaluation fail as in Eval.
+func EvalWith(e Expr, env map[string]value.Value) (value.Value, bool) {
+	bindings := make(map[string]Expr, len(env))
+	for name, v := range env {
+		bindings[name] = NewConstant(v)
+	}
+	return Substitute(e, bindings).Eval()
+}
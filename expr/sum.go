@@ -0,0 +1,62 @@
+package expr
+
+import (
+	"exprtree/ast"
+	"exprtree/value"
+)
+
+// Sum is the summation of body over the integers index = lower..upper
+// The index is bound within the body; an empty range sums to 0.
+type Sum struct {
+	Indexed
+	index        string
+	lower, upper Expr
+	body         Expr
+}
+
+func NewSum(index string, lower, upper, body Expr) *Sum {
+	if lower == nil || upper == nil || body == nil {
+		panic("lower, upper or body is nil")
+	}
+	return &Sum{
+		index: index,
+		lower: lower,
+		upper: upper,
+		body:  body,
+	}
+}
+
+func (s *Sum) Index() string {
+	return s.index
+}
+
+func (s *Sum) Lower() Expr {
+	return s.lower
+}
+
+func (s *Sum) Upper() Expr {
+	return s.upper
+}
+
+func (s *Sum) Body() Expr {
+	return s.body
+}
+
+func (s *Sum) Eval() (value.Value, bool) {
+	return iterate(s.index, s.lower, s.upper, s.body, value.NewIntegerValue(0), value.Add)
+}
+
+func (s *Sum) Equals(other any) bool {
+	otherSum, ok := other.(*Sum)
+	if !ok {
+		return false
+	}
+	return s.index == otherSum.index &&
+		s.lower.Equals(otherSum.lower) &&
+		s.upper.Equals(otherSum.upper) &&
+		s.body.Equals(otherSum.body)
+}
+
+func (s *Sum) Children() []ast.HasChildren {
+	return []ast.HasChildren{s.lower, s.upper, s.body}
+}
@@ -0,0 +1,6 @@
+package expr
+
+type Unary interface {
+	Expr
+	Operand() Expr
+}
@@ -3,21 +3,58 @@ package expr
 import (
 	"exprtree/ast"
 	"exprtree/value"
+	"strings"
 )
 
+// Variable is a named unknown such as x, x_1 or v_max
+// The name is made of a base and an optional subscript; Name joins them with
+// an underscore.
 type Variable struct {
 	Expr
-	name string
+	base      string
+	subscript string
 }
 
+// NewVariable creates a Variable from a name such as x or x_1
 func NewVariable(name string) *Variable {
+	base, subscript := SplitName(name)
+	return NewSubscriptedVariable(base, subscript)
+}
+
+// NewSubscriptedVariable creates a Variable from a base and a subscript,
+// which may be empty
+func NewSubscriptedVariable(base, subscript string) *Variable {
 	return &Variable{
-		name: name,
+		base:      base,
+		subscript: subscript,
 	}
 }
 
+// SplitName splits a variable name at its first underscore
+func SplitName(name string) (base, subscript string) {
+	base, subscript, _ = strings.Cut(name, "_")
+	return base, subscript
+}
+
+// JoinName joins a base and a subscript into a variable name
+func JoinName(base, subscript string) string {
+	if subscript == "" {
+		return base
+	}
+	return base + "_" + subscript
+}
+
 func (v *Variable) Name() string {
-	return v.name
+	return JoinName(v.base, v.subscript)
+}
+
+func (v *Variable) Base() string {
+	return v.base
+}
+
+// Subscript returns the subscript of the name, or "" if there is none
+func (v *Variable) Subscript() string {
+	return v.subscript
 }
 
 func (v *Variable) Eval() (value.Value, bool) {
@@ -29,7 +66,7 @@ func (v *Variable) Equals(other any) bool {
 	if !ok {
 		return false
 	}
-	return v.name == otherVar.name
+	return v.base == otherVar.base && v.subscript == otherVar.subscript
 }
 
 func (v *Variable) Children() []ast.HasChildren {
@@ -0,0 +1,49 @@
+package expr
+
+import "exprtree/ast"
+
+// FreeVariables returns the names of the variables in node that are not
+// bound by an enclosing Sum, Product, Integral or Limit, in order of first
+// appearance
+// It accepts any node with children so that propositions can be analyzed too.
+func FreeVariables(node ast.HasChildren) []string {
+	var names []string
+	seen := map[string]bool{}
+	collectFreeVariables(node, map[string]int{}, seen, &names)
+	return names
+}
+
+func collectFreeVariables(node ast.HasChildren, bound map[string]int, seen map[string]bool, names *[]string) {
+	switch n := node.(type) {
+	case *Variable:
+		name := n.Name()
+		if bound[name] == 0 && !seen[name] {
+			seen[name] = true
+			*names = append(*names, name)
+		}
+	case Indexed:
+		// The bounds are outside the scope of the index
+		collectFreeVariables(n.Lower(), bound, seen, names)
+		collectFreeVariables(n.Upper(), bound, seen, names)
+		bound[n.Index()]++
+		collectFreeVariables(n.Body(), bound, seen, names)
+		bound[n.Index()]--
+	case *Integral:
+		if n.IsDefinite() {
+			collectFreeVariables(n.lower, bound, seen, names)
+			collectFreeVariables(n.upper, bound, seen, names)
+		}
+		bound[n.variable]++
+		collectFreeVariables(n.integrand, bound, seen, names)
+		bound[n.variable]--
+	case *Limit:
+		collectFreeVariables(n.point, bound, seen, names)
+		bound[n.variable]++
+		collectFreeVariables(n.body, bound, seen, names)
+		bound[n.variable]--
+	default:
+		for _, child := range node.Children() {
+			collectFreeVariables(child, bound, seen, names)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package exprjson
+
+import (
+	"encoding/json"
+	"exprtree/expr"
+	"exprtree/prop"
+	"exprtree/value"
+	"fmt"
+	"math/big"
+)
+
+// relations maps the names written for inequalities to their relations
+var relations = map[string]prop.Relation{
+	prop.Less.String():         prop.Less,
+	prop.LessEqual.String():    prop.LessEqual,
+	prop.Greater.String():      prop.Greater,
+	prop.GreaterEqual.String(): prop.GreaterEqual,
+}
+
+// Options configures Unmarshal
+type Options struct {
+	// Definitions receives the definitions written in the document and
+	// resolves calls; without it the calls share a new set of definitions
+	Definitions *expr.Definitions
+}
+
+// Unmarshal decodes a JSON document written by Marshal
+func Unmarshal(data []byte) (expr.Expr, error) {
+	return UnmarshalWithOptions(data, Options{})
+}
+
+// UnmarshalWithOptions decodes a JSON document written by Marshal with
+// options
+// The definitions in the document replace any of the same name in
+// Definitions.
+func UnmarshalWithOptions(data []byte, options Options) (expr.Expr, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to read JSON: %w", err)
+	}
+	if doc.Version != Version {
+		return nil, fmt.Errorf("unsupported version: %d", doc.Version)
+	}
+	if doc.Expr == nil {
+		return nil, fmt.Errorf("document has no expr")
+	}
+
+	decoder := &decoder{definitions: options.Definitions, documented: make(map[string]bool)}
+	if decoder.definitions == nil {
+		decoder.definitions = expr.NewDefinitions()
+	}
+	for _, d := range doc.Definitions {
+		if d == nil || d.Body == nil {
+			return nil, fmt.Errorf("definition has no body")
+		}
+		decoder.documented[d.Name] = true
+	}
+	for _, d := range doc.Definitions {
+		body, err := decoder.expr(d.Body)
+		if err != nil {
+			return nil, fmt.Errorf("definition of %s: %w", d.Name, err)
+		}
+		decoder.definitions.Define(expr.NewDefinition(d.Name, d.Params, body))
+	}
+	return decoder.expr(doc.Expr)
+}
+
+// decoder converts nodes to expressions
+// A call must name a function defined in the document, whose definition
+// may come after the call, or in the definitions given in the options.
+type decoder struct {
+	definitions *expr.Definitions
+	documented  map[string]bool
+}
+
+func (dec *decoder) expr(n *node) (expr.Expr, error) {
+	switch n.Type {
+	case typeConstant:
+		if n.Value == nil {
+			return nil, fmt.Errorf("constant node has no value")
+		}
+		v, err := constantValue(n.Value)
+		if err != nil {
+			return nil, err
+		}
+		return expr.NewConstant(v), nil
+	case typeVariable:
+		if n.Name == "" {
+			return nil, fmt.Errorf("variable node has no name")
+		}
+		return expr.NewSubscriptedVariable(n.Name, n.Subscript), nil
+	case typeAdd, typeSub, typeMul, typeDiv, typeEqual, typeInequality, typeAnd:
+		return dec.binary(n)
+	case typePower:
+		c, err := dec.children(n, field{"base", n.Base}, field{"exponent", n.Exponent})
+		if err != nil {
+			return nil, err
+		}
+		return expr.NewPower(c[0], c[1]), nil
+	case typeRoot:
+		c, err := dec.children(n, field{"radicand", n.Radicand}, field{"degree", n.Degree})
+		if err != nil {
+			return nil, err
+		}
+		return expr.NewNthRoot(c[0], c[1]), nil
+	case typeNeg, typeAbs, typeFloor, typeCeil, typeFactorial:
+		return dec.unary(n)
+	case typeBinomial, typePermutation:
+		c, err := dec.children(n, field{"n", n.N}, field{"k", n.K})
+		if err != nil {
+			return nil, err
+		}
+		if n.Type == typeBinomial {
+			return expr.NewBinomial(c[0], c[1]), nil
+		}
+		return expr.NewPermutation(c[0], c[1]), nil
+	case typeFunction:
+		kind, ok := expr.LookupFunction(n.Function)
+		if !ok {
+			return nil, fmt.Errorf("unknown function: %q", n.Function)
+		}
+		c, err := dec.children(n, field{"argument", n.Argument})
+		if err != nil {
+			return nil, err
+		}
+		return expr.NewFunction(kind, c[0]), nil
+	case typeLog:
+		c, err := dec.children(n, field{"base", n.Base}, field{"argument", n.Argument})
+		if err != nil {
+			return nil, err
+		}
+		return expr.NewLog(c[0], c[1]), nil
+	case typeSum, typeProduct:
+		c, err := dec.children(n, field{"lower", n.Lower}, field{"upper", n.Upper}, field{"body", n.Body})
+		if err != nil {
+			return nil, err
+		}
+		if n.Type == typeSum {
+			return expr.NewSum(n.Index, c[0], c[1], c[2]), nil
+		}
+		return expr.NewProduct(n.Index, c[0], c[1], c[2]), nil
+	case typeIntegral:
+		return dec.integral(n)
+	case typeLimit:
+		return dec.limit(n)
+	case typePiecewise:
+		return dec.piecewise(n)
+	case typeCall:
+		if _, ok := dec.definitions.Lookup(n.Name); !ok && !dec.documented[n.Name] {
+			return nil, fmt.Errorf("undefined function: %q", n.Name)
+		}
+		args := make([]expr.Expr, len(n.Args))
+		for i, arg := range n.Args {
+			e, err := dec.child(n, "args", arg)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = e
+		}
+		return expr.NewCall(dec.definitions, n.Name, args), nil
+	default:
+		return nil, fmt.Errorf("unknown node type: %q", n.Type)
+	}
+}
+
+// field is a child node with its JSON name, for error messages
+type field struct {
+	name string
+	node *node
+}
+
+// child decodes a required child of n
+func (dec *decoder) child(n *node, name string, c *node) (expr.Expr, error) {
+	if c == nil {
+		return nil, fmt.Errorf("%s node has no %s", n.Type, name)
+	}
+	return dec.expr(c)
+}
+
+// children decodes required children of n
+func (dec *decoder) children(n *node, fields ...field) ([]expr.Expr, error) {
+	exprs := make([]expr.Expr, len(fields))
+	for i, f := range fields {
+		e, err := dec.child(n, f.name, f.node)
+		if err != nil {
+			return nil, err
+		}
+		exprs[i] = e
+	}
+	return exprs, nil
+}
+
+func (dec *decoder) unary(n *node) (expr.Expr, error) {
+	operand, err := dec.child(n, "operand", n.Operand)
+	if err != nil {
+		return nil, err
+	}
+	switch n.Type {
+	case typeNeg:
+		return expr.NewNeg(operand), nil
+	case typeAbs:
+		return expr.NewAbs(operand), nil
+	case typeFloor:
+		return expr.NewFloor(operand), nil
+	case typeCeil:
+		return expr.NewCeil(operand), nil
+	default:
+		return expr.NewFactorial(operand), nil
+	}
+}
+
+func (dec *decoder) binary(n *node) (expr.Expr, error) {
+	c, err := dec.children(n, field{"left", n.Left}, field{"right", n.Right})
+	if err != nil {
+		return nil, err
+	}
+	left, right := c[0], c[1]
+	switch n.Type {
+	case typeAdd:
+		return expr.NewAdd(left, right), nil
+	case typeSub:
+		return expr.NewSub(left, right), nil
+	case typeMul:
+		return expr.NewMul(left, right), nil
+	case typeDiv:
+		return expr.NewDiv(left, right), nil
+	case typeEqual:
+		return prop.NewEqual(left, right), nil
+	case typeInequality:
+		relation, ok := relations[n.Relation]
+		if !ok {
+			return nil, fmt.Errorf("unknown relation: %q", n.Relation)
+		}
+		return prop.NewInequality(relation, left, right), nil
+	default:
+		return prop.NewAnd(left, right), nil
+	}
+}
+
+// integral decodes a definite integral, or an indefinite one when both
+// bounds are missing
+func (dec *decoder) integral(n *node) (expr.Expr, error) {
+	integrand, err := dec.child(n, "integrand", n.Integrand)
+	if err != nil {
+		return nil, err
+	}
+	if n.Lower == nil && n.Upper == nil {
+		return expr.NewIndefiniteIntegral(n.Variable, integrand), nil
+	}
+	c, err := dec.children(n, field{"lower", n.Lower}, field{"upper", n.Upper})
+	if err != nil {
+		return nil, err
+	}
+	return expr.NewIntegral(n.Variable, c[0], c[1], integrand), nil
+}
+
+func (dec *decoder) limit(n *node) (expr.Expr, error) {
+	direction, ok := expr.TwoSided, false
+	for d, name := range limitDirections {
+		if name == n.Direction {
+			direction, ok = d, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown limit direction: %q", n.Direction)
+	}
+	c, err := dec.children(n, field{"point", n.Point}, field{"body", n.Body})
+	if err != nil {
+		return nil, err
+	}
+	return expr.NewLimit(n.Variable, c[0], direction, c[1]), nil
+}
+
+func (dec *decoder) piecewise(n *node) (expr.Expr, error) {
+	if len(n.Branches) == 0 {
+		return nil, fmt.Errorf("piecewise node has no branches")
+	}
+	branches := make([]expr.Branch, len(n.Branches))
+	for i, b := range n.Branches {
+		if b == nil {
+			return nil, fmt.Errorf("piecewise node has an empty branch")
+		}
+		c, err := dec.children(n, field{"condition", b.Condition}, field{"value", b.Value})
+		if err != nil {
+			return nil, err
+		}
+		branches[i] = expr.Branch{Condition: c[0], Value: c[1]}
+	}
+	var otherwise expr.Expr
+	if n.Otherwise != nil {
+		var err error
+		otherwise, err = dec.expr(n.Otherwise)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expr.NewPiecewise(branches, otherwise), nil
+}
+
+// constantValue decodes a value of any kind
+func constantValue(c *constant) (value.Value, error) {
+	switch c.Kind {
+	case kindReal:
+		var x float
+		if err := json.Unmarshal(c.Value, &x); err != nil {
+			return nil, fmt.Errorf("invalid real value: %w", err)
+		}
+		return value.NewRealValue(float64(x)), nil
+	case kindInteger:
+		var s string
+		if err := json.Unmarshal(c.Value, &s); err != nil {
+			return nil, fmt.Errorf("invalid integer value: %s", c.Value)
+		}
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer value: %q", s)
+		}
+		return value.NewBigIntegerValue(n), nil
+	case kindBool:
+		var b bool
+		if err := json.Unmarshal(c.Value, &b); err != nil {
+			return nil, fmt.Errorf("invalid bool value: %s", c.Value)
+		}
+		return value.NewBoolValue(b), nil
+	case kindInterval:
+		var bounds []float
+		if err := json.Unmarshal(c.Value, &bounds); err != nil || len(bounds) != 2 {
+			return nil, fmt.Errorf("invalid interval value: %s", c.Value)
+		}
+		// NewIntervalValue would swap reversed bounds
+		if bounds[0] > bounds[1] {
+			return nil, fmt.Errorf("invalid interval value: %s", c.Value)
+		}
+		return value.NewIntervalValue(float64(bounds[0]), float64(bounds[1])), nil
+	case kindDual:
+		var x float
+		if err := json.Unmarshal(c.Value, &x); err != nil {
+			return nil, fmt.Errorf("invalid dual value: %w", err)
+		}
+		derivatives := make([]float64, len(c.Derivatives))
+		for i, d := range c.Derivatives {
+			derivatives[i] = float64(d)
+		}
+		return value.NewDualValue(float64(x), derivatives...), nil
+	default:
+		return nil, fmt.Errorf("unknown value kind: %q", c.Kind)
+	}
+}
@@ -0,0 +1,288 @@
+package exprjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"exprtree/expr"
+	"exprtree/prop"
+	"exprtree/value"
+	"fmt"
+	"sort"
+)
+
+// limitDirections names the directions of limits
+var limitDirections = map[expr.LimitDirection]string{
+	expr.TwoSided:  "two_sided",
+	expr.FromAbove: "from_above",
+	expr.FromBelow: "from_below",
+}
+
+// Marshal encodes an expression or proposition as a JSON document such as
+// {"version":1,"expr":{"type":"add","left":...,"right":...}}
+// The definitions of called functions are written along with it, so all
+// calls must share one set of definitions.
+func Marshal(e expr.Expr) ([]byte, error) {
+	enc := &encoder{called: make(map[string]bool)}
+	root, err := enc.node(e)
+	if err != nil {
+		return nil, err
+	}
+	definitions, err := enc.definitionNodes()
+	if err != nil {
+		return nil, err
+	}
+	// Relations such as "<=" are written as they are, not as \u003c=
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(&document{Version: Version, Expr: root, Definitions: definitions}); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+// encoder converts expressions to nodes, collecting the functions they call
+type encoder struct {
+	definitions *expr.Definitions
+	called      map[string]bool
+}
+
+func (enc *encoder) node(e expr.Expr) (*node, error) {
+	switch n := e.(type) {
+	case *expr.Constant:
+		c, err := constantNode(n.Value())
+		if err != nil {
+			return nil, err
+		}
+		return &node{Type: typeConstant, Value: c}, nil
+	case *expr.Variable:
+		return &node{Type: typeVariable, Name: n.Base(), Subscript: n.Subscript()}, nil
+	case *expr.Add:
+		return enc.binary(typeAdd, n.Left(), n.Right())
+	case *expr.Sub:
+		return enc.binary(typeSub, n.Left(), n.Right())
+	case *expr.Mul:
+		return enc.binary(typeMul, n.Left(), n.Right())
+	case *expr.Div:
+		return enc.binary(typeDiv, n.Left(), n.Right())
+	case *expr.Power:
+		c, err := enc.nodes(n.Base(), n.Exponent())
+		if err != nil {
+			return nil, err
+		}
+		return &node{Type: typePower, Base: c[0], Exponent: c[1]}, nil
+	case *expr.NthRoot:
+		c, err := enc.nodes(n.Radicand(), n.Degree())
+		if err != nil {
+			return nil, err
+		}
+		return &node{Type: typeRoot, Radicand: c[0], Degree: c[1]}, nil
+	case *expr.Neg:
+		return enc.unary(typeNeg, n.Operand())
+	case *expr.Abs:
+		return enc.unary(typeAbs, n.Operand())
+	case *expr.Floor:
+		return enc.unary(typeFloor, n.Operand())
+	case *expr.Ceil:
+		return enc.unary(typeCeil, n.Operand())
+	case *expr.Factorial:
+		return enc.unary(typeFactorial, n.Operand())
+	case *expr.Binomial:
+		return enc.combinatorial(typeBinomial, n.N(), n.K())
+	case *expr.Permutation:
+		return enc.combinatorial(typePermutation, n.N(), n.K())
+	case *expr.Function:
+		c, err := enc.nodes(n.Argument())
+		if err != nil {
+			return nil, err
+		}
+		return &node{Type: typeFunction, Function: n.Kind().String(), Argument: c[0]}, nil
+	case *expr.Log:
+		c, err := enc.nodes(n.Base(), n.Argument())
+		if err != nil {
+			return nil, err
+		}
+		return &node{Type: typeLog, Base: c[0], Argument: c[1]}, nil
+	case *expr.Sum:
+		return enc.indexed(typeSum, n)
+	case *expr.Product:
+		return enc.indexed(typeProduct, n)
+	case *expr.Integral:
+		// Lower and Upper are nil for an indefinite integral
+		c, err := enc.nodes(n.Lower(), n.Upper(), n.Integrand())
+		if err != nil {
+			return nil, err
+		}
+		return &node{Type: typeIntegral, Variable: n.Variable(), Lower: c[0], Upper: c[1], Integrand: c[2]}, nil
+	case *expr.Limit:
+		c, err := enc.nodes(n.Point(), n.Body())
+		if err != nil {
+			return nil, err
+		}
+		return &node{Type: typeLimit, Variable: n.Variable(), Direction: limitDirections[n.Direction()], Point: c[0], Body: c[1]}, nil
+	case *expr.Piecewise:
+		return enc.piecewise(n)
+	case *expr.Call:
+		return enc.call(n)
+	case *prop.Equal:
+		return enc.binary(typeEqual, n.Left(), n.Right())
+	case *prop.Inequality:
+		result, err := enc.binary(typeInequality, n.Left(), n.Right())
+		if err != nil {
+			return nil, err
+		}
+		result.Relation = n.Relation().String()
+		return result, nil
+	case *prop.And:
+		return enc.binary(typeAnd, n.Left(), n.Right())
+	default:
+		return nil, fmt.Errorf("unsupported expression type: %T", e)
+	}
+}
+
+// nodes encodes several expressions, leaving nil ones nil
+func (enc *encoder) nodes(exprs ...expr.Expr) ([]*node, error) {
+	nodes := make([]*node, len(exprs))
+	for i, e := range exprs {
+		if e == nil {
+			continue
+		}
+		n, err := enc.node(e)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = n
+	}
+	return nodes, nil
+}
+
+func (enc *encoder) unary(nodeType string, operand expr.Expr) (*node, error) {
+	c, err := enc.nodes(operand)
+	if err != nil {
+		return nil, err
+	}
+	return &node{Type: nodeType, Operand: c[0]}, nil
+}
+
+func (enc *encoder) binary(nodeType string, left, right expr.Expr) (*node, error) {
+	c, err := enc.nodes(left, right)
+	if err != nil {
+		return nil, err
+	}
+	return &node{Type: nodeType, Left: c[0], Right: c[1]}, nil
+}
+
+func (enc *encoder) combinatorial(nodeType string, n, k expr.Expr) (*node, error) {
+	c, err := enc.nodes(n, k)
+	if err != nil {
+		return nil, err
+	}
+	return &node{Type: nodeType, N: c[0], K: c[1]}, nil
+}
+
+// indexed encodes a Sum or Product
+func (enc *encoder) indexed(nodeType string, indexed expr.Indexed) (*node, error) {
+	c, err := enc.nodes(indexed.Lower(), indexed.Upper(), indexed.Body())
+	if err != nil {
+		return nil, err
+	}
+	return &node{Type: nodeType, Index: indexed.Index(), Lower: c[0], Upper: c[1], Body: c[2]}, nil
+}
+
+func (enc *encoder) piecewise(piecewise *expr.Piecewise) (*node, error) {
+	result := &node{Type: typePiecewise}
+	for _, b := range piecewise.Branches() {
+		c, err := enc.nodes(b.Condition, b.Value)
+		if err != nil {
+			return nil, err
+		}
+		result.Branches = append(result.Branches, &branch{Condition: c[0], Value: c[1]})
+	}
+	c, err := enc.nodes(piecewise.Otherwise())
+	if err != nil {
+		return nil, err
+	}
+	result.Otherwise = c[0]
+	return result, nil
+}
+
+// call encodes a Call and records the function it calls
+func (enc *encoder) call(call *expr.Call) (*node, error) {
+	if enc.definitions == nil {
+		enc.definitions = call.Definitions()
+	} else if enc.definitions != call.Definitions() {
+		return nil, fmt.Errorf("calls to %s use a different set of definitions", call.Name())
+	}
+	enc.called[call.Name()] = true
+
+	args, err := enc.nodes(call.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	return &node{Type: typeCall, Name: call.Name(), Args: args}, nil
+}
+
+// definitionNodes encodes the definitions of the called functions, sorted
+// by name
+// Functions that are called but not defined are left out; their calls
+// only decode with Options.Definitions defining them.
+func (enc *encoder) definitionNodes() ([]*definition, error) {
+	var definitions []*definition
+	done := make(map[string]bool)
+	for len(done) < len(enc.called) {
+		// Encoding a body may call further functions
+		var names []string
+		for name := range enc.called {
+			if !done[name] {
+				names = append(names, name)
+			}
+		}
+		for _, name := range names {
+			done[name] = true
+			d, ok := enc.definitions.Lookup(name)
+			if !ok {
+				continue
+			}
+			body, err := enc.node(d.Body())
+			if err != nil {
+				return nil, err
+			}
+			definitions = append(definitions, &definition{Name: d.Name(), Params: append([]string{}, d.Params()...), Body: body})
+		}
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
+	})
+	return definitions, nil
+}
+
+// constantNode encodes a value of any kind
+func constantNode(v value.Value) (*constant, error) {
+	var (
+		kind        string
+		raw         any
+		derivatives []float
+	)
+	switch c := v.(type) {
+	case *value.RealValue:
+		kind, raw = kindReal, float(c.Float64())
+	case *value.IntegerValue:
+		kind, raw = kindInteger, c.BigInt().String()
+	case *value.BoolValue:
+		kind, raw = kindBool, c.Bool()
+	case *value.IntervalValue:
+		kind, raw = kindInterval, []float{float(c.Lo()), float(c.Hi())}
+	case *value.DualValue:
+		kind, raw = kindDual, float(c.Float64())
+		for _, d := range c.Derivatives() {
+			derivatives = append(derivatives, float(d))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported constant value type: %T", v)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &constant{Kind: kind, Value: data, Derivatives: derivatives}, nil
+}
@@ -0,0 +1,266 @@
+package exprjson_test
+
+import (
+	"exprtree/expr"
+	"exprtree/exprjson"
+	"exprtree/latex"
+	"exprtree/prop"
+	"exprtree/value"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, input string) expr.Expr {
+	t.Helper()
+	result, err := latex.ParseLatex(input)
+	if err != nil {
+		t.Fatalf("ParseLatex failed: %v", err)
+	}
+	return result.(expr.Expr)
+}
+
+func roundTrip(t *testing.T, e expr.Expr) expr.Expr {
+	t.Helper()
+	data, err := exprjson.Marshal(e)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	result, err := exprjson.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal failed for %s: %v", data, err)
+	}
+	return result
+}
+
+func TestRoundTrip(t *testing.T) {
+	inputs := []string{
+		"2x^2 + 1",
+		"a - (b - c) / d",
+		"-x_1 * y_{max}",
+		"(x + 1)^{n}",
+		"\\sqrt{x} + \\sqrt[3]{2}",
+		"\\log_{2}(x) + \\log(x) + \\ln(x)",
+		"\\sin^2(x) + \\arctan(x) + \\cosh(x)",
+		"|x| + \\lfloor x \\rfloor + \\lceil x \\rceil",
+		"n! + \\binom{n}{k}",
+		"\\sum_{i=1}^{n} i^2",
+		"\\prod_{k=1}^{n} k",
+		"\\int_{0}^{1} x^2 dx",
+		"\\int x dx",
+		"\\lim_{x \\to 0} x",
+		"\\lim_{x \\to 0^+} \\frac{1}{x}",
+		"\\lim_{x \\to -\\infty} x",
+		"\\begin{cases} x & x > 0 \\\\ 0 & \\text{otherwise} \\end{cases}",
+		"\\begin{cases} 1 & x \\ge 0 \\end{cases}",
+		"a = b",
+		"0 \\le x < 1",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			original := parse(t, input)
+			if result := roundTrip(t, original); !result.Equals(original) {
+				t.Errorf("round trip changed the expression")
+			}
+		})
+	}
+}
+
+func TestRoundTrip_Values(t *testing.T) {
+	big100 := new(big.Int).Lsh(big.NewInt(1), 100)
+	tests := []struct {
+		name  string
+		value value.Value
+	}{
+		{"real", value.NewRealValue(0.1)},
+		{"smallest real", value.NewRealValue(math.SmallestNonzeroFloat64)},
+		{"largest real", value.NewRealValue(math.MaxFloat64)},
+		{"infinity", value.NewRealValue(math.Inf(1))},
+		{"negative infinity", value.NewRealValue(math.Inf(-1))},
+		{"integer", value.NewIntegerValue(-42)},
+		{"big integer", value.NewBigIntegerValue(big100)},
+		{"true", value.NewBoolValue(true)},
+		{"false", value.NewBoolValue(false)},
+		{"interval", value.NewIntervalValue(-0.5, math.Inf(1))},
+		{"dual", value.NewDualValue(1.5, 1, 0, math.Pi)},
+		{"dual without derivatives", value.NewDualValue(2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := expr.NewConstant(tt.value)
+			if result := roundTrip(t, original); !result.Equals(original) {
+				t.Errorf("Expected %v, got %v", tt.value, result.(*expr.Constant).Value())
+			}
+		})
+	}
+
+	// NaN is not equal to itself
+	result := roundTrip(t, expr.NewConstant(value.NewRealValue(math.NaN())))
+	if real, ok := result.(*expr.Constant).Value().(*value.RealValue); !ok || !math.IsNaN(real.Float64()) {
+		t.Errorf("Expected NaN, got %v", result)
+	}
+	result = roundTrip(t, expr.NewConstant(value.NewRealValue(math.Copysign(0, -1))))
+	if real := result.(*expr.Constant).Value().(*value.RealValue); !math.Signbit(real.Float64()) {
+		t.Errorf("Expected -0, got %v", real.Float64())
+	}
+}
+
+func TestRoundTrip_Variables(t *testing.T) {
+	// A base containing an underscore is kept apart from the subscript
+	original := expr.NewSubscriptedVariable("v_max", "1")
+	if result := roundTrip(t, original); !result.Equals(original) {
+		t.Errorf("Expected %v, got %v", original, result)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    expr.Expr
+		expected string
+	}{
+		{
+			"sum",
+			expr.NewAdd(expr.NewVariable("x_1"), expr.NewConstant(value.NewIntegerValue(2))),
+			`{"type":"add","left":{"type":"variable","name":"x","subscript":"1"},"right":{"type":"constant","value":{"kind":"integer","value":"2"}}}`,
+		},
+		{
+			"power",
+			expr.NewPower(expr.NewVariable("x"), expr.NewConstant(value.NewRealValue(0.5))),
+			`{"type":"power","base":{"type":"variable","name":"x"},"exponent":{"type":"constant","value":{"kind":"real","value":0.5}}}`,
+		},
+		{
+			"inequality",
+			prop.NewInequality(prop.LessEqual, expr.NewVariable("x"), expr.NewConstant(value.NewRealValue(math.Inf(1)))),
+			`{"type":"inequality","relation":"<=","left":{"type":"variable","name":"x"},"right":{"type":"constant","value":{"kind":"real","value":"Infinity"}}}`,
+		},
+		{
+			"limit",
+			expr.NewLimit("x", expr.NewConstant(value.NewIntervalValue(0, 1)), expr.FromBelow, expr.NewVariable("x")),
+			`{"type":"limit","variable":"x","direction":"from_below","point":{"type":"constant","value":{"kind":"interval","value":[0,1]}},"body":{"type":"variable","name":"x"}}`,
+		},
+		{
+			"dual",
+			expr.NewConstant(value.NewDualValue(1, 0, 2)),
+			`{"type":"constant","value":{"kind":"dual","value":1,"derivatives":[0,2]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := exprjson.Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			expected := `{"version":1,"expr":` + tt.expected + "}"
+			if string(data) != expected {
+				t.Errorf("Expected %s, got %s", expected, data)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_Calls(t *testing.T) {
+	// fact(n) = piecewise calling itself, and g calling fact
+	definitions := expr.NewDefinitions()
+	n := expr.NewVariable("n")
+	one := expr.NewConstant(value.NewIntegerValue(1))
+	recursive := expr.NewMul(n, expr.NewCall(definitions, "fact", []expr.Expr{expr.NewSub(n, one)}))
+	definitions.Define(expr.NewDefinition("fact", []string{"n"}, expr.NewPiecewise(
+		[]expr.Branch{{Condition: prop.NewInequality(prop.LessEqual, n, one), Value: one}},
+		recursive,
+	)))
+	definitions.Define(expr.NewDefinition("g", []string{"m"}, expr.NewCall(definitions, "fact", []expr.Expr{expr.NewVariable("m")})))
+	definitions.Define(expr.NewDefinition("unused", nil, one))
+	call := expr.NewCall(definitions, "g", []expr.Expr{expr.NewConstant(value.NewIntegerValue(5))})
+
+	data, err := exprjson.Marshal(call)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"definitions":[{"name":"fact","params":["n"]`) || strings.Contains(string(data), "unused") {
+		t.Errorf("unexpected definitions in %s", data)
+	}
+
+	result, err := exprjson.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if v, ok := result.Eval(); !ok || !v.Equals(value.NewIntegerValue(120)) {
+		t.Errorf("Expected 120, got %v", v)
+	}
+
+	// Decoding into the original definitions gives back an equal call
+	result, err = exprjson.UnmarshalWithOptions(data, exprjson.Options{Definitions: definitions})
+	if err != nil {
+		t.Fatalf("UnmarshalWithOptions failed: %v", err)
+	}
+	if !result.Equals(call) {
+		t.Errorf("Expected %v, got %v", call, result)
+	}
+}
+
+func TestUnmarshal_OptionsDefinitions(t *testing.T) {
+	// A call without a definition in the document resolves to Options.Definitions
+	definitions := expr.NewDefinitions()
+	definitions.Define(expr.NewDefinition("g", []string{"x"}, expr.NewMul(expr.NewVariable("x"), expr.NewVariable("x"))))
+	data := []byte(`{"version":1,"expr":{"type":"call","name":"g","args":[{"type":"constant","value":{"kind":"integer","value":"3"}}]}}`)
+
+	result, err := exprjson.UnmarshalWithOptions(data, exprjson.Options{Definitions: definitions})
+	if err != nil {
+		t.Fatalf("UnmarshalWithOptions failed: %v", err)
+	}
+	if v, ok := result.Eval(); !ok || !v.Equals(value.NewIntegerValue(9)) {
+		t.Errorf("Expected 9, got %v", v)
+	}
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	first := expr.NewCall(expr.NewDefinitions(), "f", nil)
+	second := expr.NewCall(expr.NewDefinitions(), "f", nil)
+	if _, err := exprjson.Marshal(expr.NewAdd(first, second)); err == nil || !strings.Contains(err.Error(), "different set of definitions") {
+		t.Errorf("expected definitions error, got %v", err)
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		errMsg string
+	}{
+		{"malformed", `{"version":1,`, "failed to read JSON"},
+		{"version", `{"version":2,"expr":{"type":"variable","name":"x"}}`, "unsupported version: 2"},
+		{"no version", `{"expr":{"type":"variable","name":"x"}}`, "unsupported version: 0"},
+		{"no expr", `{"version":1}`, "document has no expr"},
+		{"node type", `{"version":1,"expr":{"type":"gcd"}}`, `unknown node type: "gcd"`},
+		{"missing child", `{"version":1,"expr":{"type":"add","left":{"type":"variable","name":"x"}}}`, "add node has no right"},
+		{"function", `{"version":1,"expr":{"type":"function","function":"sec","argument":{"type":"variable","name":"x"}}}`, `unknown function: "sec"`},
+		{"relation", `{"version":1,"expr":{"type":"inequality","relation":"!=","left":{"type":"variable","name":"x"},"right":{"type":"variable","name":"y"}}}`, `unknown relation: "!="`},
+		{"direction", `{"version":1,"expr":{"type":"limit","variable":"x","direction":"left","point":{"type":"variable","name":"a"},"body":{"type":"variable","name":"x"}}}`, `unknown limit direction: "left"`},
+		{"value kind", `{"version":1,"expr":{"type":"constant","value":{"kind":"complex","value":1}}}`, `unknown value kind: "complex"`},
+		{"integer", `{"version":1,"expr":{"type":"constant","value":{"kind":"integer","value":"1.5"}}}`, `invalid integer value: "1.5"`},
+		{"real", `{"version":1,"expr":{"type":"constant","value":{"kind":"real","value":"inf"}}}`, "invalid real value"},
+		{"interval", `{"version":1,"expr":{"type":"constant","value":{"kind":"interval","value":[1]}}}`, "invalid interval value"},
+		{"reversed interval", `{"version":1,"expr":{"type":"constant","value":{"kind":"interval","value":[3,1]}}}`, "invalid interval value: [3,1]"},
+		{"undefined function", `{"version":1,"expr":{"type":"call","name":"g","args":[{"type":"variable","name":"x"}]}}`, `undefined function: "g"`},
+		{"undefined in definition", `{"version":1,"expr":{"type":"call","name":"f"},"definitions":[{"name":"f","params":[],"body":{"type":"call","name":"g"}}]}`, `definition of f: undefined function: "g"`},
+		{"branches", `{"version":1,"expr":{"type":"piecewise"}}`, "piecewise node has no branches"},
+		{"definition", `{"version":1,"expr":{"type":"call","name":"f"},"definitions":[{"name":"f","params":[],"body":{"type":"gcd"}}]}`, "definition of f"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := exprjson.Unmarshal([]byte(tt.input))
+			if err == nil {
+				t.Fatalf("expected error containing %q", tt.errMsg)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package exprjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Version is the version of the schema written by Marshal
+// Unmarshal rejects documents of any other version.
+const Version = 1
+
+// document is the top-level JSON object
+// Definitions holds every user-defined function called in the expression,
+// including those called from other definitions.
+type document struct {
+	Version     int           `json:"version"`
+	Expr        *node         `json:"expr"`
+	Definitions []*definition `json:"definitions,omitempty"`
+}
+
+// definition is a user-defined function such as f(x, y) = x^2 + y
+type definition struct {
+	Name   string   `json:"name"`
+	Params []string `json:"params"`
+	Body   *node    `json:"body"`
+}
+
+// node is an expression or proposition
+// Type selects the node; each type uses only some of the fields.
+type node struct {
+	Type string `json:"type"`
+
+	Value     *constant `json:"value,omitempty"`     // constant
+	Name      string    `json:"name,omitempty"`      // variable base, called function
+	Subscript string    `json:"subscript,omitempty"` // variable
+	Function  string    `json:"function,omitempty"`  // elementary function such as "sin"
+	Relation  string    `json:"relation,omitempty"`  // inequality: "<", "<=", ">" or ">="
+	Index     string    `json:"index,omitempty"`     // sum, product
+	Variable  string    `json:"variable,omitempty"`  // integral, limit
+	Direction string    `json:"direction,omitempty"` // limit
+
+	Operand   *node `json:"operand,omitempty"`
+	Left      *node `json:"left,omitempty"`
+	Right     *node `json:"right,omitempty"`
+	Base      *node `json:"base,omitempty"`     // power, log
+	Exponent  *node `json:"exponent,omitempty"` // power
+	Radicand  *node `json:"radicand,omitempty"`
+	Degree    *node `json:"degree,omitempty"`
+	Argument  *node `json:"argument,omitempty"` // function, log
+	N         *node `json:"n,omitempty"`        // binomial, permutation
+	K         *node `json:"k,omitempty"`        // binomial, permutation
+	Lower     *node `json:"lower,omitempty"`
+	Upper     *node `json:"upper,omitempty"`
+	Point     *node `json:"point,omitempty"` // limit
+	Body      *node `json:"body,omitempty"`
+	Integrand *node `json:"integrand,omitempty"`
+
+	Args      []*node   `json:"args,omitempty"` // call
+	Branches  []*branch `json:"branches,omitempty"`
+	Otherwise *node     `json:"otherwise,omitempty"`
+}
+
+// branch is a value of a piecewise node with its condition
+type branch struct {
+	Condition *node `json:"condition"`
+	Value     *node `json:"value"`
+}
+
+// constant is a value of one of the value kinds
+// Value is a number for reals and duals, a decimal string for integers,
+// a boolean for bools and a [lo, hi] pair for intervals.
+type constant struct {
+	Kind        string          `json:"kind"`
+	Value       json.RawMessage `json:"value"`
+	Derivatives []float         `json:"derivatives,omitempty"` // dual
+}
+
+// Node types
+const (
+	typeConstant    = "constant"
+	typeVariable    = "variable"
+	typeAdd         = "add"
+	typeSub         = "sub"
+	typeMul         = "mul"
+	typeDiv         = "div"
+	typePower       = "power"
+	typeRoot        = "root"
+	typeNeg         = "neg"
+	typeAbs         = "abs"
+	typeFloor       = "floor"
+	typeCeil        = "ceil"
+	typeFactorial   = "factorial"
+	typeBinomial    = "binomial"
+	typePermutation = "permutation"
+	typeFunction    = "function"
+	typeLog         = "log"
+	typeSum         = "sum"
+	typeProduct     = "product"
+	typeIntegral    = "integral"
+	typeLimit       = "limit"
+	typePiecewise   = "piecewise"
+	typeCall        = "call"
+	typeEqual       = "equal"
+	typeInequality  = "inequality"
+	typeAnd         = "and"
+)
+
+// Value kinds
+const (
+	kindReal     = "real"
+	kindInteger  = "integer"
+	kindBool     = "bool"
+	kindInterval = "interval"
+	kindDual     = "dual"
+)
+
+// float is a float64 written as the shortest JSON number that reads back
+// to the same value
+// JSON has no NaN or infinities, so those are the strings "NaN",
+// "Infinity" and "-Infinity".
+type float float64
+
+func (f float) MarshalJSON() ([]byte, error) {
+	x := float64(f)
+	switch {
+	case math.IsNaN(x):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(x, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(x, -1):
+		return []byte(`"-Infinity"`), nil
+	}
+	return []byte(strconv.FormatFloat(x, 'g', -1, 64)), nil
+}
+
+func (f *float) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		switch name {
+		case "NaN":
+			*f = float(math.NaN())
+		case "Infinity":
+			*f = float(math.Inf(1))
+		case "-Infinity":
+			*f = float(math.Inf(-1))
+		default:
+			return fmt.Errorf("invalid number: %q", name)
+		}
+		return nil
+	}
+	x, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("invalid number: %s", data)
+	}
+	*f = float(x)
+	return nil
+}
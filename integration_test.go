@@ -1,9 +1,12 @@
 package main
 
 import (
+	"exprtree/calculus"
 	"exprtree/expr"
 	"exprtree/latex"
 	"exprtree/value"
+	"math"
+	"strings"
 	"testing"
 )
 
@@ -251,3 +254,481 @@ func TestIntegration_PowerAndSqrt(t *testing.T) {
 		})
 	}
 }
+
+func TestIntegration_Functions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"\\sin 0", 0},
+		{"\\cos 0", 1},
+		{"\\tan(0)", 0},
+		{"\\sin^2 1 + \\cos^2 1", 1},
+		{"\\arcsin 1", math.Pi / 2},
+		{"\\arccos 1", 0},
+		{"\\arctan 1 * 4", math.Pi},
+		{"\\sinh 0 + \\cosh 0 + \\tanh 0", 1},
+		{"\\exp 1", math.E},
+		{"\\ln \\exp 2", 2},
+		{"\\log 1000", 3},
+		{"\\log_{2} 8", 3},
+		{"\\log_2 (2^{10})", 10},
+		{"2\\sin 0.5 \\cos 0.5", math.Sin(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := latex.ParseAndEval(tt.input)
+			if err != nil {
+				t.Fatalf("Parse/eval error for '%s': %v", tt.input, err)
+			}
+			if math.Abs(result.Float64()-tt.expected) > 1e-10 {
+				t.Errorf("For '%s': expected %f, got %f", tt.input, tt.expected, result.Float64())
+			}
+		})
+	}
+}
+
+func TestIntegration_FunctionDomainErrors(t *testing.T) {
+	tests := []string{
+		"\\ln 0",
+		"\\ln(-1)",
+		"\\arcsin 2",
+		"\\log_{1} 5",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if _, err := latex.ParseAndEval(input); err == nil {
+				t.Errorf("expected evaluation error for input: %s", input)
+			}
+		})
+	}
+}
+
+func TestIntegration_Combinatorics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"5!", 120},
+		{"0!", 1},
+		{"2^3!", 64},
+		{"3!^2", 36},
+		{"-3!", -6},
+		{"(1 + 2)! * 2", 12},
+		{"\\binom{5}{2}", 10},
+		{"\\binom{4}{5}", 0},
+		{"\\binom{2 + 3}{0}", 1},
+		{"0.5!", math.Sqrt(math.Pi) / 2},
+		{"\\binom{0.5}{1}", 0.5},
+		{"20! / 18!", 380},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := latex.ParseAndEval(tt.input)
+			if err != nil {
+				t.Fatalf("Parse/eval error for '%s': %v", tt.input, err)
+			}
+			if math.Abs(result.Float64()-tt.expected) > 1e-10 {
+				t.Errorf("For '%s': expected %f, got %f", tt.input, tt.expected, result.Float64())
+			}
+		})
+	}
+}
+
+func TestIntegration_CombinatoricsExact(t *testing.T) {
+	// 30! exceeds the exact range of float64
+	result, err := latex.ParseLatex("30!")
+	if err != nil {
+		t.Fatalf("ParseLatex failed: %v", err)
+	}
+	v, ok := result.(expr.Expr).Eval()
+	if !ok {
+		t.Fatalf("evaluation failed")
+	}
+	integer, ok := v.(*value.IntegerValue)
+	if !ok {
+		t.Fatalf("expected IntegerValue, got %T", v)
+	}
+	if got := integer.BigInt().String(); got != "265252859812191058636308480000000" {
+		t.Errorf("expected exact 30!, got %s", got)
+	}
+
+	if _, err := latex.ParseAndEval("(0 - 2)!"); err == nil {
+		t.Errorf("expected evaluation error for a negative integer factorial")
+	}
+}
+
+func TestIntegration_SumProduct(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"\\sum_{i=1}^{10} i", 55},
+		{"\\sum_{i=1}^{4} i^2 + 1", 31},
+		{"\\sum_{i=1}^{4} (i + 1)", 14},
+		{"\\prod_{k=1}^{5} k", 120},
+		{"\\sum_{i=1}^{0} i", 0},
+		{"\\prod_{k=3}^{2} k", 1},
+		{"\\sum_{i=1}^{3} \\sum_{j=1}^{i} j", 10},
+		{"\\sum_{n=0}^{20} 1 / n!", math.E},
+		{"2\\sum_{i=1}^{3} i", 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := latex.ParseAndEval(tt.input)
+			if err != nil {
+				t.Fatalf("Parse/eval error for '%s': %v", tt.input, err)
+			}
+			if math.Abs(result.Float64()-tt.expected) > 1e-10 {
+				t.Errorf("For '%s': expected %f, got %f", tt.input, tt.expected, result.Float64())
+			}
+		})
+	}
+}
+
+func TestIntegration_SumBoundIndex(t *testing.T) {
+	result, err := latex.ParseLatex("\\sum_{i=1}^{n} i x")
+	if err != nil {
+		t.Fatalf("ParseLatex failed: %v", err)
+	}
+	sum := result.(expr.Expr)
+
+	free := expr.FreeVariables(sum)
+	if len(free) != 2 || free[0] != "n" || free[1] != "x" {
+		t.Errorf("expected free variables [n x], got %v", free)
+	}
+
+	// Binding the index has no effect; binding x to an expression using i
+	// must not be captured by the index.
+	substituted := expr.Substitute(sum, map[string]expr.Expr{
+		"i": expr.NewConstant(value.NewRealValue(100)),
+		"x": expr.NewVariable("i"),
+		"n": expr.NewConstant(value.NewRealValue(3)),
+	})
+	v, ok := expr.EvalWith(substituted, map[string]value.Value{"i": value.NewRealValue(2)})
+	if !ok {
+		t.Fatalf("evaluation failed")
+	}
+	if got, _ := value.ToReal(v); got.Float64() != 12 {
+		t.Errorf("expected (1 + 2 + 3) * 2 = 12, got %f", got.Float64())
+	}
+}
+
+func TestIntegration_Integral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"\\int_{0}^{1} x^2 \\, dx", 1.0 / 3},
+		{"\\int_0^2 (x + 1) dx", 4},
+		{"\\int_{0}^{1} \\exp x \\, dx + 1", math.E},
+		{"\\int_{1}^{2} 1 / x \\, dx", math.Ln2},
+		{"\\int_{0}^{1} \\int_{0}^{2} x y \\, dy \\, dx", 1},
+		{"\\int_{2}^{0} x \\, dx", -2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := latex.ParseAndEval(tt.input)
+			if err != nil {
+				t.Fatalf("Parse/eval error for '%s': %v", tt.input, err)
+			}
+			if math.Abs(result.Float64()-tt.expected) > 1e-10 {
+				t.Errorf("For '%s': expected %f, got %f", tt.input, tt.expected, result.Float64())
+			}
+		})
+	}
+
+	// Indefinite integrals have no value
+	if _, err := latex.ParseAndEval("\\int x \\, dx"); err == nil {
+		t.Errorf("expected evaluation error for an indefinite integral")
+	}
+}
+
+func TestIntegration_Limit(t *testing.T) {
+	// Continuous bodies evaluate by direct substitution
+	result, err := latex.ParseAndEval("\\lim_{x \\to 2} (x^2 + 1) + 1")
+	if err != nil {
+		t.Fatalf("Parse/eval error: %v", err)
+	}
+	if result.Float64() != 6 {
+		t.Errorf("expected 6, got %f", result.Float64())
+	}
+	if _, err := latex.ParseAndEval("\\lim_{x \\to 1} (x^2 - 1) / (x - 1)"); err == nil {
+		t.Errorf("expected evaluation error for an indeterminate form")
+	}
+
+	// Indeterminate forms need the calculus package
+	parsed, err := latex.ParseLatex("\\lim_{x \\to 1} (x^2 - 1) / (x - 1)")
+	if err != nil {
+		t.Fatalf("ParseLatex error: %v", err)
+	}
+	limit, err := calculus.Limit(parsed.(*expr.Limit))
+	if err != nil {
+		t.Fatalf("Limit failed: %v", err)
+	}
+	if limit.Value != 2 {
+		t.Errorf("expected 2, got %g", limit.Value)
+	}
+
+}
+
+func TestIntegration_Piecewise(t *testing.T) {
+	// Tiered pricing: 10 per unit up to 100 units, 8 per unit above
+	parsed, err := latex.ParseLatex("\\begin{cases} 10q & 0 \\le q \\le 100 \\\\ 1000 + 8(q - 100) & q > 100 \\end{cases}")
+	if err != nil {
+		t.Fatalf("ParseLatex error: %v", err)
+	}
+	price := parsed.(expr.Expr)
+
+	tests := []struct {
+		quantity float64
+		expected float64
+	}{
+		{0, 0},
+		{50, 500},
+		{100, 1000},
+		{150, 1400},
+	}
+	for _, tt := range tests {
+		result, ok := expr.EvalWith(price, map[string]value.Value{"q": value.NewRealValue(tt.quantity)})
+		if !ok {
+			t.Fatalf("evaluation failed for q=%g", tt.quantity)
+		}
+		if got, _ := value.ToReal(result); got.Float64() != tt.expected {
+			t.Errorf("q=%g: expected %g, got %g", tt.quantity, tt.expected, got.Float64())
+		}
+	}
+
+	// No condition holds and there is no otherwise branch
+	if _, ok := expr.EvalWith(price, map[string]value.Value{"q": value.NewRealValue(-1)}); ok {
+		t.Errorf("expected evaluation to fail for a negative quantity")
+	}
+
+	rendered, err := latex.ExpressionToLatex(price)
+	if err != nil {
+		t.Fatalf("ExpressionToLatex failed: %v", err)
+	}
+	if expected := "\\begin{cases} 10 * q & 0 \\le q \\le 100 \\\\ 1000 + 8 * (q - 100) & q > 100 \\end{cases}"; rendered != expected {
+		t.Errorf("expected %s, got %s", expected, rendered)
+	}
+}
+
+func TestIntegration_UserFunctions(t *testing.T) {
+	definitions := expr.NewDefinitions()
+	options := latex.Options{Definitions: definitions}
+	declarations := []string{
+		"d(x, y) = \\sqrt{x * x + y * y}",
+		"f(n) = \\begin{cases} 1 & n = 0 \\\\ n f(n - 1) & \\text{otherwise} \\end{cases}",
+		"g(x) = 2d(x, 4)",
+	}
+	for _, declaration := range declarations {
+		if _, err := latex.ParseLatexWithOptions(declaration, options); err != nil {
+			t.Fatalf("failed to declare %s: %v", declaration, err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"d(3, 4)", 5},
+		{"d(3, 2 + 2) + 1", 6},
+		{"f(5)", 120},
+		{"g(3) - f(3)", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := latex.ParseLatexWithOptions(tt.input, options)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			v, ok := result.(expr.Expr).Eval()
+			if !ok {
+				t.Fatalf("evaluation failed")
+			}
+			if got, _ := value.ToReal(v); got.Float64() != tt.expected {
+				t.Errorf("expected %g, got %g", tt.expected, got.Float64())
+			}
+		})
+	}
+
+	// Recursion deeper than MaxDepth fails
+	definitions.MaxDepth = 10
+	result, err := latex.ParseLatexWithOptions("f(20)", options)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if _, ok := result.(expr.Expr).Eval(); ok {
+		t.Errorf("expected evaluation to fail beyond the recursion limit")
+	}
+	if _, ok := result.(expr.Expr).Eval(); ok {
+		t.Errorf("expected the failure to be repeatable")
+	}
+	definitions.MaxDepth = expr.DefaultMaxDepth
+	if _, ok := result.(expr.Expr).Eval(); !ok {
+		t.Errorf("expected evaluation to succeed within the default limit")
+	}
+}
+
+func TestIntegration_AbsFloorCeil(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"|3 - 5|", 2},
+		{"\\left| 3 - 5 \\right|", 2},
+		{"||1 - 4| - 5|", 2},
+		{"2|1 - 2|", 2},
+		{"|2||3|", 6},
+		{"\\lfloor 2.7 \\rfloor", 2},
+		{"\\lfloor 0 - 2.5 \\rfloor", -3},
+		{"\\lceil 2.1 \\rceil", 3},
+		{"\\lceil 7 / 2 \\rceil + \\lfloor 7 / 2 \\rfloor", 7},
+		{"\\sqrt{|0 - 9|}", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := latex.ParseAndEval(tt.input)
+			if err != nil {
+				t.Fatalf("Parse/eval error for '%s': %v", tt.input, err)
+			}
+			if result.Float64() != tt.expected {
+				t.Errorf("For '%s': expected %f, got %f", tt.input, tt.expected, result.Float64())
+			}
+		})
+	}
+}
+
+func TestIntegration_UnaryMinus(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"-2^2", -4},
+		{"(-2)^2", 4},
+		{"-3!", -6},
+		{"-2 * 3", -6},
+		{"-(2 + 3)", -5},
+		{"2 - -3", 5},
+		{"--2", 2},
+		{"2^-1", 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := latex.ParseAndEval(tt.input)
+			if err != nil {
+				t.Fatalf("Parse/eval error for '%s': %v", tt.input, err)
+			}
+			if result.Float64() != tt.expected {
+				t.Errorf("For '%s': expected %f, got %f", tt.input, tt.expected, result.Float64())
+			}
+		})
+	}
+}
+
+func TestIntegration_Frac(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"\\frac{1}{2}", 0.5},
+		{"\\dfrac{3}{4} + \\tfrac{1}{4}", 1},
+		{"\\frac{\\frac{1}{2}}{\\frac{1}{4}}", 2},
+		{"2\\frac{3}{4}", 1.5},
+		{"\\frac{1}{2}^2", 0.25},
+		{"-\\frac{6}{3}", -2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := latex.ParseAndEval(tt.input)
+			if err != nil {
+				t.Fatalf("Parse/eval error for '%s': %v", tt.input, err)
+			}
+			if result.Float64() != tt.expected {
+				t.Errorf("For '%s': expected %f, got %f", tt.input, tt.expected, result.Float64())
+			}
+		})
+	}
+}
+
+func TestIntegration_Identifiers(t *testing.T) {
+	options := latex.Options{Identifiers: latex.JoinLetters}
+	parsed, err := latex.ParseLatexWithOptions("\\frac{1}{2} mv^2 + \\Delta_{h} \\mathrm{g} x_1", options)
+	if err != nil {
+		t.Fatalf("ParseLatexWithOptions failed: %v", err)
+	}
+	expression, ok := parsed.(expr.Expr)
+	if !ok {
+		t.Fatalf("expected Expression, got %T", parsed)
+	}
+
+	names := expr.FreeVariables(expression)
+	expected := []string{"mv", "Δ_h", "g", "x_1"}
+	if strings.Join(names, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected variables %v, got %v", expected, names)
+	}
+
+	result, ok := expr.EvalWith(expression, map[string]value.Value{
+		"mv":  value.NewRealValue(2),
+		"Δ_h": value.NewRealValue(3),
+		"g":   value.NewRealValue(4),
+		"x_1": value.NewRealValue(5),
+	})
+	if !ok {
+		t.Fatalf("evaluation failed")
+	}
+	if number, _ := value.ToReal(result); number.Float64() != 62 {
+		t.Errorf("expected 62, got %v", result)
+	}
+}
+
+func TestIntegration_NumberFormats(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.5e-3 * 2", 0.003},
+		{"6.02 \\times 10^{23} / 2", 3.01e23},
+		{"1{,}000 + .5", 1000.5},
+		{"2 \\cdot 3 \\div 4", 1.5},
+		{"-1.5 \\times 10^{2}", -150},
+		{"2e", math.NaN()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := latex.ParseAndEval(tt.input)
+			if math.IsNaN(tt.expected) {
+				// 2e is 2 times the variable e
+				if err == nil {
+					t.Errorf("expected evaluation error for '%s'", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse/eval error for '%s': %v", tt.input, err)
+			}
+			if result.Float64() != tt.expected {
+				t.Errorf("For '%s': expected %g, got %g", tt.input, tt.expected, result.Float64())
+			}
+		})
+	}
+
+	options := latex.Options{RadixLiterals: true}
+	parsed, err := latex.ParseLatexWithOptions("0xFF + 0b11", options)
+	if err != nil {
+		t.Fatalf("ParseLatexWithOptions failed: %v", err)
+	}
+	v, ok := parsed.(expr.Expr).Eval()
+	if number, _ := value.ToReal(v); !ok || number.Float64() != 258 {
+		t.Errorf("expected 258, got %v", v)
+	}
+}
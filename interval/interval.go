@@ -0,0 +1,66 @@
+package interval
+
+import (
+	"exprtree/expr"
+	"exprtree/value"
+	"fmt"
+)
+
+// Eval evaluates e with each variable bound to an interval
+// The result is guaranteed to contain the value of e for every choice of
+// variables inside their intervals. A real or integer result (e.g. a
+// constant expression) is returned as a point interval.
+func Eval(e expr.Expr, bounds map[string]*value.IntervalValue) (*value.IntervalValue, error) {
+	result, ok := expr.EvalWith(e, toEnv(bounds))
+	if !ok {
+		return nil, fmt.Errorf("interval evaluation failed")
+	}
+
+	switch v := result.(type) {
+	case *value.IntervalValue:
+		return v, nil
+	case *value.RealValue, *value.IntegerValue:
+		realValue, _ := value.ToReal(v)
+		return value.NewIntervalValue(realValue.Float64(), realValue.Float64()), nil
+	default:
+		return nil, fmt.Errorf("result is not an interval (got %T)", result)
+	}
+}
+
+// PossibleDivisionsByZero returns the Div nodes of e whose divisor may be
+// zero for some choice of variables inside bounds
+// Divisors that cannot be evaluated are reported as well.
+func PossibleDivisionsByZero(e expr.Expr, bounds map[string]*value.IntervalValue) []*expr.Div {
+	var divs []*expr.Div
+	collectDivs(e, &divs)
+
+	var result []*expr.Div
+	for _, div := range divs {
+		divisor, err := Eval(div.Right(), bounds)
+		if err != nil || divisor.ContainsZero() {
+			result = append(result, div)
+		}
+	}
+	return result
+}
+
+// collectDivs appends every Div node in e to divs
+func collectDivs(e expr.Expr, divs *[]*expr.Div) {
+	if div, ok := e.(*expr.Div); ok {
+		*divs = append(*divs, div)
+	}
+	for _, child := range e.Children() {
+		if childExpr, ok := child.(expr.Expr); ok {
+			collectDivs(childExpr, divs)
+		}
+	}
+}
+
+// toEnv converts interval bounds to an evaluation environment
+func toEnv(bounds map[string]*value.IntervalValue) map[string]value.Value {
+	env := make(map[string]value.Value, len(bounds))
+	for name, b := range bounds {
+		env[name] = b
+	}
+	return env
+}
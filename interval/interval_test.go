@@ -0,0 +1,118 @@
+package interval_test
+
+import (
+	"exprtree/expr"
+	"exprtree/interval"
+	"exprtree/latex"
+	"exprtree/value"
+	"math"
+	"testing"
+)
+
+func parseExpr(t *testing.T, input string) expr.Expr {
+	t.Helper()
+	parsed, err := latex.ParseLatex(input)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", input, err)
+	}
+	expression, ok := parsed.(expr.Expr)
+	if !ok {
+		t.Fatalf("expected Expression, got %T", parsed)
+	}
+	return expression
+}
+
+func TestEval(t *testing.T) {
+	bounds := map[string]*value.IntervalValue{
+		"x": value.NewIntervalValue(1, 2),
+		"y": value.NewIntervalValue(-3, 4),
+	}
+
+	tests := []struct {
+		input  string
+		lo, hi float64
+	}{
+		{"x + y", -2, 6},
+		{"x - y", -3, 5},
+		{"x * y", -6, 8},
+		{"y / x", -3, 4},
+		{"y^2", 0, 16},
+		{"y^3", -27, 64},
+		{"x^{-1}", 0.5, 1},
+		{"x^{0.5}", 1, math.Sqrt2},
+		{"\\sqrt{x}", 1, math.Sqrt2},
+		{"\\sqrt[3]{8x}", 2, math.Cbrt(16)},
+		{"2 + 3", 5, 5},
+		{"5!", 120, 120},
+		{"\\binom{5}{2}", 10, 10},
+		{"3! x", 6, 12},
+		{"\\exp x", math.E, math.Exp(2)},
+		{"\\ln x", 0, math.Ln2},
+		{"\\sin(2y)", -1, 1},
+		{"\\sin x", math.Sin(1), 1},
+		{"\\cos x", math.Cos(2), math.Cos(1)},
+		{"\\cosh y", 1, math.Cosh(4)},
+		{"|y|", 0, 4},
+		{"|x - 3|", 1, 2},
+		{"\\lfloor y / 2 \\rfloor", -2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := interval.Eval(parseExpr(t, tt.input), bounds)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			// the result must enclose the exact range and be tight up to rounding
+			if result.Lo() > tt.lo || result.Hi() < tt.hi {
+				t.Errorf("[%g, %g] does not enclose [%g, %g]", result.Lo(), result.Hi(), tt.lo, tt.hi)
+			}
+			if tt.lo-result.Lo() > 1e-9 || result.Hi()-tt.hi > 1e-9 {
+				t.Errorf("[%g, %g] is not tight around [%g, %g]", result.Lo(), result.Hi(), tt.lo, tt.hi)
+			}
+		})
+	}
+}
+
+func TestEval_ZeroDivisor(t *testing.T) {
+	tests := []struct {
+		name    string
+		divisor *value.IntervalValue
+		lo, hi  float64
+	}{
+		{"Contains zero", value.NewIntervalValue(-1, 1), math.Inf(-1), math.Inf(1)},
+		{"Lower bound zero", value.NewIntervalValue(0, 2), 0.5, math.Inf(1)},
+		{"Upper bound zero", value.NewIntervalValue(-2, 0), math.Inf(-1), -0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interval.Eval(parseExpr(t, "1 / y"), map[string]*value.IntervalValue{"y": tt.divisor})
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if result.Lo() > tt.lo || result.Hi() < tt.hi || tt.lo-result.Lo() > 1e-9 || result.Hi()-tt.hi > 1e-9 {
+				t.Errorf("expected [%g, %g], got [%g, %g]", tt.lo, tt.hi, result.Lo(), result.Hi())
+			}
+		})
+	}
+
+	_, err := interval.Eval(parseExpr(t, "1 / y"), map[string]*value.IntervalValue{"y": value.NewIntervalValue(0, 0)})
+	if err == nil {
+		t.Errorf("expected error for division by [0, 0]")
+	}
+}
+
+func TestPossibleDivisionsByZero(t *testing.T) {
+	e := parseExpr(t, "1 / (x - 1) + 1 / (x + 1)")
+	bounds := map[string]*value.IntervalValue{"x": value.NewIntervalValue(0, 2)}
+
+	divs := interval.PossibleDivisionsByZero(e, bounds)
+	if len(divs) != 1 {
+		t.Fatalf("expected 1 division, got %d", len(divs))
+	}
+	expected := parseExpr(t, "1 / (x - 1)")
+	if !divs[0].Equals(expected) {
+		t.Errorf("unexpected division reported")
+	}
+}
@@ -0,0 +1,331 @@
+package latex
+
+import (
+	"strings"
+)
+
+// asciiMathArgument is the LaTeX bracketing of an argument of an AsciiMath
+// function, which takes the place of the parentheses around it
+type asciiMathArgument struct {
+	open  []Token
+	close []Token
+	comma []Token // replaces a comma at the top of the argument, nil to keep it
+	cases bool    // the group is a cases environment where if, otherwise and ; separate
+}
+
+// LaTeX bracketings of AsciiMath arguments
+var (
+	braceArgument   = asciiMathArgument{open: []Token{{Type: LBRACE, Literal: "{"}}, close: []Token{{Type: RBRACE, Literal: "}"}}}
+	bracketArgument = asciiMathArgument{open: []Token{{Type: LBRACKET, Literal: "["}}, close: []Token{{Type: RBRACKET, Literal: "]"}}}
+	parenArgument   = asciiMathArgument{open: []Token{{Type: LPAREN, Literal: "("}}, close: []Token{{Type: RPAREN, Literal: ")"}}}
+	absArgument     = asciiMathArgument{open: []Token{{Type: PIPE, Literal: "|"}}, close: []Token{{Type: PIPE, Literal: "|"}}}
+	floorArgument   = asciiMathArgument{open: []Token{{Type: COMMAND, Literal: "lfloor"}}, close: []Token{{Type: COMMAND, Literal: "rfloor"}}}
+	ceilArgument    = asciiMathArgument{open: []Token{{Type: COMMAND, Literal: "lceil"}}, close: []Token{{Type: COMMAND, Literal: "rceil"}}}
+	// binom(n, k) becomes \binom{n}{k}
+	pairArgument = asciiMathArgument{
+		open:  braceArgument.open,
+		close: braceArgument.close,
+		comma: []Token{{Type: RBRACE, Literal: "}"}, {Type: LBRACE, Literal: "{"}},
+	}
+	// {x if x > 0; 0 otherwise} becomes a cases environment
+	casesArgument = asciiMathArgument{
+		open:  []Token{{Type: COMMAND, Literal: "begin"}, {Type: LBRACE, Literal: "{"}, {Type: VARIABLE, Literal: "cases"}, {Type: RBRACE, Literal: "}"}},
+		close: []Token{{Type: COMMAND, Literal: "end"}, {Type: LBRACE, Literal: "{"}, {Type: VARIABLE, Literal: "cases"}, {Type: RBRACE, Literal: "}"}},
+		cases: true,
+	}
+)
+
+// asciiMathWord is a reserved word of AsciiMath with the LaTeX tokens it
+// stands for and the arguments it expects
+type asciiMathWord struct {
+	tokens    []Token
+	arguments []asciiMathArgument
+}
+
+// asciiMathWords lists the reserved words other than function and Greek
+// letter names, which are the names of their LaTeX commands
+var asciiMathWords = map[string]asciiMathWord{
+	"sqrt":  {tokens: []Token{{Type: COMMAND, Literal: "sqrt"}}, arguments: []asciiMathArgument{braceArgument}},
+	"root":  {tokens: []Token{{Type: COMMAND, Literal: "sqrt"}}, arguments: []asciiMathArgument{bracketArgument, braceArgument}},
+	"frac":  {tokens: []Token{{Type: COMMAND, Literal: "frac"}}, arguments: []asciiMathArgument{braceArgument, braceArgument}},
+	"binom": {tokens: []Token{{Type: COMMAND, Literal: "binom"}}, arguments: []asciiMathArgument{pairArgument}},
+	"abs":   {arguments: []asciiMathArgument{absArgument}},
+	"floor": {arguments: []asciiMathArgument{floorArgument}},
+	"ceil":  {arguments: []asciiMathArgument{ceilArgument}},
+	"sum":   {tokens: []Token{{Type: COMMAND, Literal: "sum"}}},
+	"prod":  {tokens: []Token{{Type: COMMAND, Literal: "prod"}}},
+	"int":   {tokens: []Token{{Type: COMMAND, Literal: "int"}}},
+	"lim":   {tokens: []Token{{Type: COMMAND, Literal: "lim"}}},
+	"oo":    {tokens: []Token{{Type: COMMAND, Literal: "infty"}}},
+	"asin":  {tokens: []Token{{Type: COMMAND, Literal: "arcsin"}}},
+	"acos":  {tokens: []Token{{Type: COMMAND, Literal: "arccos"}}},
+	"atan":  {tokens: []Token{{Type: COMMAND, Literal: "arctan"}}},
+	// There is no remainder operator, so mod is reported as unsupported
+	// rather than read as m·o·d
+	"mod": {tokens: []Token{{Type: ILLEGAL, Literal: "mod"}}},
+}
+
+// casesWords are the words that separate the value and condition of a row
+// of {x if x > 0; 0 otherwise}
+var casesWords = map[string][]Token{
+	"if":        {{Type: AMPERSAND, Literal: "&"}},
+	"otherwise": {{Type: AMPERSAND, Literal: "&"}, {Type: COMMAND, Literal: "text"}, {Type: LBRACE, Literal: "{"}, {Type: VARIABLE, Literal: "otherwise"}, {Type: RBRACE, Literal: "}"}},
+}
+
+// asciiMathOperators maps the operators of AsciiMath and calculator syntax
+// that differ from LaTeX to their tokens, longest first
+var asciiMathOperators = []struct {
+	text  string
+	token Token
+}{
+	{"|__", Token{Type: COMMAND, Literal: "lfloor"}},
+	{"__|", Token{Type: COMMAND, Literal: "rfloor"}},
+	{"|~", Token{Type: COMMAND, Literal: "lceil"}},
+	{"~|", Token{Type: COMMAND, Literal: "rceil"}},
+	{"**", Token{Type: CARET, Literal: "^"}},
+	{"<=", Token{Type: LESSEQ, Literal: "<="}},
+	{">=", Token{Type: GREATEREQ, Literal: ">="}},
+	{"->", Token{Type: COMMAND, Literal: "to"}},
+	// There is no inequation, so != is reported as unsupported rather than
+	// read as (x!) = 2
+	{"!=", Token{Type: ILLEGAL, Literal: "!="}},
+}
+
+// asciiMathLexer reads AsciiMath and calculator syntax as the tokens of the
+// equivalent LaTeX, so that the LaTeX parser builds the same trees
+// Parentheses around the arguments of sqrt, root, frac and binom and
+// around scripts become braces and brackets, abs(x) becomes |x| and
+// {x if x > 0; 0 otherwise} a cases environment.
+type asciiMathLexer struct {
+	*Lexer
+	pending   []Token             // tokens read but not yet returned
+	groups    []asciiMathGroup    // open ( and {, innermost last
+	arguments []asciiMathArgument // arguments expected by the last word
+	previous  Token               // last token returned
+}
+
+// asciiMathGroup is an open ( or { with the arguments expected after it
+type asciiMathGroup struct {
+	asciiMathArgument
+	following []asciiMathArgument
+}
+
+// newAsciiMathLexer creates a lexer for AsciiMath input
+func newAsciiMathLexer(input string, options Options) *asciiMathLexer {
+	return &asciiMathLexer{Lexer: NewLexerWithOptions(input, options)}
+}
+
+// NextToken returns the next LaTeX token of the input
+func (l *asciiMathLexer) NextToken() Token {
+	for len(l.pending) == 0 {
+		l.pending = l.readTokens()
+	}
+	tok := l.pending[0]
+	l.pending = l.pending[1:]
+	l.previous = tok
+	return tok
+}
+
+// readTokens reads the tokens that the next piece of input stands for
+func (l *asciiMathLexer) readTokens() []Token {
+	l.skipWhitespace()
+	start := l.position
+	rest := l.input[l.position:]
+
+	switch {
+	case l.ch == '(' || l.ch == '{':
+		l.readChar()
+		return l.open(start)
+	case l.ch == ')' || l.ch == '}':
+		l.readChar()
+		return l.close(start)
+	case l.ch == ',' && len(l.groups) > 0 && l.groups[len(l.groups)-1].comma != nil:
+		l.readChar()
+		return l.at(start, l.groups[len(l.groups)-1].comma)
+	case l.ch == ';' && l.inCases():
+		l.readChar()
+		return l.at(start, []Token{{Type: ROWSEP, Literal: ";"}})
+	case isLetter(l.ch):
+		return l.readWord(start)
+	}
+	for _, operator := range asciiMathOperators {
+		if strings.HasPrefix(rest, operator.text) {
+			for range operator.text {
+				l.readChar()
+			}
+			return l.argument(l.at(start, []Token{operator.token}))
+		}
+	}
+	return l.argument([]Token{l.Lexer.NextToken()})
+}
+
+// at places tokens at the input from start to the current position
+func (l *asciiMathLexer) at(start int, tokens []Token) []Token {
+	placed := make([]Token, len(tokens))
+	for i, tok := range tokens {
+		tok.Pos, tok.End = start, l.position
+		placed[i] = tok
+	}
+	return placed
+}
+
+// open reads ( or {, which opens an expected argument, a script after ^
+// or _, a cases environment or a plain group
+func (l *asciiMathLexer) open(start int) []Token {
+	var group asciiMathGroup
+	switch {
+	case len(l.arguments) > 0:
+		// The remaining arguments are expected once the group closes
+		group = asciiMathGroup{l.arguments[0], l.arguments[1:]}
+		l.arguments = nil
+	case l.previous.Type == CARET || l.previous.Type == UNDERSCORE:
+		group.asciiMathArgument = braceArgument
+	case l.input[start] == '{' && l.atCases():
+		group.asciiMathArgument = casesArgument
+	case l.input[start] == '{':
+		group.asciiMathArgument = braceArgument
+	default:
+		group.asciiMathArgument = parenArgument
+	}
+	l.groups = append(l.groups, group)
+	return l.at(start, group.open)
+}
+
+// close reads ) or }, which closes the innermost group
+func (l *asciiMathLexer) close(start int) []Token {
+	if len(l.groups) == 0 {
+		if l.input[start] == '}' {
+			return l.at(start, braceArgument.close)
+		}
+		return l.at(start, parenArgument.close)
+	}
+	group := l.groups[len(l.groups)-1]
+	l.groups = l.groups[:len(l.groups)-1]
+	l.arguments = group.following
+	return l.at(start, group.close)
+}
+
+// inCases reports whether the innermost group is a cases environment
+func (l *asciiMathLexer) inCases() bool {
+	return len(l.groups) > 0 && l.groups[len(l.groups)-1].cases
+}
+
+// atCases reports whether the braces opened before the current position
+// hold rows separated by if or otherwise, as in {x if x > 0; 0 otherwise}
+func (l *asciiMathLexer) atCases() bool {
+	depth := 0
+	for i := l.position; i < len(l.input); i++ {
+		switch ch := l.input[i]; {
+		case ch == '(' || ch == '{' || ch == '[':
+			depth++
+		case ch == ')' || ch == '}' || ch == ']':
+			if depth == 0 {
+				return false
+			}
+			depth--
+		case isLetter(ch) && (i == 0 || !isLetter(l.input[i-1])):
+			end := i
+			for end < len(l.input) && isLetter(l.input[end]) {
+				end++
+			}
+			if _, ok := casesWords[l.input[i:end]]; ok && depth == 0 {
+				return true
+			}
+			i = end - 1
+		}
+	}
+	return false
+}
+
+// readWord reads the longest reserved word at the current position, or a
+// single letter
+func (l *asciiMathLexer) readWord(start int) []Token {
+	end := l.position
+	for end < len(l.input) && isLetter(l.input[end]) {
+		end++
+	}
+	letters := l.input[l.position:end]
+
+	for n := len(letters); n > 1; n-- {
+		word, ok := l.lookupWord(letters[:n])
+		if !ok {
+			continue
+		}
+		for i := 0; i < n; i++ {
+			l.readChar()
+		}
+		tokens := l.argument(l.at(start, word.tokens))
+		if word.arguments != nil {
+			l.arguments = append([]asciiMathArgument(nil), word.arguments...)
+		}
+		if len(tokens) == 0 {
+			// abs, floor and ceil stand for their argument's brackets only
+			return l.readTokens()
+		}
+		return tokens
+	}
+
+	l.readChar()
+	return l.argument(l.at(start, []Token{{Type: VARIABLE, Literal: letters[:1]}}))
+}
+
+// lookupWord finds a reserved word: a word of asciiMathWords, a function
+// or Greek letter named as in LaTeX, or a separator of a cases row
+func (l *asciiMathLexer) lookupWord(text string) (asciiMathWord, bool) {
+	if word, ok := asciiMathWords[text]; ok {
+		return word, true
+	}
+	if _, ok := greekLetters[text]; ok || isFunctionCommand(text) {
+		return asciiMathWord{tokens: []Token{{Type: COMMAND, Literal: text}}}, true
+	}
+	if tokens, ok := casesWords[text]; ok && l.inCases() {
+		return asciiMathWord{tokens: tokens}, true
+	}
+	return asciiMathWord{}, false
+}
+
+// argument brackets a single number, letter or Greek letter given as the
+// expected argument of a word, as in sqrt 2; any other token cancels the
+// expected arguments
+func (l *asciiMathLexer) argument(tokens []Token) []Token {
+	if len(l.arguments) == 0 || len(tokens) == 0 {
+		return tokens
+	}
+	if len(tokens) != 1 || !isAsciiMathAtom(tokens[0]) {
+		l.arguments = nil
+		return tokens
+	}
+	argument := l.arguments[0]
+	l.arguments = l.arguments[1:]
+	tok := tokens[0]
+	result := l.at(tok.Pos, argument.open)
+	result = append(result, tok)
+	return append(result, l.at(tok.Pos, argument.close)...)
+}
+
+// isAsciiMathAtom reports whether a token is a number, a letter, a Greek
+// letter or infinity
+func isAsciiMathAtom(tok Token) bool {
+	switch tok.Type {
+	case NUMBER, VARIABLE:
+		return true
+	case COMMAND:
+		_, ok := greekLetters[tok.Literal]
+		return ok || tok.Literal == "infty"
+	default:
+		return false
+	}
+}
+
+// ParseAsciiMath parses AsciiMath or calculator syntax such as sqrt(x)/2,
+// sum_(i=1)^n i^2 or 2*x**2 and returns an Expression or Proposition
+// The result is the same as that of ParseLatex for the equivalent LaTeX,
+// and the ASCII render style reads back unchanged.
+func ParseAsciiMath(input string) (interface{}, error) {
+	return ParseAsciiMathWithOptions(input, Options{})
+}
+
+// ParseAsciiMathWithOptions parses AsciiMath like ParseAsciiMath with options
+func ParseAsciiMathWithOptions(input string, options Options) (interface{}, error) {
+	return parse(newParser(newAsciiMathLexer(input, options), input, options), options)
+}
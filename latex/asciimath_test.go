@@ -0,0 +1,165 @@
+package latex
+
+import (
+	"errors"
+	"exprtree/expr"
+	"testing"
+)
+
+func TestParseAsciiMath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"sqrt(x)", "\\sqrt{x}"},
+		{"sqrt x + 1", "\\sqrt{x} + 1"},
+		{"sqrt(3)", "\\sqrt{3}"},
+		{"root(3)(x)", "\\sqrt[3]{x}"},
+		{"x^(2)", "x^2"},
+		{"x^(n+1)", "x^{n+1}"},
+		{"x^-1", "x^{-1}"},
+		{"a/b", "a / b"},
+		{"frac(a)(b)", "\\frac{a}{b}"},
+		{"frac{a+1}{b}", "\\frac{a+1}{b}"},
+		{"2*x**2", "2 * x^2"},
+		{"2pi r", "2 \\pi r"},
+		{"sum_(i=1)^n i^2", "\\sum_{i=1}^{n} i^2"},
+		{"prod_(k=1)^n k", "\\prod_{k=1}^{n} k"},
+		{"int_0^1 x^2 dx", "\\int_{0}^{1} x^2 dx"},
+		{"lim_(x->0^+) 1/x", "\\lim_{x \\to 0^+} 1/x"},
+		{"lim_(x->oo) x", "\\lim_{x \\to \\infty} x"},
+		{"sin^2(x) + log_2(x) + ln x", "\\sin^2(x) + \\log_{2}(x) + \\ln(x)"},
+		{"asin(x)", "\\arcsin(x)"},
+		{"n! + binom(n,k)", "n! + \\binom{n}{k}"},
+		{"abs(x-1) + |x|", "|x-1| + |x|"},
+		{"floor(x) + |__x__|", "\\lfloor x \\rfloor + \\lfloor x \\rfloor"},
+		{"ceil(x) + |~x~|", "\\lceil x \\rceil + \\lceil x \\rceil"},
+		{"0<=x_1<alpha_(ij)", "0 \\le x_1 < \\alpha_{ij}"},
+		{"x >= 1", "x \\ge 1"},
+		{"a = b = c", "a = b = c"},
+		{"{x if x > 0; 0 otherwise}", "\\begin{cases} x & x > 0 \\\\ 0 & \\text{otherwise} \\end{cases}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseAsciiMath(tt.input)
+			if err != nil {
+				t.Fatalf("ParseAsciiMath failed: %v", err)
+			}
+			expected, err := ParseLatex(tt.expected)
+			if err != nil {
+				t.Fatalf("ParseLatex failed: %v", err)
+			}
+			if !result.(expr.Expr).Equals(expected.(expr.Expr)) {
+				t.Errorf("Expected %v, got %v", expected, result)
+			}
+		})
+	}
+}
+
+func TestParseAsciiMath_LatexRoundTrip(t *testing.T) {
+	// An AsciiMath tree rendered as LaTeX reads back as the same tree
+	inputs := []string{
+		"a - (b + c)",
+		"a - (b - c)",
+		"a/(b c)",
+		"1/(2x)",
+		"2^(3^2) - (x/y)/z",
+		"sqrt(x)^2 + (sin x)!",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			original, err := ParseAsciiMath(input)
+			if err != nil {
+				t.Fatalf("ParseAsciiMath failed: %v", err)
+			}
+			text, err := ExpressionToLatex(original.(expr.Expr))
+			if err != nil {
+				t.Fatalf("ExpressionToLatex failed: %v", err)
+			}
+			result, err := ParseLatex(text)
+			if err != nil {
+				t.Fatalf("ParseLatex failed for %s: %v", text, err)
+			}
+			if !result.(expr.Expr).Equals(original.(expr.Expr)) {
+				t.Errorf("%s read back as %v", text, result)
+			}
+		})
+	}
+}
+
+func TestParseAsciiMath_ASCIIStyle(t *testing.T) {
+	// The ASCII render style reads back as AsciiMath
+	inputs := []string{
+		"2x^2 + 1",
+		"\\frac{a + b}{c}",
+		"(2^3)^2",
+		"a - (b - c)",
+		"a * (-3)",
+		"-x^2",
+		"\\sqrt{x} + \\sqrt[3]{x + 1}",
+		"\\sin^2(x) + \\log_{2}(x) + \\ln(x)",
+		"|x_1| + \\lfloor x \\rfloor + \\lceil x \\rceil",
+		"\\sum_{i=1}^{n} i^2",
+		"\\int_{0}^{1} x^2 dx",
+		"\\lim_{x \\to 0^+} \\frac{1}{x}",
+		"\\lim_{x \\to -\\infty} x",
+		"0 \\le x_1 < \\alpha_{ij}",
+		"\\begin{cases} x & x > 0 \\\\ 0 & \\text{otherwise} \\end{cases}",
+		"n! + \\binom{n}{k}",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			original, err := ParseLatex(input)
+			if err != nil {
+				t.Fatalf("ParseLatex failed: %v", err)
+			}
+			text, err := ExpressionToText(original.(expr.Expr), ASCIIStyle)
+			if err != nil {
+				t.Fatalf("ExpressionToText failed: %v", err)
+			}
+			result, err := ParseAsciiMath(text)
+			if err != nil {
+				t.Fatalf("ParseAsciiMath failed for %s: %v", text, err)
+			}
+			if !result.(expr.Expr).Equals(original.(expr.Expr)) {
+				t.Errorf("%s read back as %v", text, result)
+			}
+		})
+	}
+}
+
+func TestParseAsciiMath_Errors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+		column  int
+	}{
+		{"x +", "unexpected end of input", 4},
+		{"(x", "expected ')'", 3},
+		{"sqrt", "expected '{' after \\sqrt", 5},
+		{"frac(a)", "expected '{' after \\frac", 8},
+		{"2 $ 3", "illegal character $", 3},
+		{"x + 1.2.3", "invalid number 1.2.3", 5},
+		{"10 mod 3", "unsupported operator mod", 4},
+		{"x != 2", "unsupported operator !=", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ParseAsciiMath(tt.input)
+			var errs ParseErrors
+			if !errors.As(err, &errs) || len(errs) == 0 {
+				t.Fatalf("expected ParseErrors, got %v", err)
+			}
+			if errs[0].Message != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, errs[0].Message)
+			}
+			if errs[0].Span.Start.Column != tt.column {
+				t.Errorf("expected column %d, got %d", tt.column, errs[0].Span.Start.Column)
+			}
+		})
+	}
+}
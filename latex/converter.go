@@ -9,13 +9,21 @@ import (
 
 // Converter converts LaTeX AST to Expression tree or Proposition
 type Converter struct {
-	errors []string
+	errors      []string
+	definitions *expr.Definitions
 }
 
 // NewConverter creates a new Converter instance
 func NewConverter() *Converter {
+	return NewConverterWithDefinitions(nil)
+}
+
+// NewConverterWithDefinitions creates a Converter that resolves calls
+// against definitions and adds declarations to it
+func NewConverterWithDefinitions(definitions *expr.Definitions) *Converter {
 	return &Converter{
-		errors: []string{},
+		errors:      []string{},
+		definitions: definitions,
 	}
 }
 
@@ -41,6 +49,24 @@ func (c *Converter) Convert(node LatexNode) (interface{}, error) {
 		return c.convertCommand(n)
 	case *UnaryMinusNode:
 		return c.convertUnaryMinus(n)
+	case *FunctionNode:
+		return c.convertFunction(n)
+	case *FenceNode:
+		return c.convertFence(n)
+	case *FactorialNode:
+		return c.convertFactorial(n)
+	case *BigOperatorNode:
+		return c.convertBigOperator(n)
+	case *IntegralNode:
+		return c.convertIntegral(n)
+	case *LimitNode:
+		return c.convertLimit(n)
+	case *CasesNode:
+		return c.convertCases(n)
+	case *CallNode:
+		return c.convertCall(n)
+	case *DefinitionNode:
+		return c.convertDefinition(n)
 	default:
 		return nil, fmt.Errorf("unknown node type: %T", node)
 	}
@@ -48,6 +74,9 @@ func (c *Converter) Convert(node LatexNode) (interface{}, error) {
 
 // convertNumber converts a NumberNode to a Constant
 func (c *Converter) convertNumber(node *NumberNode) expr.Expr {
+	if node.Exact != nil {
+		return expr.NewConstant(value.NewBigIntegerValue(node.Exact))
+	}
 	return expr.NewConstant(value.NewRealValue(node.Value))
 }
 
@@ -97,8 +126,9 @@ func (c *Converter) convertBinaryOp(node *BinaryOpNode) (interface{}, error) {
 	}
 }
 
-// convertEqual converts an EqualNode to Equal or And proposition
-// Handles chained equality: a = b = c becomes And(Eq(a,b), Eq(b,c))
+// convertEqual converts an EqualNode to an Equal, Inequality or And proposition
+// Handles chained relations: a = b = c becomes And(Eq(a,b), Eq(b,c)) and
+// 0 \le x < 1 becomes And(Le(0,x), Lt(x,1))
 func (c *Converter) convertEqual(node *EqualNode) (interface{}, error) {
 	// Check if left side is also an EqualNode (chained equality)
 	if leftEqualNode, ok := node.Left.(*EqualNode); ok {
@@ -134,8 +164,8 @@ func (c *Converter) convertEqual(node *EqualNode) (interface{}, error) {
 			return nil, fmt.Errorf("right operand must be an Expression, got %T", right)
 		}
 
-		// Create new Equal(middle, right)
-		newEqual := prop.NewEqual(middleExpr, rightExpr)
+		// Create new relation(middle, right)
+		newEqual := newRelation(node.Operator, middleExpr, rightExpr)
 
 		// Convert leftResult to Proposition
 		leftProp, ok := leftResult.(prop.Proposition)
@@ -169,7 +199,53 @@ func (c *Converter) convertEqual(node *EqualNode) (interface{}, error) {
 		return nil, fmt.Errorf("right operand must be an Expression, got %T", right)
 	}
 
-	return prop.NewEqual(leftExpr, rightExpr), nil
+	return newRelation(node.Operator, leftExpr, rightExpr), nil
+}
+
+// newRelation creates the proposition for a relation operator token
+func newRelation(operator Token, left, right expr.Expr) prop.Proposition {
+	switch operator.Type {
+	case LESS:
+		return prop.NewInequality(prop.Less, left, right)
+	case LESSEQ:
+		return prop.NewInequality(prop.LessEqual, left, right)
+	case GREATER:
+		return prop.NewInequality(prop.Greater, left, right)
+	case GREATEREQ:
+		return prop.NewInequality(prop.GreaterEqual, left, right)
+	default:
+		return prop.NewEqual(left, right)
+	}
+}
+
+// convertCases converts a CasesNode to a Piecewise
+func (c *Converter) convertCases(node *CasesNode) (interface{}, error) {
+	var branches []expr.Branch
+	var otherwise expr.Expr
+	for i, row := range node.Rows {
+		result, err := c.convertExpr(row.Value, "case value")
+		if err != nil {
+			return nil, err
+		}
+		if row.Condition == nil {
+			otherwise = result
+			continue
+		}
+
+		condition, err := c.Convert(row.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert case condition: %w", err)
+		}
+		proposition, ok := condition.(prop.Proposition)
+		if !ok || !prop.IsProposition(proposition) {
+			return nil, fmt.Errorf("condition of case %d must be a Proposition, got %T", i+1, condition)
+		}
+		branches = append(branches, expr.Branch{Condition: proposition, Value: result})
+	}
+	if len(branches) == 0 {
+		return nil, fmt.Errorf("cases require at least one condition")
+	}
+	return expr.NewPiecewise(branches, otherwise), nil
 }
 
 // convertGroup converts a GroupNode by converting its inner expression
@@ -183,6 +259,10 @@ func (c *Converter) convertCommand(node *CommandNode) (interface{}, error) {
 	switch node.Name {
 	case "sqrt":
 		return c.convertSqrt(node)
+	case "binom":
+		return c.convertBinom(node)
+	case "frac", "dfrac", "tfrac":
+		return c.convertFrac(node)
 	default:
 		return nil, fmt.Errorf("unknown command: \\%s", node.Name)
 	}
@@ -227,7 +307,230 @@ func (c *Converter) convertSqrt(node *CommandNode) (interface{}, error) {
 	return expr.NewSqrt(argument), nil
 }
 
-// convertUnaryMinus converts a UnaryMinusNode to a Multiply by -1
+// convertBinom converts \binom{n}{k} to a Binomial
+func (c *Converter) convertBinom(node *CommandNode) (interface{}, error) {
+	n, err := c.convertExpr(node.Argument, "binomial n")
+	if err != nil {
+		return nil, err
+	}
+	k, err := c.convertExpr(node.Second, "binomial k")
+	if err != nil {
+		return nil, err
+	}
+	return expr.NewBinomial(n, k), nil
+}
+
+// convertFrac converts \frac{a}{b} and its display and text variants to a Div
+func (c *Converter) convertFrac(node *CommandNode) (interface{}, error) {
+	numerator, err := c.convertExpr(node.Argument, "numerator")
+	if err != nil {
+		return nil, err
+	}
+	denominator, err := c.convertExpr(node.Second, "denominator")
+	if err != nil {
+		return nil, err
+	}
+	return expr.NewDiv(numerator, denominator), nil
+}
+
+// convertFactorial converts a FactorialNode to a Factorial
+func (c *Converter) convertFactorial(node *FactorialNode) (interface{}, error) {
+	operand, err := c.convertExpr(node.Operand, "factorial operand")
+	if err != nil {
+		return nil, err
+	}
+	return expr.NewFactorial(operand), nil
+}
+
+// convertBigOperator converts a BigOperatorNode to a Sum or Product
+func (c *Converter) convertBigOperator(node *BigOperatorNode) (interface{}, error) {
+	lower, err := c.convertExpr(node.Lower, "lower bound")
+	if err != nil {
+		return nil, err
+	}
+	upper, err := c.convertExpr(node.Upper, "upper bound")
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.convertExpr(node.Body, "\\"+node.Name+" body")
+	if err != nil {
+		return nil, err
+	}
+
+	switch node.Name {
+	case "sum":
+		return expr.NewSum(node.Index.Name, lower, upper, body), nil
+	case "prod":
+		return expr.NewProduct(node.Index.Name, lower, upper, body), nil
+	default:
+		return nil, fmt.Errorf("unknown operator: \\%s", node.Name)
+	}
+}
+
+// convertIntegral converts an IntegralNode to an Integral
+func (c *Converter) convertIntegral(node *IntegralNode) (interface{}, error) {
+	integrand, err := c.convertExpr(node.Integrand, "integrand")
+	if err != nil {
+		return nil, err
+	}
+	if node.Lower == nil {
+		return expr.NewIndefiniteIntegral(node.Variable.Name, integrand), nil
+	}
+
+	lower, err := c.convertExpr(node.Lower, "lower bound")
+	if err != nil {
+		return nil, err
+	}
+	upper, err := c.convertExpr(node.Upper, "upper bound")
+	if err != nil {
+		return nil, err
+	}
+	return expr.NewIntegral(node.Variable.Name, lower, upper, integrand), nil
+}
+
+// convertLimit converts a LimitNode to a Limit
+func (c *Converter) convertLimit(node *LimitNode) (interface{}, error) {
+	point, err := c.convertExpr(node.Point, "limit point")
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.convertExpr(node.Body, "limit body")
+	if err != nil {
+		return nil, err
+	}
+
+	direction := expr.TwoSided
+	switch node.Direction {
+	case "+":
+		direction = expr.FromAbove
+	case "-":
+		direction = expr.FromBelow
+	}
+	return expr.NewLimit(node.Variable.Name, point, direction, body), nil
+}
+
+// convertCall converts a CallNode to a Call
+// A function being declared may not be defined yet, so the number of
+// arguments is only checked against existing definitions.
+func (c *Converter) convertCall(node *CallNode) (interface{}, error) {
+	if c.definitions == nil {
+		return nil, fmt.Errorf("function %s called without definitions", node.Name.Name)
+	}
+	if definition, ok := c.definitions.Lookup(node.Name.Name); ok && len(definition.Params()) != len(node.Arguments) {
+		return nil, fmt.Errorf("function %s takes %d arguments, got %d", node.Name.Name, len(definition.Params()), len(node.Arguments))
+	}
+
+	args := make([]expr.Expr, len(node.Arguments))
+	for i, argument := range node.Arguments {
+		arg, err := c.convertExpr(argument, "function argument")
+		if err != nil {
+			return nil, err
+		}
+		args[i] = arg
+	}
+	return expr.NewCall(c.definitions, node.Name.Name, args), nil
+}
+
+// convertDefinition converts a DefinitionNode to a Definition and adds it
+// to the definitions
+func (c *Converter) convertDefinition(node *DefinitionNode) (interface{}, error) {
+	if c.definitions == nil {
+		return nil, fmt.Errorf("function %s declared without definitions", node.Name.Name)
+	}
+
+	params := make([]string, len(node.Params))
+	seen := map[string]bool{node.Name.Name: true}
+	for i, param := range node.Params {
+		if seen[param.Name] {
+			return nil, fmt.Errorf("parameter %s of function %s is declared twice or shadows the function", param.Name, node.Name.Name)
+		}
+		seen[param.Name] = true
+		params[i] = param.Name
+	}
+
+	body, err := c.convertExpr(node.Body, "function body")
+	if err != nil {
+		return nil, err
+	}
+	definition := expr.NewDefinition(node.Name.Name, params, body)
+	c.definitions.Define(definition)
+	return definition, nil
+}
+
+// convertFunction converts a FunctionNode to a Function or Log
+// \sin^{n} x becomes Power(Function(sin, x), n).
+func (c *Converter) convertFunction(node *FunctionNode) (interface{}, error) {
+	argument, err := c.convertExpr(node.Argument, "function argument")
+	if err != nil {
+		return nil, err
+	}
+
+	var result expr.Expr
+	if node.Name == "log" {
+		if node.Base != nil {
+			base, err := c.convertExpr(node.Base, "logarithm base")
+			if err != nil {
+				return nil, err
+			}
+			result = expr.NewLog(base, argument)
+		} else {
+			result = expr.NewLog10(argument)
+		}
+	} else {
+		kind, ok := expr.LookupFunction(node.Name)
+		if !ok {
+			return nil, fmt.Errorf("unknown function: \\%s", node.Name)
+		}
+		if node.Base != nil {
+			return nil, fmt.Errorf("\\%s does not take a subscript", node.Name)
+		}
+		result = expr.NewFunction(kind, argument)
+	}
+
+	if node.Exponent != nil {
+		exponent, err := c.convertExpr(node.Exponent, "function exponent")
+		if err != nil {
+			return nil, err
+		}
+		return expr.NewPower(result, exponent), nil
+	}
+	return result, nil
+}
+
+// convertFence converts a FenceNode to Abs, Floor or Ceil
+func (c *Converter) convertFence(node *FenceNode) (interface{}, error) {
+	inner, err := c.convertExpr(node.Inner, "delimited expression")
+	if err != nil {
+		return nil, err
+	}
+
+	switch node.Kind {
+	case FenceAbs:
+		return expr.NewAbs(inner), nil
+	case FenceFloor:
+		return expr.NewFloor(inner), nil
+	case FenceCeil:
+		return expr.NewCeil(inner), nil
+	default:
+		return nil, fmt.Errorf("unknown fence kind: %v", node.Kind)
+	}
+}
+
+// convertExpr converts a node that must produce an Expression
+// what describes the node in error messages.
+func (c *Converter) convertExpr(node LatexNode, what string) (expr.Expr, error) {
+	result, err := c.Convert(node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", what, err)
+	}
+	expression, ok := result.(expr.Expr)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an Expression, got %T", what, result)
+	}
+	return expression, nil
+}
+
+// convertUnaryMinus converts a UnaryMinusNode to a Neg
 func (c *Converter) convertUnaryMinus(node *UnaryMinusNode) (interface{}, error) {
 	operandResult, err := c.Convert(node.Operand)
 	if err != nil {
@@ -239,9 +542,7 @@ func (c *Converter) convertUnaryMinus(node *UnaryMinusNode) (interface{}, error)
 		return nil, fmt.Errorf("unary minus operand must be an Expression, got %T", operandResult)
 	}
 
-	// Convert -x to (-1) * x
-	negOne := expr.NewConstant(value.NewRealValue(-1))
-	return expr.NewMul(negOne, operand), nil
+	return expr.NewNeg(operand), nil
 }
 
 // Errors returns the list of conversion errors
@@ -4,6 +4,7 @@ import (
 	"exprtree/expr"
 	"exprtree/prop"
 	"exprtree/value"
+	"math"
 	"testing"
 )
 
@@ -1108,3 +1109,236 @@ func TestConverter_ChainedEquality_WithExpressions(t *testing.T) {
 	}
 	_ = rightSub // Structure verification is sufficient
 }
+
+func TestConvert_Function(t *testing.T) {
+	x := expr.NewVariable("x")
+	two := expr.NewConstant(value.NewRealValue(2))
+
+	tests := []struct {
+		input    string
+		expected expr.Expr
+	}{
+		{"\\sin x", expr.NewFunction(expr.Sin, x)},
+		{"\\arctan(x)", expr.NewFunction(expr.Arctan, x)},
+		{"\\sin^2 x", expr.NewPower(expr.NewFunction(expr.Sin, x), two)},
+		{"\\sin^{-1} x", expr.NewFunction(expr.Arcsin, x)},
+		{"\\cos^{-1}(2x)", expr.NewFunction(expr.Arccos, expr.NewMul(two, x))},
+		{"\\tan^{-1} x", expr.NewFunction(expr.Arctan, x)},
+		{"(\\sin x)^{-1}", expr.NewPower(expr.NewFunction(expr.Sin, x), expr.NewNeg(expr.NewConstant(value.NewRealValue(1))))},
+		{"\\exp 2x", expr.NewFunction(expr.Exp, expr.NewMul(two, x))},
+		{"\\ln x", expr.NewFunction(expr.Ln, x)},
+		{"\\log x", expr.NewLog10(x)},
+		{"\\log_{2} x", expr.NewLog(two, x)},
+		{"|x|", expr.NewAbs(x)},
+		{"\\left| 2x \\right|", expr.NewAbs(expr.NewMul(two, x))},
+		{"\\lfloor x \\rfloor", expr.NewFloor(x)},
+		{"\\lceil x \\rceil", expr.NewCeil(x)},
+		{"x!", expr.NewFactorial(x)},
+		{"(x + 2)!", expr.NewFactorial(expr.NewAdd(x, two))},
+		{"2x!", expr.NewMul(two, expr.NewFactorial(x))},
+		{"\\binom{x}{2}", expr.NewBinomial(x, two)},
+		{"\\sum_{i=1}^{x} i^2", expr.NewSum("i", expr.NewConstant(value.NewRealValue(1)), x, expr.NewPower(expr.NewVariable("i"), two))},
+		{"\\prod^{2}_{k=x} k", expr.NewProduct("k", x, two, expr.NewVariable("k"))},
+		{"\\int_{0}^{x} t \\, dt", expr.NewIntegral("t", expr.NewConstant(value.NewRealValue(0)), x, expr.NewVariable("t"))},
+		{"\\int \\sin x dx", expr.NewIndefiniteIntegral("x", expr.NewFunction(expr.Sin, x))},
+		{"\\sum_{i=1}^{2} i + x", expr.NewAdd(expr.NewSum("i", expr.NewConstant(value.NewRealValue(1)), two, expr.NewVariable("i")), x)},
+		{"\\lim_{x \\to 2} x", expr.NewLimit("x", two, expr.TwoSided, x)},
+		{"\\lim_{x \\to 2^-} x", expr.NewLimit("x", two, expr.FromBelow, x)},
+		{"\\lim_{x \\to \\infty} 2x", expr.NewLimit("x", expr.NewConstant(value.NewRealValue(math.Inf(1))), expr.TwoSided, expr.NewMul(two, x))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseLatex(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLatex error: %v", err)
+			}
+			if !tt.expected.Equals(result) {
+				t.Errorf("unexpected tree for %s: %T", tt.input, result)
+			}
+		})
+	}
+}
+
+func TestConvert_Piecewise(t *testing.T) {
+	x := expr.NewVariable("x")
+	zero := expr.NewConstant(value.NewRealValue(0))
+	one := expr.NewConstant(value.NewRealValue(1))
+
+	tests := []struct {
+		input    string
+		expected expr.Expr
+	}{
+		{
+			"x \\ge 1",
+			prop.NewInequality(prop.GreaterEqual, x, one),
+		},
+		{
+			"0 \\le x < 1",
+			prop.NewAnd(prop.NewInequality(prop.LessEqual, zero, x), prop.NewInequality(prop.Less, x, one)),
+		},
+		{
+			"\\begin{cases} 0 & x < 0 \\\\ x & \\text{otherwise} \\end{cases}",
+			expr.NewPiecewise([]expr.Branch{{Condition: prop.NewInequality(prop.Less, x, zero), Value: zero}}, x),
+		},
+		{
+			"\\begin{cases} 1 & x > 1 \\\\ 0 & x = 1 \\\\ \\end{cases}",
+			expr.NewPiecewise([]expr.Branch{
+				{Condition: prop.NewInequality(prop.Greater, x, one), Value: one},
+				{Condition: prop.NewEqual(x, one), Value: zero},
+			}, nil),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseLatex(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLatex error: %v", err)
+			}
+			if !tt.expected.Equals(result) {
+				t.Errorf("unexpected tree for %s: %T", tt.input, result)
+			}
+		})
+	}
+
+	// Conditions must be propositions
+	for _, input := range []string{
+		"\\begin{cases} 1 & x \\end{cases}",
+		"\\begin{cases} 1 & \\text{otherwise} \\end{cases}",
+	} {
+		if _, err := ParseLatex(input); err == nil {
+			t.Errorf("expected error for input: %s", input)
+		}
+	}
+}
+
+func TestConvert_Call(t *testing.T) {
+	definitions := expr.NewDefinitions()
+	options := Options{Definitions: definitions}
+
+	result, err := ParseLatexWithOptions("f(x, y) = x^2 + y", options)
+	if err != nil {
+		t.Fatalf("ParseLatexWithOptions error: %v", err)
+	}
+	definition, ok := result.(*expr.Definition)
+	if !ok {
+		t.Fatalf("expected Definition, got %T", result)
+	}
+	if registered, ok := definitions.Lookup("f"); !ok || registered != definition {
+		t.Fatalf("expected f to be registered")
+	}
+
+	result, err = ParseLatexWithOptions("f(2, a + 1)", options)
+	if err != nil {
+		t.Fatalf("ParseLatexWithOptions error: %v", err)
+	}
+	two := expr.NewConstant(value.NewRealValue(2))
+	expected := expr.NewCall(definitions, "f", []expr.Expr{two, expr.NewAdd(expr.NewVariable("a"), expr.NewConstant(value.NewRealValue(1)))})
+	if !expected.Equals(result) {
+		t.Errorf("unexpected tree: %T", result)
+	}
+
+	tests := []string{
+		"f(1)",
+		"f(x, x) = x",
+		"g(g) = 1",
+	}
+	for _, input := range tests {
+		if _, err := ParseLatexWithOptions(input, options); err == nil {
+			t.Errorf("expected error for input: %s", input)
+		}
+	}
+}
+
+func TestConvert_UnaryMinus(t *testing.T) {
+	x := expr.NewVariable("x")
+	two := expr.NewConstant(value.NewRealValue(2))
+	tests := []struct {
+		input    string
+		expected expr.Expr
+	}{
+		{"-x", expr.NewNeg(x)},
+		{"-(x + 2)", expr.NewNeg(expr.NewAdd(x, two))},
+		{"-x^2", expr.NewNeg(expr.NewPower(x, two))},
+		{"(-x)^2", expr.NewPower(expr.NewNeg(x), two)},
+		{"2 - -x", expr.NewSub(two, expr.NewNeg(x))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseLatex(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLatex error: %v", err)
+			}
+			if !tt.expected.Equals(result) {
+				t.Errorf("unexpected tree: %T", result)
+			}
+		})
+	}
+}
+
+func TestConvert_Frac(t *testing.T) {
+	x := expr.NewVariable("x")
+	one := expr.NewConstant(value.NewRealValue(1))
+	two := expr.NewConstant(value.NewRealValue(2))
+	tests := []struct {
+		input    string
+		expected expr.Expr
+	}{
+		{"\\frac{1}{x}", expr.NewDiv(one, x)},
+		{"\\dfrac{x + 1}{2}", expr.NewDiv(expr.NewAdd(x, one), two)},
+		{"\\tfrac{1}{2} x", expr.NewMul(expr.NewDiv(one, two), x)},
+		{"\\frac{\\frac{1}{x}}{2}", expr.NewDiv(expr.NewDiv(one, x), two)},
+		{"2\\frac{1}{x}", expr.NewMul(two, expr.NewDiv(one, x))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseLatex(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLatex error: %v", err)
+			}
+			if !tt.expected.Equals(result) {
+				t.Errorf("unexpected tree: %T", result)
+			}
+		})
+	}
+}
+
+func TestConvert_SubscriptedVariable(t *testing.T) {
+	result, err := ParseLatex("x_{ij} + \\alpha_1")
+	if err != nil {
+		t.Fatalf("ParseLatex error: %v", err)
+	}
+	sum, ok := result.(*expr.Add)
+	if !ok {
+		t.Fatalf("expected Add, got %T", result)
+	}
+	x, ok := sum.Left().(*expr.Variable)
+	if !ok || x.Base() != "x" || x.Subscript() != "ij" {
+		t.Errorf("expected x with subscript ij, got %v", sum.Left())
+	}
+	alpha, ok := sum.Right().(*expr.Variable)
+	if !ok || alpha.Base() != "α" || alpha.Subscript() != "1" {
+		t.Errorf("expected α with subscript 1, got %v", sum.Right())
+	}
+}
+
+func TestConvert_ExactInteger(t *testing.T) {
+	result, err := ParseLatex("12345678901234567891")
+	if err != nil {
+		t.Fatalf("ParseLatex error: %v", err)
+	}
+	v, ok := result.(expr.Expr).Eval()
+	if !ok {
+		t.Fatalf("evaluation failed")
+	}
+	integer, ok := v.(*value.IntegerValue)
+	if !ok {
+		t.Fatalf("expected IntegerValue, got %T", v)
+	}
+	if got := integer.BigInt().String(); got != "12345678901234567891" {
+		t.Errorf("expected 12345678901234567891, got %s", got)
+	}
+}
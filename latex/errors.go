@@ -0,0 +1,112 @@
+package latex
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrorCode identifies the kind of a ParseError
+type ErrorCode int
+
+const (
+	ErrUnexpectedToken ErrorCode = iota // 予期しないトークン
+	ErrExpectedToken                    // 必要なトークンがない
+	ErrIllegalToken                     // 字句解析できない文字やコマンド
+	ErrInvalidSyntax                    // 構文の制約違反（範囲の指定など）
+	ErrUnsupported                      // 未対応の構文
+)
+
+func (c ErrorCode) String() string {
+	switch c {
+	case ErrUnexpectedToken:
+		return "unexpected-token"
+	case ErrExpectedToken:
+		return "expected-token"
+	case ErrIllegalToken:
+		return "illegal-token"
+	case ErrInvalidSyntax:
+		return "invalid-syntax"
+	case ErrUnsupported:
+		return "unsupported"
+	default:
+		return fmt.Sprintf("ErrorCode(%d)", int(c))
+	}
+}
+
+// Position is a location in the input
+// Line and Column start at 1; Column counts characters, not bytes.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// Span is the part of the input covered by a token, End being exclusive
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// ParseError is a diagnostic reported by the parser
+type ParseError struct {
+	Code    ErrorCode
+	Message string
+	Span    Span
+	Token   Token // the offending token
+	Excerpt string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Span.Start.Line, e.Span.Start.Column, e.Message)
+}
+
+// ParseErrors lists the errors found in one pass over the input
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// newParseError creates a ParseError for a token of input
+func newParseError(input string, code ErrorCode, token Token, message string) *ParseError {
+	end := max(token.End, token.Pos)
+	return &ParseError{
+		Code:    code,
+		Message: message,
+		Span:    Span{Start: positionAt(input, token.Pos), End: positionAt(input, end)},
+		Token:   token,
+		Excerpt: excerpt(input, token.Pos, end),
+	}
+}
+
+// positionAt converts a byte offset to a Position
+func positionAt(input string, offset int) Position {
+	offset = min(offset, len(input))
+	lineStart := strings.LastIndexByte(input[:offset], '\n') + 1
+	return Position{
+		Offset: offset,
+		Line:   strings.Count(input[:offset], "\n") + 1,
+		Column: utf8.RuneCountInString(input[lineStart:offset]) + 1,
+	}
+}
+
+// excerpt returns the line containing start, with carets under the span
+// from start to end on the following line
+func excerpt(input string, start, end int) string {
+	start = min(start, len(input))
+	lineStart := strings.LastIndexByte(input[:start], '\n') + 1
+	lineEnd := len(input)
+	if i := strings.IndexByte(input[start:], '\n'); i >= 0 {
+		lineEnd = start + i
+	}
+	end = min(max(end, start), lineEnd)
+
+	indent := utf8.RuneCountInString(input[lineStart:start])
+	width := max(utf8.RuneCountInString(input[start:end]), 1)
+	return input[lineStart:lineEnd] + "\n" + strings.Repeat(" ", indent) + strings.Repeat("^", width)
+}
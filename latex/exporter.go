@@ -2,8 +2,10 @@ package latex
 
 import (
 	"exprtree/expr"
+	"exprtree/prop"
 	"exprtree/value"
 	"fmt"
+	"math/big"
 )
 
 // Exporter converts Expression tree to LaTeX AST
@@ -35,8 +37,44 @@ func (e *Exporter) Export(expression expr.Expr) (LatexNode, error) {
 		return e.exportBinaryOp(exp, MULTIPLY, "*")
 	case *expr.Div:
 		return e.exportBinaryOp(exp, DIVIDE, "/")
+	case *expr.Power:
+		return e.exportPower(exp)
+	case *expr.NthRoot:
+		return e.exportRoot(exp)
 	case *expr.Variable:
 		return e.exportVariable(exp), nil
+	case *expr.Function:
+		return e.exportFunction(exp)
+	case *expr.Log:
+		return e.exportLog(exp)
+	case *expr.Neg:
+		return e.exportNeg(exp)
+	case *expr.Abs:
+		return e.exportFence(exp, FenceAbs, Token{Type: PIPE, Literal: "|"})
+	case *expr.Floor:
+		return e.exportFence(exp, FenceFloor, Token{Type: COMMAND, Literal: "lfloor"})
+	case *expr.Ceil:
+		return e.exportFence(exp, FenceCeil, Token{Type: COMMAND, Literal: "lceil"})
+	case *expr.Factorial:
+		return e.exportFactorial(exp)
+	case *expr.Binomial:
+		return e.exportBinomial(exp)
+	case *expr.Permutation:
+		return e.exportPermutation(exp)
+	case *expr.Sum:
+		return e.exportBigOperator(exp, "sum")
+	case *expr.Product:
+		return e.exportBigOperator(exp, "prod")
+	case *expr.Integral:
+		return e.exportIntegral(exp)
+	case *expr.Limit:
+		return e.exportLimit(exp)
+	case *expr.Piecewise:
+		return e.exportPiecewise(exp)
+	case *expr.Call:
+		return e.exportCall(exp)
+	case *prop.Equal, *prop.Inequality, *prop.And:
+		return e.exportProposition(exp)
 	default:
 		return nil, fmt.Errorf("unknown expression type: %T", expression)
 	}
@@ -44,8 +82,8 @@ func (e *Exporter) Export(expression expr.Expr) (LatexNode, error) {
 
 // exportConstant converts a Constant to a NumberNode
 func (e *Exporter) exportConstant(constant *expr.Constant) LatexNode {
-	// Float64() to get the float64 value
-	realValue, ok := constant.Value().(*value.RealValue)
+	// Integers are exported through their float64 value as well
+	realValue, ok := value.ToReal(constant.Value())
 	if !ok {
 		e.errors = append(e.errors, fmt.Sprintf("unsupported constant value type: %T", constant.Value()))
 		return &NumberNode{
@@ -58,12 +96,23 @@ func (e *Exporter) exportConstant(constant *expr.Constant) LatexNode {
 		}
 	}
 
+	// Large integers keep their exact value
+	var exact *big.Int
+	literal := formatNumber(realValue.Float64())
+	if integer, ok := constant.Value().(*value.IntegerValue); ok {
+		if _, exact = integerValue(integer.BigInt()); exact != nil {
+			literal = exact.String()
+		}
+	}
+
 	return &NumberNode{
 		Value: realValue.Float64(),
+		Exact: exact,
 		Token: Token{
 			Type:    NUMBER,
-			Literal: fmt.Sprintf("%g", realValue.Float64()),
+			Literal: literal,
 			Value:   realValue.Float64(),
+			Exact:   exact,
 		},
 	}
 }
@@ -105,6 +154,428 @@ func (e *Exporter) exportBinaryOp(binaryExpr expr.Expr, opType TokenType, opLite
 	}, nil
 }
 
+// exportPower converts a Power to a BinaryOpNode
+// A power of a function such as (\sin x)^2 is exported as \sin^{2}(x), the
+// form the converter reads it from. The reciprocal keeps its parentheses,
+// since \sin^{-1} reads as the inverse function.
+func (e *Exporter) exportPower(power *expr.Power) (LatexNode, error) {
+	base, err := e.Export(power.Base())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export power base: %w", err)
+	}
+	exponent, err := e.Export(power.Exponent())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export exponent: %w", err)
+	}
+
+	if function, ok := base.(*FunctionNode); ok && function.Exponent == nil && !isMinusOne(exponent) {
+		function.Exponent = exponent
+		return function, nil
+	}
+	return &BinaryOpNode{
+		Left:     base,
+		Operator: Token{Type: CARET, Literal: "^"},
+		Right:    exponent,
+	}, nil
+}
+
+// exportRoot converts an NthRoot to \sqrt{x}, or \sqrt[n]{x} when the degree
+// is not 2
+func (e *Exporter) exportRoot(root *expr.NthRoot) (LatexNode, error) {
+	radicand, err := e.Export(root.Radicand())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export radicand: %w", err)
+	}
+
+	node := &CommandNode{
+		Name:     "sqrt",
+		Argument: radicand,
+		Token: Token{
+			Type:    COMMAND,
+			Literal: "sqrt",
+		},
+	}
+	if !root.Degree().Equals(expr.NewConstant(value.NewRealValue(2))) {
+		node.Optional, err = e.Export(root.Degree())
+		if err != nil {
+			return nil, fmt.Errorf("failed to export root degree: %w", err)
+		}
+	}
+	return node, nil
+}
+
+// exportNeg converts a Neg to a UnaryMinusNode
+func (e *Exporter) exportNeg(neg *expr.Neg) (LatexNode, error) {
+	operand, err := e.Export(neg.Operand())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export negated operand: %w", err)
+	}
+	return &UnaryMinusNode{
+		Operand: operand,
+		Token:   Token{Type: MINUS, Literal: "-"},
+	}, nil
+}
+
+// exportFunction converts a Function to a FunctionNode
+func (e *Exporter) exportFunction(function *expr.Function) (LatexNode, error) {
+	argument, err := e.Export(function.Argument())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export function argument: %w", err)
+	}
+
+	name := function.Kind().String()
+	return &FunctionNode{
+		Name:     name,
+		Argument: argument,
+		Token: Token{
+			Type:    COMMAND,
+			Literal: name,
+		},
+	}, nil
+}
+
+// exportLog converts a Log to a FunctionNode
+// The base is omitted for the common logarithm.
+func (e *Exporter) exportLog(log *expr.Log) (LatexNode, error) {
+	argument, err := e.Export(log.Argument())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export logarithm argument: %w", err)
+	}
+
+	var base LatexNode
+	if !log.Base().Equals(expr.NewConstant(value.NewRealValue(10))) {
+		base, err = e.Export(log.Base())
+		if err != nil {
+			return nil, fmt.Errorf("failed to export logarithm base: %w", err)
+		}
+	}
+
+	return &FunctionNode{
+		Name:     "log",
+		Argument: argument,
+		Base:     base,
+		Token: Token{
+			Type:    COMMAND,
+			Literal: "log",
+		},
+	}, nil
+}
+
+// exportFence converts Abs, Floor or Ceil to a FenceNode
+func (e *Exporter) exportFence(unary expr.Unary, kind FenceKind, token Token) (LatexNode, error) {
+	inner, err := e.Export(unary.Operand())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export delimited expression: %w", err)
+	}
+
+	return &FenceNode{
+		Kind:  kind,
+		Inner: inner,
+		Token: token,
+	}, nil
+}
+
+// exportFactorial converts a Factorial to a FactorialNode
+func (e *Exporter) exportFactorial(factorial *expr.Factorial) (LatexNode, error) {
+	operand, err := e.Export(factorial.Operand())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export factorial operand: %w", err)
+	}
+	return newFactorialNode(operand), nil
+}
+
+// exportBinomial converts a Binomial to a \binom CommandNode
+func (e *Exporter) exportBinomial(binomial *expr.Binomial) (LatexNode, error) {
+	n, err := e.Export(binomial.N())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export binomial n: %w", err)
+	}
+	k, err := e.Export(binomial.K())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export binomial k: %w", err)
+	}
+
+	return &CommandNode{
+		Name:     "binom",
+		Argument: n,
+		Second:   k,
+		Token: Token{
+			Type:    COMMAND,
+			Literal: "binom",
+		},
+	}, nil
+}
+
+// exportPermutation converts a Permutation to n! / (n - k)!
+// LaTeX has no standard notation for permutation counts.
+func (e *Exporter) exportPermutation(permutation *expr.Permutation) (LatexNode, error) {
+	n, err := e.Export(permutation.N())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export permutation n: %w", err)
+	}
+	k, err := e.Export(permutation.K())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export permutation k: %w", err)
+	}
+
+	difference := &BinaryOpNode{
+		Left:     n,
+		Operator: Token{Type: MINUS, Literal: "-"},
+		Right:    k,
+	}
+	return &BinaryOpNode{
+		Left:     newFactorialNode(n),
+		Operator: Token{Type: DIVIDE, Literal: "/"},
+		Right:    newFactorialNode(difference),
+	}, nil
+}
+
+// exportBigOperator converts a Sum or Product to a BigOperatorNode
+func (e *Exporter) exportBigOperator(indexed expr.Indexed, name string) (LatexNode, error) {
+	lower, err := e.Export(indexed.Lower())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export lower bound: %w", err)
+	}
+	upper, err := e.Export(indexed.Upper())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export upper bound: %w", err)
+	}
+	body, err := e.Export(indexed.Body())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export \\%s body: %w", name, err)
+	}
+
+	return &BigOperatorNode{
+		Name: name,
+		Index: &VariableNode{
+			Name:  indexed.Index(),
+			Token: Token{Type: VARIABLE, Literal: indexed.Index()},
+		},
+		Lower: lower,
+		Upper: upper,
+		Body:  body,
+		Token: Token{
+			Type:    COMMAND,
+			Literal: name,
+		},
+	}, nil
+}
+
+// exportIntegral converts an Integral to an IntegralNode
+func (e *Exporter) exportIntegral(integral *expr.Integral) (LatexNode, error) {
+	integrand, err := e.Export(integral.Integrand())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export integrand: %w", err)
+	}
+
+	node := &IntegralNode{
+		Integrand: integrand,
+		Variable: &VariableNode{
+			Name:  integral.Variable(),
+			Token: Token{Type: VARIABLE, Literal: integral.Variable()},
+		},
+		Token: Token{
+			Type:    COMMAND,
+			Literal: "int",
+		},
+	}
+	if integral.IsDefinite() {
+		node.Lower, err = e.Export(integral.Lower())
+		if err != nil {
+			return nil, fmt.Errorf("failed to export lower bound: %w", err)
+		}
+		node.Upper, err = e.Export(integral.Upper())
+		if err != nil {
+			return nil, fmt.Errorf("failed to export upper bound: %w", err)
+		}
+	}
+	return node, nil
+}
+
+// exportLimit converts a Limit to a LimitNode
+func (e *Exporter) exportLimit(limit *expr.Limit) (LatexNode, error) {
+	point, err := e.Export(limit.Point())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export limit point: %w", err)
+	}
+	body, err := e.Export(limit.Body())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export limit body: %w", err)
+	}
+
+	node := &LimitNode{
+		Variable: &VariableNode{
+			Name:  limit.Variable(),
+			Token: Token{Type: VARIABLE, Literal: limit.Variable()},
+		},
+		Point: point,
+		Body:  body,
+		Token: Token{
+			Type:    COMMAND,
+			Literal: "lim",
+		},
+	}
+	switch limit.Direction() {
+	case expr.FromAbove:
+		node.Direction = "+"
+	case expr.FromBelow:
+		node.Direction = "-"
+	}
+	return node, nil
+}
+
+// exportPiecewise converts a Piecewise to a CasesNode
+func (e *Exporter) exportPiecewise(piecewise *expr.Piecewise) (LatexNode, error) {
+	node := &CasesNode{Token: Token{Type: COMMAND, Literal: "begin"}}
+	for _, branch := range piecewise.Branches() {
+		result, err := e.Export(branch.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export case value: %w", err)
+		}
+		condition, err := e.exportProposition(branch.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export case condition: %w", err)
+		}
+		node.Rows = append(node.Rows, CaseRow{Value: result, Condition: condition})
+	}
+	if piecewise.Otherwise() != nil {
+		result, err := e.Export(piecewise.Otherwise())
+		if err != nil {
+			return nil, fmt.Errorf("failed to export otherwise value: %w", err)
+		}
+		node.Rows = append(node.Rows, CaseRow{Value: result})
+	}
+	return node, nil
+}
+
+// exportCall converts a Call to a CallNode
+func (e *Exporter) exportCall(call *expr.Call) (LatexNode, error) {
+	node := &CallNode{
+		Name:  newVariableNode(call.Name()),
+		Token: Token{Type: VARIABLE, Literal: call.Name()},
+	}
+	for _, arg := range call.Args() {
+		argument, err := e.Export(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export argument of %s: %w", call.Name(), err)
+		}
+		node.Arguments = append(node.Arguments, argument)
+	}
+	return node, nil
+}
+
+// ExportDefinition converts a Definition to a DefinitionNode
+func (e *Exporter) ExportDefinition(definition *expr.Definition) (LatexNode, error) {
+	body, err := e.Export(definition.Body())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export body of %s: %w", definition.Name(), err)
+	}
+	node := &DefinitionNode{
+		Name:  newVariableNode(definition.Name()),
+		Body:  body,
+		Token: Token{Type: VARIABLE, Literal: definition.Name()},
+	}
+	for _, param := range definition.Params() {
+		node.Params = append(node.Params, newVariableNode(param))
+	}
+	return node, nil
+}
+
+// newVariableNode creates a VariableNode for a name
+func newVariableNode(name string) *VariableNode {
+	return &VariableNode{
+		Name:  name,
+		Token: Token{Type: VARIABLE, Literal: name},
+	}
+}
+
+// relationTokens maps inequality relations to their operator tokens
+var relationTokens = map[prop.Relation]Token{
+	prop.Less:         {Type: LESS, Literal: "<"},
+	prop.LessEqual:    {Type: LESSEQ, Literal: "\\le"},
+	prop.Greater:      {Type: GREATER, Literal: ">"},
+	prop.GreaterEqual: {Type: GREATEREQ, Literal: "\\ge"},
+}
+
+// exportProposition converts an Equal or Inequality to an EqualNode
+// A conjunction is exported when it is a chain like 0 \le x < 1, whose
+// relations share their middle operands.
+func (e *Exporter) exportProposition(proposition expr.Expr) (LatexNode, error) {
+	var operator Token
+	var left, right expr.Expr
+	switch p := proposition.(type) {
+	case *prop.Equal:
+		operator, left, right = Token{Type: EQUAL, Literal: "="}, p.Left(), p.Right()
+	case *prop.Inequality:
+		operator, left, right = relationTokens[p.Relation()], p.Left(), p.Right()
+	case *prop.And:
+		chain, err := e.exportProposition(p.Left())
+		if err != nil {
+			return nil, err
+		}
+		link, err := e.exportProposition(p.Right())
+		if err != nil {
+			return nil, err
+		}
+		last, ok1 := chainEnd(p.Left())
+		next, ok2 := link.(*EqualNode)
+		first, ok3 := chainStart(p.Right())
+		if !ok1 || !ok2 || !ok3 || !last.Equals(first) {
+			return nil, fmt.Errorf("cannot export a conjunction that is not a chain of relations")
+		}
+		return &EqualNode{Left: chain, Operator: next.Operator, Right: next.Right}, nil
+	default:
+		return nil, fmt.Errorf("unknown proposition type: %T", proposition)
+	}
+
+	leftNode, err := e.Export(left)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export left operand: %w", err)
+	}
+	rightNode, err := e.Export(right)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export right operand: %w", err)
+	}
+	return &EqualNode{Left: leftNode, Operator: operator, Right: rightNode}, nil
+}
+
+// chainEnd returns the rightmost operand of a relation chain
+func chainEnd(proposition expr.Expr) (expr.Expr, bool) {
+	switch p := proposition.(type) {
+	case *prop.Equal:
+		return p.Right(), true
+	case *prop.Inequality:
+		return p.Right(), true
+	case *prop.And:
+		return chainEnd(p.Right())
+	default:
+		return nil, false
+	}
+}
+
+// chainStart returns the left operand of a single relation
+func chainStart(proposition expr.Expr) (expr.Expr, bool) {
+	switch p := proposition.(type) {
+	case *prop.Equal:
+		return p.Left(), true
+	case *prop.Inequality:
+		return p.Left(), true
+	default:
+		return nil, false
+	}
+}
+
+// newFactorialNode wraps operand in a FactorialNode
+func newFactorialNode(operand LatexNode) LatexNode {
+	return &FactorialNode{
+		Operand: operand,
+		Token: Token{
+			Type:    BANG,
+			Literal: "!",
+		},
+	}
+}
+
 // Errors returns the list of export errors
 func (e *Exporter) Errors() []string {
 	return e.errors
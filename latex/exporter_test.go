@@ -2,7 +2,10 @@ package latex
 
 import (
 	"exprtree/expr"
+	"exprtree/prop"
 	"exprtree/value"
+	"math/big"
+	"strings"
 	"testing"
 )
 
@@ -29,6 +32,33 @@ func TestExportConstant(t *testing.T) {
 	}
 }
 
+func TestExportConstant_Integral(t *testing.T) {
+	// Integral values are written out in full, not as 1e+06
+	tests := []struct {
+		value    value.Value
+		expected string
+	}{
+		{value.NewRealValue(1000000), "1000000"},
+		{value.NewIntegerValue(123456789), "123456789"},
+		{value.NewRealValue(-2.5e7), "-25000000"},
+		{value.NewRealValue(1.5e-7), "1.5e-07"},
+		{value.NewBigIntegerValue(new(big.Int).Exp(big.NewInt(10), big.NewInt(309), nil)), "1" + strings.Repeat("0", 309)},
+	}
+
+	for _, tt := range tests {
+		node, err := NewExporter().Export(expr.NewConstant(tt.value))
+		if err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		if literal := node.(*NumberNode).Token.Literal; literal != tt.expected {
+			t.Errorf("Expected literal %s, got %s", tt.expected, literal)
+		}
+		if result, err := ExpressionToLatex(expr.NewConstant(tt.value)); err != nil || result != tt.expected {
+			t.Errorf("Expected %s, got %s (%v)", tt.expected, result, err)
+		}
+	}
+}
+
 func TestExportAddExpression(t *testing.T) {
 	// Create: 2 + 3
 	left := expr.NewConstant(value.NewRealValue(2))
@@ -122,6 +152,11 @@ func TestExportAllOperators(t *testing.T) {
 			expr:     expr.NewDiv(expr.NewConstant(value.NewRealValue(10)), expr.NewConstant(value.NewRealValue(2))),
 			expected: DIVIDE,
 		},
+		{
+			name:     "Power",
+			expr:     expr.NewPower(expr.NewConstant(value.NewRealValue(2)), expr.NewConstant(value.NewRealValue(3))),
+			expected: CARET,
+		},
 	}
 
 	for _, tt := range tests {
@@ -144,3 +179,52 @@ func TestExportAllOperators(t *testing.T) {
 		})
 	}
 }
+
+func TestExportRoot(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     expr.Expr
+		optional bool
+	}{
+		{"Square root", expr.NewSqrt(expr.NewVariable("x")), false},
+		{"Cube root", expr.NewNthRoot(expr.NewVariable("x"), expr.NewConstant(value.NewRealValue(3))), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := NewExporter().Export(tt.expr)
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+			command, ok := node.(*CommandNode)
+			if !ok || command.Name != "sqrt" {
+				t.Fatalf("Expected \\sqrt CommandNode, got %T", node)
+			}
+			if (command.Optional != nil) != tt.optional {
+				t.Errorf("Expected optional degree %v, got %v", tt.optional, command.Optional)
+			}
+		})
+	}
+}
+
+func TestExportProposition(t *testing.T) {
+	a, b, c := expr.NewVariable("a"), expr.NewVariable("b"), expr.NewVariable("c")
+
+	node, err := NewExporter().Export(prop.NewAnd(prop.NewEqual(a, b), prop.NewEqual(b, c)))
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	chain, ok := node.(*EqualNode)
+	if !ok {
+		t.Fatalf("Expected EqualNode, got %T", node)
+	}
+	if _, ok := chain.Left.(*EqualNode); !ok {
+		t.Errorf("Expected the chain to fold into the left operand, got %T", chain.Left)
+	}
+
+	// A conjunction of unrelated relations has no LaTeX chain
+	_, err = NewExporter().Export(prop.NewAnd(prop.NewEqual(a, b), prop.NewEqual(c, a)))
+	if err == nil {
+		t.Errorf("Expected error for a conjunction that is not a chain")
+	}
+}
@@ -0,0 +1,255 @@
+package latex
+
+import (
+	"exprtree/expr"
+	"strings"
+	"unicode/utf8"
+)
+
+// IdentifierPolicy decides whether adjacent letters such as xy form a
+// single name or a product of single-letter variables
+// Letters separated by whitespace are always separate variables.
+type IdentifierPolicy int
+
+const (
+	SplitLetters IdentifierPolicy = iota // xy is x * y
+	JoinLetters                          // xy is the single name xy
+	KnownNames                           // xy is a single name when listed in Options.Names
+)
+
+// greekLetters maps the LaTeX commands for Greek letters to the letters
+// used in variable names
+var greekLetters = map[string]string{
+	"alpha":      "α",
+	"beta":       "β",
+	"gamma":      "γ",
+	"delta":      "δ",
+	"epsilon":    "ϵ",
+	"varepsilon": "ε",
+	"zeta":       "ζ",
+	"eta":        "η",
+	"theta":      "θ",
+	"vartheta":   "ϑ",
+	"iota":       "ι",
+	"kappa":      "κ",
+	"lambda":     "λ",
+	"mu":         "μ",
+	"nu":         "ν",
+	"xi":         "ξ",
+	"pi":         "π",
+	"varpi":      "ϖ",
+	"rho":        "ρ",
+	"varrho":     "ϱ",
+	"sigma":      "σ",
+	"varsigma":   "ς",
+	"tau":        "τ",
+	"upsilon":    "υ",
+	"phi":        "ϕ",
+	"varphi":     "φ",
+	"chi":        "χ",
+	"psi":        "ψ",
+	"omega":      "ω",
+	"Gamma":      "Γ",
+	"Delta":      "Δ",
+	"Theta":      "Θ",
+	"Lambda":     "Λ",
+	"Xi":         "Ξ",
+	"Pi":         "Π",
+	"Sigma":      "Σ",
+	"Upsilon":    "Υ",
+	"Phi":        "Φ",
+	"Psi":        "Ψ",
+	"Omega":      "Ω",
+}
+
+// greekCommands maps Greek letters back to their LaTeX commands
+var greekCommands = func() map[rune]string {
+	result := make(map[rune]string, len(greekLetters))
+	for command, letter := range greekLetters {
+		r, _ := utf8.DecodeRuneInString(letter)
+		result[r] = command
+	}
+	return result
+}()
+
+// isIdentifierStart reports whether a token starts a variable name
+func isIdentifierStart(token Token) bool {
+	if token.Type == VARIABLE {
+		return true
+	}
+	if token.Type != COMMAND {
+		return false
+	}
+	_, ok := greekLetters[token.Literal]
+	return ok || token.Literal == "mathrm" || token.Literal == "text"
+}
+
+// parseIdentifier parses a variable name starting at the current token
+// The name is a letter, a run of adjacent letters joined by the identifier
+// policy, a Greek letter or \mathrm{name}, optionally followed by a
+// subscript such as x_1 or x_{ij}. An unbraced subscript is a letter, a
+// Greek letter or an integer, so x_12 is x_{12}.
+func (p *Parser) parseIdentifier() *VariableNode {
+	token := p.currentToken
+	var base string
+	switch {
+	case token.Type == VARIABLE:
+		base = p.joinLetters()
+	case token.Literal == "mathrm" || token.Literal == "text":
+		name, ok := p.parseName()
+		if !ok || name == "" {
+			p.errorAt(ErrExpectedToken, token, "expected a name after \\%s", token.Literal)
+			return nil
+		}
+		base = name
+	default:
+		base = greekLetters[token.Literal]
+	}
+
+	node := &VariableNode{Name: base, Token: token}
+	if p.peekToken.Type != UNDERSCORE {
+		return node
+	}
+	p.nextToken() // move to '_'
+
+	var subscript string
+	if p.peekToken.Type == LBRACE {
+		name, ok := p.parseName()
+		if !ok || name == "" {
+			p.errorAt(ErrExpectedToken, p.currentToken, "expected a subscript name")
+			return nil
+		}
+		subscript = name
+	} else {
+		p.nextToken() // move to the subscript
+		name, ok := p.nameCharacter()
+		if !ok {
+			p.errorAt(ErrExpectedToken, p.currentToken, "expected a subscript")
+			return nil
+		}
+		subscript = name
+	}
+	node.Name = expr.JoinName(base, subscript)
+	return node
+}
+
+// joinLetters returns the letters that form a name starting at the current
+// VARIABLE token, consuming all but the first
+// Inside an integral a trailing d followed by a letter is left for the
+// differential.
+func (p *Parser) joinLetters() string {
+	if p.options.Identifiers == SplitLetters {
+		return p.currentToken.Literal
+	}
+
+	run := []string{p.currentToken.Literal}
+	last := p.currentToken
+	for next := p.peekToken; next.Type == VARIABLE && next.Pos == last.Pos+len(last.Literal); next = p.peekAhead(len(run) - 1) {
+		run = append(run, next.Literal)
+		last = next
+	}
+	if p.integralDepth > 0 && len(run) > 2 && run[len(run)-2] == "d" {
+		run = run[:len(run)-2]
+	}
+
+	n := len(run)
+	if p.options.Identifiers == KnownNames {
+		n = 1
+		for i := len(run); i > 1; i-- {
+			if p.isKnownName(strings.Join(run[:i], "")) {
+				n = i
+				break
+			}
+		}
+	}
+	for i := 1; i < n; i++ {
+		p.nextToken()
+	}
+	return strings.Join(run[:n], "")
+}
+
+// isKnownName reports whether name is listed in Options.Names
+func (p *Parser) isKnownName(name string) bool {
+	for _, known := range p.options.Names {
+		if known == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseName parses a braced name such as {max}, {ij} or {\alpha 1}
+// made of letters, digits and Greek letters
+func (p *Parser) parseName() (string, bool) {
+	if !p.expectPeek(LBRACE) {
+		return "", false
+	}
+	var name strings.Builder
+	for p.peekToken.Type != RBRACE {
+		p.nextToken()
+		character, ok := p.nameCharacter()
+		if !ok {
+			return "", false
+		}
+		name.WriteString(character)
+	}
+	p.nextToken() // move to '}'
+	return name.String(), true
+}
+
+// nameCharacter returns the current token as part of a name: a letter,
+// digits or a Greek letter
+func (p *Parser) nameCharacter() (string, bool) {
+	token := p.currentToken
+	switch token.Type {
+	case VARIABLE:
+		return token.Literal, true
+	case NUMBER:
+		if strings.Trim(token.Literal, "0123456789") != "" {
+			return "", false
+		}
+		return token.Literal, true
+	case COMMAND:
+		letter, ok := greekLetters[token.Literal]
+		return letter, ok
+	default:
+		return "", false
+	}
+}
+
+// renderName converts a variable name to LaTeX, such as x_1, \alpha_{ij} or
+// \mathrm{max}
+func renderName(name string) string {
+	base, subscript := expr.SplitName(name)
+	result := renderNamePart(base)
+	if utf8.RuneCountInString(base) > 1 {
+		result = "\\mathrm{" + result + "}"
+	}
+	if subscript == "" {
+		return result
+	}
+	part := renderNamePart(subscript)
+	if len(part) == 1 {
+		return result + "_" + part
+	}
+	return result + "_{" + part + "}"
+}
+
+// renderNamePart writes Greek letters as commands, separated by a space
+// from a following letter
+func renderNamePart(part string) string {
+	var result strings.Builder
+	runes := []rune(part)
+	for i, r := range runes {
+		command, ok := greekCommands[r]
+		if !ok {
+			result.WriteRune(r)
+			continue
+		}
+		result.WriteString("\\" + command)
+		if i+1 < len(runes) && runes[i+1] < utf8.RuneSelf && isLetter(byte(runes[i+1])) {
+			result.WriteString(" ")
+		}
+	}
+	return result.String()
+}
@@ -1,30 +1,42 @@
 package latex
 
 import (
-	"strconv"
+	"math/big"
+	"strings"
+	"unicode/utf8"
 )
 
 // TokenType represents the type of a token
 type TokenType int
 
 const (
-	NUMBER   TokenType = iota // 数値リテラル
-	PLUS                      // +
-	MINUS                     // -
-	MULTIPLY                  // *
-	DIVIDE                    // /
-	LPAREN                    // (
-	RPAREN                    // )
-	VARIABLE                  // 変数（a-z, A-Z）
-	CARET                     // ^
-	LBRACE                    // {
-	RBRACE                    // }
-	LBRACKET                  // [
-	RBRACKET                  // ]
-	COMMAND                   // \sqrt, etc.
-	EQUAL                     // =
-	EOF                       // 入力終端
-	ILLEGAL                   // 不正なトークン
+	NUMBER     TokenType = iota // 数値リテラル
+	PLUS                        // +
+	MINUS                       // -
+	MULTIPLY                    // *
+	DIVIDE                      // /
+	LPAREN                      // (
+	RPAREN                      // )
+	VARIABLE                    // 変数（a-z, A-Z）
+	CARET                       // ^
+	LBRACE                      // {
+	RBRACE                      // }
+	LBRACKET                    // [
+	RBRACKET                    // ]
+	COMMAND                     // \sqrt, etc.
+	EQUAL                       // =
+	UNDERSCORE                  // _
+	PIPE                        // |
+	BANG                        // !
+	LESS                        // <, \lt
+	LESSEQ                      // \le, \leq
+	GREATER                     // >, \gt
+	GREATEREQ                   // \ge, \geq
+	AMPERSAND                   // & (列の区切り)
+	ROWSEP                      // \\ (行の区切り)
+	COMMA                       // ,
+	EOF                         // 入力終端
+	ILLEGAL                     // 不正なトークン
 )
 
 // Token represents a lexical token
@@ -32,7 +44,64 @@ type Token struct {
 	Type    TokenType // トークンの種類
 	Literal string    // 元のテキスト
 	Value   float64   // 数値の場合の値
+	Exact   *big.Int  // float64で正確に表せない整数の値（それ以外はnil）
 	Pos     int       // 入力文字列内の位置
+	End     int       // トークン終端の位置（Posからの範囲は半開区間）
+}
+
+// commands lists the LaTeX commands recognized as COMMAND tokens
+var commands = map[string]bool{
+	"sqrt":   true,
+	"sin":    true,
+	"cos":    true,
+	"tan":    true,
+	"arcsin": true,
+	"arccos": true,
+	"arctan": true,
+	"sinh":   true,
+	"cosh":   true,
+	"tanh":   true,
+	"exp":    true,
+	"ln":     true,
+	"log":    true,
+	"left":   true,
+	"right":  true,
+	"lfloor": true,
+	"rfloor": true,
+	"lceil":  true,
+	"rceil":  true,
+	"binom":  true,
+	"frac":   true,
+	"dfrac":  true,
+	"tfrac":  true,
+	"sum":    true,
+	"prod":   true,
+	"int":    true,
+	"lim":    true,
+	"to":     true,
+	"infty":  true,
+	"begin":  true,
+	"end":    true,
+	"text":   true,
+	"mathrm": true,
+}
+
+// relationCommands maps relation commands like \le to their token types
+var relationCommands = map[string]TokenType{
+	"lt":  LESS,
+	"le":  LESSEQ,
+	"leq": LESSEQ,
+	"gt":  GREATER,
+	"ge":  GREATEREQ,
+	"geq": GREATEREQ,
+}
+
+// operatorCommands maps commands that stand for arithmetic operators to
+// their token types
+var operatorCommands = map[string]TokenType{
+	"times": MULTIPLY,
+	"cdot":  MULTIPLY,
+	"div":   DIVIDE,
 }
 
 // Lexer performs lexical analysis on LaTeX input
@@ -41,11 +110,18 @@ type Lexer struct {
 	position     int    // 現在の位置
 	readPosition int    // 次に読む位置
 	ch           byte   // 現在の文字
+	options      Options
 }
 
 // NewLexer creates a new Lexer instance
 func NewLexer(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewLexerWithOptions(input, Options{})
+}
+
+// NewLexerWithOptions creates a new Lexer instance with options
+// Only RadixLiterals affects the lexer.
+func NewLexerWithOptions(input string, options Options) *Lexer {
+	l := &Lexer{input: input, options: options}
 	l.readChar()
 	return l
 }
@@ -69,13 +145,27 @@ func (l *Lexer) peekChar() byte {
 	return l.input[l.readPosition]
 }
 
-// skipWhitespace skips over whitespace characters
+// skipWhitespace skips over whitespace characters and spacing commands
+// like \, and \;
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
-		l.readChar()
+	for {
+		switch {
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r':
+			l.readChar()
+		case l.ch == '\\' && isSpacing(l.peekChar()):
+			l.readChar()
+			l.readChar()
+		default:
+			return
+		}
 	}
 }
 
+// isSpacing reports whether \ followed by ch is a spacing command
+func isSpacing(ch byte) bool {
+	return ch == ',' || ch == ';' || ch == ':' || ch == '!' || ch == ' '
+}
+
 // isDigit checks if a character is a digit
 func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
@@ -99,14 +189,12 @@ func (l *Lexer) readCommand() string {
 	return l.input[startPos+1 : l.position]
 }
 
-// readNumber reads a number (integer or decimal)
+// readNumber reads a number such as 42, 3.14, .5, 1{,}000 or 1.5e-3
 func (l *Lexer) readNumber() string {
 	startPos := l.position
 
-	// Read digits before decimal point
-	for isDigit(l.ch) {
-		l.readChar()
-	}
+	// Read digits before decimal point, which may be grouped by {,}
+	l.readDigits()
 
 	// Check for decimal point
 	if l.ch == '.' && isDigit(l.peekChar()) {
@@ -116,13 +204,149 @@ func (l *Lexer) readNumber() string {
 		for isDigit(l.ch) {
 			l.readChar()
 		}
+
+		// A second decimal point as in 1.2.3 makes the whole literal
+		// invalid, rather than leaving .3 as another number
+		for l.ch == '.' && isDigit(l.peekChar()) {
+			l.readChar()
+			for isDigit(l.ch) {
+				l.readChar()
+			}
+		}
+	}
+
+	// Check for an exponent; a lone e is a variable as in 2e
+	if l.ch == 'e' || l.ch == 'E' {
+		next := l.peekAt(1)
+		if next == '+' || next == '-' {
+			next = l.peekAt(2)
+		}
+		if isDigit(next) {
+			l.readChar() // consume 'e'
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			for isDigit(l.ch) {
+				l.readChar()
+			}
+		}
 	}
 
 	return l.input[startPos:l.position]
 }
 
+// readDigits reads digits separated by the group separator {,}
+func (l *Lexer) readDigits() {
+	for {
+		for isDigit(l.ch) {
+			l.readChar()
+		}
+		if !strings.HasPrefix(l.input[l.position:], "{,}") || !isDigit(l.peekAt(3)) {
+			return
+		}
+		l.readChar() // consume '{'
+		l.readChar() // consume ','
+		l.readChar() // consume '}'
+
+	}
+}
+
+// peekAt returns the character n positions after the current one
+func (l *Lexer) peekAt(n int) byte {
+	if l.position+n >= len(l.input) {
+		return 0
+	}
+	return l.input[l.position+n]
+}
+
+// radixPrefixes maps the prefixes of hexadecimal and binary literals to
+// their bases
+var radixPrefixes = map[byte]int{'x': 16, 'X': 16, 'b': 2, 'B': 2}
+
+// atRadixLiteral reports whether a literal such as 0x1F or 0b101 starts
+// at the current character
+func (l *Lexer) atRadixLiteral() bool {
+	base, ok := radixPrefixes[l.peekChar()]
+	return ok && l.options.RadixLiterals && l.ch == '0' && isRadixDigit(l.peekAt(2), base)
+}
+
+// isRadixDigit checks if a character is a digit in the given base
+func isRadixDigit(ch byte, base int) bool {
+	if base == 2 {
+		return ch == '0' || ch == '1'
+	}
+	return isDigit(ch) || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')
+}
+
+// readRadixLiteral reads a literal such as 0x1F or 0b101
+func (l *Lexer) readRadixLiteral() Token {
+	tok := Token{Type: NUMBER, Pos: l.position}
+	base := radixPrefixes[l.peekChar()]
+	l.readChar() // consume '0'
+	l.readChar() // consume the prefix
+	start := l.position
+	for isRadixDigit(l.ch, base) {
+		l.readChar()
+	}
+
+	n, _ := new(big.Int).SetString(l.input[start:l.position], base)
+	tok.Literal = l.input[tok.Pos:l.position]
+	tok.Value, tok.Exact = integerValue(n)
+	return tok
+}
+
+// numberValue returns the value of a decimal literal such as 1{,}000 or
+// 6.02e23
+// Integers that float64 cannot hold are also returned exactly.
+func numberValue(literal string) (float64, *big.Int, bool) {
+	if !hasThreeDigitGroups(literal) {
+		return 0, nil, false
+	}
+	rat, ok := new(big.Rat).SetString(strings.ReplaceAll(literal, "{,}", ""))
+	if !ok {
+		return 0, nil, false
+	}
+	if rat.IsInt() {
+		v, exact := integerValue(rat.Num())
+		return v, exact, true
+	}
+	v, _ := rat.Float64()
+	return v, nil, true
+}
+
+// hasThreeDigitGroups reports whether every group after a {,} separator
+// in a number literal has exactly three digits, so 1{,}00 is rejected
+// rather than read as 100
+func hasThreeDigitGroups(literal string) bool {
+	groups := strings.Split(literal, "{,}")
+	for _, group := range groups[1:] {
+		digits := len(group) - len(strings.TrimLeft(group, "0123456789"))
+		if digits != 3 {
+			return false
+		}
+	}
+	return true
+}
+
+// integerValue returns the float64 value of n, and n itself if the float64
+// value is not exact
+func integerValue(n *big.Int) (float64, *big.Int) {
+	v, accuracy := new(big.Float).SetInt(n).Float64()
+	if accuracy != big.Exact {
+		return v, n
+	}
+	return v, nil
+}
+
 // NextToken returns the next token from the input
 func (l *Lexer) NextToken() Token {
+	tok := l.readToken()
+	tok.End = max(l.position, tok.Pos)
+	return tok
+}
+
+// readToken reads the next token without its end position
+func (l *Lexer) readToken() Token {
 	var tok Token
 
 	l.skipWhitespace()
@@ -166,26 +390,63 @@ func (l *Lexer) NextToken() Token {
 	case '=':
 		tok = Token{Type: EQUAL, Literal: "=", Pos: l.position}
 		l.readChar()
+	case '_':
+		tok = Token{Type: UNDERSCORE, Literal: "_", Pos: l.position}
+		l.readChar()
+	case '|':
+		tok = Token{Type: PIPE, Literal: "|", Pos: l.position}
+		l.readChar()
+	case '!':
+		tok = Token{Type: BANG, Literal: "!", Pos: l.position}
+		l.readChar()
+	case '<':
+		tok = Token{Type: LESS, Literal: "<", Pos: l.position}
+		l.readChar()
+	case '>':
+		tok = Token{Type: GREATER, Literal: ">", Pos: l.position}
+		l.readChar()
+	case '&':
+		tok = Token{Type: AMPERSAND, Literal: "&", Pos: l.position}
+		l.readChar()
+	case ',':
+		tok = Token{Type: COMMA, Literal: ",", Pos: l.position}
+		l.readChar()
 	case '\\':
+		if l.peekChar() == '\\' {
+			tok = Token{Type: ROWSEP, Literal: "\\\\", Pos: l.position}
+			l.readChar()
+			l.readChar()
+			return tok
+		}
 		cmdName := l.readCommand()
-		if cmdName == "sqrt" {
+		if tokenType, ok := relationCommands[cmdName]; ok {
+			return Token{Type: tokenType, Literal: "\\" + cmdName, Pos: l.position - len(cmdName) - 1}
+		}
+		if tokenType, ok := operatorCommands[cmdName]; ok {
+			return Token{Type: tokenType, Literal: "\\" + cmdName, Pos: l.position - len(cmdName) - 1}
+		}
+		if _, ok := greekLetters[cmdName]; ok || commands[cmdName] {
 			tok = Token{Type: COMMAND, Literal: cmdName, Pos: l.position - len(cmdName) - 1}
 			return tok
 		}
-		tok = Token{Type: ILLEGAL, Literal: "\\" + cmdName, Pos: l.position}
+		tok = Token{Type: ILLEGAL, Literal: "\\" + cmdName, Pos: l.position - len(cmdName) - 1}
 		return tok
 	case 0:
 		tok = Token{Type: EOF, Literal: "", Pos: l.position}
 	default:
-		if isDigit(l.ch) {
+		if l.atRadixLiteral() {
+			return l.readRadixLiteral()
+		}
+		if isDigit(l.ch) || (l.ch == '.' && isDigit(l.peekChar())) {
 			tok.Literal = l.readNumber()
 			tok.Type = NUMBER
 			// Parse the number value
-			val, err := strconv.ParseFloat(tok.Literal, 64)
-			if err != nil {
+			val, exact, ok := numberValue(tok.Literal)
+			if !ok {
 				tok.Type = ILLEGAL
 			} else {
 				tok.Value = val
+				tok.Exact = exact
 			}
 			return tok
 		} else if isLetter(l.ch) {
@@ -197,8 +458,12 @@ func (l *Lexer) NextToken() Token {
 			// by the parser, so we just return single-character variables
 			return tok
 		} else {
-			tok = Token{Type: ILLEGAL, Literal: string(l.ch), Pos: l.position}
-			l.readChar()
+			// A character outside ASCII is a single illegal token
+			r, size := utf8.DecodeRuneInString(l.input[l.position:])
+			tok = Token{Type: ILLEGAL, Literal: string(r), Pos: l.position}
+			for i := 0; i < size; i++ {
+				l.readChar()
+			}
 		}
 	}
 
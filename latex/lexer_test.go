@@ -1,6 +1,9 @@
 package latex
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestLexer_Numbers(t *testing.T) {
 	tests := []struct {
@@ -189,6 +192,33 @@ func TestLexer_DecimalNumbers(t *testing.T) {
 	}
 }
 
+func TestLexer_SecondDecimalPoint(t *testing.T) {
+	// 1.2.3 is one invalid literal, not 1.2 followed by .3
+	lexer := NewLexer("1.2.3 + 4")
+
+	tok := lexer.NextToken()
+	if tok.Type != ILLEGAL || tok.Literal != "1.2.3" || tok.Pos != 0 {
+		t.Errorf("expected ILLEGAL 1.2.3 at 0, got %v %q at %d", tok.Type, tok.Literal, tok.Pos)
+	}
+	if tok := lexer.NextToken(); tok.Type != PLUS {
+		t.Errorf("expected PLUS, got %v", tok.Type)
+	}
+}
+
+func TestLexer_DigitGroups(t *testing.T) {
+	// Every group after {,} needs exactly three digits
+	tests := []string{"1{,}00", "1{,}0000", "1{,}000{,}5", "12{,}34.5"}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			tok := NewLexer(input).NextToken()
+			if tok.Type != ILLEGAL || tok.Literal != input {
+				t.Errorf("expected ILLEGAL %s, got %v %q", input, tok.Type, tok.Literal)
+			}
+		})
+	}
+}
+
 func TestLexer_Position(t *testing.T) {
 	input := "2+3"
 	lexer := NewLexer(input)
@@ -505,3 +535,311 @@ func TestLexer_ComplexEqualExpression(t *testing.T) {
 		}
 	}
 }
+
+func TestLexer_Delimiters(t *testing.T) {
+	input := "|x| \\left| \\right| \\lfloor \\rfloor \\lceil \\rceil"
+	lexer := NewLexer(input)
+
+	expectedTokens := []struct {
+		tokenType TokenType
+		literal   string
+	}{
+		{PIPE, "|"},
+		{VARIABLE, "x"},
+		{PIPE, "|"},
+		{COMMAND, "left"},
+		{PIPE, "|"},
+		{COMMAND, "right"},
+		{PIPE, "|"},
+		{COMMAND, "lfloor"},
+		{COMMAND, "rfloor"},
+		{COMMAND, "lceil"},
+		{COMMAND, "rceil"},
+		{EOF, ""},
+	}
+
+	for i, expected := range expectedTokens {
+		tok := lexer.NextToken()
+		if tok.Type != expected.tokenType {
+			t.Errorf("token[%d] - expected type %v, got %v", i, expected.tokenType, tok.Type)
+		}
+		if tok.Literal != expected.literal {
+			t.Errorf("token[%d] - expected literal %s, got %s", i, expected.literal, tok.Literal)
+		}
+	}
+}
+
+func TestLexer_FunctionCommands(t *testing.T) {
+	input := "\\sin \\arccos \\tanh \\exp \\ln \\log_"
+	lexer := NewLexer(input)
+
+	expectedTokens := []struct {
+		tokenType TokenType
+		literal   string
+	}{
+		{COMMAND, "sin"},
+		{COMMAND, "arccos"},
+		{COMMAND, "tanh"},
+		{COMMAND, "exp"},
+		{COMMAND, "ln"},
+		{COMMAND, "log"},
+		{UNDERSCORE, "_"},
+		{EOF, ""},
+	}
+
+	for i, expected := range expectedTokens {
+		tok := lexer.NextToken()
+		if tok.Type != expected.tokenType {
+			t.Errorf("token[%d] - expected type %v, got %v", i, expected.tokenType, tok.Type)
+		}
+		if tok.Literal != expected.literal {
+			t.Errorf("token[%d] - expected literal %s, got %s", i, expected.literal, tok.Literal)
+		}
+	}
+}
+
+func TestLexer_Combinatorics(t *testing.T) {
+	input := "n! \\binom{n}{k}"
+	lexer := NewLexer(input)
+
+	expectedTokens := []struct {
+		tokenType TokenType
+		literal   string
+	}{
+		{VARIABLE, "n"},
+		{BANG, "!"},
+		{COMMAND, "binom"},
+		{LBRACE, "{"},
+		{VARIABLE, "n"},
+		{RBRACE, "}"},
+		{LBRACE, "{"},
+		{VARIABLE, "k"},
+		{RBRACE, "}"},
+		{EOF, ""},
+	}
+
+	for i, expected := range expectedTokens {
+		tok := lexer.NextToken()
+		if tok.Type != expected.tokenType {
+			t.Errorf("token[%d] - expected type %v, got %v", i, expected.tokenType, tok.Type)
+		}
+		if tok.Literal != expected.literal {
+			t.Errorf("token[%d] - expected literal %s, got %s", i, expected.literal, tok.Literal)
+		}
+	}
+}
+
+func TestLexer_SpacingCommands(t *testing.T) {
+	input := "x\\,dx \\; y\\!z"
+	lexer := NewLexer(input)
+
+	expected := []string{"x", "d", "x", "y", "z", ""}
+	for i, literal := range expected {
+		tok := lexer.NextToken()
+		if tok.Literal != literal {
+			t.Errorf("token[%d] - expected literal %q, got %q", i, literal, tok.Literal)
+		}
+	}
+}
+
+func TestLexer_Limit(t *testing.T) {
+	input := "\\lim_{x \\to \\infty}"
+	lexer := NewLexer(input)
+
+	expectedTokens := []struct {
+		tokenType TokenType
+		literal   string
+	}{
+		{COMMAND, "lim"},
+		{UNDERSCORE, "_"},
+		{LBRACE, "{"},
+		{VARIABLE, "x"},
+		{COMMAND, "to"},
+		{COMMAND, "infty"},
+		{RBRACE, "}"},
+		{EOF, ""},
+	}
+
+	for i, expected := range expectedTokens {
+		tok := lexer.NextToken()
+		if tok.Type != expected.tokenType {
+			t.Errorf("token[%d] - expected type %v, got %v", i, expected.tokenType, tok.Type)
+		}
+		if tok.Literal != expected.literal {
+			t.Errorf("token[%d] - expected literal %s, got %s", i, expected.literal, tok.Literal)
+		}
+	}
+}
+
+func TestLexer_Relations(t *testing.T) {
+	input := "a < b \\le c > d \\geq e & f, \\\\"
+	lexer := NewLexer(input)
+
+	expectedTokens := []struct {
+		tokenType TokenType
+		literal   string
+	}{
+		{VARIABLE, "a"},
+		{LESS, "<"},
+		{VARIABLE, "b"},
+		{LESSEQ, "\\le"},
+		{VARIABLE, "c"},
+		{GREATER, ">"},
+		{VARIABLE, "d"},
+		{GREATEREQ, "\\geq"},
+		{VARIABLE, "e"},
+		{AMPERSAND, "&"},
+		{VARIABLE, "f"},
+		{COMMA, ","},
+		{ROWSEP, "\\\\"},
+		{EOF, ""},
+	}
+
+	for i, expected := range expectedTokens {
+		tok := lexer.NextToken()
+		if tok.Type != expected.tokenType {
+			t.Errorf("token[%d] - expected type %v, got %v", i, expected.tokenType, tok.Type)
+		}
+		if tok.Literal != expected.literal {
+			t.Errorf("token[%d] - expected literal %s, got %s", i, expected.literal, tok.Literal)
+		}
+	}
+}
+
+func TestLexer_GreekLetters(t *testing.T) {
+	tokens := []Token{}
+	lexer := NewLexer("\\alpha + \\Omega \\mathrm{v}")
+	for tok := lexer.NextToken(); tok.Type != EOF; tok = lexer.NextToken() {
+		tokens = append(tokens, tok)
+	}
+
+	expected := []struct {
+		typ     TokenType
+		literal string
+	}{
+		{COMMAND, "alpha"},
+		{PLUS, "+"},
+		{COMMAND, "Omega"},
+		{COMMAND, "mathrm"},
+		{LBRACE, "{"},
+		{VARIABLE, "v"},
+		{RBRACE, "}"},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, tt := range expected {
+		if tokens[i].Type != tt.typ || tokens[i].Literal != tt.literal {
+			t.Errorf("token %d: expected %v %q, got %v %q", i, tt.typ, tt.literal, tokens[i].Type, tokens[i].Literal)
+		}
+	}
+}
+
+func TestLexer_NumberFormats(t *testing.T) {
+	tests := []struct {
+		input    string
+		literal  string
+		expected float64
+	}{
+		{"1.5e-3", "1.5e-3", 0.0015},
+		{"2E+2", "2E+2", 200},
+		{"6.02e23", "6.02e23", 6.02e23},
+		{".5", ".5", 0.5},
+		{"1{,}000", "1{,}000", 1000},
+		{"12{,}345.5", "12{,}345.5", 12345.5},
+		{"1{,}000{,}000", "1{,}000{,}000", 1000000},
+		{"2e", "2", 2},
+		{"3e-x", "3", 3},
+		{"1{,}x", "1", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tok := NewLexer(tt.input).NextToken()
+			if tok.Type != NUMBER {
+				t.Fatalf("expected NUMBER token, got %v", tok.Type)
+			}
+			if tok.Literal != tt.literal {
+				t.Errorf("expected literal %s, got %s", tt.literal, tok.Literal)
+			}
+			if tok.Value != tt.expected {
+				t.Errorf("expected value %g, got %g", tt.expected, tok.Value)
+			}
+		})
+	}
+}
+
+func TestLexer_ExactIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		options  Options
+		expected string // "" when the float64 value is exact
+	}{
+		{"42", Options{}, ""},
+		{"12345678901234567891", Options{}, "12345678901234567891"},
+		{"6.02e23", Options{}, "602000000000000000000000"},
+		{"1e300", Options{}, "1" + strings.Repeat("0", 300)},
+		{"0x1F", Options{RadixLiterals: true}, ""},
+		{"0xFFFFFFFFFFFFFFFFFF", Options{RadixLiterals: true}, "4722366482869645213695"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tok := NewLexerWithOptions(tt.input, tt.options).NextToken()
+			if tok.Type != NUMBER {
+				t.Fatalf("expected NUMBER token, got %v", tok.Type)
+			}
+			got := ""
+			if tok.Exact != nil {
+				got = tok.Exact.String()
+			}
+			if got != tt.expected {
+				t.Errorf("expected exact value %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLexer_RadixLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"0x1F", 31},
+		{"0Xff", 255},
+		{"0b101", 5},
+		{"0B0", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lexer := NewLexerWithOptions(tt.input, Options{RadixLiterals: true})
+			tok := lexer.NextToken()
+			if tok.Type != NUMBER || tok.Value != tt.expected || tok.Literal != tt.input {
+				t.Errorf("expected NUMBER %g, got %v %q %g", tt.expected, tok.Type, tok.Literal, tok.Value)
+			}
+			if next := lexer.NextToken(); next.Type != EOF {
+				t.Errorf("expected EOF, got %v", next.Type)
+			}
+		})
+	}
+
+	// Without the option 0x1F is 0 followed by variables
+	lexer := NewLexer("0x1F")
+	if tok := lexer.NextToken(); tok.Type != NUMBER || tok.Literal != "0" {
+		t.Errorf("expected NUMBER 0, got %v %q", tok.Type, tok.Literal)
+	}
+	if tok := lexer.NextToken(); tok.Type != VARIABLE || tok.Literal != "x" {
+		t.Errorf("expected VARIABLE x, got %v %q", tok.Type, tok.Literal)
+	}
+}
+
+func TestLexer_OperatorCommands(t *testing.T) {
+	lexer := NewLexer("2 \\times 3 \\cdot 4 \\div 5")
+	expected := []TokenType{NUMBER, MULTIPLY, NUMBER, MULTIPLY, NUMBER, DIVIDE, NUMBER, EOF}
+	for i, typ := range expected {
+		if tok := lexer.NextToken(); tok.Type != typ {
+			t.Errorf("token %d: expected %v, got %v", i, typ, tok.Type)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package latex
+
+import (
+	"exprtree/expr"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// mathMLNamespace is the XML namespace of the math element
+const mathMLNamespace = "http://www.w3.org/1998/Math/MathML"
+
+// Invisible operators of MathML
+const (
+	invisibleTimes      = "\u2062"
+	functionApplication = "\u2061"
+)
+
+// mathMLOperators spells operator tokens in MathML
+var mathMLOperators = map[TokenType]string{
+	PLUS:      "+",
+	MINUS:     "−",
+	MULTIPLY:  "⋅",
+	DIVIDE:    "/",
+	EQUAL:     "=",
+	LESS:      "<",
+	LESSEQ:    "≤",
+	GREATER:   ">",
+	GREATEREQ: "≥",
+}
+
+// mathMLEscaper escapes the text content of MathML elements
+var mathMLEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// MathMLRenderer converts LaTeX AST to Presentation MathML
+// Parentheses are placed with the same precedence rules as Renderer.
+type MathMLRenderer struct{}
+
+// NewMathMLRenderer creates a new MathMLRenderer instance
+func NewMathMLRenderer() *MathMLRenderer {
+	return &MathMLRenderer{}
+}
+
+// Render converts a LatexNode to a math element
+func (r *MathMLRenderer) Render(node LatexNode) string {
+	return `<math xmlns="` + mathMLNamespace + `">` + r.renderNode(node, LOWEST, false) + "</math>"
+}
+
+// renderNode converts a LatexNode to MathML with precedence handling
+// parentPrec and isRightOperand have the same meaning as in Renderer.
+func (r *MathMLRenderer) renderNode(node LatexNode, parentPrec int, isRightOperand bool) string {
+	switch n := node.(type) {
+	case *NumberNode:
+		result := r.renderNumber(n)
+		if n.Value < 0 && signNeedsParens(parentPrec, isRightOperand) {
+			return fenced("(", result, ")")
+		}
+		return result
+	case *UnaryMinusNode:
+		result := mrow(mo("−") + r.renderNode(n.Operand, POWER, false))
+		if signNeedsParens(parentPrec, isRightOperand) {
+			return fenced("(", result, ")")
+		}
+		return result
+	case *VariableNode:
+		return r.renderName(n.Name)
+	case *BinaryOpNode:
+		return r.renderBinaryOp(n, ILLEGAL, parentPrec, isRightOperand)
+	case *GroupNode:
+		return fenced("(", r.renderNode(n.Inner, LOWEST, false), ")")
+	case *FunctionNode:
+		return r.renderFunction(n)
+	case *FenceNode:
+		return r.renderFence(n)
+	case *FactorialNode:
+		return mrow(r.renderNode(n.Operand, POSTFIX, false) + mo("!"))
+	case *CommandNode:
+		return r.renderCommand(n, parentPrec)
+	case *BigOperatorNode:
+		return r.renderBigOperator(n, parentPrec)
+	case *IntegralNode:
+		return r.renderIntegral(n, parentPrec)
+	case *LimitNode:
+		return r.renderLimit(n, parentPrec)
+	case *EqualNode:
+		return mrow(r.renderNode(n.Left, EQUALITY, false) + r.renderOperator(n.Operator) + r.renderNode(n.Right, EQUALITY, true))
+	case *CasesNode:
+		return r.renderCases(n)
+	case *CallNode:
+		return r.renderCall(n.Name, n.Arguments)
+	case *DefinitionNode:
+		params := make([]LatexNode, len(n.Params))
+		for i, param := range n.Params {
+			params[i] = param
+		}
+		return mrow(r.renderCall(n.Name, params) + mo("=") + r.renderNode(n.Body, LOWEST, false))
+	default:
+		return ""
+	}
+}
+
+// renderNumber converts a NumberNode to an mn element
+// Negative numbers get a leading minus operator.
+func (r *MathMLRenderer) renderNumber(node *NumberNode) string {
+	var result string
+	switch {
+	case node.Exact != nil:
+		// Checked first, as an integer beyond the float64 range has an
+		// infinite Value
+		result = mn(new(big.Int).Abs(node.Exact).String())
+	case math.IsInf(node.Value, 0):
+		result = mi("∞")
+	default:
+		result = mn(formatNumber(math.Abs(node.Value)))
+	}
+	if node.Value < 0 {
+		return mrow(mo("−") + result)
+	}
+	return result
+}
+
+// renderName converts a variable name to an mi element, with an msub for
+// its subscript
+func (r *MathMLRenderer) renderName(name string) string {
+	base, subscript := expr.SplitName(name)
+	if subscript == "" {
+		return mi(base)
+	}
+	return "<msub>" + mi(base) + r.renderNamePart(subscript) + "</msub>"
+}
+
+// renderNamePart converts a subscript to an mn for digits or an mi otherwise
+func (r *MathMLRenderer) renderNamePart(part string) string {
+	if strings.Trim(part, "0123456789") == "" {
+		return mn(part)
+	}
+	return mi(part)
+}
+
+// renderOperator converts an operator token to an mo element
+func (r *MathMLRenderer) renderOperator(token Token) string {
+	if spelled, ok := mathMLOperators[token.Type]; ok {
+		return mo(spelled)
+	}
+	return mo(token.Literal)
+}
+
+// renderBinaryOp converts a BinaryOpNode to an mrow, mfrac or msup
+// parentType is the operator of the parent binary operation, or ILLEGAL.
+func (r *MathMLRenderer) renderBinaryOp(node *BinaryOpNode, parentType TokenType, parentPrec int, isRightOperand bool) string {
+	switch node.Operator.Type {
+	case DIVIDE:
+		return r.renderFrac(node.Left, node.Right, parentPrec)
+	case CARET:
+		exponent := node.Right
+		if group, ok := exponent.(*GroupNode); ok && group.Token.Type == LBRACE {
+			exponent = group.Inner
+		}
+		result := "<msup>" + r.renderNode(node.Left, POSTFIX, false) + r.renderNode(exponent, LOWEST, false) + "</msup>"
+		if parentPrec > POWER {
+			return fenced("(", result, ")")
+		}
+		return result
+	}
+
+	opPrec := precedences[node.Operator.Type]
+	left := r.renderNode(node.Left, opPrec, false)
+	right := r.renderRightOperand(node, opPrec)
+	operator := r.renderOperator(node.Operator)
+	if impliedProduct(node) {
+		operator = mo(invisibleTimes)
+	}
+
+	result := mrow(left + operator + right)
+	if binaryNeedsParens(node.Operator.Type, parentType, parentPrec, isRightOperand) {
+		return fenced("(", result, ")")
+	}
+	return result
+}
+
+// renderRightOperand converts the right operand of a binary operation
+// A binary operand is told the operator it is under, so that a − (b + c)
+// is fenced.
+func (r *MathMLRenderer) renderRightOperand(node *BinaryOpNode, opPrec int) string {
+	if binary, ok := node.Right.(*BinaryOpNode); ok {
+		return r.renderBinaryOp(binary, node.Operator.Type, opPrec, true)
+	}
+	return r.renderNode(node.Right, opPrec, true)
+}
+
+// impliedProduct reports whether a product is written without a visible
+// operator, as in 2x or 2(x + 1)
+func impliedProduct(node *BinaryOpNode) bool {
+	if node.Operator.Type != MULTIPLY {
+		return false
+	}
+	number, ok := node.Left.(*NumberNode)
+	if !ok || number.Value < 0 || math.IsInf(number.Value, 0) {
+		return false
+	}
+	switch right := node.Right.(type) {
+	case *VariableNode, *FunctionNode, *GroupNode:
+		return true
+	case *CommandNode:
+		return right.Name == "sqrt"
+	case *BinaryOpNode:
+		if right.Operator.Type == CARET {
+			_, ok := right.Left.(*VariableNode)
+			return ok
+		}
+		// 2 followed by a fraction would read as a mixed number
+		return right.Operator.Type != DIVIDE && binaryNeedsParens(right.Operator.Type, MULTIPLY, PRODUCT, true)
+	default:
+		return false
+	}
+}
+
+// renderFrac converts a division to an mfrac
+// The fraction bar delimits both operands, so only powers need parentheses.
+func (r *MathMLRenderer) renderFrac(numerator, denominator LatexNode, parentPrec int) string {
+	result := "<mfrac>" + r.renderNode(numerator, LOWEST, false) + r.renderNode(denominator, LOWEST, false) + "</mfrac>"
+	if parentPrec >= POWER {
+		return fenced("(", result, ")")
+	}
+	return result
+}
+
+// renderFunction converts a FunctionNode to an applied mi such as sin⁡(x),
+// with msub, msup or msubsup for the base and exponent
+func (r *MathMLRenderer) renderFunction(node *FunctionNode) string {
+	name := mi(node.Name)
+	switch {
+	case node.Base != nil && node.Exponent != nil:
+		name = "<msubsup>" + name + r.renderNode(node.Base, LOWEST, false) + r.renderNode(node.Exponent, LOWEST, false) + "</msubsup>"
+	case node.Base != nil:
+		name = "<msub>" + name + r.renderNode(node.Base, LOWEST, false) + "</msub>"
+	case node.Exponent != nil:
+		name = "<msup>" + name + r.renderNode(node.Exponent, LOWEST, false) + "</msup>"
+	}
+	return mrow(name + mo(functionApplication) + fenced("(", r.renderNode(node.Argument, LOWEST, false), ")"))
+}
+
+// renderFence converts a FenceNode to an mrow between its delimiters
+func (r *MathMLRenderer) renderFence(node *FenceNode) string {
+	delimiters := fenceSymbols[node.Kind]
+	return fenced(delimiters[0], r.renderNode(node.Inner, LOWEST, false), delimiters[1])
+}
+
+// renderCommand converts \sqrt to msqrt or mroot, \binom to a fraction
+// without a bar and \frac to an mfrac
+func (r *MathMLRenderer) renderCommand(node *CommandNode, parentPrec int) string {
+	argument := r.renderNode(node.Argument, LOWEST, false)
+	switch node.Name {
+	case "sqrt":
+		if node.Optional != nil {
+			return "<mroot>" + argument + r.renderNode(node.Optional, LOWEST, false) + "</mroot>"
+		}
+		return "<msqrt>" + argument + "</msqrt>"
+	case "binom":
+		return fenced("(", `<mfrac linethickness="0">`+argument+r.renderNode(node.Second, LOWEST, false)+"</mfrac>", ")")
+	case "frac", "dfrac", "tfrac":
+		return r.renderFrac(node.Argument, node.Second, parentPrec)
+	default:
+		return mrow(mi(node.Name) + mo(functionApplication) + fenced("(", argument, ")"))
+	}
+}
+
+// bigOperatorSymbols are the symbols of \sum and \prod
+var bigOperatorSymbols = map[string]string{
+	"sum":  "∑",
+	"prod": "∏",
+}
+
+// renderBigOperator converts a BigOperatorNode to an munderover followed
+// by its body
+// Parentheses follow Renderer.renderBigOperator.
+func (r *MathMLRenderer) renderBigOperator(node *BigOperatorNode, parentPrec int) string {
+	body := r.renderNode(node.Body, PRODUCT, false)
+	if nested, ok := node.Body.(*BigOperatorNode); ok {
+		body = r.renderBigOperator(nested, LOWEST)
+	}
+
+	lower := mrow(r.renderName(node.Index.Name) + mo("=") + r.renderNode(node.Lower, LOWEST, false))
+	result := mrow("<munderover>" + mo(bigOperatorSymbols[node.Name]) + lower + r.renderNode(node.Upper, LOWEST, false) + "</munderover>" + body)
+	if parentPrec > SUM {
+		return fenced("(", result, ")")
+	}
+	return result
+}
+
+// renderIntegral converts an IntegralNode to an integral sign, the integrand
+// and the differential
+func (r *MathMLRenderer) renderIntegral(node *IntegralNode, parentPrec int) string {
+	sign := mo("∫")
+	if node.Lower != nil {
+		sign = "<msubsup>" + sign + r.renderNode(node.Lower, LOWEST, false) + r.renderNode(node.Upper, LOWEST, false) + "</msubsup>"
+	}
+	differential := mrow(mi("d") + r.renderName(node.Variable.Name))
+	result := mrow(sign + r.renderNode(node.Integrand, LOWEST, false) + `<mspace width="0.167em"/>` + differential)
+	if parentPrec > POWER {
+		return fenced("(", result, ")")
+	}
+	return result
+}
+
+// renderLimit converts a LimitNode to lim with the approach beneath it
+// Parentheses follow Renderer.renderLimit.
+func (r *MathMLRenderer) renderLimit(node *LimitNode, parentPrec int) string {
+	point := r.renderNode(node.Point, LOWEST, false)
+	if node.Direction != "" {
+		point = "<msup>" + r.renderNode(node.Point, POWER, false) + mo(node.Direction) + "</msup>"
+	}
+	approach := mrow(r.renderName(node.Variable.Name) + mo("→") + point)
+	result := mrow("<munder>" + mo("lim") + approach + "</munder>" + r.renderNode(node.Body, PRODUCT, false))
+	if parentPrec > SUM {
+		return fenced("(", result, ")")
+	}
+	return result
+}
+
+// renderCases converts a CasesNode to a table after an opening brace
+func (r *MathMLRenderer) renderCases(node *CasesNode) string {
+	var rows strings.Builder
+	for _, row := range node.Rows {
+		condition := "<mtext>otherwise</mtext>"
+		if row.Condition != nil {
+			condition = r.renderNode(row.Condition, LOWEST, false)
+		}
+		rows.WriteString("<mtr><mtd>" + r.renderNode(row.Value, LOWEST, false) + "</mtd><mtd>" + condition + "</mtd></mtr>")
+	}
+	return mrow(mo("{") + `<mtable columnalign="left">` + rows.String() + "</mtable>")
+}
+
+// renderCall converts a call such as f(x, y) to an applied mi
+func (r *MathMLRenderer) renderCall(name *VariableNode, arguments []LatexNode) string {
+	args := make([]string, len(arguments))
+	for i, argument := range arguments {
+		args[i] = r.renderNode(argument, LOWEST, false)
+	}
+	return mrow(r.renderName(name.Name) + mo(functionApplication) + fenced("(", strings.Join(args, mo(",")), ")"))
+}
+
+// mi, mn and mo create token elements with escaped text
+// MathML sets a single letter in italics and longer names upright.
+func mi(text string) string {
+	return "<mi>" + mathMLEscaper.Replace(text) + "</mi>"
+}
+
+func mn(text string) string {
+	return "<mn>" + mathMLEscaper.Replace(text) + "</mn>"
+}
+
+func mo(text string) string {
+	return "<mo>" + mathMLEscaper.Replace(text) + "</mo>"
+}
+
+// mrow groups elements into a row
+func mrow(content string) string {
+	return "<mrow>" + content + "</mrow>"
+}
+
+// fenced wraps content in a pair of delimiters
+func fenced(open, content, close string) string {
+	return mrow(mo(open) + content + mo(close))
+}
+
+// RenderMathML converts a LatexNode to Presentation MathML
+func RenderMathML(node LatexNode) string {
+	return NewMathMLRenderer().Render(node)
+}
+
+// ExpressionToMathML converts an Expression tree, including propositions
+// such as a = b = c, to Presentation MathML
+func ExpressionToMathML(expression expr.Expr) (string, error) {
+	ast, err := ExportToLatex(expression)
+	if err != nil {
+		return "", fmt.Errorf("failed to export expression: %w", err)
+	}
+	return RenderMathML(ast), nil
+}
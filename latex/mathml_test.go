@@ -0,0 +1,79 @@
+package latex
+
+import (
+	"encoding/xml"
+	"exprtree/expr"
+	"exprtree/prop"
+	"exprtree/value"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestExpressionToMathML(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2x^2 + 1", "<mrow><mrow><mn>2</mn><mo>\u2062</mo><msup><mi>x</mi><mn>2</mn></msup></mrow><mo>+</mo><mn>1</mn></mrow>"},
+		{"\\frac{a + b}{c}", "<mfrac><mrow><mi>a</mi><mo>+</mo><mi>b</mi></mrow><mi>c</mi></mfrac>"},
+		{"(2^3)^2", "<msup><mrow><mo>(</mo><msup><mn>2</mn><mn>3</mn></msup><mo>)</mo></mrow><mn>2</mn></msup>"},
+		{"2^{3^2}", "<msup><mn>2</mn><msup><mn>3</mn><mn>2</mn></msup></msup>"},
+		{"a - (b - c)", "<mrow><mi>a</mi><mo>−</mo><mrow><mo>(</mo><mrow><mi>b</mi><mo>−</mo><mi>c</mi></mrow><mo>)</mo></mrow></mrow>"},
+		{"a - (b + c)", "<mrow><mi>a</mi><mo>−</mo><mrow><mo>(</mo><mrow><mi>b</mi><mo>+</mo><mi>c</mi></mrow><mo>)</mo></mrow></mrow>"},
+		{"a - (b c)", "<mrow><mi>a</mi><mo>−</mo><mrow><mi>b</mi><mo>⋅</mo><mi>c</mi></mrow></mrow>"},
+		{"a * (-3)", "<mrow><mi>a</mi><mo>⋅</mo><mrow><mo>(</mo><mrow><mo>−</mo><mn>3</mn></mrow><mo>)</mo></mrow></mrow>"},
+		{"\\sqrt{x} + \\sqrt[3]{2}", "<mrow><msqrt><mi>x</mi></msqrt><mo>+</mo><mroot><mn>2</mn><mn>3</mn></mroot></mrow>"},
+		{"\\sin^2(x)", "<mrow><msup><mi>sin</mi><mn>2</mn></msup><mo>\u2061</mo><mrow><mo>(</mo><mi>x</mi><mo>)</mo></mrow></mrow>"},
+		{"|x_1|", "<mrow><mo>|</mo><msub><mi>x</mi><mn>1</mn></msub><mo>|</mo></mrow>"},
+		{"\\sum_{i=1}^{n} i", "<mrow><munderover><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover><mi>i</mi></mrow>"},
+		{"0 \\le x < 1", "<mrow><mrow><mn>0</mn><mo>≤</mo><mi>x</mi></mrow><mo>&lt;</mo><mn>1</mn></mrow>"},
+		{"a = b = c", "<mrow><mrow><mi>a</mi><mo>=</mo><mi>b</mi></mrow><mo>=</mo><mi>c</mi></mrow>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parsed, err := ParseLatex(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLatex failed: %v", err)
+			}
+			result, err := ExpressionToMathML(parsed.(expr.Expr))
+			if err != nil {
+				t.Fatalf("ExpressionToMathML failed: %v", err)
+			}
+			expected := `<math xmlns="http://www.w3.org/1998/Math/MathML">` + tt.expected + "</math>"
+			if result != expected {
+				t.Errorf("Expected %s, got %s", expected, result)
+			}
+		})
+	}
+}
+
+func TestExpressionToMathML_WellFormed(t *testing.T) {
+	x := expr.NewVariable("x")
+	one := expr.NewConstant(value.NewRealValue(1))
+	tests := []expr.Expr{
+		expr.NewIntegral("x", expr.NewConstant(value.NewRealValue(0)), one, expr.NewPower(x, one)),
+		expr.NewLimit("x", expr.NewConstant(value.NewRealValue(0)), expr.FromAbove, expr.NewDiv(one, x)),
+		expr.NewBinomial(expr.NewVariable("n"), expr.NewVariable("k")),
+		expr.NewPiecewise([]expr.Branch{{Condition: prop.NewInequality(prop.Greater, x, one), Value: x}}, one),
+		expr.NewFloor(expr.NewNeg(expr.NewAdd(x, one))),
+	}
+
+	for _, tt := range tests {
+		result, err := ExpressionToMathML(tt)
+		if err != nil {
+			t.Fatalf("ExpressionToMathML failed: %v", err)
+		}
+		decoder := xml.NewDecoder(strings.NewReader(result))
+		for {
+			_, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("malformed MathML %s: %v", result, err)
+			}
+		}
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"exprtree/expr"
 	"exprtree/value"
 	"fmt"
+	"math"
+	"math/big"
 	"strings"
+	"unicode/utf8"
 )
 
 // LatexNode is the interface for all AST nodes
@@ -15,6 +18,7 @@ type LatexNode interface {
 // NumberNode represents a numeric literal
 type NumberNode struct {
 	Value float64
+	Exact *big.Int // exact value of integers that Value cannot hold, nil otherwise
 	Token Token
 }
 
@@ -45,17 +49,92 @@ type GroupNode struct {
 
 func (n *GroupNode) NodeType() string { return "GroupNode" }
 
-// CommandNode represents a LaTeX command like \sqrt
+// CommandNode represents a LaTeX command like \sqrt or \binom
 type CommandNode struct {
 	Name     string
 	Argument LatexNode
 	Optional LatexNode // nil if not present
+	Second   LatexNode // second argument of two-argument commands, nil otherwise
 	Token    Token
 }
 
 func (n *CommandNode) NodeType() string { return "CommandNode" }
 
-// EqualNode represents an equality expression
+// FunctionNode represents a function application like \sin x or \log_{2} x
+type FunctionNode struct {
+	Name     string
+	Argument LatexNode
+	Base     LatexNode // \log_{b}, nil if not present
+	Exponent LatexNode // \sin^{2} x, nil if not present
+	Token    Token
+}
+
+func (n *FunctionNode) NodeType() string { return "FunctionNode" }
+
+// FenceKind identifies the delimiters of a FenceNode
+type FenceKind int
+
+const (
+	FenceAbs   FenceKind = iota // |x|
+	FenceFloor                  // \lfloor x \rfloor
+	FenceCeil                   // \lceil x \rceil
+)
+
+// FenceNode represents an expression enclosed in delimiters that carry a
+// meaning, such as absolute value bars
+type FenceNode struct {
+	Kind  FenceKind
+	Inner LatexNode
+	Token Token
+}
+
+func (n *FenceNode) NodeType() string { return "FenceNode" }
+
+// BigOperatorNode represents \sum_{i=a}^{b} body or \prod_{i=a}^{b} body
+type BigOperatorNode struct {
+	Name  string
+	Index *VariableNode
+	Lower LatexNode
+	Upper LatexNode
+	Body  LatexNode
+	Token Token
+}
+
+func (n *BigOperatorNode) NodeType() string { return "BigOperatorNode" }
+
+// IntegralNode represents \int_{a}^{b} f(x) dx, or \int f(x) dx without bounds
+type IntegralNode struct {
+	Lower     LatexNode // nil for an indefinite integral
+	Upper     LatexNode // nil for an indefinite integral
+	Integrand LatexNode
+	Variable  *VariableNode
+	Token     Token
+}
+
+func (n *IntegralNode) NodeType() string { return "IntegralNode" }
+
+// LimitNode represents \lim_{x \to a} body, with a one-sided point written
+// a^{+} or a^{-}
+type LimitNode struct {
+	Variable  *VariableNode
+	Point     LatexNode
+	Direction string // "+", "-" or "" for a two-sided limit
+	Body      LatexNode
+	Token     Token
+}
+
+func (n *LimitNode) NodeType() string { return "LimitNode" }
+
+// FactorialNode represents the postfix factorial n!
+type FactorialNode struct {
+	Operand LatexNode
+	Token   Token
+}
+
+func (n *FactorialNode) NodeType() string { return "FactorialNode" }
+
+// EqualNode represents an equality or an inequality such as a < b, with
+// the relation given by the operator token
 type EqualNode struct {
 	Left     LatexNode
 	Operator Token
@@ -64,39 +143,134 @@ type EqualNode struct {
 
 func (n *EqualNode) NodeType() string { return "EqualNode" }
 
+// CaseRow is a row of a cases environment
+type CaseRow struct {
+	Value     LatexNode
+	Condition LatexNode // nil for the \text{otherwise} row
+}
+
+// CasesNode represents \begin{cases} value & condition \\ ... \end{cases}
+type CasesNode struct {
+	Rows  []CaseRow
+	Token Token
+}
+
+func (n *CasesNode) NodeType() string { return "CasesNode" }
+
+// CallNode represents the application f(a, b) of a user-defined function
+type CallNode struct {
+	Name      *VariableNode
+	Arguments []LatexNode
+	Token     Token
+}
+
+func (n *CallNode) NodeType() string { return "CallNode" }
+
+// DefinitionNode represents the declaration f(x, y) = body of a function
+type DefinitionNode struct {
+	Name   *VariableNode
+	Params []*VariableNode
+	Body   LatexNode
+	Token  Token
+}
+
+func (n *DefinitionNode) NodeType() string { return "DefinitionNode" }
+
+// badNode stands in for an expression that failed to parse, so that the
+// parser can go on looking for further errors
+type badNode struct {
+	Token Token
+}
+
+func (n *badNode) NodeType() string { return "badNode" }
+
+// Options configures the parser
+type Options struct {
+	// Definitions holds the user-defined functions. When set, f(a, b) is a
+	// call if f is defined, and an input of the form f(x, y) = body is a
+	// declaration, which the converter adds to Definitions.
+	Definitions *expr.Definitions
+	// Identifiers decides whether adjacent letters form a single name
+	Identifiers IdentifierPolicy
+	// Names lists the multi-letter names recognized by the KnownNames policy
+	Names []string
+	// RadixLiterals enables hexadecimal and binary literals such as 0x1F and
+	// 0b101
+	RadixLiterals bool
+}
+
+// tokenSource supplies the tokens of the input to the parser
+type tokenSource interface {
+	NextToken() Token
+}
+
 // Parser parses tokens into a LaTeX AST
 type Parser struct {
-	lexer        *Lexer
-	currentToken Token
-	peekToken    Token
-	errors       []string
+	options       Options
+	lexer         tokenSource
+	input         string // source text, for error excerpts
+	currentToken  Token
+	peekToken     Token
+	previousToken Token // token before currentToken, restored by backup
+	errors        ParseErrors
+	buffered      []Token // tokens read ahead of peekToken
+	absDepth      int     // number of enclosing |...| whose closing bar is pending
+	integralDepth int     // number of enclosing integrals whose differential is pending
+	limitDepth    int     // number of enclosing limit points whose direction may follow
+	declaring     string  // name of the function whose declaration is being parsed
 }
 
 // Precedence levels for operators
 const (
 	_ int = iota
 	LOWEST
-	EQUALITY // =
+	EQUALITY // =, <, \le, >, \ge
 	SUM      // +, -
 	PRODUCT  // *, /
 	POWER    // ^
+	POSTFIX  // !
 )
 
 // precedences maps token types to their precedence
 var precedences = map[TokenType]int{
-	EQUAL:    EQUALITY,
-	PLUS:     SUM,
-	MINUS:    SUM,
-	MULTIPLY: PRODUCT,
-	DIVIDE:   PRODUCT,
-	CARET:    POWER,
+	EQUAL:     EQUALITY,
+	LESS:      EQUALITY,
+	LESSEQ:    EQUALITY,
+	GREATER:   EQUALITY,
+	GREATEREQ: EQUALITY,
+	PLUS:      SUM,
+	MINUS:     SUM,
+	MULTIPLY:  PRODUCT,
+	DIVIDE:    PRODUCT,
+	CARET:     POWER,
+	BANG:      POSTFIX,
+}
+
+// commandArity lists the number of required arguments of commands that take
+// more than one
+var commandArity = map[string]int{
+	"binom": 2,
+	"frac":  2,
+	"dfrac": 2,
+	"tfrac": 2,
 }
 
 // NewParser creates a new Parser instance
 func NewParser(lexer *Lexer) *Parser {
+	return NewParserWithOptions(lexer, Options{})
+}
+
+// NewParserWithOptions creates a new Parser instance with options
+func NewParserWithOptions(lexer *Lexer, options Options) *Parser {
+	return newParser(lexer, lexer.input, options)
+}
+
+// newParser creates a Parser reading the tokens of input from lexer
+func newParser(lexer tokenSource, input string, options Options) *Parser {
 	p := &Parser{
-		lexer:  lexer,
-		errors: []string{},
+		options: options,
+		lexer:   lexer,
+		input:   input,
 	}
 
 	// Read two tokens to initialize current and peek
@@ -108,29 +282,169 @@ func NewParser(lexer *Lexer) *Parser {
 
 // nextToken advances to the next token
 func (p *Parser) nextToken() {
+	p.previousToken = p.currentToken
 	p.currentToken = p.peekToken
+	if len(p.buffered) > 0 {
+		p.peekToken = p.buffered[0]
+		p.buffered = p.buffered[1:]
+		return
+	}
 	p.peekToken = p.lexer.NextToken()
 }
 
+// backup moves back to the previous token
+// Only one step back is possible.
+func (p *Parser) backup() {
+	p.buffered = append([]Token{p.peekToken}, p.buffered...)
+	p.peekToken = p.currentToken
+	p.currentToken = p.previousToken
+}
+
+// peekAhead returns the n-th token after peekToken without consuming it
+func (p *Parser) peekAhead(n int) Token {
+	for len(p.buffered) < n {
+		p.buffered = append(p.buffered, p.lexer.NextToken())
+	}
+	return p.buffered[n-1]
+}
+
+// atDifferential reports whether the next tokens are the differential dx
+// that ends the integrand of an enclosing integral
+func (p *Parser) atDifferential() bool {
+	return p.integralDepth > 0 &&
+		p.peekToken.Type == VARIABLE && p.peekToken.Literal == "d" &&
+		isIdentifierStart(p.peekAhead(1))
+}
+
 // Parse parses the input and returns the root AST node
+// On failure the error is a ParseErrors listing every error found. After an
+// error the parser skips ahead and goes on, so independent errors such as
+// those in (1 + ) * (2 * ) are reported together.
 func (p *Parser) Parse() (LatexNode, error) {
-	node := p.parseExpression(LOWEST)
+	var node LatexNode
+	if p.atDeclaration() {
+		node = p.parseDeclaration()
+	} else {
+		node = p.parseExpression(LOWEST)
+	}
+
+	// The whole input must be consumed (e.g. a stray \right or closing bar).
+	// The operators following a stray token are parsed for further errors;
+	// stray tokens after an earlier error are usually left over from it and
+	// are not reported.
+	for p.peekToken.Type != EOF {
+		if len(p.errors) == 0 {
+			p.unexpected(p.peekToken)
+		}
+		p.nextToken() // move to the stray token
+		p.parseInfix(&badNode{Token: p.currentToken}, LOWEST)
+	}
 
 	if len(p.errors) > 0 {
-		return nil, fmt.Errorf("parse errors: %s", strings.Join(p.errors, "; "))
+		return nil, p.errors
 	}
 
 	return node, nil
 }
 
+// errorAt records an error at a token
+// Only the first error at a position is kept, so the consequences of an
+// error at the same place are not reported again.
+func (p *Parser) errorAt(code ErrorCode, token Token, format string, args ...any) {
+	for _, err := range p.errors {
+		if err.Token.Pos == token.Pos {
+			return
+		}
+	}
+	p.errors = append(p.errors, newParseError(p.input, code, token, fmt.Sprintf(format, args...)))
+}
+
+// unexpected records an error for a token that cannot appear where it is
+func (p *Parser) unexpected(token Token) {
+	switch {
+	case token.Type == EOF:
+		p.errorAt(ErrUnexpectedToken, token, "unexpected end of input")
+	case token.Type == ILLEGAL && strings.HasPrefix(token.Literal, "\\"):
+		p.errorAt(ErrIllegalToken, token, "unknown command %s", token.Literal)
+	case token.Type == ILLEGAL && isDigit(token.Literal[0]) && !hasThreeDigitGroups(token.Literal):
+		p.errorAt(ErrInvalidSyntax, token, "digit groups separated by {,} must have three digits in %s", token.Literal)
+	case token.Type == ILLEGAL && len(token.Literal) > 1 && (isDigit(token.Literal[0]) || token.Literal[0] == '.'):
+		p.errorAt(ErrIllegalToken, token, "invalid number %s", token.Literal)
+	case token.Type == ILLEGAL && utf8.RuneCountInString(token.Literal) > 1:
+		p.errorAt(ErrUnsupported, token, "unsupported operator %s", token.Literal)
+	case token.Type == ILLEGAL:
+		p.errorAt(ErrIllegalToken, token, "illegal character %s", token.Literal)
+	case token.Type == COMMAND:
+		p.errorAt(ErrUnexpectedToken, token, "unexpected \\%s", token.Literal)
+	default:
+		p.errorAt(ErrUnexpectedToken, token, "unexpected token %s", token.Literal)
+	}
+}
+
+// recoverExpression skips the operand containing an error at the current
+// token and returns a placeholder for it
+// Skipping stops before a binary operator or a token that closes an
+// enclosing construct, so the parser can go on after it and report the
+// errors in 1 + \foo + \bar separately. A closing token that caused the
+// error itself is left for its construct as well.
+func (p *Parser) recoverExpression() LatexNode {
+	token := p.currentToken
+	if p.isClosing(token) {
+		p.backup()
+		return &badNode{Token: token}
+	}
+	depth := 0
+	for p.peekToken.Type != EOF && (depth > 0 || !p.isClosing(p.peekToken) && !isBinaryOperator(p.peekToken)) {
+		switch p.peekToken.Type {
+		case LPAREN, LBRACE, LBRACKET:
+			depth++
+		case RPAREN, RBRACE, RBRACKET:
+			depth--
+		}
+		p.nextToken()
+	}
+	return &badNode{Token: token}
+}
+
+// isClosing reports whether a token closes an enclosing construct
+func (p *Parser) isClosing(token Token) bool {
+	switch token.Type {
+	case RPAREN, RBRACE, RBRACKET, AMPERSAND, ROWSEP, COMMA, EOF:
+		return true
+	case PIPE:
+		return p.absDepth > 0
+	case COMMAND:
+		return isClosingCommand(token.Literal)
+	default:
+		return false
+	}
+}
+
+// isBinaryOperator reports whether a token is an explicit binary operator
+// or relation
+func isBinaryOperator(token Token) bool {
+	switch token.Type {
+	case PLUS, MINUS, MULTIPLY, DIVIDE, CARET, EQUAL, LESS, LESSEQ, GREATER, GREATEREQ:
+		return true
+	default:
+		return false
+	}
+}
+
 // parseExpression implements Pratt parsing
 func (p *Parser) parseExpression(precedence int) LatexNode {
+	token := p.currentToken
+	errorCount := len(p.errors)
+
 	// Parse prefix expression
 	var left LatexNode
 
 	switch p.currentToken.Type {
 	case NUMBER:
 		left = p.parseNumber()
+		if precedence < PRODUCT && p.atPowerOfTen() {
+			left = p.parseScientific()
+		}
 	case VARIABLE:
 		left = p.parseVariable()
 	case LPAREN:
@@ -141,18 +455,36 @@ func (p *Parser) parseExpression(precedence int) LatexNode {
 		left = p.parseUnaryMinus()
 	case COMMAND:
 		left = p.parseCommand()
+	case PIPE:
+		left = p.parseAbs()
 	default:
-		p.errors = append(p.errors, fmt.Sprintf("unexpected token at position %d: %s", p.currentToken.Pos, p.currentToken.Literal))
-		return nil
+		p.unexpected(p.currentToken)
+		left = p.recoverExpression()
+	}
+
+	// A failed prefix leaves a placeholder, so the operators after it are
+	// still checked
+	if left == nil {
+		if len(p.errors) == errorCount {
+			p.unexpected(token)
+		}
+		left = &badNode{Token: token}
 	}
 
-	// Parse infix expressions with precedence climbing
+	return p.parseInfix(left, precedence)
+}
+
+// parseInfix parses the operators following left with precedence climbing
+func (p *Parser) parseInfix(left LatexNode, precedence int) LatexNode {
 	for p.peekToken.Type != EOF && precedence < p.peekPrecedence() {
 		switch p.peekToken.Type {
-		case PLUS, MINUS, MULTIPLY, DIVIDE, CARET, EQUAL:
+		case PLUS, MINUS, MULTIPLY, DIVIDE, CARET, EQUAL, LESS, LESSEQ, GREATER, GREATEREQ:
 			p.nextToken()
 			left = p.parseBinaryOp(left)
-		case NUMBER, VARIABLE, LPAREN, LBRACE, COMMAND:
+		case BANG:
+			p.nextToken()
+			left = &FactorialNode{Operand: left, Token: p.currentToken}
+		case NUMBER, VARIABLE, LPAREN, LBRACE, COMMAND, PIPE:
 			// Implicit multiplication: xy -> x*y, 2x -> 2*x, x(y+z) -> x*(y+z)
 			left = p.parseImplicitMultiply(left)
 		default:
@@ -167,15 +499,159 @@ func (p *Parser) parseExpression(precedence int) LatexNode {
 func (p *Parser) parseNumber() LatexNode {
 	return &NumberNode{
 		Value: p.currentToken.Value,
+		Exact: p.currentToken.Exact,
 		Token: p.currentToken,
 	}
 }
 
-// parseVariable parses a variable
+// atPowerOfTen reports whether the current decimal number is followed by
+// \times 10^{n} or \cdot 10^{n} with an integer n
+func (p *Parser) atPowerOfTen() bool {
+	if strings.ContainsAny(p.currentToken.Literal, "eExXbB") {
+		return false
+	}
+	if p.peekToken.Type != MULTIPLY || p.peekToken.Literal == "*" {
+		return false
+	}
+	if ten := p.peekAhead(1); ten.Type != NUMBER || ten.Literal != "10" || p.peekAhead(2).Type != CARET {
+		return false
+	}
+	if p.peekAhead(3).Type != LBRACE {
+		return isInteger(p.peekAhead(3))
+	}
+	n := 4
+	if sign := p.peekAhead(n); sign.Type == PLUS || sign.Type == MINUS {
+		n++
+	}
+	return isInteger(p.peekAhead(n)) && p.peekAhead(n+1).Type == RBRACE
+}
+
+// isInteger reports whether a token is an integer literal
+func isInteger(token Token) bool {
+	return token.Type == NUMBER && strings.Trim(token.Literal, "0123456789") == ""
+}
+
+// parseScientific folds a number in scientific notation such as
+// 6.02 \times 10^{23} into a single literal
+func (p *Parser) parseScientific() LatexNode {
+	mantissa := p.currentToken
+	p.nextToken() // move to \times
+	p.nextToken() // move to 10
+	p.nextToken() // move to '^'
+	p.nextToken() // move to the exponent or '{'
+	braced := p.currentToken.Type == LBRACE
+	if braced {
+		p.nextToken()
+	}
+	exponent := ""
+	if p.currentToken.Type == PLUS || p.currentToken.Type == MINUS {
+		exponent = p.currentToken.Literal
+		p.nextToken()
+	}
+	exponent += p.currentToken.Literal
+	if braced {
+		p.nextToken() // move to '}'
+	}
+
+	literal := mantissa.Literal + "e" + exponent
+	v, exact, _ := numberValue(literal)
+	return &NumberNode{
+		Value: v,
+		Exact: exact,
+		Token: Token{Type: NUMBER, Literal: literal, Value: v, Exact: exact, Pos: mantissa.Pos, End: p.currentToken.End},
+	}
+}
+
+// parseVariable parses a variable, or the call of a user-defined function
 func (p *Parser) parseVariable() LatexNode {
-	return &VariableNode{
-		Name:  p.currentToken.Literal,
-		Token: p.currentToken,
+	name := p.parseIdentifier()
+	if name == nil {
+		return nil
+	}
+	if p.isFunction(name.Name) && p.peekToken.Type == LPAREN {
+		return p.parseCall(name)
+	}
+	return name
+}
+
+// isFunction reports whether name is a user-defined function, or the
+// function being declared, which may call itself
+func (p *Parser) isFunction(name string) bool {
+	if p.options.Definitions == nil {
+		return false
+	}
+	_, ok := p.options.Definitions.Lookup(name)
+	return ok || name == p.declaring
+}
+
+// atDeclaration reports whether the input starts with f(x, y) =
+func (p *Parser) atDeclaration() bool {
+	if p.options.Definitions == nil || p.currentToken.Type != VARIABLE || p.peekToken.Type != LPAREN {
+		return false
+	}
+	for n := 1; ; n += 2 {
+		if p.peekAhead(n).Type != VARIABLE {
+			return false
+		}
+		switch p.peekAhead(n + 1).Type {
+		case COMMA:
+			continue
+		case RPAREN:
+			return p.peekAhead(n+2).Type == EQUAL
+		default:
+			return false
+		}
+	}
+}
+
+// parseDeclaration parses f(x, y) = body
+func (p *Parser) parseDeclaration() LatexNode {
+	token := p.currentToken
+	node := &DefinitionNode{
+		Name:  &VariableNode{Name: token.Literal, Token: token},
+		Token: token,
+	}
+
+	p.nextToken() // move to '('
+	for p.currentToken.Type != RPAREN {
+		p.nextToken() // move to the parameter
+		node.Params = append(node.Params, &VariableNode{Name: p.currentToken.Literal, Token: p.currentToken})
+		p.nextToken() // move to ',' or ')'
+	}
+	p.nextToken() // move to '='
+	p.nextToken() // move to the body
+
+	p.declaring = node.Name.Name
+	node.Body = p.parseExpression(LOWEST)
+	p.declaring = ""
+	if node.Body == nil {
+		return nil
+	}
+	return node
+}
+
+// parseCall parses the arguments (a, b) of a call of a user-defined function
+func (p *Parser) parseCall(name *VariableNode) LatexNode {
+	node := &CallNode{
+		Name:  name,
+		Token: name.Token,
+	}
+
+	p.nextToken() // move to '('
+	for {
+		p.nextToken() // move to the argument
+		argument := p.parseDelimitedInner()
+		if argument == nil {
+			return nil
+		}
+		node.Arguments = append(node.Arguments, argument)
+		if p.expectPeek(RPAREN) {
+			return node
+		}
+		if !p.expectPeek(COMMA) {
+			p.errorAt(ErrExpectedToken, p.peekToken, "expected ',' or ')'")
+			return nil
+		}
 	}
 }
 
@@ -188,7 +664,7 @@ func (p *Parser) parseGroupExpression() LatexNode {
 	inner := p.parseExpression(LOWEST)
 
 	if !p.expectPeek(RPAREN) {
-		p.errors = append(p.errors, fmt.Sprintf("expected ')' at position %d", p.peekToken.Pos))
+		p.errorAt(ErrExpectedToken, p.peekToken, "expected ')'")
 		return nil
 	}
 
@@ -207,7 +683,7 @@ func (p *Parser) parseBraceExpression() LatexNode {
 	inner := p.parseExpression(LOWEST)
 
 	if !p.expectPeek(RBRACE) {
-		p.errors = append(p.errors, fmt.Sprintf("expected '}' at position %d", p.peekToken.Pos))
+		p.errorAt(ErrExpectedToken, p.peekToken, "expected '}'")
 		return nil
 	}
 
@@ -231,8 +707,12 @@ func (p *Parser) parseUnaryMinus() LatexNode {
 
 	p.nextToken() // Move past '-'
 
-	// Parse the operand with high precedence so it binds tightly
-	operand := p.parseExpression(POWER)
+	// The operand extends over powers and factorials but not products, so
+	// -2^2 is -(2^2) and -2x is (-2) * x
+	operand := p.parseExpression(PRODUCT)
+	if operand == nil {
+		return nil
+	}
 
 	return &UnaryMinusNode{
 		Operand: operand,
@@ -240,28 +720,100 @@ func (p *Parser) parseUnaryMinus() LatexNode {
 	}
 }
 
-// parseCommand parses \sqrt{...} and \sqrt[n]{...}
+// parseCommand parses \sqrt{...}, \sqrt[n]{...} and two-argument commands
+// such as \binom{n}{k} and \frac{a}{b}
 func (p *Parser) parseCommand() LatexNode {
 	token := p.currentToken
 	commandName := token.Literal
 
+	if isFunctionCommand(commandName) {
+		return p.parseFunction()
+	}
+	switch commandName {
+	case "sum", "prod":
+		return p.parseBigOperator()
+	case "int":
+		return p.parseIntegral()
+	case "lim":
+		return p.parseLimit()
+	case "infty":
+		return &NumberNode{Value: math.Inf(1), Token: token}
+	case "begin":
+		return p.parseEnvironment()
+	case "to":
+		p.unexpected(token)
+		return p.recoverExpression()
+	case "left":
+		return p.parseLeftRight()
+	case "lfloor":
+		return p.parseFence(FenceFloor, "rfloor")
+	case "lceil":
+		return p.parseFence(FenceCeil, "rceil")
+	}
+	if isClosingCommand(commandName) {
+		p.unexpected(token)
+		return p.recoverExpression()
+	}
+	if isIdentifierStart(token) {
+		return p.parseVariable()
+	}
+
 	var optional LatexNode
 
 	// Check for optional argument [n]
-	if p.peekToken.Type == LBRACKET {
+	if commandName == "sqrt" && p.peekToken.Type == LBRACKET {
 		p.nextToken() // consume LBRACKET
 		p.nextToken() // move to content
 		optional = p.parseExpression(LOWEST)
 
 		if !p.expectPeek(RBRACKET) {
-			p.errors = append(p.errors, fmt.Sprintf("expected ']' at position %d", p.peekToken.Pos))
+			p.errorAt(ErrExpectedToken, p.peekToken, "expected ']'")
+			return nil
+		}
+	}
+
+	argument := p.parseCommandArgument(commandName)
+	if argument == nil {
+		return nil
+	}
+
+	var second LatexNode
+	if commandArity[commandName] == 2 {
+		second = p.parseCommandArgument(commandName)
+		if second == nil {
 			return nil
 		}
 	}
 
-	// Parse required argument {expr}
+	return &CommandNode{
+		Name:     commandName,
+		Argument: argument,
+		Optional: optional,
+		Second:   second,
+		Token:    token,
+	}
+}
+
+// parseCommandArgument parses a required argument {expr} of a command
+// As in TeX, an unbraced argument is a single digit, letter or Greek
+// letter, so \frac12 is \frac{1}{2} and \binom nk is \binom{n}{k}.
+func (p *Parser) parseCommandArgument(commandName string) LatexNode {
+	switch token := p.peekToken; {
+	case token.Type == NUMBER && token.Literal[0] >= '0' && token.Literal[0] <= '9':
+		if p.splitDigit() {
+			p.nextToken()
+			return p.parseNumber()
+		}
+	case token.Type == VARIABLE:
+		p.nextToken()
+		return &VariableNode{Name: token.Literal, Token: token}
+	case token.Type == COMMAND && greekLetters[token.Literal] != "":
+		p.nextToken()
+		return &VariableNode{Name: greekLetters[token.Literal], Token: token}
+	}
+
 	if !p.expectPeek(LBRACE) {
-		p.errors = append(p.errors, fmt.Sprintf("expected '{' after \\%s at position %d", commandName, p.peekToken.Pos))
+		p.errorAt(ErrExpectedToken, p.peekToken, "expected '{' after \\%s", commandName)
 		return nil
 	}
 
@@ -269,18 +821,556 @@ func (p *Parser) parseCommand() LatexNode {
 	argument := p.parseExpression(LOWEST)
 
 	if !p.expectPeek(RBRACE) {
-		p.errors = append(p.errors, fmt.Sprintf("expected '}' at position %d", p.peekToken.Pos))
+		p.errorAt(ErrExpectedToken, p.peekToken, "expected '}'")
 		return nil
 	}
+	return argument
+}
 
-	return &CommandNode{
-		Name:     commandName,
-		Argument: argument,
-		Optional: optional,
-		Token:    token,
+// splitDigit splits the first digit off the number in peekToken, leaving
+// the rest of the number to be read next
+// It reports false when the rest does not read as a number by itself.
+func (p *Parser) splitDigit() bool {
+	token := p.peekToken
+	if len(token.Literal) == 1 {
+		return true
+	}
+	rest := NewLexerWithOptions(token.Literal[1:], p.options).NextToken()
+	if rest.Type != NUMBER || rest.End != len(token.Literal)-1 {
+		return false
+	}
+	rest.Pos += token.Pos + 1
+	rest.End += token.Pos + 1
+	digit := NewLexerWithOptions(token.Literal[:1], p.options).NextToken()
+	digit.Pos += token.Pos
+	digit.End += token.Pos
+	p.peekToken = digit
+	p.buffered = append([]Token{rest}, p.buffered...)
+	return true
+}
+
+// isFunctionCommand reports whether a command is a function like \sin or \log
+func isFunctionCommand(name string) bool {
+	_, ok := expr.LookupFunction(name)
+	return ok || name == "log"
+}
+
+// parseFunction parses \sin x, \sin(x), \sin^2 x and \log_{b} x
+func (p *Parser) parseFunction() LatexNode {
+	token := p.currentToken
+	node := &FunctionNode{
+		Name:  token.Literal,
+		Token: token,
+	}
+
+	// The subscript (logarithm base) and superscript may come in either order
+	for p.peekToken.Type == UNDERSCORE || p.peekToken.Type == CARET {
+		p.nextToken()
+		if p.currentToken.Type == UNDERSCORE {
+			if node.Name != "log" || node.Base != nil {
+				p.errorAt(ErrUnexpectedToken, p.currentToken, "unexpected '_'")
+				return nil
+			}
+			node.Base = p.parseScript()
+			if node.Base == nil {
+				return nil
+			}
+		} else {
+			if node.Exponent != nil {
+				p.errorAt(ErrUnexpectedToken, p.currentToken, "unexpected '^'")
+				return nil
+			}
+			node.Exponent = p.parseScript()
+			if node.Exponent == nil {
+				return nil
+			}
+		}
+	}
+
+	p.nextToken() // move to the argument
+	node.Argument = p.parseFunctionArgument()
+	if node.Argument == nil {
+		return nil
+	}
+
+	// \sin^{-1} x is \arcsin x, not the reciprocal of \sin x
+	if isMinusOne(node.Exponent) {
+		inverse, ok := inverseFunctions[node.Name]
+		if !ok {
+			p.errorAt(ErrUnsupported, token, "\\%s^{-1} is ambiguous; write (\\%s x)^{-1} for the reciprocal", node.Name, node.Name)
+			return nil
+		}
+		node.Name, node.Exponent = inverse, nil
+	}
+
+	return node
+}
+
+// inverseFunctions maps the functions whose power -1 denotes the inverse
+// function to that function
+var inverseFunctions = map[string]string{
+	"sin": "arcsin",
+	"cos": "arccos",
+	"tan": "arctan",
+}
+
+// isMinusOne reports whether a script is -1 or {-1}
+func isMinusOne(node LatexNode) bool {
+	if group, ok := node.(*GroupNode); ok {
+		node = group.Inner
+	}
+	if number, ok := node.(*NumberNode); ok {
+		return number.Value == -1
+	}
+	minus, ok := node.(*UnaryMinusNode)
+	if !ok {
+		return false
+	}
+	number, ok := minus.Operand.(*NumberNode)
+	return ok && number.Value == 1
+}
+
+// parseBigOperator parses \sum_{i=a}^{b} body and \prod_{i=a}^{b} body
+// The body extends over products and powers but ends at + or -, so
+// \sum_{i=1}^{n} i^2 + 1 adds 1 to the sum.
+func (p *Parser) parseBigOperator() LatexNode {
+	token := p.currentToken
+	node := &BigOperatorNode{
+		Name:  token.Literal,
+		Token: token,
+	}
+
+	// The subscript and superscript may come in either order
+	for p.peekToken.Type == UNDERSCORE || p.peekToken.Type == CARET {
+		p.nextToken()
+		if p.currentToken.Type == UNDERSCORE {
+			if node.Lower != nil {
+				p.errorAt(ErrUnexpectedToken, p.currentToken, "unexpected '_'")
+				return nil
+			}
+			script := p.parseScript()
+			if script == nil {
+				return nil
+			}
+			if group, ok := script.(*GroupNode); ok {
+				script = group.Inner
+			}
+			equal, ok := script.(*EqualNode)
+			if !ok {
+				p.errorAt(ErrExpectedToken, token, "expected index assignment like i=1 after \\%s", token.Literal)
+				return nil
+			}
+			index, ok := equal.Left.(*VariableNode)
+			if !ok {
+				p.errorAt(ErrExpectedToken, token, "expected index variable after \\%s", token.Literal)
+				return nil
+			}
+			node.Index = index
+			node.Lower = equal.Right
+		} else {
+			if node.Upper != nil {
+				p.errorAt(ErrUnexpectedToken, p.currentToken, "unexpected '^'")
+				return nil
+			}
+			node.Upper = p.parseScript()
+			if node.Upper == nil {
+				return nil
+			}
+		}
+	}
+
+	if node.Lower == nil || node.Upper == nil {
+		p.errorAt(ErrInvalidSyntax, token, "\\%s requires bounds _{i=a}^{b}", token.Literal)
+		return nil
+	}
+
+	p.nextToken() // move to the body
+	node.Body = p.parseExpression(SUM)
+	if node.Body == nil {
+		return nil
+	}
+
+	return node
+}
+
+// parseIntegral parses \int_{a}^{b} f(x) dx and \int f(x) dx
+// The integrand extends up to the differential, which may be preceded by
+// a spacing command as in \int x^2 \, dx.
+func (p *Parser) parseIntegral() LatexNode {
+	token := p.currentToken
+	node := &IntegralNode{Token: token}
+
+	// The bounds may come in either order
+	for p.peekToken.Type == UNDERSCORE || p.peekToken.Type == CARET {
+		p.nextToken()
+		if p.currentToken.Type == UNDERSCORE {
+			if node.Lower != nil {
+				p.errorAt(ErrUnexpectedToken, p.currentToken, "unexpected '_'")
+				return nil
+			}
+			node.Lower = p.parseScript()
+			if node.Lower == nil {
+				return nil
+			}
+		} else {
+			if node.Upper != nil {
+				p.errorAt(ErrUnexpectedToken, p.currentToken, "unexpected '^'")
+				return nil
+			}
+			node.Upper = p.parseScript()
+			if node.Upper == nil {
+				return nil
+			}
+		}
+	}
+	if (node.Lower == nil) != (node.Upper == nil) {
+		p.errorAt(ErrInvalidSyntax, token, "\\int requires both bounds or none")
+		return nil
+	}
+
+	p.nextToken() // move to the integrand
+	p.integralDepth++
+	node.Integrand = p.parseExpression(EQUALITY)
+	isDifferential := p.atDifferential()
+	p.integralDepth--
+	if node.Integrand == nil {
+		return nil
+	}
+	if !isDifferential {
+		p.errorAt(ErrExpectedToken, p.peekToken, "expected differential like dx")
+		return nil
+	}
+
+	p.nextToken() // move to 'd'
+	p.nextToken() // move to the variable
+	node.Variable = p.parseIdentifier()
+	if node.Variable == nil {
+		return nil
+	}
+	return node
+}
+
+// parseLimit parses \lim_{x \to a} body
+// The point may carry a direction a^+, a^-, a^{+} or a^{-}. Like the body of
+// a sum, the body extends over products and powers but ends at + or -.
+func (p *Parser) parseLimit() LatexNode {
+	token := p.currentToken
+	node := &LimitNode{Token: token}
+
+	if !p.expectPeek(UNDERSCORE) || !p.expectPeek(LBRACE) || !isIdentifierStart(p.peekToken) {
+		p.errorAt(ErrInvalidSyntax, token, "\\lim requires a subscript like _{x \\to a}")
+		return nil
+	}
+	p.nextToken() // move to the variable
+	node.Variable = p.parseIdentifier()
+	if node.Variable == nil {
+		return nil
+	}
+	if !p.expectPeekCommand("to") {
+		p.errorAt(ErrExpectedToken, p.peekToken, "expected \\to")
+		return nil
+	}
+
+	p.nextToken() // move to the point
+	p.limitDepth++
+	node.Point = p.parseExpression(LOWEST)
+	isDirection := p.atLimitDirection()
+	p.limitDepth--
+	if node.Point == nil {
+		return nil
+	}
+	if isDirection {
+		p.nextToken() // move to '^'
+		braced := p.expectPeek(LBRACE)
+		p.nextToken() // move to the sign
+		node.Direction = p.currentToken.Literal
+		if braced {
+			p.nextToken() // move to '}'
+		}
+	}
+	if !p.expectPeek(RBRACE) {
+		p.errorAt(ErrExpectedToken, p.peekToken, "expected '}'")
+		return nil
+	}
+
+	p.nextToken() // move to the body
+	node.Body = p.parseExpression(SUM)
+	if node.Body == nil {
+		return nil
+	}
+	return node
+}
+
+// atLimitDirection reports whether the next tokens are the direction ^+ or
+// ^{-} that ends the point of an enclosing limit
+func (p *Parser) atLimitDirection() bool {
+	if p.limitDepth == 0 || p.peekToken.Type != CARET {
+		return false
+	}
+	isSign := func(t Token) bool { return t.Type == PLUS || t.Type == MINUS }
+	if isSign(p.peekAhead(1)) {
+		return p.peekAhead(2).Type == RBRACE
+	}
+	return p.peekAhead(1).Type == LBRACE && isSign(p.peekAhead(2)) && p.peekAhead(3).Type == RBRACE
+}
+
+// parseEnvironment parses \begin{name} ... \end{name}
+// Only the cases environment is supported.
+func (p *Parser) parseEnvironment() LatexNode {
+	token := p.currentToken
+	name, ok := p.parseText()
+	if !ok {
+		p.errorAt(ErrExpectedToken, token, "expected environment name after \\begin")
+		return nil
+	}
+	if name != "cases" {
+		p.errorAt(ErrUnsupported, token, "unsupported environment %s", name)
+		return nil
+	}
+
+	node := p.parseCases(token)
+	if node == nil {
+		return nil
+	}
+	p.nextToken() // move to \end
+	if end, ok := p.parseText(); !ok || end != name {
+		p.errorAt(ErrExpectedToken, p.currentToken, "expected \\end{%s}", name)
+		return nil
+	}
+	return node
+}
+
+// parseCases parses the rows of a cases environment up to \end
+// Each row is value & condition, where the value may be followed by a comma
+// and the condition may be written \text{if } x < 0. A \text{otherwise} or
+// \text{else} row must come last.
+func (p *Parser) parseCases(token Token) *CasesNode {
+	node := &CasesNode{Token: token}
+	for {
+		p.nextToken() // move to the value
+		row := CaseRow{Value: p.parseExpression(LOWEST)}
+		if row.Value == nil {
+			return nil
+		}
+		p.expectPeek(COMMA)
+		if !p.expectPeek(AMPERSAND) {
+			p.errorAt(ErrExpectedToken, p.peekToken, "expected '&'")
+			return nil
+		}
+
+		otherwise := false
+		if p.peekToken.Type == COMMAND && p.peekToken.Literal == "text" {
+			p.nextToken() // move to \text
+			text, ok := p.parseText()
+			switch {
+			case ok && (text == "otherwise" || text == "else"):
+				otherwise = true
+			case ok && (text == "if" || text == "for" || text == "when"):
+			default:
+				p.errorAt(ErrUnexpectedToken, p.currentToken, "unexpected \\text{%s}", text)
+				return nil
+			}
+		}
+		if !otherwise {
+			p.nextToken() // move to the condition
+			row.Condition = p.parseExpression(LOWEST)
+			if row.Condition == nil {
+				return nil
+			}
+		}
+		node.Rows = append(node.Rows, row)
+
+		p.expectPeek(ROWSEP)
+		if p.peekToken.Type == COMMAND && p.peekToken.Literal == "end" {
+			return node
+		}
+		if otherwise {
+			p.errorAt(ErrInvalidSyntax, p.peekToken, "the otherwise row must be the last row")
+			return nil
+		}
+		if p.currentToken.Type != ROWSEP {
+			p.errorAt(ErrExpectedToken, p.peekToken, "expected \\\\ or \\end{cases}")
+			return nil
+		}
+	}
+}
+
+// parseText parses the braced argument {word} of \text, \begin or \end
+// The letters are concatenated; spaces between them are dropped.
+func (p *Parser) parseText() (string, bool) {
+	if !p.expectPeek(LBRACE) {
+		return "", false
+	}
+	var text strings.Builder
+	for p.peekToken.Type == VARIABLE {
+		p.nextToken()
+		text.WriteString(p.currentToken.Literal)
+	}
+	if !p.expectPeek(RBRACE) {
+		return "", false
+	}
+	return text.String(), true
+}
+
+// parseScript parses the argument of ^ or _ after a command
+// The argument is either a braced expression or a single number or variable.
+func (p *Parser) parseScript() LatexNode {
+	p.nextToken() // move past '^' or '_'
+
+	switch p.currentToken.Type {
+	case LBRACE:
+		return p.parseBraceExpression()
+	case NUMBER:
+		return p.parseNumber()
+	case VARIABLE:
+		return p.parseVariable()
+	case COMMAND:
+		if isIdentifierStart(p.currentToken) {
+			return p.parseVariable()
+		}
+		p.errorAt(ErrExpectedToken, p.currentToken, "expected script argument, got \\%s", p.currentToken.Literal)
+		return nil
+	default:
+		p.errorAt(ErrExpectedToken, p.currentToken, "expected script argument, got %s", p.currentToken.Literal)
+		return nil
 	}
 }
 
+// parseFunctionArgument parses the argument of a function
+// A parenthesized argument ends at the closing parenthesis. Otherwise the
+// argument extends over juxtaposed numbers and variables, so \sin 2x is
+// \sin(2x) while \sin x \cos x is \sin(x) \cos(x).
+func (p *Parser) parseFunctionArgument() LatexNode {
+	if p.currentToken.Type == LPAREN {
+		return p.parseGroupExpression()
+	}
+
+	argument := p.parseExpression(PRODUCT)
+	for argument != nil && (p.peekToken.Type == NUMBER || isIdentifierStart(p.peekToken)) && !p.atDifferential() {
+		argument = p.parseImplicitMultiply(argument)
+	}
+	return argument
+}
+
+// isClosingCommand reports whether a command closes a delimited expression
+func isClosingCommand(name string) bool {
+	return name == "right" || name == "rfloor" || name == "rceil" || name == "end"
+}
+
+// parseAbs parses an absolute value |...|
+// While the closing bar is pending, a '|' ends the inner expression instead
+// of starting an implicit multiplication.
+func (p *Parser) parseAbs() LatexNode {
+	token := p.currentToken // Save the '|' token
+
+	p.nextToken() // Move past '|'
+
+	p.absDepth++
+	inner := p.parseExpression(LOWEST)
+	p.absDepth--
+
+	if !p.expectPeek(PIPE) {
+		p.errorAt(ErrExpectedToken, p.peekToken, "expected '|'")
+		return nil
+	}
+
+	return &FenceNode{
+		Kind:  FenceAbs,
+		Inner: inner,
+		Token: token,
+	}
+}
+
+// parseFence parses \lfloor ... \rfloor and \lceil ... \rceil
+func (p *Parser) parseFence(kind FenceKind, closing string) LatexNode {
+	token := p.currentToken
+
+	p.nextToken() // Move past the opening command
+
+	inner := p.parseDelimitedInner()
+
+	if !p.expectPeekCommand(closing) {
+		p.errorAt(ErrExpectedToken, p.peekToken, "expected '\\%s'", closing)
+		return nil
+	}
+
+	return &FenceNode{
+		Kind:  kind,
+		Inner: inner,
+		Token: token,
+	}
+}
+
+// parseLeftRight parses \left<delimiter> ... \right<delimiter>
+// Parentheses and brackets produce a GroupNode, the other delimiters a FenceNode.
+func (p *Parser) parseLeftRight() LatexNode {
+	token := p.currentToken
+
+	p.nextToken() // Move to the opening delimiter
+	open := p.currentToken
+
+	var closing Token
+	switch {
+	case open.Type == LPAREN:
+		closing = Token{Type: RPAREN, Literal: ")"}
+	case open.Type == LBRACKET:
+		closing = Token{Type: RBRACKET, Literal: "]"}
+	case open.Type == PIPE:
+		closing = Token{Type: PIPE, Literal: "|"}
+	case open.Type == COMMAND && open.Literal == "lfloor":
+		closing = Token{Type: COMMAND, Literal: "rfloor"}
+	case open.Type == COMMAND && open.Literal == "lceil":
+		closing = Token{Type: COMMAND, Literal: "rceil"}
+	default:
+		p.errorAt(ErrUnexpectedToken, open, "unexpected delimiter %s after \\left", open.Literal)
+		return nil
+	}
+
+	p.nextToken() // Move past the opening delimiter
+
+	inner := p.parseDelimitedInner()
+
+	if !p.expectPeekCommand("right") {
+		p.errorAt(ErrExpectedToken, p.peekToken, "expected '\\right'")
+		return nil
+	}
+	p.nextToken() // Move to the closing delimiter
+	if p.currentToken.Type != closing.Type || p.currentToken.Literal != closing.Literal {
+		p.errorAt(ErrExpectedToken, p.currentToken, "expected '%s' after \\right", closing.Literal)
+		return nil
+	}
+
+	switch {
+	case open.Type == PIPE:
+		return &FenceNode{Kind: FenceAbs, Inner: inner, Token: token}
+	case open.Literal == "lfloor":
+		return &FenceNode{Kind: FenceFloor, Inner: inner, Token: token}
+	case open.Literal == "lceil":
+		return &FenceNode{Kind: FenceCeil, Inner: inner, Token: token}
+	default:
+		return &GroupNode{Inner: inner, Token: token}
+	}
+}
+
+// parseDelimitedInner parses the inside of a delimiter pair closed by a command
+// Bars inside are independent of any enclosing |...|.
+func (p *Parser) parseDelimitedInner() LatexNode {
+	savedDepth := p.absDepth
+	p.absDepth = 0
+	inner := p.parseExpression(LOWEST)
+	p.absDepth = savedDepth
+	return inner
+}
+
+// expectPeekCommand checks if the next token is the given command
+func (p *Parser) expectPeekCommand(name string) bool {
+	if p.peekToken.Type == COMMAND && p.peekToken.Literal == name {
+		p.nextToken()
+		return true
+	}
+	return false
+}
+
 // parseImplicitMultiply parses implicit multiplication (e.g., xy, 2x, x(y+z))
 func (p *Parser) parseImplicitMultiply(left LatexNode) LatexNode {
 	// Create a synthetic multiply token
@@ -312,8 +1402,8 @@ func (p *Parser) parseBinaryOp(left LatexNode) LatexNode {
 		right = p.parseExpression(precedence) // Left-associative: use same precedence
 	}
 
-	// Create EqualNode for equality, BinaryOpNode for other operators
-	if operator.Type == EQUAL {
+	// Create EqualNode for relations, BinaryOpNode for other operators
+	if precedence == EQUALITY {
 		return &EqualNode{
 			Left:     left,
 			Operator: operator,
@@ -347,33 +1437,57 @@ func (p *Parser) currentPrecedence() int {
 
 // peekPrecedence returns the precedence of the peek token
 func (p *Parser) peekPrecedence() int {
+	if p.atDifferential() || p.atLimitDirection() {
+		return LOWEST
+	}
 	if p, ok := precedences[p.peekToken.Type]; ok {
 		return p
 	}
 	// Implicit multiplication has PRODUCT precedence
 	switch p.peekToken.Type {
-	case NUMBER, VARIABLE, LPAREN, LBRACE, COMMAND:
+	case NUMBER, VARIABLE, LPAREN, LBRACE:
+		return PRODUCT
+	case COMMAND:
+		if isClosingCommand(p.peekToken.Literal) {
+			return LOWEST
+		}
+		return PRODUCT
+	case PIPE:
+		// A bar closes the innermost pending absolute value
+		if p.absDepth > 0 {
+			return LOWEST
+		}
 		return PRODUCT
 	}
 	return LOWEST
 }
 
 // Errors returns the list of parse errors
-func (p *Parser) Errors() []string {
+func (p *Parser) Errors() ParseErrors {
 	return p.errors
 }
 
 // ParseLatex parses a LaTeX string and returns an Expression or Proposition
 // The return value can be either expr.Expression or expr.Proposition depending on the input
 func ParseLatex(input string) (interface{}, error) {
-	lexer := NewLexer(input)
-	parser := NewParser(lexer)
+	return ParseLatexWithOptions(input, Options{})
+}
+
+// ParseLatexWithOptions parses a LaTeX string like ParseLatex with options
+// With Definitions set, a declaration f(x) = body returns the new
+// *expr.Definition after adding it to Definitions.
+func ParseLatexWithOptions(input string, options Options) (interface{}, error) {
+	return parse(NewParserWithOptions(NewLexerWithOptions(input, options), options), options)
+}
+
+// parse converts the AST read by parser to an Expression or Proposition
+func parse(parser *Parser, options Options) (interface{}, error) {
 	ast, err := parser.Parse()
 	if err != nil {
 		return nil, fmt.Errorf("parse error: %w", err)
 	}
 
-	converter := NewConverter()
+	converter := NewConverterWithDefinitions(options.Definitions)
 	result, err := converter.Convert(ast)
 	if err != nil {
 		return nil, fmt.Errorf("conversion error: %w", err)
@@ -401,7 +1515,7 @@ func ParseAndEval(input string) (*value.RealValue, error) {
 		return nil, fmt.Errorf("evaluation failed")
 	}
 
-	num, ok := evalResult.(*value.RealValue)
+	num, ok := value.ToReal(evalResult)
 	if !ok {
 		return nil, fmt.Errorf("result is not a number")
 	}
@@ -1,6 +1,12 @@
 package latex
 
-import "testing"
+import (
+	"errors"
+	"exprtree/expr"
+	"fmt"
+	"strings"
+	"testing"
+)
 
 func TestParser_Number(t *testing.T) {
 	input := "42"
@@ -647,7 +653,7 @@ func TestParser_SqrtInExpression(t *testing.T) {
 }
 
 func TestParser_ErrorSqrtMissingBrace(t *testing.T) {
-	input := "\\sqrt 4"
+	input := "\\sqrt + 4"
 	lexer := NewLexer(input)
 	parser := NewParser(lexer)
 
@@ -869,3 +875,903 @@ func TestParser_ErrorEqualIncomplete(t *testing.T) {
 		t.Errorf("expected error for incomplete equal expression")
 	}
 }
+
+func TestParser_Function(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		argument string // NodeType of the argument
+		base     bool
+		exponent bool
+	}{
+		{"\\sin x", "sin", "VariableNode", false, false},
+		{"\\sin(x + 1)", "sin", "GroupNode", false, false},
+		{"\\sin 2x", "sin", "BinaryOpNode", false, false},
+		{"\\sin^2 x", "sin", "VariableNode", false, true},
+		{"\\cos^{2}(x)", "cos", "GroupNode", false, true},
+		{"\\log_{2} x", "log", "VariableNode", true, false},
+		{"\\log_2 8", "log", "NumberNode", true, false},
+		{"\\ln x^2", "ln", "BinaryOpNode", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parser := NewParser(NewLexer(tt.input))
+			node, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			function, ok := node.(*FunctionNode)
+			if !ok {
+				t.Fatalf("expected FunctionNode, got %T", node)
+			}
+			if function.Name != tt.name {
+				t.Errorf("expected name %s, got %s", tt.name, function.Name)
+			}
+			if function.Argument.NodeType() != tt.argument {
+				t.Errorf("expected argument %s, got %s", tt.argument, function.Argument.NodeType())
+			}
+			if (function.Base != nil) != tt.base {
+				t.Errorf("expected base present: %v", tt.base)
+			}
+			if (function.Exponent != nil) != tt.exponent {
+				t.Errorf("expected exponent present: %v", tt.exponent)
+			}
+		})
+	}
+}
+
+func TestParser_FunctionProducts(t *testing.T) {
+	// \sin x \cos x is sin(x) * cos(x), not sin(x * cos(x))
+	parser := NewParser(NewLexer("\\sin x \\cos x"))
+	node, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	binOp, ok := node.(*BinaryOpNode)
+	if !ok || binOp.Operator.Type != MULTIPLY {
+		t.Fatalf("expected multiplication, got %T", node)
+	}
+	if _, ok := binOp.Left.(*FunctionNode); !ok {
+		t.Errorf("expected FunctionNode on left, got %T", binOp.Left)
+	}
+	if _, ok := binOp.Right.(*FunctionNode); !ok {
+		t.Errorf("expected FunctionNode on right, got %T", binOp.Right)
+	}
+}
+
+func TestParser_ErrorFunction(t *testing.T) {
+	tests := []string{
+		"\\sin",
+		"\\sin_{2} x",
+		"\\log_ x",
+		"\\sin^2^3 x",
+		"\\cosh^{-1} x",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			parser := NewParser(NewLexer(input))
+			if _, err := parser.Parse(); err == nil {
+				t.Errorf("expected error for input: %s", input)
+			}
+		})
+	}
+}
+
+func TestParser_Fence(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  FenceKind
+	}{
+		{"|x|", FenceAbs},
+		{"|x - 1|", FenceAbs},
+		{"\\left| x \\right|", FenceAbs},
+		{"||x| - 1|", FenceAbs},
+		{"\\lfloor x / 2 \\rfloor", FenceFloor},
+		{"\\lceil x \\rceil", FenceCeil},
+		{"\\left\\lfloor x \\right\\rfloor", FenceFloor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parser := NewParser(NewLexer(tt.input))
+			node, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			fence, ok := node.(*FenceNode)
+			if !ok {
+				t.Fatalf("expected FenceNode, got %T", node)
+			}
+			if fence.Kind != tt.kind {
+				t.Errorf("expected kind %v, got %v", tt.kind, fence.Kind)
+			}
+		})
+	}
+}
+
+func TestParser_AbsImplicitMultiply(t *testing.T) {
+	// |x||y| is |x| * |y|
+	parser := NewParser(NewLexer("|x||y|"))
+	node, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	binOp, ok := node.(*BinaryOpNode)
+	if !ok || binOp.Operator.Type != MULTIPLY {
+		t.Fatalf("expected multiplication, got %T", node)
+	}
+	if _, ok := binOp.Left.(*FenceNode); !ok {
+		t.Errorf("expected FenceNode on left, got %T", binOp.Left)
+	}
+	if _, ok := binOp.Right.(*FenceNode); !ok {
+		t.Errorf("expected FenceNode on right, got %T", binOp.Right)
+	}
+}
+
+func TestParser_LeftRightGroup(t *testing.T) {
+	parser := NewParser(NewLexer("\\left( 1 + 2 \\right) * 3"))
+	node, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	binOp, ok := node.(*BinaryOpNode)
+	if !ok {
+		t.Fatalf("expected BinaryOpNode, got %T", node)
+	}
+	if _, ok := binOp.Left.(*GroupNode); !ok {
+		t.Errorf("expected GroupNode on left, got %T", binOp.Left)
+	}
+}
+
+func TestParser_ErrorFence(t *testing.T) {
+	tests := []string{
+		"|x",
+		"\\lfloor x",
+		"\\lfloor x \\rceil",
+		"\\left( x \\right]",
+		"\\left x \\right|",
+		"x \\rfloor",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			parser := NewParser(NewLexer(input))
+			if _, err := parser.Parse(); err == nil {
+				t.Errorf("expected error for input: %s", input)
+			}
+		})
+	}
+}
+
+func TestParser_FactorialPrecedence(t *testing.T) {
+	// 2^3! is 2^(3!)
+	node, err := NewParser(NewLexer("2^3!")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	binOp, ok := node.(*BinaryOpNode)
+	if !ok || binOp.Operator.Type != CARET {
+		t.Fatalf("expected power, got %T", node)
+	}
+	if _, ok := binOp.Right.(*FactorialNode); !ok {
+		t.Errorf("expected FactorialNode as exponent, got %T", binOp.Right)
+	}
+
+	// 3!^2 is (3!)^2
+	node, err = NewParser(NewLexer("3!^2")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	binOp, ok = node.(*BinaryOpNode)
+	if !ok || binOp.Operator.Type != CARET {
+		t.Fatalf("expected power, got %T", node)
+	}
+	if _, ok := binOp.Left.(*FactorialNode); !ok {
+		t.Errorf("expected FactorialNode as base, got %T", binOp.Left)
+	}
+
+	// -3! is -(3!)
+	node, err = NewParser(NewLexer("-3!")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	minus, ok := node.(*UnaryMinusNode)
+	if !ok {
+		t.Fatalf("expected UnaryMinusNode, got %T", node)
+	}
+	if _, ok := minus.Operand.(*FactorialNode); !ok {
+		t.Errorf("expected FactorialNode operand, got %T", minus.Operand)
+	}
+}
+
+func TestParser_UnaryMinusPower(t *testing.T) {
+	// -2^2 is -(2^2)
+	node, err := NewParser(NewLexer("-2^2")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	minus, ok := node.(*UnaryMinusNode)
+	if !ok {
+		t.Fatalf("expected UnaryMinusNode, got %T", node)
+	}
+	if power, ok := minus.Operand.(*BinaryOpNode); !ok || power.Operator.Type != CARET {
+		t.Errorf("expected power operand, got %T", minus.Operand)
+	}
+
+	// -2x is (-2) * x
+	node, err = NewParser(NewLexer("-2x")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	product, ok := node.(*BinaryOpNode)
+	if !ok || product.Operator.Type != MULTIPLY {
+		t.Fatalf("expected product, got %T", node)
+	}
+	if _, ok := product.Left.(*UnaryMinusNode); !ok {
+		t.Errorf("expected UnaryMinusNode as left operand, got %T", product.Left)
+	}
+}
+
+func TestParser_Binom(t *testing.T) {
+	node, err := NewParser(NewLexer("\\binom{n}{k + 1}")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	cmd, ok := node.(*CommandNode)
+	if !ok || cmd.Name != "binom" {
+		t.Fatalf("expected binom CommandNode, got %T", node)
+	}
+	if _, ok := cmd.Argument.(*VariableNode); !ok {
+		t.Errorf("expected VariableNode as first argument, got %T", cmd.Argument)
+	}
+	if _, ok := cmd.Second.(*BinaryOpNode); !ok {
+		t.Errorf("expected BinaryOpNode as second argument, got %T", cmd.Second)
+	}
+}
+
+func TestParser_Frac(t *testing.T) {
+	for _, name := range []string{"frac", "dfrac", "tfrac"} {
+		t.Run(name, func(t *testing.T) {
+			node, err := NewParser(NewLexer("\\" + name + "{1}{x + 1}")).Parse()
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			cmd, ok := node.(*CommandNode)
+			if !ok || cmd.Name != name {
+				t.Fatalf("expected %s CommandNode, got %T", name, node)
+			}
+			if _, ok := cmd.Argument.(*NumberNode); !ok {
+				t.Errorf("expected NumberNode as numerator, got %T", cmd.Argument)
+			}
+			if _, ok := cmd.Second.(*BinaryOpNode); !ok {
+				t.Errorf("expected BinaryOpNode as denominator, got %T", cmd.Second)
+			}
+		})
+	}
+}
+
+func TestParser_UnbracedArguments(t *testing.T) {
+	// TeX takes a single digit, letter or control word as an argument
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"\\frac12", "\\frac{1}{2}"},
+		{"\\frac 1 2", "\\frac{1}{2}"},
+		{"\\frac123", "\\frac{1}{2} 3"},
+		{"\\frac1{x + 1}", "\\frac{1}{x + 1}"},
+		{"\\binom nk", "\\binom{n}{k}"},
+		{"\\frac\\alpha 2", "\\frac{\\alpha}{2}"},
+		{"\\sqrt2 + \\sqrt[3]8", "\\sqrt{2} + \\sqrt[3]{8}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseLatex(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLatex failed: %v", err)
+			}
+			expected, err := ParseLatex(tt.expected)
+			if err != nil {
+				t.Fatalf("ParseLatex failed: %v", err)
+			}
+			if !result.(expr.Expr).Equals(expected) {
+				t.Errorf("Expected %v, got %v", expected, result)
+			}
+		})
+	}
+}
+
+func TestParser_ErrorCombinatorics(t *testing.T) {
+	tests := []string{
+		"!",
+		"\\binom{n}",
+		"\\binom{n}+",
+		"\\frac{1}",
+		"\\frac[2]{1}{2}",
+		"2 + !",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			parser := NewParser(NewLexer(input))
+			if _, err := parser.Parse(); err == nil {
+				t.Errorf("expected error for input: %s", input)
+			}
+		})
+	}
+}
+
+func TestParser_BigOperator(t *testing.T) {
+	node, err := NewParser(NewLexer("\\sum_{i=1}^{n} 2i + 1")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	// The body ends at +
+	binOp, ok := node.(*BinaryOpNode)
+	if !ok || binOp.Operator.Type != PLUS {
+		t.Fatalf("expected addition, got %T", node)
+	}
+	sum, ok := binOp.Left.(*BigOperatorNode)
+	if !ok {
+		t.Fatalf("expected BigOperatorNode, got %T", binOp.Left)
+	}
+	if sum.Name != "sum" || sum.Index.Name != "i" {
+		t.Errorf("expected \\sum over i, got \\%s over %s", sum.Name, sum.Index.Name)
+	}
+	if _, ok := sum.Body.(*BinaryOpNode); !ok {
+		t.Errorf("expected product body, got %T", sum.Body)
+	}
+}
+
+func TestParser_ErrorBigOperator(t *testing.T) {
+	tests := []string{
+		"\\sum i",
+		"\\sum_{i=1} i",
+		"\\sum^{n} i",
+		"\\sum_{1=1}^{n} i",
+		"\\sum_{i}^{n} i",
+		"\\prod_{i=1}^{n}",
+		"\\sum_{i=1}_{j=1}^{n} i",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			parser := NewParser(NewLexer(input))
+			if _, err := parser.Parse(); err == nil {
+				t.Errorf("expected error for input: %s", input)
+			}
+		})
+	}
+}
+
+func TestParser_Integral(t *testing.T) {
+	node, err := NewParser(NewLexer("\\int_{0}^{1} x^2 + \\sin x \\, dx + 1")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	// The integrand extends up to the differential
+	binOp, ok := node.(*BinaryOpNode)
+	if !ok || binOp.Operator.Type != PLUS {
+		t.Fatalf("expected addition, got %T", node)
+	}
+	integral, ok := binOp.Left.(*IntegralNode)
+	if !ok {
+		t.Fatalf("expected IntegralNode, got %T", binOp.Left)
+	}
+	if integral.Variable.Name != "x" || integral.Lower == nil || integral.Upper == nil {
+		t.Errorf("unexpected integral: %+v", integral)
+	}
+	if sum, ok := integral.Integrand.(*BinaryOpNode); !ok || sum.Operator.Type != PLUS {
+		t.Errorf("expected sum integrand, got %T", integral.Integrand)
+	}
+
+	node, err = NewParser(NewLexer("\\int t dt")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	integral, ok = node.(*IntegralNode)
+	if !ok || integral.Lower != nil || integral.Variable.Name != "t" {
+		t.Errorf("expected indefinite integral over t, got %T", node)
+	}
+}
+
+func TestParser_ErrorIntegral(t *testing.T) {
+	tests := []string{
+		"\\int x",
+		"\\int_{0} x dx",
+		"\\int^{1} x dx",
+		"\\int_{0}^{1} dx",
+		"\\int_{0}^{1}_{2} x dx",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			parser := NewParser(NewLexer(input))
+			if _, err := parser.Parse(); err == nil {
+				t.Errorf("expected error for input: %s", input)
+			}
+		})
+	}
+}
+
+func TestParser_Limit(t *testing.T) {
+	tests := []struct {
+		input     string
+		direction string
+	}{
+		{"\\lim_{x \\to 1} (x^2 - 1) / (x - 1)", ""},
+		{"\\lim_{x \\to 0^+} 1 / x", "+"},
+		{"\\lim_{x \\to 0^{-}} 1 / x", "-"},
+		{"\\lim_{x \\to a + 1^{+}} x", "+"},
+		{"\\lim_{x \\to \\infty} 1 / x", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			node, err := NewParser(NewLexer(tt.input)).Parse()
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			limit, ok := node.(*LimitNode)
+			if !ok {
+				t.Fatalf("expected LimitNode, got %T", node)
+			}
+			if limit.Variable.Name != "x" || limit.Direction != tt.direction {
+				t.Errorf("unexpected limit: variable %s, direction %q", limit.Variable.Name, limit.Direction)
+			}
+		})
+	}
+
+	// The body ends at + or -, and a power in the point is not a direction
+	node, err := NewParser(NewLexer("\\lim_{x \\to 2^2} x + 1")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	binOp, ok := node.(*BinaryOpNode)
+	if !ok || binOp.Operator.Type != PLUS {
+		t.Fatalf("expected addition, got %T", node)
+	}
+	limit, ok := binOp.Left.(*LimitNode)
+	if !ok {
+		t.Fatalf("expected LimitNode, got %T", binOp.Left)
+	}
+	if power, ok := limit.Point.(*BinaryOpNode); !ok || power.Operator.Type != CARET || limit.Direction != "" {
+		t.Errorf("expected point 2^2 without direction, got %T %q", limit.Point, limit.Direction)
+	}
+}
+
+func TestParser_ErrorLimit(t *testing.T) {
+	tests := []string{
+		"\\lim x",
+		"\\lim_{x} x",
+		"\\lim_{x = 0} x",
+		"\\lim_{x \\to 0",
+		"\\lim_{x \\to 0}",
+		"x \\to 0",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			parser := NewParser(NewLexer(input))
+			if _, err := parser.Parse(); err == nil {
+				t.Errorf("expected error for input: %s", input)
+			}
+		})
+	}
+}
+
+func TestParser_Cases(t *testing.T) {
+	input := "\\begin{cases} -x, & x < 0 \\\\ x^2 & \\text{if } 0 \\le x < 1 \\\\ 1 & \\text{otherwise} \\end{cases}"
+	node, err := NewParser(NewLexer(input)).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	cases, ok := node.(*CasesNode)
+	if !ok {
+		t.Fatalf("expected CasesNode, got %T", node)
+	}
+	if len(cases.Rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(cases.Rows))
+	}
+	if _, ok := cases.Rows[0].Value.(*UnaryMinusNode); !ok {
+		t.Errorf("expected -x in the first row, got %T", cases.Rows[0].Value)
+	}
+	chain, ok := cases.Rows[1].Condition.(*EqualNode)
+	if !ok || chain.Operator.Type != LESS {
+		t.Fatalf("expected chained condition ending in <, got %T", cases.Rows[1].Condition)
+	}
+	if first, ok := chain.Left.(*EqualNode); !ok || first.Operator.Type != LESSEQ {
+		t.Errorf("expected 0 \\le x in the chain, got %T", chain.Left)
+	}
+	if cases.Rows[2].Condition != nil {
+		t.Errorf("expected otherwise row, got %T", cases.Rows[2].Condition)
+	}
+}
+
+func TestParser_ErrorCases(t *testing.T) {
+	tests := []string{
+		"\\begin{cases} 1 \\end{cases}",
+		"\\begin{cases} 1 & x < 0",
+		"\\begin{cases} 1 & x < 0 \\end{case}",
+		"\\begin{cases} 1 & \\text{otherwise} \\\\ 2 & x < 0 \\end{cases}",
+		"\\begin{cases} 1 & \\text{sometimes} \\end{cases}",
+		"\\begin{cases} 1 & x < 0 2 & x > 0 \\end{cases}",
+		"\\begin{matrix} 1 \\end{matrix}",
+		"x \\end{cases}",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			parser := NewParser(NewLexer(input))
+			if _, err := parser.Parse(); err == nil {
+				t.Errorf("expected error for input: %s", input)
+			}
+		})
+	}
+}
+
+func TestParser_Call(t *testing.T) {
+	definitions := expr.NewDefinitions()
+	definitions.Define(expr.NewDefinition("f", []string{"x", "y"}, expr.NewVariable("x")))
+	options := Options{Definitions: definitions}
+
+	node, err := NewParserWithOptions(NewLexer("2f(1, a + 1) g(x)"), options).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	// ((2 * f(1, a + 1)) * g) * (x): g is not defined
+	product, ok := node.(*BinaryOpNode)
+	if !ok {
+		t.Fatalf("expected product, got %T", node)
+	}
+	if _, ok := product.Right.(*GroupNode); !ok {
+		t.Errorf("expected implicit multiplication by (x), got %T", product.Right)
+	}
+	inner, ok := product.Left.(*BinaryOpNode)
+	if !ok {
+		t.Fatalf("expected product, got %T", product.Left)
+	}
+	left, ok := inner.Left.(*BinaryOpNode)
+	if !ok {
+		t.Fatalf("expected 2 * f(...), got %T", inner.Left)
+	}
+	call, ok := left.Right.(*CallNode)
+	if !ok || call.Name.Name != "f" || len(call.Arguments) != 2 {
+		t.Fatalf("expected call of f with two arguments, got %T", left.Right)
+	}
+
+	// Without definitions f(x) stays an implicit multiplication
+	node, err = NewParser(NewLexer("f(x)")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if _, ok := node.(*BinaryOpNode); !ok {
+		t.Errorf("expected implicit multiplication, got %T", node)
+	}
+}
+
+func TestParser_Declaration(t *testing.T) {
+	options := Options{Definitions: expr.NewDefinitions()}
+
+	node, err := NewParserWithOptions(NewLexer("g(n, m) = n g(n - 1, m)"), options).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	declaration, ok := node.(*DefinitionNode)
+	if !ok {
+		t.Fatalf("expected DefinitionNode, got %T", node)
+	}
+	if declaration.Name.Name != "g" || len(declaration.Params) != 2 || declaration.Params[1].Name != "m" {
+		t.Errorf("unexpected declaration: %+v", declaration)
+	}
+	// The function may call itself
+	body, ok := declaration.Body.(*BinaryOpNode)
+	if !ok {
+		t.Fatalf("expected product body, got %T", declaration.Body)
+	}
+	if _, ok := body.Right.(*CallNode); !ok {
+		t.Errorf("expected recursive call, got %T", body.Right)
+	}
+
+	// Arguments that are not plain variables make an equation
+	node, err = NewParserWithOptions(NewLexer("a(b + c) = ab + ac"), options).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if _, ok := node.(*EqualNode); !ok {
+		t.Errorf("expected equation, got %T", node)
+	}
+}
+
+func TestParser_ErrorCall(t *testing.T) {
+	definitions := expr.NewDefinitions()
+	definitions.Define(expr.NewDefinition("f", []string{"x"}, expr.NewVariable("x")))
+	tests := []string{
+		"f()",
+		"f(1",
+		"f(1 & 2)",
+		"f(1,)",
+		"g(x) =",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			parser := NewParserWithOptions(NewLexer(input), Options{Definitions: definitions})
+			if _, err := parser.Parse(); err == nil {
+				t.Errorf("expected error for input: %s", input)
+			}
+		})
+	}
+}
+
+func TestParser_Identifier(t *testing.T) {
+	tests := []struct {
+		input    string
+		options  Options
+		expected string
+	}{
+		{"x", Options{}, "x"},
+		{"x_1", Options{}, "x_1"},
+		{"x_12", Options{}, "x_12"},
+		{"x_{ij}", Options{}, "x_ij"},
+		{"v_{max}", Options{}, "v_max"},
+		{"\\alpha", Options{}, "α"},
+		{"\\Omega_{\\alpha 1}", Options{}, "Ω_α1"},
+		{"x_\\beta", Options{}, "x_β"},
+		{"\\mathrm{rate}", Options{}, "rate"},
+		{"\\text{v2}_0", Options{}, "v2_0"},
+		{"xy", Options{Identifiers: JoinLetters}, "xy"},
+		{"xy_1", Options{Identifiers: JoinLetters}, "xy_1"},
+		{"ab", Options{Identifiers: KnownNames, Names: []string{"ab"}}, "ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			node, err := NewParserWithOptions(NewLexer(tt.input), tt.options).Parse()
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			variable, ok := node.(*VariableNode)
+			if !ok {
+				t.Fatalf("expected VariableNode, got %T", node)
+			}
+			if variable.Name != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, variable.Name)
+			}
+		})
+	}
+}
+
+func TestParser_IdentifierPolicy(t *testing.T) {
+	tests := []struct {
+		input    string
+		options  Options
+		expected []string // names of the factors of the product
+	}{
+		{"xy", Options{}, []string{"x", "y"}},
+		{"x y", Options{Identifiers: JoinLetters}, []string{"x", "y"}},
+		{"2rate", Options{Identifiers: JoinLetters}, []string{"2", "rate"}},
+		{"abc", Options{Identifiers: KnownNames, Names: []string{"ab"}}, []string{"ab", "c"}},
+		{"cab", Options{Identifiers: KnownNames, Names: []string{"ab"}}, []string{"c", "ab"}},
+		{"x\\alpha", Options{Identifiers: JoinLetters}, []string{"x", "α"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			node, err := NewParserWithOptions(NewLexer(tt.input), tt.options).Parse()
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			product, ok := node.(*BinaryOpNode)
+			if !ok || product.Operator.Type != MULTIPLY {
+				t.Fatalf("expected product, got %T", node)
+			}
+			names := []string{}
+			for _, factor := range []LatexNode{product.Left, product.Right} {
+				switch f := factor.(type) {
+				case *VariableNode:
+					names = append(names, f.Name)
+				case *NumberNode:
+					names = append(names, f.Token.Literal)
+				}
+			}
+			if strings.Join(names, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("expected factors %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestParser_IdentifierInIntegral(t *testing.T) {
+	node, err := NewParserWithOptions(NewLexer("\\int_{0}^{1} xdx"), Options{Identifiers: JoinLetters}).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	integral, ok := node.(*IntegralNode)
+	if !ok {
+		t.Fatalf("expected IntegralNode, got %T", node)
+	}
+	if integrand, ok := integral.Integrand.(*VariableNode); !ok || integrand.Name != "x" {
+		t.Errorf("expected integrand x, got %T", integral.Integrand)
+	}
+	if integral.Variable.Name != "x" {
+		t.Errorf("expected variable x, got %s", integral.Variable.Name)
+	}
+
+	node, err = NewParser(NewLexer("\\lim_{\\theta \\to 0} \\theta_1")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if limit, ok := node.(*LimitNode); !ok || limit.Variable.Name != "θ" {
+		t.Errorf("expected limit in θ, got %T", node)
+	}
+}
+
+func TestParser_ErrorIdentifier(t *testing.T) {
+	tests := []string{
+		"x_",
+		"x_{}",
+		"x_{i + 1}",
+		"x_+",
+		"\\mathrm{}",
+		"\\mathrm x",
+		"x_{1.5}",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			parser := NewParser(NewLexer(input))
+			if _, err := parser.Parse(); err == nil {
+				t.Errorf("expected error for input: %s", input)
+			}
+		})
+	}
+}
+
+func TestParser_ScientificNotation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"6.02 \\times 10^{23}", 6.02e23},
+		{"1.5 \\cdot 10^{-3}", 1.5e-3},
+		{"2 \\times 10^3", 2000},
+		{"1{,}500 \\times 10^{+2}", 150000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			node, err := NewParser(NewLexer(tt.input)).Parse()
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			number, ok := node.(*NumberNode)
+			if !ok {
+				t.Fatalf("expected NumberNode, got %T", node)
+			}
+			if number.Value != tt.expected {
+				t.Errorf("expected %g, got %g", tt.expected, number.Value)
+			}
+		})
+	}
+
+	// Only a whole term folds: the exponent of 2^3 \times 10^2 is 3
+	node, err := NewParser(NewLexer("2^3 \\times 10^2")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if product, ok := node.(*BinaryOpNode); !ok || product.Operator.Type != MULTIPLY {
+		t.Errorf("expected product, got %T", node)
+	}
+
+	// 10^{x} is not scientific notation
+	node, err = NewParser(NewLexer("3 \\times 10^{x}")).Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if _, ok := node.(*BinaryOpNode); !ok {
+		t.Errorf("expected product, got %T", node)
+	}
+}
+
+func TestParser_ParseError(t *testing.T) {
+	tests := []struct {
+		input   string
+		code    ErrorCode
+		message string
+		line    int
+		column  int
+		excerpt string
+	}{
+		{"(1 + 2", ErrExpectedToken, "expected ')'", 1, 7, "(1 + 2\n      ^"},
+		{"2 +", ErrUnexpectedToken, "unexpected end of input", 1, 4, "2 +\n   ^"},
+		{"1 +\n  2 * )", ErrUnexpectedToken, "unexpected token )", 2, 7, "  2 * )\n      ^"},
+		{"2 + \\foo - 3", ErrIllegalToken, "unknown command \\foo", 1, 5, "2 + \\foo - 3\n    ^^^^"},
+		{"x_{1.5}", ErrExpectedToken, "expected a subscript name", 1, 4, "x_{1.5}\n   ^^^"},
+		{"1 + α · 2", ErrIllegalToken, "illegal character α", 1, 5, "1 + α · 2\n    ^"},
+		{"1{,}00 + x", ErrInvalidSyntax, "digit groups separated by {,} must have three digits in 1{,}00", 1, 1, "1{,}00 + x\n^^^^^^"},
+		{"\\ln^{-1} x", ErrUnsupported, "\\ln^{-1} is ambiguous; write (\\ln x)^{-1} for the reciprocal", 1, 1, "\\ln^{-1} x\n^^^"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := NewParser(NewLexer(tt.input)).Parse()
+			var errs ParseErrors
+			if !errors.As(err, &errs) || len(errs) == 0 {
+				t.Fatalf("expected ParseErrors, got %v", err)
+			}
+			got := errs[0]
+			if got.Code != tt.code {
+				t.Errorf("expected code %s, got %s", tt.code, got.Code)
+			}
+			if got.Message != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, got.Message)
+			}
+			if got.Span.Start.Line != tt.line || got.Span.Start.Column != tt.column {
+				t.Errorf("expected %d:%d, got %d:%d", tt.line, tt.column, got.Span.Start.Line, got.Span.Start.Column)
+			}
+			if got.Excerpt != tt.excerpt {
+				t.Errorf("expected excerpt\n%s\ngot\n%s", tt.excerpt, got.Excerpt)
+			}
+		})
+	}
+}
+
+func TestParser_ErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input   string
+		columns []int
+	}{
+		{"(1 + ) * (2 * )", []int{6, 15}},
+		{"x_{1.5} + y_", []int{4, 13}},
+		{"(1 + 2)) + (3 * )", []int{8, 17}},
+		{"1 2 )) 3 +", []int{5, 11}},
+		{"\\frac{1 + }{2 * }", []int{11, 17}},
+		{"\\sqrt{} + 1 +", []int{7, 14}},
+		{"1 + \\foo + \\bar", []int{5, 12}},
+		{"1 + \\foo * 2 + (3 +)", []int{5, 20}},
+		{"\\sqrt + \\foo", []int{7, 9}},
+		{"a \\rfloor + \\foo", []int{3, 13}},
+		{"\\lceil \\rceil", []int{8}},
+		{"\\lim_{x \\to 0} \\to", []int{16}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := NewParser(NewLexer(tt.input)).Parse()
+			var errs ParseErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected ParseErrors, got %v", err)
+			}
+			columns := make([]int, len(errs))
+			for i, e := range errs {
+				columns[i] = e.Span.Start.Column
+			}
+			if fmt.Sprint(columns) != fmt.Sprint(tt.columns) {
+				t.Errorf("expected errors at columns %v, got %v (%v)", tt.columns, columns, err)
+			}
+		})
+	}
+
+	// ParseLatex wraps the errors
+	_, err := ParseLatex("(1 + ) * (2 * )")
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("expected two ParseErrors from ParseLatex, got %v", err)
+	}
+	expected := "parse error: 1:6: unexpected token ); 1:15: unexpected token )"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,679 @@
+package latex
+
+import (
+	"exprtree/expr"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// PrettyOptions configures PrettyRenderer
+type PrettyOptions struct {
+	// ASCII draws with ASCII characters only instead of Unicode box drawing
+	ASCII bool
+	// Width breaks long sums and relation chains into lines of at most this
+	// many columns; 0 disables line breaking
+	Width int
+}
+
+// PrettyRenderer lays LaTeX AST out in two dimensions for the terminal
+// Fractions are stacked, exponents raised and radicals drawn. Parentheses
+// are placed with the same precedence rules as Renderer.
+type PrettyRenderer struct {
+	options PrettyOptions
+	text    *Renderer // renders numbers, names and operators
+}
+
+// NewPrettyRenderer creates a new PrettyRenderer instance
+func NewPrettyRenderer(options PrettyOptions) *PrettyRenderer {
+	style := UnicodeStyle
+	if options.ASCII {
+		style = ASCIIStyle
+	}
+	return &PrettyRenderer{
+		options: options,
+		text:    NewRendererWithOptions(RenderOptions{Style: style}),
+	}
+}
+
+// Render lays a LatexNode out as lines of text without trailing spaces
+func (r *PrettyRenderer) Render(node LatexNode) string {
+	result := r.renderNode(node, LOWEST, false)
+	if r.options.Width > 0 && result.width() > r.options.Width {
+		result = r.wrap(node)
+	}
+	lines := make([]string, len(result.lines))
+	for i, line := range result.lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// box is a block of text lines of equal width
+// The baseline is the line that lines up with the surrounding text, such as
+// the fraction bar of a fraction.
+type box struct {
+	lines    []string
+	baseline int
+}
+
+// newBox creates a box, padding its lines to the same width
+func newBox(lines []string, baseline int) *box {
+	width := 0
+	for _, line := range lines {
+		width = max(width, utf8.RuneCountInString(line))
+	}
+	padded := make([]string, len(lines))
+	for i, line := range lines {
+		padded[i] = padRight(line, width)
+	}
+	return &box{lines: padded, baseline: baseline}
+}
+
+// textBox creates a box of a single line
+func textBox(text string) *box {
+	return &box{lines: []string{text}}
+}
+
+func (b *box) width() int {
+	return utf8.RuneCountInString(b.lines[0])
+}
+
+func (b *box) height() int {
+	return len(b.lines)
+}
+
+func padRight(line string, width int) string {
+	return line + strings.Repeat(" ", width-utf8.RuneCountInString(line))
+}
+
+// center pads a line on both sides to width, with any odd space on the right
+func center(line string, width int) string {
+	left := (width - utf8.RuneCountInString(line)) / 2
+	return padRight(strings.Repeat(" ", left)+line, width)
+}
+
+// hcat joins boxes side by side on a common baseline
+func hcat(boxes ...*box) *box {
+	ascent, descent := 0, 0
+	for _, b := range boxes {
+		ascent = max(ascent, b.baseline)
+		descent = max(descent, b.height()-b.baseline-1)
+	}
+	lines := make([]string, ascent+descent+1)
+	for _, b := range boxes {
+		blank := strings.Repeat(" ", b.width())
+		for row := range lines {
+			if i := row - ascent + b.baseline; i >= 0 && i < b.height() {
+				lines[row] += b.lines[i]
+			} else {
+				lines[row] += blank
+			}
+		}
+	}
+	return &box{lines: lines, baseline: ascent}
+}
+
+// stack places boxes above each other, centered, with the baseline at the
+// given line
+func stack(baseline int, boxes ...*box) *box {
+	width := 0
+	for _, b := range boxes {
+		width = max(width, b.width())
+	}
+	var lines []string
+	for _, b := range boxes {
+		for _, line := range b.lines {
+			lines = append(lines, center(line, width))
+		}
+	}
+	return &box{lines: lines, baseline: baseline}
+}
+
+// scripts attaches a superscript above and a subscript below the right of
+// a nucleus; either may be nil
+func scripts(nucleus, superscript, subscript *box) *box {
+	width := 0
+	for _, script := range []*box{superscript, subscript} {
+		if script != nil {
+			width = max(width, script.width())
+		}
+	}
+	indent := strings.Repeat(" ", nucleus.width())
+	var lines []string
+	baseline := nucleus.baseline
+	if superscript != nil {
+		for _, line := range superscript.lines {
+			lines = append(lines, indent+padRight(line, width))
+		}
+		baseline += superscript.height()
+	}
+	for _, line := range nucleus.lines {
+		lines = append(lines, line+strings.Repeat(" ", width))
+	}
+	if subscript != nil {
+		for _, line := range subscript.lines {
+			lines = append(lines, indent+padRight(line, width))
+		}
+	}
+	return &box{lines: lines, baseline: baseline}
+}
+
+// delimiter is a delimiter drawn in one line or stretched over several
+type delimiter struct {
+	single, top, middle, bottom string
+	center                      string // middle piece of odd heights, or "" for middle
+}
+
+// prettyDelimiters draw parentheses, bars, brackets and braces
+var prettyDelimiters = map[bool]map[string]delimiter{
+	false: {
+		"(": {"(", "⎛", "⎜", "⎝", ""},
+		")": {")", "⎞", "⎟", "⎠", ""},
+		"|": {"|", "│", "│", "│", ""},
+		"⌊": {"⌊", "⎢", "⎢", "⎣", ""},
+		"⌋": {"⌋", "⎥", "⎥", "⎦", ""},
+		"⌈": {"⌈", "⎡", "⎢", "⎢", ""},
+		"⌉": {"⌉", "⎤", "⎥", "⎥", ""},
+		"{": {"{", "⎧", "⎪", "⎩", "⎨"},
+	},
+	true: {
+		"(": {"(", "/", "|", "\\", ""},
+		")": {")", "\\", "|", "/", ""},
+		"|": {"|", "|", "|", "|", ""},
+		"{": {"{", "/", "|", "\\", "<"},
+	},
+}
+
+// draw stretches a delimiter over height lines
+func (d delimiter) draw(height int) *box {
+	if height == 1 {
+		return textBox(d.single)
+	}
+	lines := make([]string, height)
+	for i := range lines {
+		switch {
+		case i == 0:
+			lines[i] = d.top
+		case i == height-1:
+			lines[i] = d.bottom
+		case i == (height-1)/2 && d.center != "":
+			lines[i] = d.center
+		default:
+			lines[i] = d.middle
+		}
+	}
+	return &box{lines: lines}
+}
+
+// fence encloses a box in delimiters as tall as the box
+func (r *PrettyRenderer) fence(open string, content *box, close string) *box {
+	delimiters := prettyDelimiters[r.options.ASCII]
+	left := delimiters[open].draw(content.height())
+	left.baseline = content.baseline
+	right := delimiters[close].draw(content.height())
+	right.baseline = content.baseline
+	return hcat(left, content, right)
+}
+
+func (r *PrettyRenderer) parens(content *box) *box {
+	return r.fence("(", content, ")")
+}
+
+// renderNode lays a LatexNode out with precedence handling
+// parentPrec and isRightOperand have the same meaning as in Renderer.
+func (r *PrettyRenderer) renderNode(node LatexNode, parentPrec int, isRightOperand bool) *box {
+	switch n := node.(type) {
+	case *NumberNode, *VariableNode:
+		return textBox(r.text.renderNode(n, parentPrec, isRightOperand))
+	case *UnaryMinusNode:
+		return r.renderUnaryMinus(n, parentPrec, isRightOperand)
+	case *BinaryOpNode:
+		return r.renderBinaryOp(n, ILLEGAL, parentPrec, isRightOperand)
+	case *GroupNode:
+		if n.Token.Type == LBRACE {
+			return r.renderNode(n.Inner, parentPrec, isRightOperand)
+		}
+		return r.parens(r.renderNode(n.Inner, LOWEST, false))
+	case *FunctionNode:
+		return r.renderFunction(n)
+	case *FenceNode:
+		return r.renderFence(n)
+	case *FactorialNode:
+		return hcat(r.renderNode(n.Operand, POSTFIX, false), textBox("!"))
+	case *CommandNode:
+		return r.renderCommand(n, parentPrec)
+	case *BigOperatorNode:
+		return r.renderBigOperator(n, parentPrec)
+	case *IntegralNode:
+		return r.renderIntegral(n, parentPrec)
+	case *LimitNode:
+		return r.renderLimit(n, parentPrec)
+	case *EqualNode:
+		return hcat(r.renderNode(n.Left, EQUALITY, false), r.operator(n.Operator), r.renderNode(n.Right, EQUALITY, true))
+	case *CasesNode:
+		return r.renderCases(n)
+	case *CallNode:
+		return r.renderCall(n.Name, n.Arguments)
+	case *DefinitionNode:
+		params := make([]LatexNode, len(n.Params))
+		for i, param := range n.Params {
+			params[i] = param
+		}
+		return hcat(r.renderCall(n.Name, params), r.operator(Token{Type: EQUAL, Literal: "="}), r.renderNode(n.Body, LOWEST, false))
+	default:
+		return textBox("")
+	}
+}
+
+// operator spells an operator, spacing all but products
+func (r *PrettyRenderer) operator(token Token) *box {
+	spelled := strings.TrimSpace(r.text.operator(token))
+	if token.Type == MULTIPLY {
+		return textBox(spelled)
+	}
+	return textBox(" " + spelled + " ")
+}
+
+// renderUnaryMinus lays out -x, with a fraction operand left unparenthesized
+func (r *PrettyRenderer) renderUnaryMinus(node *UnaryMinusNode, parentPrec int, isRightOperand bool) *box {
+	var operand *box
+	if binary, ok := node.Operand.(*BinaryOpNode); ok && binary.Operator.Type == DIVIDE {
+		operand = r.renderFrac(binary.Left, binary.Right, LOWEST)
+	} else {
+		operand = r.renderNode(node.Operand, POWER, false)
+	}
+	result := hcat(textBox("-"), operand)
+	if signNeedsParens(parentPrec, isRightOperand) {
+		return r.parens(result)
+	}
+	return result
+}
+
+// renderBinaryOp lays out an operation, stacking divisions as fractions
+// and raising exponents
+// parentType is the operator of the parent binary operation, or ILLEGAL.
+func (r *PrettyRenderer) renderBinaryOp(node *BinaryOpNode, parentType TokenType, parentPrec int, isRightOperand bool) *box {
+	switch node.Operator.Type {
+	case DIVIDE:
+		return r.renderFrac(node.Left, node.Right, parentPrec)
+	case CARET:
+		result := scripts(r.renderNode(node.Left, POSTFIX, false), r.renderNode(node.Right, LOWEST, false), nil)
+		if parentPrec > POWER {
+			return r.parens(result)
+		}
+		return result
+	}
+
+	opPrec := precedences[node.Operator.Type]
+	left := r.renderNode(node.Left, opPrec, false)
+	right := r.renderRightOperand(node, opPrec)
+	var result *box
+	if r.text.juxtaposes(node, strings.TrimLeft(right.lines[right.baseline], " ")) {
+		result = hcat(left, right)
+	} else {
+		result = hcat(left, r.operator(node.Operator), right)
+	}
+	if binaryNeedsParens(node.Operator.Type, parentType, parentPrec, isRightOperand) {
+		return r.parens(result)
+	}
+	return result
+}
+
+// renderRightOperand lays out the right operand of a binary operation
+// A binary operand is told the operator it is under, so that a - (b + c)
+// keeps its parentheses.
+func (r *PrettyRenderer) renderRightOperand(node *BinaryOpNode, opPrec int) *box {
+	if binary, ok := node.Right.(*BinaryOpNode); ok {
+		return r.renderBinaryOp(binary, node.Operator.Type, opPrec, true)
+	}
+	return r.renderNode(node.Right, opPrec, true)
+}
+
+// renderFrac stacks a numerator over a denominator
+// The bar delimits both operands, so only powers need parentheses.
+func (r *PrettyRenderer) renderFrac(numerator, denominator LatexNode, parentPrec int) *box {
+	top := r.renderNode(numerator, LOWEST, false)
+	bottom := r.renderNode(denominator, LOWEST, false)
+	bar := "─"
+	if r.options.ASCII {
+		bar = "-"
+	}
+	rule := textBox(strings.Repeat(bar, max(top.width(), bottom.width())))
+	result := stack(top.height(), top, rule, bottom)
+	if parentPrec >= POWER {
+		return r.parens(result)
+	}
+	return result
+}
+
+// renderFunction lays out sin(x), with the exponent of sin²(x) raised and
+// the base of log_2(x) lowered
+func (r *PrettyRenderer) renderFunction(node *FunctionNode) *box {
+	var exponent, base *box
+	if node.Exponent != nil {
+		exponent = r.renderNode(node.Exponent, LOWEST, false)
+	}
+	if node.Base != nil {
+		base = r.renderNode(node.Base, LOWEST, false)
+	}
+	name := textBox(node.Name)
+	if exponent != nil || base != nil {
+		name = scripts(name, exponent, base)
+	}
+	return hcat(name, r.parens(r.renderNode(node.Argument, LOWEST, false)))
+}
+
+// renderFence lays out |x|, ⌊x⌋ and ⌈x⌉; ASCII has no floor and ceiling
+// brackets and writes floor(x) and ceil(x)
+func (r *PrettyRenderer) renderFence(node *FenceNode) *box {
+	inner := r.renderNode(node.Inner, LOWEST, false)
+	if r.options.ASCII && node.Kind != FenceAbs {
+		return hcat(textBox(fenceNames[node.Kind]), r.parens(inner))
+	}
+	delimiters := fenceSymbols[node.Kind]
+	return r.fence(delimiters[0], inner, delimiters[1])
+}
+
+// renderCommand lays out radicals, binomial coefficients and fractions
+func (r *PrettyRenderer) renderCommand(node *CommandNode, parentPrec int) *box {
+	argument := r.renderNode(node.Argument, LOWEST, false)
+	switch node.Name {
+	case "sqrt":
+		var degree *box
+		if node.Optional != nil {
+			degree = r.renderNode(node.Optional, LOWEST, false)
+		}
+		return r.renderRoot(argument, degree)
+	case "binom":
+		// A blank line between n and k keeps the baseline between them
+		return r.parens(stack(argument.height(), argument, textBox(""), r.renderNode(node.Second, LOWEST, false)))
+	case "frac", "dfrac", "tfrac":
+		return r.renderFrac(node.Argument, node.Second, parentPrec)
+	default:
+		return hcat(textBox(node.Name), r.parens(argument))
+	}
+}
+
+// renderRoot draws a radical whose stroke rises along the radicand, with
+// the degree on its left
+//
+//	  _______
+//	╲╱ x + 1
+func (r *PrettyRenderer) renderRoot(radicand, degree *box) *box {
+	stroke, tick := "╱", "╲"
+	if r.options.ASCII {
+		stroke, tick = "/", "\\"
+	}
+	height := radicand.height()
+	inner := hcat(textBox(" "), radicand, textBox(" "))
+
+	// The stroke takes one more column than the radicand has lines
+	indent := 0
+	if degree != nil {
+		indent = max(0, degree.width()-height)
+	}
+	margin := strings.Repeat(" ", indent)
+	lines := []string{strings.Repeat(" ", indent+height+1) + strings.Repeat("_", inner.width())}
+	for i, line := range inner.lines {
+		prefix := strings.Repeat(" ", height-i) + stroke + strings.Repeat(" ", i)
+		if i == height-1 {
+			prefix = tick + prefix[1:]
+		}
+		lines = append(lines, margin+prefix+line)
+	}
+	result := &box{lines: lines, baseline: radicand.baseline + 1}
+	if degree == nil {
+		return result
+	}
+
+	// The degree sits on the overbar, left of the stroke
+	raised := make([]string, degree.height()-1)
+	for i := range raised {
+		raised[i] = padRight(degree.lines[i], result.width())
+	}
+	last := degree.lines[degree.height()-1]
+	result.lines[0] = padRight(last, indent+height+1) + string([]rune(result.lines[0])[indent+height+1:])
+	result.lines = append(raised, result.lines...)
+	result.baseline += len(raised)
+	return result
+}
+
+// bigOperatorShapes draw ∑ and ∏ in Unicode and ASCII
+var bigOperatorShapes = map[bool]map[string][3]string{
+	false: {"sum": {"╲", "╱", "‾"}, "prod": {"┬", "─", "│"}},
+	true:  {"sum": {"\\", "/", "-"}, "prod": {"_", "_", "|"}},
+}
+
+// bigOperatorSymbol draws a summation or product sign as tall as a body of
+// the given height
+func (r *PrettyRenderer) bigOperatorSymbol(name string, height int) *box {
+	shape := bigOperatorShapes[r.options.ASCII][name]
+	half := max(1, (height+1)/2)
+	if name == "prod" {
+		// ┬──┬ over legs │  │
+		lines := []string{shape[0] + strings.Repeat(shape[1], half+1) + shape[0]}
+		for i := 0; i < 2*half; i++ {
+			lines = append(lines, shape[2]+strings.Repeat(" ", half+1)+shape[2])
+		}
+		return &box{lines: lines, baseline: half + 1}
+	}
+	// A bar, strokes in to the middle and back out, and a bar
+	lines := []string{strings.Repeat("_", half+1)}
+	for i := 0; i < half; i++ {
+		lines = append(lines, strings.Repeat(" ", i)+shape[0]+strings.Repeat(" ", half-i))
+	}
+	for i := half - 1; i >= 0; i-- {
+		lines = append(lines, strings.Repeat(" ", i)+shape[1]+strings.Repeat(" ", half-i))
+	}
+	lines = append(lines, strings.Repeat(shape[2], half+1))
+	return &box{lines: lines, baseline: half + 1}
+}
+
+// renderBigOperator draws a sum or product with its limits above and below
+// Parentheses follow Renderer.renderBigOperator.
+func (r *PrettyRenderer) renderBigOperator(node *BigOperatorNode, parentPrec int) *box {
+	body := r.renderNode(node.Body, PRODUCT, false)
+	if nested, ok := node.Body.(*BigOperatorNode); ok {
+		body = r.renderBigOperator(nested, LOWEST)
+	}
+
+	symbol := r.bigOperatorSymbol(node.Name, body.height())
+	upper := r.renderNode(node.Upper, LOWEST, false)
+	lower := hcat(textBox(r.text.renderVariable(node.Index)), r.operator(Token{Type: EQUAL, Literal: "="}), r.renderNode(node.Lower, LOWEST, false))
+	operator := stack(upper.height()+symbol.baseline, upper, symbol, lower)
+	result := hcat(operator, textBox(" "), body)
+	if parentPrec > SUM {
+		return r.parens(result)
+	}
+	return result
+}
+
+// renderIntegral draws an integral sign as tall as its integrand, with the
+// bounds at its ends
+func (r *PrettyRenderer) renderIntegral(node *IntegralNode, parentPrec int) *box {
+	integrand := r.renderNode(node.Integrand, LOWEST, false)
+	top, middle, bottom := "⌠", "⎮", "⌡"
+	if r.options.ASCII {
+		top, middle, bottom = " /", "| ", "/ "
+	}
+	lines := []string{top}
+	for range integrand.lines {
+		lines = append(lines, middle)
+	}
+	lines = append(lines, bottom)
+	sign := &box{lines: lines, baseline: integrand.baseline + 1}
+	if node.Lower != nil {
+		upper := r.renderNode(node.Upper, LOWEST, false)
+		lower := r.renderNode(node.Lower, LOWEST, false)
+		var column []string
+		column = append(column, upper.lines...)
+		column = append(column, sign.lines...)
+		column = append(column, lower.lines...)
+		sign = newBox(column, upper.height()+sign.baseline)
+	}
+
+	differential := textBox(" d" + r.text.renderVariable(node.Variable))
+	result := hcat(sign, textBox(" "), integrand, differential)
+	if parentPrec > POWER {
+		return r.parens(result)
+	}
+	return result
+}
+
+// renderLimit draws lim with the approach of the variable beneath it
+// Parentheses follow Renderer.renderLimit.
+func (r *PrettyRenderer) renderLimit(node *LimitNode, parentPrec int) *box {
+	arrow := "→"
+	if r.options.ASCII {
+		arrow = "->"
+	}
+	var point *box
+	if node.Direction != "" {
+		point = hcat(r.renderNode(node.Point, POWER, false), textBox(r.text.superscript(node.Direction)))
+	} else {
+		point = r.renderNode(node.Point, LOWEST, false)
+	}
+	approach := hcat(textBox(r.text.renderVariable(node.Variable)+arrow), point)
+	operator := stack(0, textBox("lim"), approach)
+	result := hcat(operator, textBox(" "), r.renderNode(node.Body, PRODUCT, false))
+	if parentPrec > SUM {
+		return r.parens(result)
+	}
+	return result
+}
+
+// renderCases draws a brace before rows of values and conditions
+//
+//	⎧x  for x > 0
+//	⎨
+//	⎩0  otherwise
+func (r *PrettyRenderer) renderCases(node *CasesNode) *box {
+	values := make([]*box, len(node.Rows))
+	width := 0
+	for i, row := range node.Rows {
+		values[i] = r.renderNode(row.Value, LOWEST, false)
+		width = max(width, values[i].width())
+	}
+	var lines []string
+	for i, row := range node.Rows {
+		condition := textBox("otherwise")
+		if row.Condition != nil {
+			condition = hcat(textBox("for "), r.renderNode(row.Condition, LOWEST, false))
+		}
+		value := &box{lines: padLines(values[i].lines, width), baseline: values[i].baseline}
+		line := hcat(value, textBox("  "), condition)
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, line.lines...)
+	}
+	rows := newBox(lines, (len(lines)-1)/2)
+	brace := prettyDelimiters[r.options.ASCII]["{"].draw(rows.height())
+	brace.baseline = rows.baseline
+	return hcat(brace, rows)
+}
+
+func padLines(lines []string, width int) []string {
+	padded := make([]string, len(lines))
+	for i, line := range lines {
+		padded[i] = padRight(line, width)
+	}
+	return padded
+}
+
+// renderCall lays out a call such as f(x, y)
+func (r *PrettyRenderer) renderCall(name *VariableNode, arguments []LatexNode) *box {
+	parts := make([]*box, 0, 2*len(arguments))
+	for i, argument := range arguments {
+		if i > 0 {
+			parts = append(parts, textBox(r.text.separator()))
+		}
+		parts = append(parts, r.renderNode(argument, LOWEST, false))
+	}
+	args := textBox("")
+	if len(parts) > 0 {
+		args = hcat(parts...)
+	}
+	return hcat(textBox(r.text.renderVariable(name)), r.parens(args))
+}
+
+// wrap breaks a long sum or relation chain into lines that fit the width,
+// continuing each line with its operator
+func (r *PrettyRenderer) wrap(node LatexNode) *box {
+	terms := r.terms(node, LOWEST)
+	var rows [][]*box
+	width := 0
+	for i, term := range terms {
+		parts := []*box{term.operand}
+		if i > 0 {
+			parts = []*box{term.operator, term.operand}
+		}
+		partWidth := 0
+		for _, part := range parts {
+			partWidth += part.width()
+		}
+		if len(rows) == 0 || width+partWidth > r.options.Width {
+			if i > 0 {
+				// A continuation line starts with its operator
+				parts[0] = textBox(strings.TrimLeft(term.operator.lines[0], " "))
+				partWidth = parts[0].width() + term.operand.width()
+			}
+			rows = append(rows, nil)
+			width = 0
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], parts...)
+		width += partWidth
+	}
+
+	tall := false
+	lines := make([]*box, len(rows))
+	for i, row := range rows {
+		lines[i] = hcat(row...)
+		tall = tall || lines[i].height() > 1
+	}
+	var result []string
+	for i, line := range lines {
+		if i > 0 && tall {
+			result = append(result, "")
+		}
+		result = append(result, line.lines...)
+	}
+	return newBox(result, 0)
+}
+
+// wrapTerm is an operand of a sum or relation chain with the operator
+// before it
+type wrapTerm struct {
+	operator *box
+	operand  *box
+}
+
+// terms splits the left-associated chain of sums and relations at the top
+// of a node into its operands
+func (r *PrettyRenderer) terms(node LatexNode, parentPrec int) []wrapTerm {
+	switch n := node.(type) {
+	case *BinaryOpNode:
+		if (n.Operator.Type == PLUS || n.Operator.Type == MINUS) && !binaryNeedsParens(n.Operator.Type, ILLEGAL, parentPrec, false) {
+			return append(r.terms(n.Left, SUM), wrapTerm{r.operator(n.Operator), r.renderRightOperand(n, SUM)})
+		}
+	case *EqualNode:
+		return append(r.terms(n.Left, EQUALITY), wrapTerm{r.operator(n.Operator), r.renderNode(n.Right, EQUALITY, true)})
+	}
+	return []wrapTerm{{operand: r.renderNode(node, parentPrec, false)}}
+}
+
+// RenderPretty lays a LatexNode out in two dimensions
+func RenderPretty(node LatexNode, options PrettyOptions) string {
+	return NewPrettyRenderer(options).Render(node)
+}
+
+// ExpressionToPretty lays an Expression tree, including propositions, out
+// in two dimensions for the terminal
+func ExpressionToPretty(expression expr.Expr, options PrettyOptions) (string, error) {
+	ast, err := ExportToLatex(expression)
+	if err != nil {
+		return "", fmt.Errorf("failed to export expression: %w", err)
+	}
+	return RenderPretty(ast, options), nil
+}
@@ -0,0 +1,139 @@
+package latex
+
+import (
+	"exprtree/expr"
+	"strings"
+	"testing"
+)
+
+func TestExpressionToPretty(t *testing.T) {
+	tests := []struct {
+		input   string
+		unicode []string
+		ascii   []string
+	}{
+		{
+			"2x^2 + 1",
+			[]string{"  2", "2x  + 1"},
+			[]string{"   2", "2*x  + 1"},
+		},
+		{
+			"\\frac{a + b}{c}",
+			[]string{"a + b", "─────", "  c"},
+			[]string{"a + b", "-----", "  c"},
+		},
+		{
+			"(\\frac{a}{b})^{n+1}",
+			[]string{"   n + 1", "⎛a⎞", "⎜─⎟", "⎝b⎠"},
+			[]string{"   n + 1", "/a\\", "|-|", "\\b/"},
+		},
+		{
+			"\\sqrt{x + 1}",
+			[]string{"  _______", "╲╱ x + 1"},
+			[]string{"  _______", "\\/ x + 1"},
+		},
+		{
+			"\\sqrt[3]{\\frac{1}{x}}",
+			[]string{"3   ___", "   ╱ 1", "  ╱  ─", "╲╱   x"},
+			[]string{"3   ___", "   / 1", "  /  -", "\\/   x"},
+		},
+		{
+			"\\sum_{i=1}^{n} i^2",
+			[]string{"  n", " __", " ╲     2", " ╱    i", " ‾‾", "i = 1"},
+			[]string{"  n", " __", " \\     2", " /    i", " --", "i = 1"},
+		},
+		{
+			"\\int_{0}^{1} x^2 dx",
+			[]string{"1", "⌠", "⎮  2", "⎮ x  dx", "⌡", "0"},
+			[]string{"1", " /", "|   2", "|  x  dx", "/", "0"},
+		},
+		{
+			"\\lim_{x \\to 0^+} \\frac{1}{x}",
+			[]string{"     1", "lim  ─", "x→0⁺ x"},
+			[]string{"       1", " lim   -", "x->0^+ x"},
+		},
+		{
+			"\\begin{cases} x & x > 0 \\\\ 0 & \\text{otherwise} \\end{cases}",
+			[]string{"⎧x  for x > 0", "⎨", "⎩0  otherwise"},
+			[]string{"/x  for x > 0", "<", "\\0  otherwise"},
+		},
+		{
+			"|\\frac{x}{2}| + \\lfloor x \\rfloor",
+			[]string{"│x│", "│─│ + ⌊x⌋", "│2│"},
+			[]string{"|x|", "|-| + floor(x)", "|2|"},
+		},
+		{
+			"\\sin^2(x) + \\log_{2}(x)",
+			[]string{"   2", "sin (x) + log (x)", "             2"},
+			[]string{"   2", "sin (x) + log (x)", "             2"},
+		},
+		{
+			"\\binom{n}{k} - 1",
+			[]string{"⎛n⎞", "⎜ ⎟ - 1", "⎝k⎠"},
+			[]string{"/n\\", "| | - 1", "\\k/"},
+		},
+		{
+			"a - (b + c) - \\frac{1}{2x}",
+			[]string{"              1", "a - (b + c) - ──", "              2x"},
+			[]string{"               1", "a - (b + c) - ---", "              2*x"},
+		},
+		{
+			"0 \\le x_1 < 1",
+			[]string{"0 ≤ x₁ < 1"},
+			[]string{"0 <= x_1 < 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parsed, err := ParseLatex(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLatex failed: %v", err)
+			}
+			for _, ascii := range []bool{false, true} {
+				expected := strings.Join(tt.unicode, "\n")
+				if ascii {
+					expected = strings.Join(tt.ascii, "\n")
+				}
+				result, err := ExpressionToPretty(parsed.(expr.Expr), PrettyOptions{ASCII: ascii})
+				if err != nil {
+					t.Fatalf("ExpressionToPretty failed: %v", err)
+				}
+				if result != expected {
+					t.Errorf("ASCII=%v: expected\n%s\ngot\n%s", ascii, expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestExpressionToPretty_Width(t *testing.T) {
+	tests := []struct {
+		input    string
+		width    int
+		expected []string
+	}{
+		{"a + b - c + d + e", 0, []string{"a + b - c + d + e"}},
+		{"a + b - c + d + e", 9, []string{"a + b - c", "+ d + e"}},
+		{"a + b - c + d + e", 5, []string{"a + b", "- c", "+ d", "+ e"}},
+		{"x^2 + \\frac{1}{x} + y = 1 + z", 12, []string{" 2   1", "x  + ─ + y", "     x", "", "= 1 + z"}},
+		{"a - (b + c + d)", 5, []string{"a", "- (b + c + d)"}},
+		{"(a + b + c + d) * e", 5, []string{"(a + b + c + d)·e"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parsed, err := ParseLatex(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLatex failed: %v", err)
+			}
+			result, err := ExpressionToPretty(parsed.(expr.Expr), PrettyOptions{Width: tt.width})
+			if err != nil {
+				t.Fatalf("ExpressionToPretty failed: %v", err)
+			}
+			if expected := strings.Join(tt.expected, "\n"); result != expected {
+				t.Errorf("expected\n%s\ngot\n%s", expected, result)
+			}
+		})
+	}
+}
@@ -3,16 +3,35 @@ package latex
 import (
 	"exprtree/expr"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// RenderOptions configures how a Renderer writes expressions
+type RenderOptions struct {
+	// Fractions renders divisions as \frac{a}{b} instead of a / b
+	// Publication LaTeX always uses fractions.
+	Fractions bool
+	// Style selects the notation; the default is plain LaTeX
+	Style RenderStyle
+}
+
 // Renderer converts LaTeX AST to string representation
 type Renderer struct {
+	options RenderOptions
 }
 
 // NewRenderer creates a new Renderer instance
 func NewRenderer() *Renderer {
-	return &Renderer{}
+	return NewRendererWithOptions(RenderOptions{})
+}
+
+// NewRendererWithOptions creates a new Renderer instance with options
+func NewRendererWithOptions(options RenderOptions) *Renderer {
+	return &Renderer{options: options}
 }
 
 // Render converts a LatexNode to a string
@@ -26,13 +45,40 @@ func (r *Renderer) Render(node LatexNode) string {
 func (r *Renderer) renderNode(node LatexNode, parentPrec int, isRightOperand bool) string {
 	switch n := node.(type) {
 	case *NumberNode:
+		if n.Value < 0 && signNeedsParens(parentPrec, isRightOperand) {
+			return r.parens(r.renderNumber(n))
+		}
 		return r.renderNumber(n)
+	case *UnaryMinusNode:
+		return r.renderUnaryMinus(n, parentPrec, isRightOperand)
 	case *VariableNode:
 		return r.renderVariable(n)
 	case *BinaryOpNode:
-		return r.renderBinaryOp(n, parentPrec, isRightOperand)
+		return r.renderBinaryOp(n, ILLEGAL, parentPrec, isRightOperand)
 	case *GroupNode:
 		return r.renderGroup(n)
+	case *FunctionNode:
+		return r.renderFunction(n, parentPrec)
+	case *FenceNode:
+		return r.renderFence(n)
+	case *FactorialNode:
+		return r.renderFactorial(n)
+	case *CommandNode:
+		return r.renderCommand(n, parentPrec, isRightOperand)
+	case *BigOperatorNode:
+		return r.renderBigOperator(n, parentPrec)
+	case *IntegralNode:
+		return r.renderIntegral(n, parentPrec)
+	case *LimitNode:
+		return r.renderLimit(n, parentPrec)
+	case *EqualNode:
+		return r.renderEqual(n)
+	case *CasesNode:
+		return r.renderCases(n)
+	case *CallNode:
+		return r.renderCall(n)
+	case *DefinitionNode:
+		return r.renderDefinition(n)
 	default:
 		return ""
 	}
@@ -40,52 +86,548 @@ func (r *Renderer) renderNode(node LatexNode, parentPrec int, isRightOperand boo
 
 // renderNumber converts a NumberNode to a string
 func (r *Renderer) renderNumber(node *NumberNode) string {
-	// Use %g to format the number (removes trailing zeros)
-	return fmt.Sprintf("%g", node.Value)
+	// An exact integer beyond the float64 range has an infinite Value
+	if node.Exact != nil {
+		return node.Exact.String()
+	}
+	if math.IsInf(node.Value, 0) {
+		infinity := "\\infty"
+		switch r.options.Style {
+		case ASCIIStyle:
+			infinity = "oo"
+		case UnicodeStyle:
+			infinity = "∞"
+		}
+		if node.Value < 0 {
+			return "-" + infinity
+		}
+		return infinity
+	}
+	return formatNumber(node.Value)
+}
+
+// formatNumber formats a finite number so that it reads back as the same
+// literal
+// Integral values are written out in full, since %g would turn 1000000
+// into 1e+06; other values use %g, which removes trailing zeros.
+func formatNumber(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%g", v)
 }
 
 // renderVariable converts a VariableNode to a string
 func (r *Renderer) renderVariable(node *VariableNode) string {
-	return node.Name
+	if r.isText() {
+		return r.textName(node.Name)
+	}
+	return renderName(node.Name)
 }
 
-// renderBinaryOp converts a BinaryOpNode to a string
-func (r *Renderer) renderBinaryOp(node *BinaryOpNode, parentPrec int, isRightOperand bool) string {
-	// Get the precedence of this operator
-	opPrec := r.getPrecedence(node.Operator.Type)
+// isText reports whether the style is ASCII or Unicode rather than LaTeX
+func (r *Renderer) isText() bool {
+	return r.options.Style == ASCIIStyle || r.options.Style == UnicodeStyle
+}
 
-	// Determine if we need parentheses
-	needsParens := false
-	if opPrec < parentPrec {
-		// Lower precedence than parent, always needs parentheses
-		needsParens = true
-	} else if opPrec == parentPrec && isRightOperand {
-		// Same precedence as parent, on the right side
-		// Need parentheses for non-associative operators (-, /)
-		if node.Operator.Type == MINUS || node.Operator.Type == DIVIDE {
-			needsParens = true
+// fractions reports whether divisions are rendered as \frac{a}{b}
+func (r *Renderer) fractions() bool {
+	return r.options.Style == LatexStyle || (r.options.Fractions && !r.isText())
+}
+
+// parens wraps text in parentheses, sized with \left( \right) in
+// publication LaTeX
+func (r *Renderer) parens(text string) string {
+	if r.options.Style == LatexStyle {
+		return "\\left(" + text + "\\right)"
+	}
+	return "(" + text + ")"
+}
+
+// operator spells an operator token with its surrounding spaces
+func (r *Renderer) operator(token Token) string {
+	switch r.options.Style {
+	case ASCIIStyle, UnicodeStyle:
+		if spelled, ok := textOperators[r.options.Style][token.Type]; ok {
+			return spelled
+		}
+	case LatexStyle:
+		if spelled, ok := latexOperators[token.Type]; ok {
+			return spelled
 		}
 	}
+	return " " + token.Literal + " "
+}
+
+// renderUnaryMinus converts a UnaryMinusNode to a string such as -x or -(a + b)
+// The operand is parenthesized unless it binds at least as tightly as a
+// power, so -2^2 stays -(2^2) while -(a * b) keeps its parentheses.
+func (r *Renderer) renderUnaryMinus(node *UnaryMinusNode, parentPrec int, isRightOperand bool) string {
+	var operand string
+	if binary, ok := node.Operand.(*BinaryOpNode); ok && r.fractions() && binary.Operator.Type == DIVIDE {
+		// -\frac{a}{b} needs no parentheses
+		operand = r.renderFrac(binary, LOWEST)
+	} else {
+		operand = r.renderNode(node.Operand, POWER, false)
+	}
+	result := "-" + operand
+	if signNeedsParens(parentPrec, isRightOperand) {
+		result = r.parens(result)
+	}
+	return result
+}
+
+// signNeedsParens reports whether a leading minus sign must be
+// parenthesized, as in (-2)^2, (-x)! and a - (-b)
+func signNeedsParens(parentPrec int, isRightOperand bool) bool {
+	return parentPrec >= POWER || (isRightOperand && parentPrec >= SUM)
+}
+
+// renderBinaryOp converts a BinaryOpNode to a string
+// parentType is the operator of the parent when the node is the operand of
+// another binary operation, and ILLEGAL otherwise.
+func (r *Renderer) renderBinaryOp(node *BinaryOpNode, parentType TokenType, parentPrec int, isRightOperand bool) string {
+	if r.fractions() && node.Operator.Type == DIVIDE {
+		return r.renderFrac(node, parentPrec)
+	}
+	if node.Operator.Type == CARET {
+		return r.renderPower(node, parentPrec)
+	}
+
+	// Get the precedence of this operator
+	opPrec := r.getPrecedence(node.Operator.Type)
+	needsParens := binaryNeedsParens(node.Operator.Type, parentType, parentPrec, isRightOperand)
 
 	// Render left and right operands
 	left := r.renderNode(node.Left, opPrec, false)
-	right := r.renderNode(node.Right, opPrec, true)
+	right := r.renderRightOperand(node, opPrec)
 
 	// Build the expression string
 	var result string
-	result = left + " " + node.Operator.Literal + " " + right
+	if r.juxtaposes(node, right) {
+		result = left + right
+	} else {
+		result = left + r.operator(node.Operator) + right
+	}
 
 	if needsParens {
-		result = "(" + result + ")"
+		result = r.parens(result)
+	}
+
+	return result
+}
+
+// renderRightOperand renders the right operand of a binary operation
+// A binary operand is told the operator it is under, so that a - (b + c)
+// keeps its parentheses.
+func (r *Renderer) renderRightOperand(node *BinaryOpNode, opPrec int) string {
+	if binary, ok := node.Right.(*BinaryOpNode); ok {
+		return r.renderBinaryOp(binary, node.Operator.Type, opPrec, true)
 	}
+	return r.renderNode(node.Right, opPrec, true)
+}
+
+// binaryNeedsParens reports whether a binary operation must be
+// parenthesized under a parent of precedence parentPrec
+// parentType is the operator of the parent, or ILLEGAL when the parent is
+// not a binary operation.
+func binaryNeedsParens(opType, parentType TokenType, parentPrec int, isRightOperand bool) bool {
+	opPrec := LOWEST
+	if prec, ok := precedences[opType]; ok {
+		opPrec = prec
+	}
+	if opPrec < parentPrec {
+		// Lower precedence than parent, always needs parentheses
+		return true
+	}
+	// Same precedence as parent, on the right side
+	// Need parentheses when either operator is non-associative (-, /), as
+	// in a + (b - c) and a - (b + c)
+	return opPrec == parentPrec && isRightOperand && (nonAssociative(opType) || nonAssociative(parentType))
+}
+
+// nonAssociative reports whether an operator does not associate, so that
+// a - (b - c) differs from (a - b) - c
+func nonAssociative(opType TokenType) bool {
+	return opType == MINUS || opType == DIVIDE
+}
 
+// juxtaposes reports whether a product is written without its operator, as
+// in 2x, 2\sqrt{x} or 2(x + 1)
+// Only publication LaTeX and Unicode do this, and only after a number, so
+// that the factors cannot run together.
+func (r *Renderer) juxtaposes(node *BinaryOpNode, right string) bool {
+	if node.Operator.Type != MULTIPLY || (r.options.Style != LatexStyle && r.options.Style != UnicodeStyle) {
+		return false
+	}
+	number, ok := node.Left.(*NumberNode)
+	if !ok || number.Value < 0 || math.IsInf(number.Value, 0) {
+		return false
+	}
+	if binary, ok := node.Right.(*BinaryOpNode); ok && binary.Operator.Type == DIVIDE {
+		// 2\frac{1}{2} would read as a mixed number
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(right)
+	return unicode.IsLetter(first) || first == '\\' || first == '(' || first == '√' || first == '∛' || first == '∜'
+}
+
+// renderPower converts a power to a string such as x^{2} or (a + b)^{n}
+// The braces delimit the exponent. The base is parenthesized unless it is
+// atomic, so (2^{3})^{2} keeps its grouping.
+func (r *Renderer) renderPower(node *BinaryOpNode, parentPrec int) string {
+	exponent := node.Right
+	if group, ok := exponent.(*GroupNode); ok && group.Token.Type == LBRACE {
+		exponent = group.Inner
+	}
+	result := r.renderNode(node.Left, POSTFIX, false) + r.renderExponent(exponent)
+	if parentPrec > POWER {
+		result = r.parens(result)
+	}
+	return result
+}
+
+// renderExponent writes an exponent such as ^{n + 1}, ^(n+1) or ⁿ⁺¹
+func (r *Renderer) renderExponent(exponent LatexNode) string {
+	if r.isText() {
+		return r.superscript(r.renderNode(exponent, LOWEST, false))
+	}
+	return "^{" + r.renderNode(exponent, LOWEST, false) + "}"
+}
+
+// renderFrac converts a division to \frac{a}{b}
+// The braces delimit both operands, so only powers need parentheses.
+func (r *Renderer) renderFrac(node *BinaryOpNode, parentPrec int) string {
+	result := "\\frac{" + r.renderNode(node.Left, LOWEST, false) + "}{" + r.renderNode(node.Right, LOWEST, false) + "}"
+	if parentPrec >= POWER {
+		result = r.parens(result)
+	}
 	return result
 }
 
 // renderGroup converts a GroupNode to a string
 func (r *Renderer) renderGroup(node *GroupNode) string {
 	inner := r.renderNode(node.Inner, LOWEST, false)
-	return "(" + inner + ")"
+	return r.parens(inner)
+}
+
+// renderFunction converts a FunctionNode to a string
+// The argument is always parenthesized so that it parses back unchanged,
+// except for a single number or variable in publication LaTeX. There the
+// whole application is parenthesized under a postfix operator, since
+// \sin x! means \sin(x!).
+func (r *Renderer) renderFunction(node *FunctionNode, parentPrec int) string {
+	if r.isText() {
+		return r.renderTextFunction(node)
+	}
+	result := "\\" + node.Name
+	if node.Base != nil {
+		result += "_{" + r.renderNode(node.Base, LOWEST, false) + "}"
+	}
+	if node.Exponent != nil {
+		result += "^{" + r.renderNode(node.Exponent, LOWEST, false) + "}"
+	}
+	argument := r.renderNode(node.Argument, LOWEST, false)
+	if r.options.Style == LatexStyle {
+		if isAtom(node.Argument) && parentPrec >= POSTFIX {
+			return r.parens(result + " " + argument)
+		}
+		if isAtom(node.Argument) {
+			return result + " " + argument
+		}
+		return result + r.parens(argument)
+	}
+	return result + "(" + argument + ")"
+}
+
+// renderTextFunction writes a function as sin(x), log_2(x) or sin²(x)
+// ASCII puts the exponent after the argument, as in sin(x)^2.
+func (r *Renderer) renderTextFunction(node *FunctionNode) string {
+	result := node.Name
+	if node.Base != nil {
+		result += r.subscript(r.renderNode(node.Base, LOWEST, false))
+	}
+	argument := "(" + r.renderNode(node.Argument, LOWEST, false) + ")"
+	if node.Exponent == nil {
+		return result + argument
+	}
+	exponent := r.renderExponent(node.Exponent)
+	if r.options.Style == UnicodeStyle && !strings.HasPrefix(exponent, "^") {
+		return result + exponent + argument
+	}
+	return result + argument + exponent
+}
+
+// isAtom reports whether a node is a single non-negative number or a
+// variable, which needs no parentheses as an argument
+func isAtom(node LatexNode) bool {
+	switch n := node.(type) {
+	case *NumberNode:
+		return n.Value >= 0
+	case *VariableNode:
+		return true
+	default:
+		return false
+	}
+}
+
+// renderFence converts a FenceNode to a string
+// Absolute values use \left| \right| so that nested bars stay unambiguous.
+func (r *Renderer) renderFence(node *FenceNode) string {
+	inner := r.renderNode(node.Inner, LOWEST, false)
+	switch r.options.Style {
+	case ASCIIStyle:
+		return fenceNames[node.Kind] + "(" + inner + ")"
+	case UnicodeStyle:
+		return fenceSymbols[node.Kind][0] + inner + fenceSymbols[node.Kind][1]
+	}
+	switch node.Kind {
+	case FenceFloor:
+		return "\\lfloor " + inner + " \\rfloor"
+	case FenceCeil:
+		return "\\lceil " + inner + " \\rceil"
+	default:
+		return "\\left| " + inner + " \\right|"
+	}
+}
+
+// fenceNames and fenceSymbols spell fences in the ASCII and Unicode styles
+var fenceNames = map[FenceKind]string{
+	FenceAbs:   "abs",
+	FenceFloor: "floor",
+	FenceCeil:  "ceil",
+}
+
+var fenceSymbols = map[FenceKind][2]string{
+	FenceAbs:   {"|", "|"},
+	FenceFloor: {"⌊", "⌋"},
+	FenceCeil:  {"⌈", "⌉"},
+}
+
+// renderFactorial converts a FactorialNode to a string
+// Binary operands get parentheses from their precedence; negative numbers
+// need them as well since -3! means -(3!).
+func (r *Renderer) renderFactorial(node *FactorialNode) string {
+	return r.renderNode(node.Operand, POSTFIX, false) + "!"
+}
+
+// renderBigOperator converts a BigOperatorNode to a string
+// The body is parenthesized when it is a sum or difference. The operator
+// itself is parenthesized inside products and powers, where its body would
+// otherwise absorb the following factors.
+func (r *Renderer) renderBigOperator(node *BigOperatorNode, parentPrec int) string {
+	body := r.renderNode(node.Body, PRODUCT, false)
+	if nested, ok := node.Body.(*BigOperatorNode); ok {
+		body = r.renderBigOperator(nested, LOWEST)
+	}
+
+	lower := r.renderNode(node.Index, LOWEST, false) + "=" + r.renderNode(node.Lower, LOWEST, false)
+	upper := r.renderNode(node.Upper, LOWEST, false)
+	var result string
+	if r.isText() {
+		result = r.textOperator(node.Name) + r.subscript(lower) + r.superscript(upper) + " " + body
+	} else {
+		result = "\\" + node.Name + "_{" + lower + "}^{" + upper + "} " + body
+	}
+	if parentPrec > SUM {
+		result = r.parens(result)
+	}
+	return result
+}
+
+// renderIntegral converts an IntegralNode to a string
+// The differential delimits the integrand, so parentheses are only needed
+// around a power base.
+func (r *Renderer) renderIntegral(node *IntegralNode, parentPrec int) string {
+	text := r.isText()
+	var result string
+	if text {
+		result = r.textOperator("int")
+	} else {
+		result = "\\int"
+	}
+	if node.Lower != nil {
+		lower, upper := r.renderNode(node.Lower, LOWEST, false), r.renderNode(node.Upper, LOWEST, false)
+		if text {
+			result += r.subscript(lower) + r.superscript(upper)
+		} else {
+			result += "_{" + lower + "}^{" + upper + "}"
+		}
+	}
+	differential := " \\, d"
+	if text {
+		differential = " d"
+	}
+	result += " " + r.renderNode(node.Integrand, LOWEST, false) + differential + r.renderVariable(node.Variable)
+	if parentPrec > POWER {
+		result = r.parens(result)
+	}
+	return result
+}
+
+// renderLimit converts a LimitNode to a string such as \lim_{x \to 0^{+}} f
+// The body and the parenthesization follow renderBigOperator. A directed
+// point is rendered as a power base so that its sign attaches to it.
+func (r *Renderer) renderLimit(node *LimitNode, parentPrec int) string {
+	point := r.renderNode(node.Point, LOWEST, false)
+	if node.Direction != "" {
+		point = r.renderNode(node.Point, POWER, false)
+		if r.isText() {
+			point += r.superscript(node.Direction)
+		} else {
+			point += "^{" + node.Direction + "}"
+		}
+	}
+
+	body := r.renderNode(node.Body, PRODUCT, false)
+	var result string
+	switch r.options.Style {
+	case ASCIIStyle:
+		result = "lim_(" + r.renderVariable(node.Variable) + "->" + point + ") " + body
+	case UnicodeStyle:
+		result = "lim_(" + r.renderVariable(node.Variable) + "→" + point + ") " + body
+	default:
+		result = "\\lim_{" + r.renderVariable(node.Variable) + " \\to " + point + "} " + body
+	}
+	if parentPrec > SUM {
+		result = r.parens(result)
+	}
+	return result
+}
+
+// renderEqual converts an EqualNode to a string such as 0 \le x < 1
+func (r *Renderer) renderEqual(node *EqualNode) string {
+	return r.renderNode(node.Left, EQUALITY, false) + r.operator(node.Operator) + r.renderNode(node.Right, EQUALITY, true)
+}
+
+// renderCases converts a CasesNode to a cases environment
+// The environment delimits itself, so no parentheses are needed.
+func (r *Renderer) renderCases(node *CasesNode) string {
+	if r.isText() {
+		return r.renderTextCases(node)
+	}
+	rows := make([]string, len(node.Rows))
+	for i, row := range node.Rows {
+		condition := "\\text{otherwise}"
+		if row.Condition != nil {
+			condition = r.renderNode(row.Condition, LOWEST, false)
+		}
+		rows[i] = r.renderNode(row.Value, LOWEST, false) + " & " + condition
+	}
+	return "\\begin{cases} " + strings.Join(rows, " \\\\ ") + " \\end{cases}"
+}
+
+// renderTextCases writes a CasesNode as {x if x > 0; 0 otherwise}
+func (r *Renderer) renderTextCases(node *CasesNode) string {
+	rows := make([]string, len(node.Rows))
+	for i, row := range node.Rows {
+		condition := "otherwise"
+		if row.Condition != nil {
+			condition = "if " + r.renderNode(row.Condition, LOWEST, false)
+		}
+		rows[i] = r.renderNode(row.Value, LOWEST, false) + " " + condition
+	}
+	return "{" + strings.Join(rows, "; ") + "}"
+}
+
+// renderCall converts a CallNode to a string such as f(2, a + 1)
+func (r *Renderer) renderCall(node *CallNode) string {
+	args := make([]string, len(node.Arguments))
+	for i, argument := range node.Arguments {
+		args[i] = r.renderNode(argument, LOWEST, false)
+	}
+	return r.renderVariable(node.Name) + "(" + strings.Join(args, r.separator()) + ")"
+}
+
+// separator returns the separator of arguments, which is compact in ASCII
+func (r *Renderer) separator() string {
+	if r.options.Style == ASCIIStyle {
+		return ","
+	}
+	return ", "
+}
+
+// renderDefinition converts a DefinitionNode to a string such as
+// f(x, y) = x^2 + y
+func (r *Renderer) renderDefinition(node *DefinitionNode) string {
+	params := make([]string, len(node.Params))
+	for i, param := range node.Params {
+		params[i] = r.renderVariable(param)
+	}
+	return r.renderVariable(node.Name) + "(" + strings.Join(params, r.separator()) + ")" +
+		r.operator(Token{Type: EQUAL, Literal: "="}) + r.renderNode(node.Body, LOWEST, false)
+}
+
+// renderCommand converts a CommandNode to a string such as \sqrt[3]{x}
+// The ASCII and Unicode styles write fractions as divisions.
+func (r *Renderer) renderCommand(node *CommandNode, parentPrec int, isRightOperand bool) string {
+	if r.isText() {
+		switch node.Name {
+		case "frac", "dfrac", "tfrac":
+			division := &BinaryOpNode{Left: node.Argument, Operator: Token{Type: DIVIDE, Literal: "/"}, Right: node.Second}
+			return r.renderBinaryOp(division, ILLEGAL, parentPrec, isRightOperand)
+		case "sqrt":
+			return r.renderTextRoot(node, parentPrec)
+		}
+		args := []string{r.renderNode(node.Argument, LOWEST, false)}
+		if node.Second != nil {
+			args = append(args, r.renderNode(node.Second, LOWEST, false))
+		}
+		return node.Name + "(" + strings.Join(args, r.separator()) + ")"
+	}
+	result := "\\" + node.Name
+	if node.Optional != nil {
+		result += "[" + r.renderNode(node.Optional, LOWEST, false) + "]"
+	}
+	result += "{" + r.renderNode(node.Argument, LOWEST, false) + "}"
+	if node.Second != nil {
+		result += "{" + r.renderNode(node.Second, LOWEST, false) + "}"
+	}
+	return result
+}
+
+// rootSymbols are the Unicode radicals for square, cube and fourth roots
+var rootSymbols = map[string]string{"": "√", "2": "√", "3": "∛", "4": "∜"}
+
+// renderTextRoot writes a root as sqrt(x) and root(3)(x) in ASCII, or as
+// √x, ∛(x + 1) and ⁵√x in Unicode
+// A Unicode radical is parenthesized as the base of a power or factorial,
+// since √x² could mean √(x²).
+func (r *Renderer) renderTextRoot(node *CommandNode, parentPrec int) string {
+	radicand := r.renderNode(node.Argument, LOWEST, false)
+	degree := ""
+	if node.Optional != nil {
+		degree = r.renderNode(node.Optional, LOWEST, false)
+	}
+
+	if r.options.Style == ASCIIStyle {
+		if degree == "" {
+			return "sqrt(" + radicand + ")"
+		}
+		return "root(" + degree + ")(" + radicand + ")"
+	}
+
+	symbol, ok := rootSymbols[degree]
+	if !ok {
+		raised, ok := shiftScript(degree, superscripts)
+		if !ok {
+			return "root(" + degree + ")(" + radicand + ")"
+		}
+		symbol = raised + "√"
+	}
+	result := symbol + "(" + radicand + ")"
+	switch node.Argument.(type) {
+	case *VariableNode, *FenceNode, *CallNode, *FunctionNode:
+		result = symbol + radicand
+	case *NumberNode:
+		if isAtom(node.Argument) {
+			result = symbol + radicand
+		}
+	}
+	if parentPrec >= POSTFIX {
+		return r.parens(result)
+	}
+	return result
 }
 
 // getPrecedence returns the precedence of an operator
@@ -115,3 +657,28 @@ func ExpressionToLatex(expression expr.Expr) (string, error) {
 
 	return strings.TrimSpace(latexString), nil
 }
+
+// ExpressionToText converts an Expression tree to a string in a style
+func ExpressionToText(expression expr.Expr, style RenderStyle) (string, error) {
+	return ExpressionToLatexWithOptions(expression, RenderOptions{Style: style})
+}
+
+// ExpressionToLatexWithOptions converts an Expression tree to a LaTeX string
+// rendered with options
+func ExpressionToLatexWithOptions(expression expr.Expr, options RenderOptions) (string, error) {
+	ast, err := ExportToLatex(expression)
+	if err != nil {
+		return "", fmt.Errorf("failed to export expression: %w", err)
+	}
+	return strings.TrimSpace(NewRendererWithOptions(options).Render(ast)), nil
+}
+
+// DefinitionToLatex converts a user-defined function to a declaration such
+// as f(x, y) = x + y
+func DefinitionToLatex(definition *expr.Definition) (string, error) {
+	ast, err := NewExporter().ExportDefinition(definition)
+	if err != nil {
+		return "", fmt.Errorf("failed to export definition: %w", err)
+	}
+	return strings.TrimSpace(RenderLatex(ast)), nil
+}
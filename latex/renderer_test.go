@@ -2,7 +2,11 @@ package latex
 
 import (
 	"exprtree/expr"
+	"exprtree/prop"
 	"exprtree/value"
+	"math"
+	"math/big"
+	"strings"
 	"testing"
 )
 
@@ -225,6 +229,166 @@ func TestExpressionToLatex(t *testing.T) {
 			),
 			expected: "2 + 3 * 4",
 		},
+		{
+			name: "Function",
+			expr: expr.NewAdd(
+				expr.NewFunction(expr.Sin, expr.NewVariable("x")),
+				expr.NewLog(expr.NewConstant(value.NewRealValue(2)), expr.NewVariable("y")),
+			),
+			expected: "\\sin(x) + \\log_{2}(y)",
+		},
+		{
+			name:     "Negation",
+			expr:     expr.NewNeg(expr.NewAdd(expr.NewVariable("a"), expr.NewVariable("b"))),
+			expected: "-(a + b)",
+		},
+		{
+			name:     "Negated operand",
+			expr:     expr.NewSub(expr.NewVariable("a"), expr.NewNeg(expr.NewVariable("b"))),
+			expected: "a - (-b)",
+		},
+		{
+			name:     "Negated product",
+			expr:     expr.NewAdd(expr.NewNeg(expr.NewMul(expr.NewVariable("a"), expr.NewVariable("b"))), expr.NewVariable("c")),
+			expected: "-(a * b) + c",
+		},
+		{
+			name:     "Negated factorial operand",
+			expr:     expr.NewFactorial(expr.NewNeg(expr.NewVariable("x"))),
+			expected: "(-x)!",
+		},
+		{
+			name:     "Absolute value and floor",
+			expr:     expr.NewMul(expr.NewAbs(expr.NewVariable("x")), expr.NewFloor(expr.NewVariable("y"))),
+			expected: "\\left| x \\right| * \\lfloor y \\rfloor",
+		},
+		{
+			name:     "Factorial",
+			expr:     expr.NewFactorial(expr.NewAdd(expr.NewVariable("n"), expr.NewConstant(value.NewRealValue(1)))),
+			expected: "(n + 1)!",
+		},
+		{
+			name:     "Binomial",
+			expr:     expr.NewBinomial(expr.NewVariable("n"), expr.NewVariable("k")),
+			expected: "\\binom{n}{k}",
+		},
+		{
+			name: "Sum times constant",
+			expr: expr.NewMul(
+				expr.NewSum("i", expr.NewConstant(value.NewRealValue(1)), expr.NewVariable("n"),
+					expr.NewAdd(expr.NewVariable("i"), expr.NewConstant(value.NewRealValue(1)))),
+				expr.NewConstant(value.NewRealValue(2)),
+			),
+			expected: "(\\sum_{i=1}^{n} (i + 1)) * 2",
+		},
+		{
+			name: "Integral",
+			expr: expr.NewMul(
+				expr.NewIntegral("t", expr.NewConstant(value.NewRealValue(0)), expr.NewVariable("x"),
+					expr.NewAdd(expr.NewVariable("t"), expr.NewConstant(value.NewRealValue(1)))),
+				expr.NewConstant(value.NewRealValue(2)),
+			),
+			expected: "\\int_{0}^{x} t + 1 \\, dt * 2",
+		},
+		{
+			name: "One-sided limit times constant",
+			expr: expr.NewMul(
+				expr.NewLimit("x", expr.NewConstant(value.NewRealValue(0)), expr.FromAbove,
+					expr.NewDiv(expr.NewConstant(value.NewRealValue(1)), expr.NewVariable("x"))),
+				expr.NewConstant(value.NewRealValue(2)),
+			),
+			expected: "(\\lim_{x \\to 0^{+}} 1 / x) * 2",
+		},
+		{
+			name: "Limit at infinity",
+			expr: expr.NewLimit("x", expr.NewConstant(value.NewRealValue(math.Inf(1))), expr.TwoSided,
+				expr.NewSub(expr.NewVariable("x"), expr.NewConstant(value.NewRealValue(1)))),
+			expected: "\\lim_{x \\to \\infty} (x - 1)",
+		},
+		{
+			name: "Piecewise",
+			expr: expr.NewPiecewise([]expr.Branch{
+				{
+					Condition: prop.NewAnd(
+						prop.NewInequality(prop.LessEqual, expr.NewConstant(value.NewRealValue(0)), expr.NewVariable("x")),
+						prop.NewInequality(prop.Less, expr.NewVariable("x"), expr.NewConstant(value.NewRealValue(1)))),
+					Value: expr.NewVariable("x"),
+				},
+			}, expr.NewConstant(value.NewRealValue(0))),
+			expected: "\\begin{cases} x & 0 \\le x < 1 \\\\ 0 & \\text{otherwise} \\end{cases}",
+		},
+		{
+			name: "Call",
+			expr: expr.NewMul(
+				expr.NewConstant(value.NewRealValue(2)),
+				expr.NewCall(expr.NewDefinitions(), "f", []expr.Expr{
+					expr.NewVariable("x"),
+					expr.NewAdd(expr.NewVariable("a"), expr.NewConstant(value.NewRealValue(1))),
+				}),
+			),
+			expected: "2 * f(x, a + 1)",
+		},
+		{
+			name:     "Permutation",
+			expr:     expr.NewPermutation(expr.NewVariable("n"), expr.NewVariable("k")),
+			expected: "n! / (n - k)!",
+		},
+		{
+			name:     "Power",
+			expr:     expr.NewMul(expr.NewConstant(value.NewRealValue(2)), expr.NewPower(expr.NewVariable("x"), expr.NewConstant(value.NewRealValue(2)))),
+			expected: "2 * x^{2}",
+		},
+		{
+			name: "Left-nested power",
+			expr: expr.NewPower(
+				expr.NewPower(expr.NewConstant(value.NewRealValue(2)), expr.NewConstant(value.NewRealValue(3))),
+				expr.NewConstant(value.NewRealValue(2))),
+			expected: "(2^{3})^{2}",
+		},
+		{
+			name: "Right-nested power",
+			expr: expr.NewPower(
+				expr.NewConstant(value.NewRealValue(2)),
+				expr.NewPower(expr.NewConstant(value.NewRealValue(3)), expr.NewConstant(value.NewRealValue(2)))),
+			expected: "2^{3^{2}}",
+		},
+		{
+			name:     "Power of a sum",
+			expr:     expr.NewPower(expr.NewAdd(expr.NewVariable("a"), expr.NewVariable("b")), expr.NewNeg(expr.NewVariable("n"))),
+			expected: "(a + b)^{-n}",
+		},
+		{
+			name:     "Power of a negative number",
+			expr:     expr.NewPower(expr.NewConstant(value.NewRealValue(-2)), expr.NewConstant(value.NewRealValue(2))),
+			expected: "(-2)^{2}",
+		},
+		{
+			name:     "Power of a function",
+			expr:     expr.NewPower(expr.NewFunction(expr.Sin, expr.NewVariable("x")), expr.NewConstant(value.NewRealValue(2))),
+			expected: "\\sin^{2}(x)",
+		},
+		{
+			name:     "Square root",
+			expr:     expr.NewSqrt(expr.NewAdd(expr.NewVariable("x"), expr.NewConstant(value.NewRealValue(1)))),
+			expected: "\\sqrt{x + 1}",
+		},
+		{
+			name:     "Cube root",
+			expr:     expr.NewNthRoot(expr.NewVariable("x"), expr.NewConstant(value.NewRealValue(3))),
+			expected: "\\sqrt[3]{x}",
+		},
+		{
+			name:     "Equality",
+			expr:     prop.NewEqual(expr.NewPower(expr.NewVariable("x"), expr.NewConstant(value.NewRealValue(2))), expr.NewConstant(value.NewRealValue(4))),
+			expected: "x^{2} = 4",
+		},
+		{
+			name: "Equality chain",
+			expr: prop.NewAnd(
+				prop.NewEqual(expr.NewVariable("a"), expr.NewVariable("b")),
+				prop.NewEqual(expr.NewVariable("b"), expr.NewVariable("c"))),
+			expected: "a = b = c",
+		},
 	}
 
 	for _, tt := range tests {
@@ -254,6 +418,25 @@ func TestRoundTrip(t *testing.T) {
 		{"Left associativity", "10 - 3 - 2"},
 		{"Right associativity", "10 - (3 - 2)"},
 		{"Complex expression", "(1 + 2) * (3 + 4)"},
+		{"Function", "\\sin 2 + \\cos(1 + 2)"},
+		{"Logarithm", "\\log_{2} 8 * \\ln 3"},
+		{"Common logarithm", "\\log 1000"},
+		{"Absolute value", "|2 - |1 - 4||"},
+		{"Floor and ceiling", "\\lfloor 7 / 2 \\rfloor - \\lceil 7 / 2 \\rceil"},
+		{"Factorial", "(2 + 1)! + 4!"},
+		{"Negation", "-(2 + 3) * 4 - (-1) + -3!"},
+		{"Binomial", "\\binom{5}{2} * 2"},
+		{"Fraction", "\\frac{1}{2} + \\dfrac{3}{\\tfrac{1}{4}}"},
+		{"Scientific notation", "6.02 \\times 10^{23} + 1.5e-3 * 1{,}000"},
+		{"Sum", "\\sum_{i=1}^{4} (i + 1) * 2 + 1"},
+		{"Nested product", "\\prod_{i=1}^{3} \\sum_{j=1}^{i} j"},
+		{"Integral", "\\int_{0}^{2} x + 1 \\, dx * 3"},
+		{"Limit", "\\lim_{x \\to 2} (x + 1) * x - 1"},
+		{"Piecewise", "\\begin{cases} 1 & 2 > 1 \\\\ 0 & \\text{otherwise} \\end{cases} + 1"},
+		{"Double integral", "\\int_{0}^{1} \\int_{0}^{2} x y \\, dy \\, dx"},
+		{"Power", "2^3 * (2^3)^2 + 2^{3^2} - (-2)^2 + -2^2"},
+		{"Root", "\\sqrt{16} + \\sqrt[3]{27} * \\sqrt{4}^{3}"},
+		{"Function power", "\\sin^2(1) + \\cos(1)^2 + \\log_{2}^{2}(8)"},
 	}
 
 	for _, tt := range tests {
@@ -296,8 +479,8 @@ func TestRoundTrip(t *testing.T) {
 				t.Fatalf("Evaluation failed")
 			}
 
-			num1, ok1 := val1.(*value.RealValue)
-			num2, ok2 := val2.(*value.RealValue)
+			num1, ok1 := value.ToReal(val1)
+			num2, ok2 := value.ToReal(val2)
 
 			if !ok1 || !ok2 {
 				t.Fatalf("Result is not a number")
@@ -309,3 +492,290 @@ func TestRoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestRoundTrip_Grouping(t *testing.T) {
+	// An operand of equal precedence keeps its parentheses under - and /
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a - (b + c)", "a - (b + c)"},
+		{"a - (b - c)", "a - (b - c)"},
+		{"a / (b c)", "a / (b * c)"},
+		{"\\frac{1}{2x}", "1 / (2 * x)"},
+		{"a + (b - c)", "a + (b - c)"},
+		{"a - b + c", "a - b + c"},
+		{"a * (b / c)", "a * (b / c)"},
+		{"(\\sin x)^{-1}", "\\sin(x)^{-1}"},
+		{"\\sin^{-1} x", "\\arcsin(x)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parsed, err := ParseLatex(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLatex failed: %v", err)
+			}
+			expression := parsed.(expr.Expr)
+			result, err := ExpressionToLatex(expression)
+			if err != nil {
+				t.Fatalf("ExpressionToLatex failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+			reparsed, err := ParseLatex(result)
+			if err != nil {
+				t.Fatalf("ParseLatex of %s failed: %v", result, err)
+			}
+			if !expression.Equals(reparsed) {
+				t.Errorf("%s changed the tree", result)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_BeyondFloat64(t *testing.T) {
+	// 1e400 is an exact integer whose float64 value is +Inf
+	parsed, err := ParseLatex("1e400 + x")
+	if err != nil {
+		t.Fatalf("ParseLatex failed: %v", err)
+	}
+	expression := parsed.(expr.Expr)
+	digits := "1" + strings.Repeat("0", 400)
+
+	result, err := ExpressionToLatex(expression)
+	if err != nil {
+		t.Fatalf("ExpressionToLatex failed: %v", err)
+	}
+	if result != digits+" + x" {
+		t.Errorf("Expected %s + x, got %s", digits, result)
+	}
+	reparsed, err := ParseLatex(result)
+	if err != nil {
+		t.Fatalf("ParseLatex of %s failed: %v", result, err)
+	}
+	if !expression.Equals(reparsed) {
+		t.Errorf("%s changed the tree", result)
+	}
+
+	mathml, err := ExpressionToMathML(expression)
+	if err != nil {
+		t.Fatalf("ExpressionToMathML failed: %v", err)
+	}
+	if !strings.Contains(mathml, "<mn>"+digits+"</mn>") || strings.Contains(mathml, "∞") {
+		t.Errorf("Expected the digits of 1e400, got %s", mathml)
+	}
+}
+
+func TestRoundTrip_Propositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2 + 3 = 5", "2 + 3 = 5"},
+		{"a = b = c", "a = b = c"},
+		{"1 = 1 = 1 = 1", "1 = 1 = 1 = 1"},
+		{"x^2 = 4", "x^{2} = 4"},
+		{"0 \\leq x < \\sqrt{2}", "0 \\le x < \\sqrt{2}"},
+		{"a < b \\le c = d", "a < b \\le c = d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parsed, err := ParseLatex(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLatex failed: %v", err)
+			}
+			proposition, ok := parsed.(prop.Proposition)
+			if !ok {
+				t.Fatalf("expected Proposition, got %T", parsed)
+			}
+
+			result, err := ExpressionToLatex(proposition)
+			if err != nil {
+				t.Fatalf("ExpressionToLatex failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+
+			reparsed, err := ParseLatex(result)
+			if err != nil {
+				t.Fatalf("Second ParseLatex failed: %v", err)
+			}
+			if !proposition.Equals(reparsed) {
+				t.Errorf("round trip changed the tree: %s", result)
+			}
+		})
+	}
+}
+
+func TestDefinitionToLatex(t *testing.T) {
+	definition := expr.NewDefinition("d", []string{"x", "y"},
+		expr.NewAdd(expr.NewAbs(expr.NewVariable("x")), expr.NewAbs(expr.NewVariable("y"))))
+	result, err := DefinitionToLatex(definition)
+	if err != nil {
+		t.Fatalf("DefinitionToLatex failed: %v", err)
+	}
+	if expected := "d(x, y) = \\left| x \\right| + \\left| y \\right|"; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestExpressionToLatexWithFractions(t *testing.T) {
+	a := expr.NewVariable("a")
+	b := expr.NewVariable("b")
+	c := expr.NewVariable("c")
+	tests := []struct {
+		name     string
+		expr     expr.Expr
+		expected string
+	}{
+		{"Fraction", expr.NewDiv(a, b), "\\frac{a}{b}"},
+		{"Sum over product", expr.NewDiv(expr.NewAdd(a, b), expr.NewMul(b, c)), "\\frac{a + b}{b * c}"},
+		{"Nested", expr.NewDiv(expr.NewDiv(a, b), c), "\\frac{\\frac{a}{b}}{c}"},
+		{"Product", expr.NewMul(a, expr.NewDiv(b, c)), "a * \\frac{b}{c}"},
+		{"Negated", expr.NewNeg(expr.NewDiv(a, b)), "-\\frac{a}{b}"},
+		{"Factorial", expr.NewFactorial(expr.NewDiv(a, b)), "(\\frac{a}{b})!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ExpressionToLatexWithOptions(tt.expr, RenderOptions{Fractions: true})
+			if err != nil {
+				t.Fatalf("ExpressionToLatexWithOptions failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+			reparsed, err := ParseLatex(result)
+			if err != nil {
+				t.Fatalf("ParseLatex(%s) failed: %v", result, err)
+			}
+			if !tt.expr.Equals(reparsed) {
+				t.Errorf("round trip of %s changed the tree", result)
+			}
+		})
+	}
+}
+
+func TestExpressionToLatex_Identifiers(t *testing.T) {
+	tests := []struct {
+		variable *expr.Variable
+		expected string
+	}{
+		{expr.NewVariable("x"), "x"},
+		{expr.NewVariable("x_1"), "x_1"},
+		{expr.NewSubscriptedVariable("x", "ij"), "x_{ij}"},
+		{expr.NewVariable("α"), "\\alpha"},
+		{expr.NewVariable("v_max"), "v_{max}"},
+		{expr.NewVariable("rate"), "\\mathrm{rate}"},
+		{expr.NewVariable("x_αi"), "x_{\\alpha i}"},
+		{expr.NewVariable("θ_0"), "\\theta_0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			result, err := ExpressionToLatex(tt.variable)
+			if err != nil {
+				t.Fatalf("ExpressionToLatex failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+			reparsed, err := ParseLatex(result)
+			if err != nil {
+				t.Fatalf("ParseLatex(%s) failed: %v", result, err)
+			}
+			if !tt.variable.Equals(reparsed) {
+				t.Errorf("round trip of %s changed the variable", result)
+			}
+		})
+	}
+}
+
+func TestExpressionToLatex_ExactInteger(t *testing.T) {
+	n, _ := new(big.Int).SetString("12345678901234567891", 10)
+	constant := expr.NewConstant(value.NewBigIntegerValue(n))
+
+	result, err := ExpressionToLatex(constant)
+	if err != nil {
+		t.Fatalf("ExpressionToLatex failed: %v", err)
+	}
+	if result != "12345678901234567891" {
+		t.Errorf("expected exact digits, got %s", result)
+	}
+	reparsed, err := ParseLatex(result)
+	if err != nil {
+		t.Fatalf("ParseLatex(%s) failed: %v", result, err)
+	}
+	if !constant.Equals(reparsed) {
+		t.Errorf("round trip of %s changed the value", result)
+	}
+
+	// Large reals print in scientific notation, which parses back
+	result, err = ExpressionToLatex(expr.NewConstant(value.NewRealValue(2.5e-7)))
+	if err != nil {
+		t.Fatalf("ExpressionToLatex failed: %v", err)
+	}
+	if v, err := ParseAndEval(result); err != nil || v.Float64() != 2.5e-7 {
+		t.Errorf("expected %s to parse back to 2.5e-7", result)
+	}
+}
+
+func TestExpressionToText(t *testing.T) {
+	tests := []struct {
+		input   string
+		latex   string
+		ascii   string
+		unicode string
+	}{
+		{"2x^2 + 1", "2x^{2} + 1", "2*x^2+1", "2x² + 1"},
+		{"2 * 3 + x y", "2 \\cdot 3 + x \\cdot y", "2*3+x*y", "2·3 + x·y"},
+		{"\\frac{a + b}{c} - 2(x + 1)", "\\frac{a + b}{c} - 2\\left(x + 1\\right)", "(a+b)/c-2*(x+1)", "(a + b)/c - 2(x + 1)"},
+		{"(2^3)^{n+1} + x^{-1} + x^{1.5}", "\\left(2^{3}\\right)^{n + 1} + x^{-1} + x^{1.5}", "(2^3)^(n+1)+x^(-1)+x^(1.5)", "(2³)ⁿ⁺¹ + x⁻¹ + x^(1.5)"},
+		{"\\sqrt{x} + \\sqrt[3]{x + 1} + \\sqrt[5]{2}", "\\sqrt{x} + \\sqrt[3]{x + 1} + \\sqrt[5]{2}", "sqrt(x)+root(3)(x+1)+root(5)(2)", "√x + ∛(x + 1) + ⁵√2"},
+		{"\\sin^2(x) + \\cos(x + 1) + \\log_{2}(8)", "\\sin^{2} x + \\cos\\left(x + 1\\right) + \\log_{2} 8", "sin(x)^2+cos(x+1)+log_2(8)", "sin²(x) + cos(x + 1) + log₂(8)"},
+		{"|x - 1| + \\lfloor x \\rfloor", "\\left| x - 1 \\right| + \\lfloor x \\rfloor", "abs(x-1)+floor(x)", "|x - 1| + ⌊x⌋"},
+		{"\\sum_{i=1}^{n} i^2", "\\sum_{i=1}^{n} i^{2}", "sum_(i=1)^n i^2", "∑ᵢ₌₁ⁿ i²"},
+		{"\\int_{0}^{1} x^2 \\, dx", "\\int_{0}^{1} x^{2} \\, dx", "int_0^1 x^2 dx", "∫₀¹ x² dx"},
+		{"\\lim_{x \\to 0^{+}} \\frac{1}{x}", "\\lim_{x \\to 0^{+}} \\frac{1}{x}", "lim_(x->0^+) 1/x", "lim_(x→0⁺) 1/x"},
+		{"0 \\le x_1 < \\alpha_{ij}", "0 \\le x_1 < \\alpha_{ij}", "0<=x_1<alpha_(ij)", "0 ≤ x₁ < αᵢⱼ"},
+		{"\\begin{cases} x & x > 0 \\\\ 0 & \\text{otherwise} \\end{cases}", "\\begin{cases} x & x > 0 \\\\ 0 & \\text{otherwise} \\end{cases}", "{x if x>0; 0 otherwise}", "{x if x > 0; 0 otherwise}"},
+		{"\\lim_{x \\to \\infty} x", "\\lim_{x \\to \\infty} x", "lim_(x->oo) x", "lim_(x→∞) x"},
+		{"a - (b + c) + \\frac{1}{2x}", "a - \\left(b + c\\right) + \\frac{1}{2x}", "a-(b+c)+1/(2*x)", "a - (b + c) + 1/(2x)"},
+		{"(\\sin x)! + (\\log_{2} x)!", "\\left(\\sin x\\right)! + \\left(\\log_{2} x\\right)!", "sin(x)!+log_2(x)!", "sin(x)! + log₂(x)!"},
+		{"\\sqrt{x}^2 + \\sqrt[3]{x}^2 + \\sqrt{x}!", "\\sqrt{x}^{2} + \\sqrt[3]{x}^{2} + \\sqrt{x}!", "sqrt(x)^2+root(3)(x)^2+sqrt(x)!", "(√x)² + (∛x)² + (√x)!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parsed, err := ParseLatex(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLatex failed: %v", err)
+			}
+			expression := parsed.(expr.Expr)
+
+			for style, expected := range map[RenderStyle]string{LatexStyle: tt.latex, ASCIIStyle: tt.ascii, UnicodeStyle: tt.unicode} {
+				result, err := ExpressionToText(expression, style)
+				if err != nil {
+					t.Fatalf("ExpressionToText failed: %v", err)
+				}
+				if result != expected {
+					t.Errorf("style %d: expected %s, got %s", style, expected, result)
+				}
+			}
+
+			// Publication LaTeX still parses back to the same tree
+			latex, _ := ExpressionToText(expression, LatexStyle)
+			reparsed, err := ParseLatex(latex)
+			if err != nil {
+				t.Fatalf("ParseLatex of %s failed: %v", latex, err)
+			}
+			if !expression.Equals(reparsed) {
+				t.Errorf("publication LaTeX %s changed the tree", latex)
+			}
+		})
+	}
+}
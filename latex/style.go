@@ -0,0 +1,149 @@
+package latex
+
+import (
+	"exprtree/expr"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RenderStyle selects the notation a Renderer writes
+type RenderStyle int
+
+const (
+	PlainStyle   RenderStyle = iota // LaTeX that parses back unchanged: a * b, a / b
+	LatexStyle                      // publication LaTeX: \frac, \cdot, 2x, \left( \right)
+	ASCIIStyle                      // compact ASCII: 2*x^2, sqrt(x), sum_(i=1)^n i
+	UnicodeStyle                    // Unicode text: 2x², √x, a·b
+)
+
+// superscripts maps characters to their Unicode superscript forms
+var superscripts = map[rune]rune{
+	'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴',
+	'5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+	'+': '⁺', '-': '⁻', '=': '⁼', '(': '⁽', ')': '⁾',
+	'a': 'ᵃ', 'b': 'ᵇ', 'c': 'ᶜ', 'd': 'ᵈ', 'e': 'ᵉ', 'f': 'ᶠ', 'g': 'ᵍ',
+	'h': 'ʰ', 'i': 'ⁱ', 'j': 'ʲ', 'k': 'ᵏ', 'l': 'ˡ', 'm': 'ᵐ', 'n': 'ⁿ',
+	'o': 'ᵒ', 'p': 'ᵖ', 'r': 'ʳ', 's': 'ˢ', 't': 'ᵗ', 'u': 'ᵘ', 'v': 'ᵛ',
+	'w': 'ʷ', 'x': 'ˣ', 'y': 'ʸ', 'z': 'ᶻ',
+}
+
+// subscripts maps characters to their Unicode subscript forms
+var subscripts = map[rune]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄',
+	'5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+	'+': '₊', '-': '₋', '=': '₌', '(': '₍', ')': '₎',
+	'a': 'ₐ', 'e': 'ₑ', 'h': 'ₕ', 'i': 'ᵢ', 'j': 'ⱼ', 'k': 'ₖ', 'l': 'ₗ',
+	'm': 'ₘ', 'n': 'ₙ', 'o': 'ₒ', 'p': 'ₚ', 'r': 'ᵣ', 's': 'ₛ', 't': 'ₜ',
+	'u': 'ᵤ', 'v': 'ᵥ', 'x': 'ₓ',
+}
+
+// shiftScript writes text with the characters of table, ignoring spaces
+// ok is false when a character has no form in the table.
+func shiftScript(text string, table map[rune]rune) (string, bool) {
+	var result strings.Builder
+	for _, r := range text {
+		if r == ' ' {
+			continue
+		}
+		shifted, ok := table[r]
+		if !ok {
+			return "", false
+		}
+		result.WriteRune(shifted)
+	}
+	return result.String(), result.Len() > 0
+}
+
+// isSimpleScript reports whether a script needs no parentheses in ASCII,
+// as in x^2, x_i, x^10 or the direction in 0^+
+func isSimpleScript(text string) bool {
+	if utf8.RuneCountInString(text) == 1 {
+		r, _ := utf8.DecodeRuneInString(text)
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '-'
+	}
+	return text != "" && strings.Trim(text, "0123456789") == ""
+}
+
+// script writes a sub- or superscript in ASCII or Unicode style
+// Unicode uses the raised or lowered characters when all of them exist and
+// falls back to the ASCII form, which parenthesizes compound scripts.
+func (r *Renderer) script(marker string, text string, table map[rune]rune) string {
+	if r.options.Style == UnicodeStyle {
+		if shifted, ok := shiftScript(text, table); ok {
+			return shifted
+		}
+	}
+	if isSimpleScript(text) {
+		return marker + text
+	}
+	return marker + "(" + text + ")"
+}
+
+// superscript writes an exponent such as ^2, ^(n+1) or ²
+func (r *Renderer) superscript(text string) string {
+	return r.script("^", text, superscripts)
+}
+
+// subscript writes a subscript such as _1, _(i=1) or ₁
+func (r *Renderer) subscript(text string) string {
+	return r.script("_", text, subscripts)
+}
+
+// textName writes a variable name for the ASCII and Unicode styles
+// ASCII spells Greek letters out, as in alpha_1.
+func (r *Renderer) textName(name string) string {
+	base, subscript := expr.SplitName(name)
+	if r.options.Style == ASCIIStyle {
+		base, subscript = spellGreek(base), spellGreek(subscript)
+	}
+	if subscript == "" {
+		return base
+	}
+	return base + r.subscript(subscript)
+}
+
+// spellGreek replaces Greek letters by the names of their commands
+func spellGreek(text string) string {
+	var result strings.Builder
+	for _, r := range text {
+		if command, ok := greekCommands[r]; ok {
+			result.WriteString(command)
+			continue
+		}
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+// textOperators spells operators in the ASCII and Unicode styles
+var textOperators = map[RenderStyle]map[TokenType]string{
+	ASCIIStyle: {
+		PLUS: "+", MINUS: "-", MULTIPLY: "*", DIVIDE: "/",
+		EQUAL: "=", LESS: "<", LESSEQ: "<=", GREATER: ">", GREATEREQ: ">=",
+	},
+	UnicodeStyle: {
+		PLUS: " + ", MINUS: " - ", MULTIPLY: "·", DIVIDE: "/",
+		EQUAL: " = ", LESS: " < ", LESSEQ: " ≤ ", GREATER: " > ", GREATEREQ: " ≥ ",
+	},
+}
+
+// textBigOperators spell big operators and integrals in the ASCII and
+// Unicode styles
+var textBigOperators = map[RenderStyle]map[string]string{
+	ASCIIStyle:   {"sum": "sum", "prod": "prod", "int": "int"},
+	UnicodeStyle: {"sum": "∑", "prod": "∏", "int": "∫"},
+}
+
+// textOperator spells a big operator or integral in the ASCII and Unicode
+// styles
+func (r *Renderer) textOperator(name string) string {
+	return textBigOperators[r.options.Style][name]
+}
+
+// latexOperators spells operators in publication LaTeX
+var latexOperators = map[TokenType]string{
+	MULTIPLY:  " \\cdot ",
+	LESSEQ:    " \\le ",
+	GREATEREQ: " \\ge ",
+}
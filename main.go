@@ -5,10 +5,20 @@ import (
 	"exprtree/latex"
 	"exprtree/prop"
 	"exprtree/value"
+	"flag"
 	"fmt"
+	"os"
 )
 
 func main() {
+	ascii := flag.Bool("ascii", false, "draw with ASCII characters only")
+	width := flag.Int("width", 80, "break long sums at this many columns (0 disables)")
+	flag.Parse()
+	if flag.NArg() > 0 {
+		// 引数の LaTeX を整形して表示する
+		os.Exit(prettyPrint(flag.Args(), latex.PrettyOptions{ASCII: *ascii, Width: *width}))
+	}
+
 	fmt.Println("=== 単純な等式のテスト ===")
 	testSimpleEquality()
 
@@ -130,3 +140,34 @@ func testFourTermEquality() {
 		_ = rightEqual
 	}
 }
+
+// prettyPrint parses each LaTeX argument and prints it laid out in two
+// dimensions, returning the exit status
+func prettyPrint(inputs []string, options latex.PrettyOptions) int {
+	status := 0
+	for i, input := range inputs {
+		if i > 0 {
+			fmt.Println()
+		}
+		result, err := latex.ParseLatex(input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		expression, ok := result.(expr.Expr)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "cannot print %T\n", result)
+			status = 1
+			continue
+		}
+		pretty, err := latex.ExpressionToPretty(expression, options)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		fmt.Println(pretty)
+	}
+	return status
+}
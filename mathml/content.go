@@ -0,0 +1,396 @@
+package mathml
+
+import (
+	"exprtree/expr"
+	"exprtree/value"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// mathMLNamespace is the XML namespace of the math element
+const mathMLNamespace = "http://www.w3.org/1998/Math/MathML"
+
+// ExpressionToContent converts an expression or proposition to Content
+// MathML such as <apply><plus/><ci>x</ci><cn>1</cn></apply>
+func ExpressionToContent(expression expr.Expr) (string, error) {
+	t, err := toTerm(expression)
+	if err != nil {
+		return "", err
+	}
+	content, err := contentElement(t)
+	if err != nil {
+		return "", err
+	}
+	return marshal(newElement("math", content).withAttr("xmlns", mathMLNamespace))
+}
+
+// ParseContent reads a Content MathML math element
+func ParseContent(input string) (expr.Expr, error) {
+	return ParseContentWithOptions(input, Options{})
+}
+
+// ParseContentWithOptions reads a Content MathML math element with options
+func ParseContentWithOptions(input string, options Options) (expr.Expr, error) {
+	root, err := unmarshal(input, "math")
+	if err != nil {
+		return nil, err
+	}
+	if len(root.Children) != 1 {
+		return nil, fmt.Errorf("expected one expression in <math>, got %d", len(root.Children))
+	}
+	t, err := contentTerm(root.Children[0])
+	if err != nil {
+		return nil, err
+	}
+	reader := &reader{options: options}
+	return reader.expression(t)
+}
+
+// contentElement converts a term to Content MathML
+func contentElement(t *term) (*element, error) {
+	switch {
+	case t.number != nil:
+		return numberElement(t.number)
+	case t.variable != "":
+		return textElement("ci", t.variable), nil
+	case t.function != "":
+		args, err := contentElements(t.args)
+		if err != nil {
+			return nil, err
+		}
+		return newElement("apply", append([]*element{textElement("ci", t.function)}, args...)...), nil
+	}
+
+	args, err := contentElements(t.args)
+	if err != nil {
+		return nil, err
+	}
+	switch t.symbol {
+	case symbolNaN:
+		return newElement("notanumber"), nil
+	case symbolInterval:
+		return newElement("interval", args...).withAttr("closure", "closed"), nil
+	case symbolPiecewise:
+		return newElement("piecewise", args...), nil
+	case symbolPiece, symbolOtherwise:
+		return newElement(t.symbol, args...), nil
+	case symbolUnaryMinus:
+		return newElement("apply", newElement("minus"), args[0]), nil
+	case symbolBinomial:
+		head := textElement("csymbol", symbolBinomial).withAttr("cd", contentDictionaries[symbolBinomial])
+		return newElement("apply", head, args[0], args[1]), nil
+	case symbolLog:
+		// The base defaults to 10
+		if isNumber(t.args[0], 10) {
+			return newElement("apply", newElement("log"), args[1]), nil
+		}
+		return newElement("apply", newElement("log"), newElement("logbase", args[0]), args[1]), nil
+	case symbolRoot:
+		// The degree defaults to 2
+		if isNumber(t.args[1], 2) {
+			return newElement("apply", newElement("root"), args[0]), nil
+		}
+		return newElement("apply", newElement("root"), newElement("degree", args[1]), args[0]), nil
+	case symbolSum, symbolProduct, symbolDefint:
+		head := t.symbol
+		if head == symbolDefint {
+			head = symbolInt
+		}
+		return newElement("apply", newElement(head), bvar(t.bound),
+			newElement("lowlimit", args[0]), newElement("uplimit", args[1]), args[2]), nil
+	case symbolInt:
+		return newElement("apply", newElement("int"), bvar(t.bound), args[0]), nil
+	case symbolLimit:
+		return limitElement(t, args), nil
+	}
+	if isConstantSymbol(t.symbol) {
+		return newElement(t.symbol), nil
+	}
+	return newElement("apply", append([]*element{newElement(t.symbol)}, args...)...), nil
+}
+
+func contentElements(terms []*term) ([]*element, error) {
+	elements := make([]*element, len(terms))
+	for i, t := range terms {
+		e, err := contentElement(t)
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = e
+	}
+	return elements, nil
+}
+
+// numberElement writes a real as <cn> and an integer as <cn type="integer">
+func numberElement(number value.Value) (*element, error) {
+	switch n := number.(type) {
+	case *value.RealValue:
+		return textElement("cn", strconv.FormatFloat(n.Float64(), 'g', -1, 64)), nil
+	case *value.IntegerValue:
+		return textElement("cn", n.BigInt().String()).withAttr("type", "integer"), nil
+	default:
+		return nil, fmt.Errorf("unsupported number type: %T", number)
+	}
+}
+
+// isNumber reports whether a term is the real number x
+func isNumber(t *term, x float64) bool {
+	return t.number != nil && t.number.Equals(value.NewRealValue(x))
+}
+
+// bvar declares a bound variable
+func bvar(name string) *element {
+	return newElement("bvar", textElement("ci", name))
+}
+
+// limitElement writes a two-sided limit with a lowlimit and a one-sided
+// limit with a tendsto condition
+func limitElement(t *term, args []*element) *element {
+	if t.direction == limitDirections[expr.TwoSided] {
+		return newElement("apply", newElement("limit"), bvar(t.bound), newElement("lowlimit", args[0]), args[1])
+	}
+	tendsto := newElement("tendsto").withAttr("type", t.direction)
+	condition := newElement("condition", newElement("apply", tendsto, textElement("ci", t.bound), args[0]))
+	return newElement("apply", newElement("limit"), bvar(t.bound), condition, args[1])
+}
+
+// contentTerm reads a Content MathML element
+func contentTerm(e *element) (*term, error) {
+	switch e.name() {
+	case "cn":
+		return numberTerm(e)
+	case "ci":
+		if e.text() == "" {
+			return nil, fmt.Errorf("empty <ci>")
+		}
+		return &term{variable: e.text()}, nil
+	case "notanumber":
+		return apply(symbolNaN), nil
+	case "interval":
+		if closure := e.attr("closure"); closure != "" && closure != "closed" {
+			return nil, fmt.Errorf("unsupported interval closure: %s", closure)
+		}
+		return contentApply(symbolInterval, e.Children)
+	case "piecewise":
+		return contentApply(symbolPiecewise, e.Children)
+	case "piece", "otherwise":
+		return contentApply(e.name(), e.Children)
+	case "apply":
+		return applyTerm(e)
+	}
+	if isConstantSymbol(e.name()) {
+		return apply(e.name()), nil
+	}
+	return nil, fmt.Errorf("unknown symbol: <%s>", e.name())
+}
+
+// numberTerm reads a <cn> element
+func numberTerm(e *element) (*term, error) {
+	switch e.attr("type") {
+	case "", "real", "double":
+		x, err := strconv.ParseFloat(e.text(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number: %s", e.text())
+		}
+		return &term{number: value.NewRealValue(x)}, nil
+	case "integer":
+		n, ok := new(big.Int).SetString(e.text(), 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer: %s", e.text())
+		}
+		return &term{number: value.NewBigIntegerValue(n)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported number type: %s", e.attr("type"))
+	}
+}
+
+func contentApply(symbol string, children []*element) (*term, error) {
+	args, err := contentTerms(children)
+	if err != nil {
+		return nil, err
+	}
+	return apply(symbol, args...), nil
+}
+
+func contentTerms(elements []*element) ([]*term, error) {
+	terms := make([]*term, len(elements))
+	for i, e := range elements {
+		t, err := contentTerm(e)
+		if err != nil {
+			return nil, err
+		}
+		terms[i] = t
+	}
+	return terms, nil
+}
+
+// qualifierNames lists the qualifier elements of an apply
+var qualifierNames = map[string]bool{
+	"bvar":      true,
+	"lowlimit":  true,
+	"uplimit":   true,
+	"degree":    true,
+	"logbase":   true,
+	"condition": true,
+}
+
+// applyTerm reads an <apply> element, separating its qualifiers such as
+// <bvar> and <lowlimit> from its arguments
+func applyTerm(e *element) (*term, error) {
+	if len(e.Children) == 0 {
+		return nil, fmt.Errorf("empty <apply>")
+	}
+	head := e.Children[0]
+	qualifiers := map[string]*element{}
+	var children []*element
+	for _, child := range e.Children[1:] {
+		if qualifierNames[child.name()] {
+			if len(child.Children) != 1 {
+				return nil, fmt.Errorf("expected one element in <%s>", child.name())
+			}
+			qualifiers[child.name()] = child.Children[0]
+		} else {
+			children = append(children, child)
+		}
+	}
+	args, err := contentTerms(children)
+	if err != nil {
+		return nil, err
+	}
+	qualifier := func(name string) (*term, error) {
+		q, ok := qualifiers[name]
+		if !ok {
+			return nil, fmt.Errorf("<%s> expects <%s>", head.name(), name)
+		}
+		delete(qualifiers, name)
+		return contentTerm(q)
+	}
+
+	var result *term
+	switch head.name() {
+	case "ci":
+		result = &term{function: head.text(), args: args}
+	case "csymbol":
+		symbol, ok := lookupSymbol(head.attr("cd"), head.text())
+		if !ok {
+			return nil, fmt.Errorf("unknown symbol: %s.%s", head.attr("cd"), head.text())
+		}
+		result = apply(symbol, args...)
+	case "minus":
+		if len(args) == 1 {
+			result = apply(symbolUnaryMinus, args...)
+		} else {
+			result = apply(symbolMinus, args...)
+		}
+	case "log":
+		base := &term{number: value.NewRealValue(10)}
+		if _, ok := qualifiers["logbase"]; ok {
+			if base, err = qualifier("logbase"); err != nil {
+				return nil, err
+			}
+		}
+		result = apply(symbolLog, append([]*term{base}, args...)...)
+	case "root":
+		degree := &term{number: value.NewRealValue(2)}
+		if _, ok := qualifiers["degree"]; ok {
+			if degree, err = qualifier("degree"); err != nil {
+				return nil, err
+			}
+		}
+		result = apply(symbolRoot, append(args, degree)...)
+	case "sum", "product", "int", "limit":
+		if result, err = binderTerm(head.name(), args, qualifiers, qualifier); err != nil {
+			return nil, err
+		}
+	default:
+		if _, ok := contentDictionaries[head.name()]; !ok || isConstantSymbol(head.name()) || isBinder(head.name()) {
+			return nil, fmt.Errorf("unknown symbol: <%s>", head.name())
+		}
+		result = apply(head.name(), args...)
+	}
+
+	for name := range qualifiers {
+		return nil, fmt.Errorf("unexpected <%s> in <apply>", name)
+	}
+	return result, nil
+}
+
+// binderTerm reads the qualifiers of a sum, product, integral or limit
+func binderTerm(head string, args []*term, qualifiers map[string]*element, qualifier func(string) (*term, error)) (*term, error) {
+	variable, err := qualifier("bvar")
+	if err != nil {
+		return nil, err
+	}
+	if variable.variable == "" {
+		return nil, fmt.Errorf("<bvar> expects <ci>")
+	}
+	result := &term{symbol: head, bound: variable.variable}
+
+	var bounds []*term
+	switch head {
+	case "limit":
+		point, direction, err := approach(qualifiers, qualifier)
+		if err != nil {
+			return nil, err
+		}
+		result.direction = direction
+		bounds = []*term{point}
+	case "int":
+		if _, ok := qualifiers["lowlimit"]; !ok {
+			break
+		}
+		result.symbol = symbolDefint
+		fallthrough
+	default:
+		lower, err := qualifier("lowlimit")
+		if err != nil {
+			return nil, err
+		}
+		upper, err := qualifier("uplimit")
+		if err != nil {
+			return nil, err
+		}
+		bounds = []*term{lower, upper}
+	}
+	result.args = append(bounds, args...)
+	return result, nil
+}
+
+// approach reads the point and direction of a limit from a lowlimit or a
+// tendsto condition
+func approach(qualifiers map[string]*element, qualifier func(string) (*term, error)) (*term, string, error) {
+	if _, ok := qualifiers["lowlimit"]; ok {
+		point, err := qualifier("lowlimit")
+		return point, limitDirections[expr.TwoSided], err
+	}
+	condition, ok := qualifiers["condition"]
+	delete(qualifiers, "condition")
+	if !ok || condition.name() != "apply" || len(condition.Children) != 3 || condition.Children[0].name() != "tendsto" {
+		return nil, "", fmt.Errorf("<limit> expects <lowlimit> or a <tendsto> condition")
+	}
+	direction := condition.Children[0].attr("type")
+	if direction == "" {
+		direction = limitDirections[expr.TwoSided]
+	}
+	point, err := contentTerm(condition.Children[2])
+	return point, direction, err
+}
+
+// isBinder reports whether a symbol binds a variable
+func isBinder(symbol string) bool {
+	switch symbol {
+	case symbolSum, symbolProduct, symbolInt, symbolDefint, symbolLimit:
+		return true
+	default:
+		return false
+	}
+}
+
+// lookupSymbol returns the symbol named name in a content dictionary
+func lookupSymbol(cd, name string) (string, bool) {
+	if contentDictionaries[name] == cd && cd != "" {
+		return name, true
+	}
+	return "", false
+}
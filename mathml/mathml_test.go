@@ -0,0 +1,254 @@
+package mathml_test
+
+import (
+	"exprtree/expr"
+	"exprtree/latex"
+	"exprtree/mathml"
+	"exprtree/value"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, input string) expr.Expr {
+	t.Helper()
+	result, err := latex.ParseLatex(input)
+	if err != nil {
+		t.Fatalf("ParseLatex failed: %v", err)
+	}
+	return result.(expr.Expr)
+}
+
+var roundTripInputs = []string{
+	"2x^2 + 1",
+	"a - (b - c)",
+	"-x / 3",
+	"\\frac{a + b}{c}",
+	"\\sqrt{x} + \\sqrt[3]{2}",
+	"\\log_{2}(x) + \\log(x) + \\ln(x)",
+	"\\sin^2(x) + \\cos(x)",
+	"|x| + \\lfloor x \\rfloor + \\lceil x \\rceil",
+	"n! + \\binom{n}{k}",
+	"\\sum_{i=1}^{n} i^2",
+	"\\prod_{k=1}^{n} k",
+	"\\int_{0}^{1} x^2 dx",
+	"\\int x dx",
+	"\\lim_{x \\to 0} x",
+	"\\lim_{x \\to 0^+} \\frac{1}{x}",
+	"\\lim_{x \\to \\infty} \\frac{1}{x}",
+	"\\lim_{x \\to -\\infty} x",
+	"\\begin{cases} x & x > 0 \\\\ 0 & \\text{otherwise} \\end{cases}",
+	"a = b",
+	"0 \\le x < 1",
+	"x_1 \\ge \\alpha",
+}
+
+func TestContent_RoundTrip(t *testing.T) {
+	for _, input := range roundTripInputs {
+		t.Run(input, func(t *testing.T) {
+			original := parse(t, input)
+			content, err := mathml.ExpressionToContent(original)
+			if err != nil {
+				t.Fatalf("ExpressionToContent failed: %v", err)
+			}
+			result, err := mathml.ParseContent(content)
+			if err != nil {
+				t.Fatalf("ParseContent failed for %s: %v", content, err)
+			}
+			if !result.Equals(original) {
+				t.Errorf("round trip through %s changed the expression", content)
+			}
+		})
+	}
+}
+
+func TestOpenMath_RoundTrip(t *testing.T) {
+	for _, input := range roundTripInputs {
+		t.Run(input, func(t *testing.T) {
+			original := parse(t, input)
+			object, err := mathml.ExpressionToOpenMath(original)
+			if err != nil {
+				t.Fatalf("ExpressionToOpenMath failed: %v", err)
+			}
+			result, err := mathml.ParseOpenMath(object)
+			if err != nil {
+				t.Fatalf("ParseOpenMath failed for %s: %v", object, err)
+			}
+			if !result.Equals(original) {
+				t.Errorf("round trip through %s changed the expression", object)
+			}
+		})
+	}
+}
+
+func TestExpressionToContent(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x + 1", "<apply><plus></plus><ci>x</ci><cn>1</cn></apply>"},
+		{"-x", "<apply><minus></minus><ci>x</ci></apply>"},
+		{"\\sqrt{x}", "<apply><root></root><ci>x</ci></apply>"},
+		{"\\log_{2}(x)", "<apply><log></log><logbase><cn>2</cn></logbase><ci>x</ci></apply>"},
+		{"\\binom{n}{k}", "<apply><csymbol cd=\"combinat1\">binomial</csymbol><ci>n</ci><ci>k</ci></apply>"},
+		{"\\sum_{i=1}^{n} i", "<apply><sum></sum><bvar><ci>i</ci></bvar><lowlimit><cn>1</cn></lowlimit><uplimit><ci>n</ci></uplimit><ci>i</ci></apply>"},
+		{"\\lim_{x \\to 0^-} x", "<apply><limit></limit><bvar><ci>x</ci></bvar><condition><apply><tendsto type=\"below\"></tendsto><ci>x</ci><cn>0</cn></apply></condition><ci>x</ci></apply>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := mathml.ExpressionToContent(parse(t, tt.input))
+			if err != nil {
+				t.Fatalf("ExpressionToContent failed: %v", err)
+			}
+			expected := `<math xmlns="http://www.w3.org/1998/Math/MathML">` + tt.expected + "</math>"
+			if result != expected {
+				t.Errorf("Expected %s, got %s", expected, result)
+			}
+		})
+	}
+}
+
+func TestExpressionToOpenMath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x + 1", `<OMA><OMS cd="arith1" name="plus"></OMS><OMV name="x"></OMV><OMF dec="1"></OMF></OMA>`},
+		{"\\sin(x)", `<OMA><OMS cd="transc1" name="sin"></OMS><OMV name="x"></OMV></OMA>`},
+		{"\\int x dx", `<OMA><OMS cd="calculus1" name="int"></OMS><OMBIND><OMS cd="fns1" name="lambda"></OMS><OMBVAR><OMV name="x"></OMV></OMBVAR><OMV name="x"></OMV></OMBIND></OMA>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := mathml.ExpressionToOpenMath(parse(t, tt.input))
+			if err != nil {
+				t.Fatalf("ExpressionToOpenMath failed: %v", err)
+			}
+			expected := `<OMOBJ xmlns="http://www.openmath.org/OpenMath" version="2.0">` + tt.expected + "</OMOBJ>"
+			if result != expected {
+				t.Errorf("Expected %s, got %s", expected, result)
+			}
+		})
+	}
+}
+
+func TestParseContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"n-ary plus", "<apply><plus/><ci>a</ci><ci>b</ci><ci>c</ci></apply>", "a + b + c"},
+		{"n-ary eq", "<apply><eq/><ci>a</ci><ci>b</ci><ci>c</ci></apply>", "a = b = c"},
+		{"double", `<cn type="double">2.5</cn>`, "2.5"},
+		{"two-sided tendsto", "<apply><limit/><bvar><ci>x</ci></bvar><condition><apply><tendsto/><ci>x</ci><cn>1</cn></apply></condition><ci>x</ci></apply>", "\\lim_{x \\to 1} x"},
+		{"whitespace", "\n  <apply>\n    <times/>\n    <cn> 2 </cn>\n    <ci> x </ci>\n  </apply>\n", "2 * x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `<math xmlns="http://www.w3.org/1998/Math/MathML">` + tt.input + "</math>"
+			result, err := mathml.ParseContent(input)
+			if err != nil {
+				t.Fatalf("ParseContent failed: %v", err)
+			}
+			if expected := parse(t, tt.expected); !result.Equals(expected) {
+				t.Errorf("Expected %v, got %v", expected, result)
+			}
+		})
+	}
+}
+
+func TestParse_Integers(t *testing.T) {
+	n := expr.NewConstant(value.NewBigIntegerValue(new(big.Int).Lsh(big.NewInt(1), 100)))
+	content, err := mathml.ExpressionToContent(n)
+	if err != nil {
+		t.Fatalf("ExpressionToContent failed: %v", err)
+	}
+	if !strings.Contains(content, `<cn type="integer">1267650600228229401496703205376</cn>`) {
+		t.Errorf("unexpected integer encoding: %s", content)
+	}
+	object, err := mathml.ExpressionToOpenMath(n)
+	if err != nil {
+		t.Fatalf("ExpressionToOpenMath failed: %v", err)
+	}
+	for _, parse := range []func(string) (expr.Expr, error){
+		func(string) (expr.Expr, error) { return mathml.ParseContent(content) },
+		func(string) (expr.Expr, error) { return mathml.ParseOpenMath(object) },
+	} {
+		result, err := parse("")
+		if err != nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+		if !result.Equals(n) {
+			t.Errorf("Expected %v, got %v", n, result)
+		}
+	}
+}
+
+func TestParse_Calls(t *testing.T) {
+	definitions := expr.NewDefinitions()
+	x := expr.NewVariable("x")
+	definitions.Define(expr.NewDefinition("f", []string{"x"}, expr.NewMul(x, x)))
+	call := expr.NewCall(definitions, "f", []expr.Expr{expr.NewConstant(value.NewRealValue(3))})
+
+	content, err := mathml.ExpressionToContent(call)
+	if err != nil {
+		t.Fatalf("ExpressionToContent failed: %v", err)
+	}
+	if _, err := mathml.ParseContent(content); err == nil || !strings.Contains(err.Error(), "unknown function: f") {
+		t.Errorf("expected unknown function error, got %v", err)
+	}
+	result, err := mathml.ParseContentWithOptions(content, mathml.Options{Definitions: definitions})
+	if err != nil {
+		t.Fatalf("ParseContentWithOptions failed: %v", err)
+	}
+	if v, ok := result.Eval(); !ok || !v.Equals(value.NewRealValue(9)) {
+		t.Errorf("Expected 9, got %v", v)
+	}
+
+	object, err := mathml.ExpressionToOpenMath(call)
+	if err != nil {
+		t.Fatalf("ExpressionToOpenMath failed: %v", err)
+	}
+	result, err = mathml.ParseOpenMathWithOptions(object, mathml.Options{Definitions: definitions})
+	if err != nil {
+		t.Fatalf("ParseOpenMathWithOptions failed: %v", err)
+	}
+	if !result.Equals(call) {
+		t.Errorf("Expected %v, got %v", call, result)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		parse  func(string) (expr.Expr, error)
+		input  string
+		errMsg string
+	}{
+		{"content unknown symbol", mathml.ParseContent, "<math><apply><gcd/><cn>4</cn><cn>6</cn></apply></math>", "unknown symbol: <gcd>"},
+		{"content unknown csymbol", mathml.ParseContent, `<math><apply><csymbol cd="arith1">gcd</csymbol><cn>4</cn></apply></math>`, "unknown symbol: arith1.gcd"},
+		{"content arity", mathml.ParseContent, "<math><apply><divide/><cn>4</cn></apply></math>", "divide"},
+		{"content number type", mathml.ParseContent, `<math><cn type="rational">1</cn></math>`, "unsupported number type: rational"},
+		{"content root", mathml.ParseContent, "<apply/>", "expected <math> element"},
+		{"content malformed", mathml.ParseContent, "<math><ci>x</math>", "failed to read XML"},
+		{"openmath unknown symbol", mathml.ParseOpenMath, `<OMOBJ><OMA><OMS cd="arith1" name="gcd"/><OMI>4</OMI></OMA></OMOBJ>`, "unknown symbol: arith1.gcd"},
+		{"openmath wrong cd", mathml.ParseOpenMath, `<OMOBJ><OMA><OMS cd="arith2" name="plus"/><OMI>4</OMI><OMI>4</OMI></OMA></OMOBJ>`, "unknown symbol: arith2.plus"},
+		{"openmath unsupported", mathml.ParseOpenMath, `<OMOBJ><OMSTR>x</OMSTR></OMOBJ>`, "unsupported OpenMath element: <OMSTR>"},
+		{"openmath direction", mathml.ParseOpenMath, `<OMOBJ><OMA><OMS cd="limit1" name="limit"/><OMI>0</OMI><OMS cd="limit1" name="null"/><OMBIND><OMS cd="fns1" name="lambda"/><OMBVAR><OMV name="x"/></OMBVAR><OMV name="x"/></OMBIND></OMA></OMOBJ>`, "unknown limit direction: null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parse(tt.input)
+			if err == nil {
+				t.Fatalf("expected error containing %q", tt.errMsg)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+}
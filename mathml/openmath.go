@@ -0,0 +1,270 @@
+package mathml
+
+import (
+	"exprtree/expr"
+	"exprtree/value"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// openMathNamespace is the XML namespace of OpenMath objects
+const openMathNamespace = "http://www.openmath.org/OpenMath"
+
+// ExpressionToOpenMath converts an expression or proposition to an
+// OpenMath object such as <OMA><OMS cd="arith1" name="plus"/>...</OMA>
+func ExpressionToOpenMath(expression expr.Expr) (string, error) {
+	t, err := toTerm(expression)
+	if err != nil {
+		return "", err
+	}
+	object, err := openMathElement(t)
+	if err != nil {
+		return "", err
+	}
+	root := newElement("OMOBJ", object).withAttr("xmlns", openMathNamespace).withAttr("version", "2.0")
+	return marshal(root)
+}
+
+// ParseOpenMath reads an OpenMath OMOBJ element
+func ParseOpenMath(input string) (expr.Expr, error) {
+	return ParseOpenMathWithOptions(input, Options{})
+}
+
+// ParseOpenMathWithOptions reads an OpenMath OMOBJ element with options
+func ParseOpenMathWithOptions(input string, options Options) (expr.Expr, error) {
+	root, err := unmarshal(input, "OMOBJ")
+	if err != nil {
+		return nil, err
+	}
+	if len(root.Children) != 1 {
+		return nil, fmt.Errorf("expected one object in <OMOBJ>, got %d", len(root.Children))
+	}
+	t, err := openMathTerm(root.Children[0])
+	if err != nil {
+		return nil, err
+	}
+	reader := &reader{options: options}
+	return reader.expression(t)
+}
+
+// oms refers to a symbol in its content dictionary
+func oms(cd, name string) *element {
+	return newElement("OMS").withAttr("cd", cd).withAttr("name", name)
+}
+
+// omv refers to a variable
+func omv(name string) *element {
+	return newElement("OMV").withAttr("name", name)
+}
+
+// oma applies a head to arguments
+func oma(head *element, args ...*element) *element {
+	return newElement("OMA", append([]*element{head}, args...)...)
+}
+
+// lambda binds a variable in a body with fns1.lambda
+func lambda(variable string, body *element) *element {
+	return newElement("OMBIND", oms("fns1", "lambda"), newElement("OMBVAR", omv(variable)), body)
+}
+
+// openMathElement converts a term to OpenMath
+func openMathElement(t *term) (*element, error) {
+	switch {
+	case t.number != nil:
+		switch n := t.number.(type) {
+		case *value.RealValue:
+			return newElement("OMF").withAttr("dec", strconv.FormatFloat(n.Float64(), 'g', -1, 64)), nil
+		case *value.IntegerValue:
+			return textElement("OMI", n.BigInt().String()), nil
+		default:
+			return nil, fmt.Errorf("unsupported number type: %T", t.number)
+		}
+	case t.variable != "":
+		return omv(t.variable), nil
+	}
+
+	args := make([]*element, len(t.args))
+	for i, arg := range t.args {
+		e, err := openMathElement(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = e
+	}
+	if t.function != "" {
+		return oma(omv(t.function), args...), nil
+	}
+
+	symbol := oms(contentDictionaries[t.symbol], t.symbol)
+	switch t.symbol {
+	case symbolSum, symbolProduct:
+		bounds := oma(oms("interval1", "integer_interval"), args[0], args[1])
+		return oma(symbol, bounds, lambda(t.bound, args[2])), nil
+	case symbolDefint:
+		bounds := oma(oms("interval1", "interval"), args[0], args[1])
+		return oma(symbol, bounds, lambda(t.bound, args[2])), nil
+	case symbolInt:
+		return oma(symbol, lambda(t.bound, args[0])), nil
+	case symbolLimit:
+		return oma(symbol, args[0], oms("limit1", t.direction), lambda(t.bound, args[1])), nil
+	}
+	if isConstantSymbol(t.symbol) {
+		return symbol, nil
+	}
+	return oma(symbol, args...), nil
+}
+
+// openMathTerm reads an OpenMath element
+func openMathTerm(e *element) (*term, error) {
+	switch e.name() {
+	case "OMI":
+		n, ok := new(big.Int).SetString(e.text(), 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer: %s", e.text())
+		}
+		return &term{number: value.NewBigIntegerValue(n)}, nil
+	case "OMF":
+		return floatTerm(e)
+	case "OMV":
+		if e.attr("name") == "" {
+			return nil, fmt.Errorf("<OMV> without a name")
+		}
+		return &term{variable: e.attr("name")}, nil
+	case "OMS":
+		symbol, err := openMathSymbol(e)
+		if err != nil {
+			return nil, err
+		}
+		if !isConstantSymbol(symbol) {
+			return nil, fmt.Errorf("symbol %s.%s is not applied", e.attr("cd"), symbol)
+		}
+		return apply(symbol), nil
+	case "OMA":
+		return applicationTerm(e)
+	default:
+		return nil, fmt.Errorf("unsupported OpenMath element: <%s>", e.name())
+	}
+}
+
+// floatTerm reads an OMF element written in decimal or as hexadecimal
+// IEEE 754 bits
+func floatTerm(e *element) (*term, error) {
+	if dec := e.attr("dec"); dec != "" {
+		x, err := strconv.ParseFloat(dec, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float: %s", dec)
+		}
+		return &term{number: value.NewRealValue(x)}, nil
+	}
+	bits, err := strconv.ParseUint(e.attr("hex"), 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid float: %s", e.attr("hex"))
+	}
+	return &term{number: value.NewRealValue(math.Float64frombits(bits))}, nil
+}
+
+// openMathSymbol resolves an OMS element to a symbol
+func openMathSymbol(e *element) (string, error) {
+	symbol, ok := lookupSymbol(e.attr("cd"), e.attr("name"))
+	if !ok {
+		return "", fmt.Errorf("unknown symbol: %s.%s", e.attr("cd"), e.attr("name"))
+	}
+	return symbol, nil
+}
+
+// applicationTerm reads an OMA element
+func applicationTerm(e *element) (*term, error) {
+	if len(e.Children) == 0 {
+		return nil, fmt.Errorf("empty <OMA>")
+	}
+	head, children := e.Children[0], e.Children[1:]
+	if head.name() == "OMV" {
+		args, err := openMathTerms(children)
+		if err != nil {
+			return nil, err
+		}
+		return &term{function: head.attr("name"), args: args}, nil
+	}
+	if head.name() != "OMS" {
+		return nil, fmt.Errorf("unsupported head of <OMA>: <%s>", head.name())
+	}
+	symbol, err := openMathSymbol(head)
+	if err != nil {
+		return nil, err
+	}
+
+	switch symbol {
+	case symbolSum, symbolProduct, symbolDefint:
+		if len(children) != 2 {
+			return nil, fmt.Errorf("%s expects bounds and a lambda", symbol)
+		}
+		bounds, err := intervalBounds(symbol, children[0])
+		if err != nil {
+			return nil, err
+		}
+		return binding(symbol, "", bounds, children[1])
+	case symbolInt:
+		if len(children) != 1 {
+			return nil, fmt.Errorf("int expects a lambda")
+		}
+		return binding(symbol, "", nil, children[0])
+	case symbolLimit:
+		if len(children) != 3 || children[1].name() != "OMS" || children[1].attr("cd") != "limit1" {
+			return nil, fmt.Errorf("limit expects a point, a direction and a lambda")
+		}
+		point, err := openMathTerm(children[0])
+		if err != nil {
+			return nil, err
+		}
+		return binding(symbol, children[1].attr("name"), []*term{point}, children[2])
+	}
+	if isConstantSymbol(symbol) {
+		return nil, fmt.Errorf("symbol %s.%s cannot be applied", head.attr("cd"), symbol)
+	}
+	args, err := openMathTerms(children)
+	if err != nil {
+		return nil, err
+	}
+	return apply(symbol, args...), nil
+}
+
+// intervalBounds reads the interval1 interval or integer_interval a sum,
+// product or definite integral ranges over
+func intervalBounds(symbol string, e *element) ([]*term, error) {
+	if e.name() != "OMA" || len(e.Children) != 3 || e.Children[0].attr("cd") != "interval1" {
+		return nil, fmt.Errorf("%s expects an interval", symbol)
+	}
+	if name := e.Children[0].attr("name"); name != "interval" && name != "integer_interval" {
+		return nil, fmt.Errorf("%s expects an interval", symbol)
+	}
+	return openMathTerms(e.Children[1:])
+}
+
+// binding reads the lambda of a binder, appending its body to args
+func binding(symbol, direction string, args []*term, e *element) (*term, error) {
+	if e.name() != "OMBIND" || len(e.Children) != 3 ||
+		e.Children[0].attr("cd") != "fns1" || e.Children[0].attr("name") != "lambda" ||
+		len(e.Children[1].Children) != 1 || e.Children[1].Children[0].name() != "OMV" {
+		return nil, fmt.Errorf("%s expects a lambda of one variable", symbol)
+	}
+	body, err := openMathTerm(e.Children[2])
+	if err != nil {
+		return nil, err
+	}
+	variable := e.Children[1].Children[0].attr("name")
+	return &term{symbol: symbol, bound: variable, direction: direction, args: append(args, body)}, nil
+}
+
+func openMathTerms(elements []*element) ([]*term, error) {
+	terms := make([]*term, len(elements))
+	for i, e := range elements {
+		t, err := openMathTerm(e)
+		if err != nil {
+			return nil, err
+		}
+		terms[i] = t
+	}
+	return terms, nil
+}
@@ -0,0 +1,623 @@
+package mathml
+
+import (
+	"exprtree/expr"
+	"exprtree/prop"
+	"exprtree/value"
+	"fmt"
+	"math"
+)
+
+// term is the semantic form shared by Content MathML and OpenMath
+// A term is a number, a variable, a symbol, or the application of a symbol
+// or a user-defined function to arguments. Binders such as sums and
+// integrals bind a variable in their last argument.
+type term struct {
+	symbol    string      // name of the applied symbol
+	variable  string      // name of a variable
+	function  string      // name of a called user-defined function
+	number    value.Value // a RealValue or IntegerValue, nil otherwise
+	bound     string      // variable bound by sum, product, int, defint and limit
+	direction string      // "above", "below" or "both_sides" for limits
+	args      []*term
+}
+
+// Symbols of the form, named as in the OpenMath content dictionaries
+const (
+	symbolPlus       = "plus"
+	symbolMinus      = "minus"
+	symbolUnaryMinus = "unary_minus"
+	symbolTimes      = "times"
+	symbolDivide     = "divide"
+	symbolPower      = "power"
+	symbolRoot       = "root"
+	symbolAbs        = "abs"
+	symbolFloor      = "floor"
+	symbolCeiling    = "ceiling"
+	symbolFactorial  = "factorial"
+	symbolBinomial   = "binomial"
+	symbolLog        = "log"
+	symbolSum        = "sum"
+	symbolProduct    = "product"
+	symbolInt        = "int"
+	symbolDefint     = "defint"
+	symbolLimit      = "limit"
+	symbolPiecewise  = "piecewise"
+	symbolPiece      = "piece"
+	symbolOtherwise  = "otherwise"
+	symbolEq         = "eq"
+	symbolLt         = "lt"
+	symbolLeq        = "leq"
+	symbolGt         = "gt"
+	symbolGeq        = "geq"
+	symbolAnd        = "and"
+	symbolTrue       = "true"
+	symbolFalse      = "false"
+	symbolInfinity   = "infinity"
+	symbolNaN        = "NaN"
+	symbolInterval   = "interval"
+)
+
+// contentDictionaries maps each symbol to its OpenMath content dictionary
+// Elementary functions are listed in functionSymbols.
+var contentDictionaries = map[string]string{
+	symbolPlus:       "arith1",
+	symbolMinus:      "arith1",
+	symbolUnaryMinus: "arith1",
+	symbolTimes:      "arith1",
+	symbolDivide:     "arith1",
+	symbolPower:      "arith1",
+	symbolRoot:       "arith1",
+	symbolAbs:        "arith1",
+	symbolSum:        "arith1",
+	symbolProduct:    "arith1",
+	symbolFloor:      "rounding1",
+	symbolCeiling:    "rounding1",
+	symbolFactorial:  "integer1",
+	symbolBinomial:   "combinat1",
+	symbolLog:        "transc1",
+	symbolInt:        "calculus1",
+	symbolDefint:     "calculus1",
+	symbolLimit:      "limit1",
+	symbolPiecewise:  "piece1",
+	symbolPiece:      "piece1",
+	symbolOtherwise:  "piece1",
+	symbolEq:         "relation1",
+	symbolLt:         "relation1",
+	symbolLeq:        "relation1",
+	symbolGt:         "relation1",
+	symbolGeq:        "relation1",
+	symbolAnd:        "logic1",
+	symbolTrue:       "logic1",
+	symbolFalse:      "logic1",
+	symbolInfinity:   "nums1",
+	symbolNaN:        "nums1",
+	symbolInterval:   "interval1",
+}
+
+// functionSymbols maps elementary functions to their symbols in transc1
+var functionSymbols = map[expr.FunctionKind]string{
+	expr.Sin:    "sin",
+	expr.Cos:    "cos",
+	expr.Tan:    "tan",
+	expr.Arcsin: "arcsin",
+	expr.Arccos: "arccos",
+	expr.Arctan: "arctan",
+	expr.Sinh:   "sinh",
+	expr.Cosh:   "cosh",
+	expr.Tanh:   "tanh",
+	expr.Exp:    "exp",
+	expr.Ln:     "ln",
+}
+
+func init() {
+	for _, symbol := range functionSymbols {
+		contentDictionaries[symbol] = "transc1"
+	}
+}
+
+// relationSymbols maps inequality relations to their symbols
+var relationSymbols = map[prop.Relation]string{
+	prop.Less:         symbolLt,
+	prop.LessEqual:    symbolLeq,
+	prop.Greater:      symbolGt,
+	prop.GreaterEqual: symbolGeq,
+}
+
+// apply creates the application of a symbol to arguments
+func apply(symbol string, args ...*term) *term {
+	return &term{symbol: symbol, args: args}
+}
+
+// isConstantSymbol reports whether a symbol stands on its own rather than
+// being applied
+func isConstantSymbol(symbol string) bool {
+	switch symbol {
+	case symbolTrue, symbolFalse, symbolInfinity, symbolNaN:
+		return true
+	default:
+		return false
+	}
+}
+
+// Options configures reading Content MathML and OpenMath
+type Options struct {
+	// Definitions resolves applications of user-defined functions; without
+	// them such applications are errors
+	Definitions *expr.Definitions
+}
+
+// toTerm converts an expression or proposition to a term
+func toTerm(e expr.Expr) (*term, error) {
+	switch n := e.(type) {
+	case *expr.Constant:
+		return constantTerm(n.Value())
+	case *expr.Variable:
+		return &term{variable: n.Name()}, nil
+	case *expr.Add:
+		return binaryTerm(symbolPlus, n.Left(), n.Right())
+	case *expr.Sub:
+		return binaryTerm(symbolMinus, n.Left(), n.Right())
+	case *expr.Mul:
+		return binaryTerm(symbolTimes, n.Left(), n.Right())
+	case *expr.Div:
+		return binaryTerm(symbolDivide, n.Left(), n.Right())
+	case *expr.Power:
+		return binaryTerm(symbolPower, n.Base(), n.Exponent())
+	case *expr.NthRoot:
+		return binaryTerm(symbolRoot, n.Radicand(), n.Degree())
+	case *expr.Neg:
+		return unaryTerm(symbolUnaryMinus, n.Operand())
+	case *expr.Abs:
+		return unaryTerm(symbolAbs, n.Operand())
+	case *expr.Floor:
+		return unaryTerm(symbolFloor, n.Operand())
+	case *expr.Ceil:
+		return unaryTerm(symbolCeiling, n.Operand())
+	case *expr.Factorial:
+		return unaryTerm(symbolFactorial, n.Operand())
+	case *expr.Binomial:
+		return binaryTerm(symbolBinomial, n.N(), n.K())
+	case *expr.Permutation:
+		// Neither format has a symbol for permutation counts
+		quotient := expr.NewDiv(expr.NewFactorial(n.N()), expr.NewFactorial(expr.NewSub(n.N(), n.K())))
+		return toTerm(quotient)
+	case *expr.Function:
+		return unaryTerm(functionSymbols[n.Kind()], n.Argument())
+	case *expr.Log:
+		return binaryTerm(symbolLog, n.Base(), n.Argument())
+	case *expr.Sum:
+		return indexedTerm(symbolSum, n)
+	case *expr.Product:
+		return indexedTerm(symbolProduct, n)
+	case *expr.Integral:
+		return integralTerm(n)
+	case *expr.Limit:
+		return limitTerm(n)
+	case *expr.Piecewise:
+		return piecewiseTerm(n)
+	case *expr.Call:
+		args, err := toTerms(n.Args())
+		if err != nil {
+			return nil, err
+		}
+		return &term{function: n.Name(), args: args}, nil
+	case *prop.Equal:
+		return binaryTerm(symbolEq, n.Left(), n.Right())
+	case *prop.Inequality:
+		return binaryTerm(relationSymbols[n.Relation()], n.Left(), n.Right())
+	case *prop.And:
+		return binaryTerm(symbolAnd, n.Left(), n.Right())
+	default:
+		return nil, fmt.Errorf("unsupported expression type: %T", e)
+	}
+}
+
+// constantTerm converts a value to a number or symbol
+func constantTerm(v value.Value) (*term, error) {
+	switch c := v.(type) {
+	case *value.RealValue:
+		x := c.Float64()
+		switch {
+		case math.IsNaN(x):
+			return apply(symbolNaN), nil
+		case math.IsInf(x, 1):
+			return apply(symbolInfinity), nil
+		case math.IsInf(x, -1):
+			return apply(symbolUnaryMinus, apply(symbolInfinity)), nil
+		}
+		return &term{number: c}, nil
+	case *value.IntegerValue:
+		return &term{number: c}, nil
+	case *value.BoolValue:
+		if c.Bool() {
+			return apply(symbolTrue), nil
+		}
+		return apply(symbolFalse), nil
+	case *value.IntervalValue:
+		lo, err := constantTerm(value.NewRealValue(c.Lo()))
+		if err != nil {
+			return nil, err
+		}
+		hi, err := constantTerm(value.NewRealValue(c.Hi()))
+		if err != nil {
+			return nil, err
+		}
+		return apply(symbolInterval, lo, hi), nil
+	default:
+		return nil, fmt.Errorf("unsupported constant value type: %T", v)
+	}
+}
+
+func unaryTerm(symbol string, operand expr.Expr) (*term, error) {
+	arg, err := toTerm(operand)
+	if err != nil {
+		return nil, err
+	}
+	return apply(symbol, arg), nil
+}
+
+func binaryTerm(symbol string, left, right expr.Expr) (*term, error) {
+	args, err := toTerms([]expr.Expr{left, right})
+	if err != nil {
+		return nil, err
+	}
+	return apply(symbol, args...), nil
+}
+
+func toTerms(exprs []expr.Expr) ([]*term, error) {
+	terms := make([]*term, len(exprs))
+	for i, e := range exprs {
+		t, err := toTerm(e)
+		if err != nil {
+			return nil, err
+		}
+		terms[i] = t
+	}
+	return terms, nil
+}
+
+// indexedTerm converts a Sum or Product to a binder over its index
+func indexedTerm(symbol string, indexed expr.Indexed) (*term, error) {
+	args, err := toTerms([]expr.Expr{indexed.Lower(), indexed.Upper(), indexed.Body()})
+	if err != nil {
+		return nil, err
+	}
+	return &term{symbol: symbol, bound: indexed.Index(), args: args}, nil
+}
+
+// integralTerm converts an Integral to int or, when it has bounds, defint
+func integralTerm(integral *expr.Integral) (*term, error) {
+	if !integral.IsDefinite() {
+		body, err := toTerm(integral.Integrand())
+		if err != nil {
+			return nil, err
+		}
+		return &term{symbol: symbolInt, bound: integral.Variable(), args: []*term{body}}, nil
+	}
+	args, err := toTerms([]expr.Expr{integral.Lower(), integral.Upper(), integral.Integrand()})
+	if err != nil {
+		return nil, err
+	}
+	return &term{symbol: symbolDefint, bound: integral.Variable(), args: args}, nil
+}
+
+// limitDirections names the directions of limits as in limit1
+var limitDirections = map[expr.LimitDirection]string{
+	expr.TwoSided:  "both_sides",
+	expr.FromAbove: "above",
+	expr.FromBelow: "below",
+}
+
+func limitTerm(limit *expr.Limit) (*term, error) {
+	args, err := toTerms([]expr.Expr{limit.Point(), limit.Body()})
+	if err != nil {
+		return nil, err
+	}
+	return &term{symbol: symbolLimit, bound: limit.Variable(), direction: limitDirections[limit.Direction()], args: args}, nil
+}
+
+func piecewiseTerm(piecewise *expr.Piecewise) (*term, error) {
+	result := apply(symbolPiecewise)
+	for _, branch := range piecewise.Branches() {
+		piece, err := binaryTerm(symbolPiece, branch.Value, branch.Condition)
+		if err != nil {
+			return nil, err
+		}
+		result.args = append(result.args, piece)
+	}
+	if piecewise.Otherwise() != nil {
+		otherwise, err := unaryTerm(symbolOtherwise, piecewise.Otherwise())
+		if err != nil {
+			return nil, err
+		}
+		result.args = append(result.args, otherwise)
+	}
+	return result, nil
+}
+
+// reader converts terms back to expressions
+type reader struct {
+	options Options
+}
+
+// expression converts a term to an expression or proposition
+func (r *reader) expression(t *term) (expr.Expr, error) {
+	switch {
+	case t.number != nil:
+		return expr.NewConstant(t.number), nil
+	case t.variable != "":
+		return expr.NewVariable(t.variable), nil
+	case t.function != "":
+		return r.call(t)
+	}
+
+	switch t.symbol {
+	case symbolTrue, symbolFalse:
+		return expr.NewConstant(value.NewBoolValue(t.symbol == symbolTrue)), nil
+	case symbolInfinity:
+		return expr.NewConstant(value.NewRealValue(math.Inf(1))), nil
+	case symbolNaN:
+		return expr.NewConstant(value.NewRealValue(math.NaN())), nil
+	case symbolInterval:
+		return r.interval(t)
+	case symbolSum, symbolProduct:
+		return r.indexed(t)
+	case symbolInt, symbolDefint:
+		return r.integral(t)
+	case symbolLimit:
+		return r.limit(t)
+	case symbolPiecewise:
+		return r.piecewise(t)
+	case symbolEq, symbolLt, symbolLeq, symbolGt, symbolGeq:
+		return r.relation(t)
+	}
+
+	args, err := r.expressions(t.args)
+	if err != nil {
+		return nil, err
+	}
+	switch t.symbol {
+	case symbolPlus:
+		return fold(t.symbol, args, func(a, b expr.Expr) expr.Expr { return expr.NewAdd(a, b) })
+	case symbolTimes:
+		return fold(t.symbol, args, func(a, b expr.Expr) expr.Expr { return expr.NewMul(a, b) })
+	case symbolAnd:
+		for _, arg := range args {
+			if !prop.IsProposition(arg) {
+				return nil, fmt.Errorf("and expects propositions, got %T", arg)
+			}
+		}
+		return fold(t.symbol, args, func(a, b expr.Expr) expr.Expr {
+			return prop.NewAnd(a.(prop.Proposition), b.(prop.Proposition))
+		})
+	case symbolUnaryMinus:
+		if err := arity(t, 1); err != nil {
+			return nil, err
+		}
+		return expr.NewNeg(args[0]), nil
+	case symbolMinus:
+		if err := arity(t, 2); err != nil {
+			return nil, err
+		}
+		return expr.NewSub(args[0], args[1]), nil
+	case symbolDivide:
+		if err := arity(t, 2); err != nil {
+			return nil, err
+		}
+		return expr.NewDiv(args[0], args[1]), nil
+	case symbolPower:
+		if err := arity(t, 2); err != nil {
+			return nil, err
+		}
+		return expr.NewPower(args[0], args[1]), nil
+	case symbolRoot:
+		if err := arity(t, 2); err != nil {
+			return nil, err
+		}
+		return expr.NewNthRoot(args[0], args[1]), nil
+	case symbolBinomial:
+		if err := arity(t, 2); err != nil {
+			return nil, err
+		}
+		return expr.NewBinomial(args[0], args[1]), nil
+	case symbolLog:
+		if err := arity(t, 2); err != nil {
+			return nil, err
+		}
+		return expr.NewLog(args[0], args[1]), nil
+	}
+
+	if err := arity(t, 1); err != nil {
+		return nil, err
+	}
+	switch t.symbol {
+	case symbolAbs:
+		return expr.NewAbs(args[0]), nil
+	case symbolFloor:
+		return expr.NewFloor(args[0]), nil
+	case symbolCeiling:
+		return expr.NewCeil(args[0]), nil
+	case symbolFactorial:
+		return expr.NewFactorial(args[0]), nil
+	}
+	for kind, symbol := range functionSymbols {
+		if symbol == t.symbol {
+			return expr.NewFunction(kind, args[0]), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown symbol: %s", t.symbol)
+}
+
+func (r *reader) expressions(terms []*term) ([]expr.Expr, error) {
+	exprs := make([]expr.Expr, len(terms))
+	for i, t := range terms {
+		e, err := r.expression(t)
+		if err != nil {
+			return nil, err
+		}
+		exprs[i] = e
+	}
+	return exprs, nil
+}
+
+// arity checks the number of arguments of an application
+func arity(t *term, n int) error {
+	if len(t.args) != n {
+		return fmt.Errorf("%s expects %d arguments, got %d", t.symbol, n, len(t.args))
+	}
+	return nil
+}
+
+// fold combines the arguments of an n-ary symbol from the left
+func fold(symbol string, args []expr.Expr, combine func(a, b expr.Expr) expr.Expr) (expr.Expr, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("%s expects at least 2 arguments, got %d", symbol, len(args))
+	}
+	result := args[0]
+	for _, arg := range args[1:] {
+		result = combine(result, arg)
+	}
+	return result, nil
+}
+
+// toFloat returns the float64 of a real or integer value, or NaN
+func toFloat(v value.Value) float64 {
+	number, ok := value.ToReal(v)
+	if !ok {
+		return math.NaN()
+	}
+	return number.Float64()
+}
+
+// call converts the application of a user-defined function
+func (r *reader) call(t *term) (expr.Expr, error) {
+	if r.options.Definitions == nil {
+		return nil, fmt.Errorf("unknown function: %s", t.function)
+	}
+	if _, ok := r.options.Definitions.Lookup(t.function); !ok {
+		return nil, fmt.Errorf("unknown function: %s", t.function)
+	}
+	args, err := r.expressions(t.args)
+	if err != nil {
+		return nil, err
+	}
+	return expr.NewCall(r.options.Definitions, t.function, args), nil
+}
+
+// interval converts an interval of two numbers to an interval constant
+func (r *reader) interval(t *term) (expr.Expr, error) {
+	if err := arity(t, 2); err != nil {
+		return nil, err
+	}
+	bounds, err := r.expressions(t.args)
+	if err != nil {
+		return nil, err
+	}
+	lo, ok1 := bounds[0].(*expr.Constant)
+	hi, ok2 := bounds[1].(*expr.Constant)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("interval bounds must be numbers")
+	}
+	return expr.NewConstant(value.NewIntervalValue(toFloat(lo.Value()), toFloat(hi.Value()))), nil
+}
+
+func (r *reader) indexed(t *term) (expr.Expr, error) {
+	if err := arity(t, 3); err != nil {
+		return nil, err
+	}
+	args, err := r.expressions(t.args)
+	if err != nil {
+		return nil, err
+	}
+	if t.symbol == symbolSum {
+		return expr.NewSum(t.bound, args[0], args[1], args[2]), nil
+	}
+	return expr.NewProduct(t.bound, args[0], args[1], args[2]), nil
+}
+
+func (r *reader) integral(t *term) (expr.Expr, error) {
+	args, err := r.expressions(t.args)
+	if err != nil {
+		return nil, err
+	}
+	switch len(args) {
+	case 1:
+		return expr.NewIndefiniteIntegral(t.bound, args[0]), nil
+	case 3:
+		return expr.NewIntegral(t.bound, args[0], args[1], args[2]), nil
+	default:
+		return nil, fmt.Errorf("%s expects a body with optional bounds, got %d arguments", t.symbol, len(args))
+	}
+}
+
+func (r *reader) limit(t *term) (expr.Expr, error) {
+	if err := arity(t, 2); err != nil {
+		return nil, err
+	}
+	args, err := r.expressions(t.args)
+	if err != nil {
+		return nil, err
+	}
+	for direction, name := range limitDirections {
+		if name == t.direction {
+			return expr.NewLimit(t.bound, args[0], direction, args[1]), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown limit direction: %s", t.direction)
+}
+
+func (r *reader) piecewise(t *term) (expr.Expr, error) {
+	var branches []expr.Branch
+	var otherwise expr.Expr
+	for i, piece := range t.args {
+		args, err := r.expressions(piece.args)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case piece.symbol == symbolPiece && len(args) == 2 && prop.IsProposition(args[1]):
+			branches = append(branches, expr.Branch{Value: args[0], Condition: args[1]})
+		case piece.symbol == symbolOtherwise && len(args) == 1 && i == len(t.args)-1:
+			otherwise = args[0]
+		default:
+			return nil, fmt.Errorf("invalid piece of piecewise")
+		}
+	}
+	if len(branches) == 0 {
+		return nil, fmt.Errorf("piecewise has no pieces")
+	}
+	return expr.NewPiecewise(branches, otherwise), nil
+}
+
+// relation converts a relation between two or more operands; a = b = c is
+// the chain a = b and b = c
+func (r *reader) relation(t *term) (expr.Expr, error) {
+	args, err := r.expressions(t.args)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < 2 {
+		return nil, fmt.Errorf("%s expects at least 2 arguments, got %d", t.symbol, len(args))
+	}
+
+	var result prop.Proposition
+	for i := 1; i < len(args); i++ {
+		var link prop.Proposition
+		if t.symbol == symbolEq {
+			link = prop.NewEqual(args[i-1], args[i])
+		} else {
+			for relation, symbol := range relationSymbols {
+				if symbol == t.symbol {
+					link = prop.NewInequality(relation, args[i-1], args[i])
+				}
+			}
+		}
+		if result == nil {
+			result = link
+		} else {
+			result = prop.NewAnd(result, link)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package mathml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// element is a generic XML element used to read and write both formats
+type element struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []*element `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+// newElement creates an element with children
+func newElement(name string, children ...*element) *element {
+	return &element{XMLName: xml.Name{Local: name}, Children: children}
+}
+
+// textElement creates an element holding text
+func textElement(name, text string) *element {
+	return &element{XMLName: xml.Name{Local: name}, Text: text}
+}
+
+// withAttr adds an attribute to the element and returns it
+func (e *element) withAttr(name, value string) *element {
+	e.Attrs = append(e.Attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+	return e
+}
+
+// name returns the local name of the element
+func (e *element) name() string {
+	return e.XMLName.Local
+}
+
+// attr returns the value of an attribute, or "" if it is not set
+func (e *element) attr(name string) string {
+	for _, attr := range e.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// text returns the text of the element without surrounding whitespace
+func (e *element) text() string {
+	return strings.TrimSpace(e.Text)
+}
+
+// marshal writes the element as XML
+func marshal(root *element) (string, error) {
+	data, err := xml.Marshal(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to write XML: %w", err)
+	}
+	return string(data), nil
+}
+
+// unmarshal reads XML whose root element has the given name
+func unmarshal(input, rootName string) (*element, error) {
+	var root element
+	if err := xml.Unmarshal([]byte(input), &root); err != nil {
+		return nil, fmt.Errorf("failed to read XML: %w", err)
+	}
+	if root.name() != rootName {
+		return nil, fmt.Errorf("expected <%s> element, got <%s>", rootName, root.name())
+	}
+	return &root, nil
+}
@@ -39,6 +39,8 @@ func IsMonomial(e expr.Expr) bool {
 	switch v := e.(type) {
 	case *expr.Mul:
 		return IsMonomial(v.Left()) && IsMonomial(v.Right())
+	case *expr.Neg:
+		return IsMonomial(v.Operand())
 	case *expr.Add:
 		return false
 	case *expr.Sub:
@@ -1,6 +1,10 @@
 package prop
 
-import "exprtree/value"
+import (
+	"exprtree/ast"
+	"exprtree/expr"
+	"exprtree/value"
+)
 
 type And struct {
 	Proposition
@@ -47,3 +51,13 @@ func (a *And) Equals(other any) bool {
 	}
 	return a.left.Equals(otherAnd.left) && a.right.Equals(otherAnd.right)
 }
+
+func (a *And) Substitute(bindings map[string]expr.Expr) expr.Expr {
+	left := expr.Substitute(a.left, bindings).(Proposition)
+	right := expr.Substitute(a.right, bindings).(Proposition)
+	return NewAnd(left, right)
+}
+
+func (a *And) Children() []ast.HasChildren {
+	return []ast.HasChildren{a.left, a.right}
+}
@@ -1,6 +1,7 @@
 package prop
 
 import (
+	"exprtree/ast"
 	"exprtree/expr"
 	"exprtree/value"
 )
@@ -36,14 +37,18 @@ func (e *Equal) Eval() (value.Value, bool) {
 	}
 
 	switch leftVal.Kind() {
-	case value.RealKind:
-		leftReal, ok1 := leftVal.(*value.RealValue)
-		rightReal, ok2 := rightVal.(*value.RealValue)
+	case value.RealKind, value.IntegerKind:
+		_, ok1 := value.ToReal(leftVal)
+		_, ok2 := value.ToReal(rightVal)
 		if !ok1 || !ok2 {
 			return nil, false
 		}
-		result := leftReal.Float64() == rightReal.Float64()
-		return value.NewBoolValue(result), true
+		cmp, ok := value.Compare(leftVal, rightVal)
+		if !ok {
+			// NaN is not equal to anything
+			return value.NewBoolValue(false), true
+		}
+		return value.NewBoolValue(cmp == 0), true
 	default:
 		return nil, false
 	}
@@ -56,3 +61,11 @@ func (e *Equal) Equals(other any) bool {
 	}
 	return e.left.Equals(otherEqual.left) && e.right.Equals(otherEqual.right)
 }
+
+func (e *Equal) Substitute(bindings map[string]expr.Expr) expr.Expr {
+	return NewEqual(expr.Substitute(e.left, bindings), expr.Substitute(e.right, bindings))
+}
+
+func (e *Equal) Children() []ast.HasChildren {
+	return []ast.HasChildren{e.left, e.right}
+}
@@ -0,0 +1,114 @@
+package prop
+
+import (
+	"exprtree/ast"
+	"exprtree/expr"
+	"exprtree/value"
+	"fmt"
+)
+
+// Relation is the comparison made by an Inequality
+type Relation int
+
+const (
+	Less         Relation = iota // a < b
+	LessEqual                    // a ≤ b
+	Greater                      // a > b
+	GreaterEqual                 // a ≥ b
+)
+
+func (r Relation) String() string {
+	switch r {
+	case Less:
+		return "<"
+	case LessEqual:
+		return "<="
+	case Greater:
+		return ">"
+	case GreaterEqual:
+		return ">="
+	default:
+		return fmt.Sprintf("Relation(%d)", int(r))
+	}
+}
+
+// holds reports whether the relation holds for a comparison result
+func (r Relation) holds(cmp int) bool {
+	switch r {
+	case Less:
+		return cmp < 0
+	case LessEqual:
+		return cmp <= 0
+	case Greater:
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+type Inequality struct {
+	Proposition
+	relation    Relation
+	left, right expr.Expr
+}
+
+func NewInequality(relation Relation, left, right expr.Expr) *Inequality {
+	return &Inequality{
+		relation: relation,
+		left:     left,
+		right:    right,
+	}
+}
+
+func (i *Inequality) Relation() Relation {
+	return i.relation
+}
+
+func (i *Inequality) Left() expr.Expr {
+	return i.left
+}
+
+func (i *Inequality) Right() expr.Expr {
+	return i.right
+}
+
+func (i *Inequality) Eval() (value.Value, bool) {
+	leftVal, ok := i.left.Eval()
+	if !ok {
+		return nil, false
+	}
+	rightVal, ok := i.right.Eval()
+	if !ok {
+		return nil, false
+	}
+
+	_, ok1 := value.ToReal(leftVal)
+	_, ok2 := value.ToReal(rightVal)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	cmp, ok := value.Compare(leftVal, rightVal)
+	if !ok {
+		// NaN is not ordered
+		return value.NewBoolValue(false), true
+	}
+	return value.NewBoolValue(i.relation.holds(cmp)), true
+}
+
+func (i *Inequality) Equals(other any) bool {
+	otherInequality, ok := other.(*Inequality)
+	if !ok {
+		return false
+	}
+	return i.relation == otherInequality.relation &&
+		i.left.Equals(otherInequality.left) &&
+		i.right.Equals(otherInequality.right)
+}
+
+func (i *Inequality) Substitute(bindings map[string]expr.Expr) expr.Expr {
+	return NewInequality(i.relation, expr.Substitute(i.left, bindings), expr.Substitute(i.right, bindings))
+}
+
+func (i *Inequality) Children() []ast.HasChildren {
+	return []ast.HasChildren{i.left, i.right}
+}
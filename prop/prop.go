@@ -6,3 +6,15 @@ type Proposition interface {
 	expr.Expr
 	Equals(other any) bool
 }
+
+// IsProposition reports whether e is one of the proposition nodes
+// Every expression satisfies the Proposition interface, so this is how
+// conditions are told apart from values.
+func IsProposition(e expr.Expr) bool {
+	switch e.(type) {
+	case *Equal, *Inequality, *And:
+		return true
+	default:
+		return false
+	}
+}
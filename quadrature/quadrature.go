@@ -0,0 +1,204 @@
+package quadrature
+
+import (
+	"errors"
+	"math"
+)
+
+// Func is an integrand; ok is false where the function is undefined
+type Func func(x float64) (float64, bool)
+
+// Result is the outcome of a numeric integration
+type Result struct {
+	Value       float64 // approximation of the integral
+	Error       float64 // estimate of the absolute error of Value
+	Evaluations int     // number of integrand evaluations
+}
+
+// Options controls the adaptive integration
+// Subdivision stops when Error <= max(AbsTol, RelTol * |Value|).
+type Options struct {
+	AbsTol          float64
+	RelTol          float64
+	MaxSubdivisions int
+}
+
+// DefaultOptions returns the options used by Integrate
+func DefaultOptions() Options {
+	return Options{
+		AbsTol:          1e-12,
+		RelTol:          1e-10,
+		MaxSubdivisions: 1000,
+	}
+}
+
+var (
+	// ErrDomain is returned when the integrand is undefined at a sample point
+	ErrDomain = errors.New("integrand undefined in the interval")
+	// ErrNotConverged is returned with the best result found when the
+	// tolerance was not reached within the subdivision limit
+	ErrNotConverged = errors.New("tolerance not reached")
+)
+
+// Integrate integrates f from a to b with the default options
+func Integrate(f Func, a, b float64) (Result, error) {
+	return IntegrateWithOptions(f, a, b, DefaultOptions())
+}
+
+// IntegrateWithOptions integrates f from a to b by adaptive Gauss–Kronrod
+// (7-point Gauss, 15-point Kronrod) quadrature
+// Infinite bounds are mapped onto a finite interval. Bounds with b < a give
+// the negated integral from b to a.
+func IntegrateWithOptions(f Func, a, b float64, opts Options) (Result, error) {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return Result{}, ErrDomain
+	}
+	if a == b {
+		return Result{}, nil
+	}
+	if b < a {
+		result, err := IntegrateWithOptions(f, b, a, opts)
+		result.Value = -result.Value
+		return result, err
+	}
+
+	switch {
+	case math.IsInf(a, -1) && math.IsInf(b, 1):
+		// x = t / (1 - t^2), t in (-1, 1)
+		return adaptive(func(t float64) (float64, bool) {
+			u := 1 - t*t
+			y, ok := f(t / u)
+			return y * (1 + t*t) / (u * u), ok
+		}, -1, 1, opts)
+	case math.IsInf(b, 1):
+		// x = a + t / (1 - t), t in [0, 1)
+		return adaptive(func(t float64) (float64, bool) {
+			u := 1 - t
+			y, ok := f(a + t/u)
+			return y / (u * u), ok
+		}, 0, 1, opts)
+	case math.IsInf(a, -1):
+		// x = b - (1 - t) / t, t in (0, 1]
+		return adaptive(func(t float64) (float64, bool) {
+			y, ok := f(b - (1-t)/t)
+			return y / (t * t), ok
+		}, 0, 1, opts)
+	default:
+		return adaptive(f, a, b, opts)
+	}
+}
+
+// segment is a subinterval with its local estimate
+type segment struct {
+	a, b         float64
+	value, error float64
+}
+
+// adaptive repeatedly bisects the segment with the largest error estimate
+func adaptive(f Func, a, b float64, opts Options) (Result, error) {
+	first, ok := kronrod(f, a, b)
+	if !ok {
+		return Result{Evaluations: 15}, ErrDomain
+	}
+	segments := []segment{first}
+	result := Result{Value: first.value, Error: first.error, Evaluations: 15}
+
+	for result.Error > math.Max(opts.AbsTol, opts.RelTol*math.Abs(result.Value)) {
+		if len(segments) >= opts.MaxSubdivisions {
+			return result, ErrNotConverged
+		}
+
+		worst := 0
+		for i, s := range segments {
+			if s.error > segments[worst].error {
+				worst = i
+			}
+		}
+		s := segments[worst]
+		mid := s.a + (s.b-s.a)/2
+		if mid <= s.a || mid >= s.b {
+			// The segment cannot be split any further in float64
+			return result, ErrNotConverged
+		}
+
+		left, ok1 := kronrod(f, s.a, mid)
+		right, ok2 := kronrod(f, mid, s.b)
+		result.Evaluations += 30
+		if !ok1 || !ok2 {
+			return result, ErrDomain
+		}
+		segments[worst] = left
+		segments = append(segments, right)
+
+		result.Value = 0
+		result.Error = 0
+		for _, s := range segments {
+			result.Value += s.value
+			result.Error += s.error
+		}
+	}
+	return result, nil
+}
+
+// Gauss–Kronrod nodes and weights on [-1, 1]; the odd-indexed Kronrod
+// nodes are the 7-point Gauss nodes
+var (
+	kronrodNodes = [8]float64{
+		0.991455371120812639206854697526329,
+		0.949107912342758524526189684047851,
+		0.864864423359769072789712788640926,
+		0.741531185599394439863864773280788,
+		0.586087235467691130294144845693013,
+		0.405845151377397166906606412076961,
+		0.207784955007898467600689403773245,
+		0,
+	}
+	kronrodWeights = [8]float64{
+		0.022935322010529224963732008058970,
+		0.063092092629978553290700663189204,
+		0.104790010322250183839876322541518,
+		0.140653259715525918745189590510238,
+		0.169004726639267902826583426598550,
+		0.190350578064785409913256402421014,
+		0.204432940075298892414161999234649,
+		0.209482141084727828012999174891714,
+	}
+	gaussWeights = [4]float64{
+		0.129484966168869693270611432679082,
+		0.279705391489276667901467771423780,
+		0.381830050505118944950369775488975,
+		0.417959183673469387755102040816327,
+	}
+)
+
+// kronrod applies the 15-point rule on [a, b]
+// The error estimate is the difference to the embedded 7-point Gauss rule.
+func kronrod(f Func, a, b float64) (segment, bool) {
+	center := (a + b) / 2
+	half := (b - a) / 2
+
+	fc, ok := f(center)
+	if !ok {
+		return segment{}, false
+	}
+	k := fc * kronrodWeights[7]
+	g := fc * gaussWeights[3]
+	for i := 0; i < 7; i++ {
+		dx := half * kronrodNodes[i]
+		f1, ok1 := f(center - dx)
+		f2, ok2 := f(center + dx)
+		if !ok1 || !ok2 {
+			return segment{}, false
+		}
+		k += kronrodWeights[i] * (f1 + f2)
+		if i%2 == 1 {
+			g += gaussWeights[i/2] * (f1 + f2)
+		}
+	}
+
+	value := k * half
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return segment{}, false
+	}
+	return segment{a: a, b: b, value: value, error: math.Abs((k - g) * half)}, true
+}
@@ -0,0 +1,68 @@
+package quadrature_test
+
+import (
+	"errors"
+	"exprtree/quadrature"
+	"math"
+	"testing"
+)
+
+func total(f func(float64) float64) quadrature.Func {
+	return func(x float64) (float64, bool) {
+		return f(x), true
+	}
+}
+
+func TestIntegrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		f        quadrature.Func
+		a, b     float64
+		expected float64
+	}{
+		{"Polynomial", total(func(x float64) float64 { return x*x*x - 2*x + 1 }), 0, 2, 2},
+		{"Sine", total(math.Sin), 0, math.Pi, 2},
+		{"Reversed bounds", total(math.Sin), math.Pi, 0, -2},
+		{"Empty interval", total(math.Exp), 1, 1, 0},
+		{"Square root singularity", total(func(x float64) float64 { return 1 / math.Sqrt(x) }), 0, 1, 2},
+		{"Peak", total(func(x float64) float64 { return 1 / (1e-4 + x*x) }), -1, 1, 2 * 100 * math.Atan(100)},
+		{"Oscillating", total(func(x float64) float64 { return math.Cos(50 * x) }), 0, 1, math.Sin(50) / 50},
+		{"Upper infinite", total(func(x float64) float64 { return math.Exp(-x) }), 0, math.Inf(1), 1},
+		{"Lower infinite", total(math.Exp), math.Inf(-1), 0, 1},
+		{"Gaussian", total(func(x float64) float64 { return math.Exp(-x * x) }), math.Inf(-1), math.Inf(1), math.Sqrt(math.Pi)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := quadrature.Integrate(tt.f, tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("Integrate failed: %v", err)
+			}
+			if math.Abs(result.Value-tt.expected) > 1e-8 {
+				t.Errorf("expected %.12f, got %.12f", tt.expected, result.Value)
+			}
+			if math.Abs(result.Value-tt.expected) > result.Error+1e-12 && result.Error > 1e-6 {
+				t.Errorf("error estimate %g does not cover actual error %g", result.Error, math.Abs(result.Value-tt.expected))
+			}
+		})
+	}
+}
+
+func TestIntegrateErrors(t *testing.T) {
+	undefined := func(x float64) (float64, bool) {
+		if x > 0.5 {
+			return 0, false
+		}
+		return x, true
+	}
+	if _, err := quadrature.Integrate(undefined, 0, 1); !errors.Is(err, quadrature.ErrDomain) {
+		t.Errorf("expected ErrDomain, got %v", err)
+	}
+
+	opts := quadrature.DefaultOptions()
+	opts.MaxSubdivisions = 2
+	_, err := quadrature.IntegrateWithOptions(total(func(x float64) float64 { return math.Sin(1 / x) }), 0, 1, opts)
+	if !errors.Is(err, quadrature.ErrNotConverged) {
+		t.Errorf("expected ErrNotConverged, got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package series
+
+import (
+	"exprtree/expr"
+	"exprtree/prop"
+	"exprtree/value"
+)
+
+// ClosedForm returns an expression equal to the sum without the summation
+// It handles sums that are linear combinations of constants, powers of the
+// index up to the third (arithmetic and power sums) and geometric terms r^i.
+// ok is false when no closed form is known for some term. The formulas
+// hold for non-empty ranges, so unless the bounds are constant the result
+// is a Piecewise that is 0 when upper < lower, as for Sum.Eval.
+func ClosedForm(sum *expr.Sum) (expr.Expr, bool) {
+	lower, upper := sum.Lower(), sum.Upper()
+	closed, ok := closedForm(sum.Index(), lower, upper, sum.Body())
+	if !ok {
+		return nil, false
+	}
+
+	l, lowerOk := constantInt(lower)
+	u, upperOk := constantInt(upper)
+	if lowerOk && upperOk {
+		if u < l {
+			return constant(0), true
+		}
+		return closed, true
+	}
+	return expr.NewPiecewise([]expr.Branch{
+		{Condition: prop.NewInequality(prop.LessEqual, lower, upper), Value: closed},
+	}, constant(0)), true
+}
+
+// closedForm returns the closed form of the sum of body over index = lower..upper
+func closedForm(index string, lower, upper, body expr.Expr) (expr.Expr, bool) {
+	if !dependsOn(body, index) {
+		return expr.NewMul(count(lower, upper), body), true
+	}
+
+	switch b := body.(type) {
+	case *expr.Add:
+		return splitTerms(index, lower, upper, b.Left(), b.Right(), func(l, r expr.Expr) expr.Expr { return expr.NewAdd(l, r) })
+	case *expr.Sub:
+		return splitTerms(index, lower, upper, b.Left(), b.Right(), func(l, r expr.Expr) expr.Expr { return expr.NewSub(l, r) })
+	case *expr.Neg:
+		inner, ok := closedForm(index, lower, upper, b.Operand())
+		if !ok {
+			return nil, false
+		}
+		return expr.NewNeg(inner), true
+	case *expr.Mul:
+		// Constant factors move out of the sum
+		if !dependsOn(b.Left(), index) {
+			inner, ok := closedForm(index, lower, upper, b.Right())
+			if !ok {
+				return nil, false
+			}
+			return expr.NewMul(b.Left(), inner), true
+		}
+		if !dependsOn(b.Right(), index) {
+			inner, ok := closedForm(index, lower, upper, b.Left())
+			if !ok {
+				return nil, false
+			}
+			return expr.NewMul(inner, b.Right()), true
+		}
+		return nil, false
+	case *expr.Div:
+		if dependsOn(b.Right(), index) {
+			return nil, false
+		}
+		inner, ok := closedForm(index, lower, upper, b.Left())
+		if !ok {
+			return nil, false
+		}
+		return expr.NewDiv(inner, b.Right()), true
+	case *expr.Variable:
+		// b is the index itself, since body depends on it
+		return powerSum(1, lower, upper)
+	case *expr.Power:
+		if isIndex(b.Left(), index) {
+			p, ok := constantInt(b.Right())
+			if !ok {
+				return nil, false
+			}
+			return powerSum(p, lower, upper)
+		}
+		if isIndex(b.Right(), index) && !dependsOn(b.Left(), index) {
+			return geometric(b.Left(), lower, upper), true
+		}
+		return nil, false
+	default:
+		return nil, false
+	}
+}
+
+// splitTerms sums two terms separately and combines the results
+func splitTerms(index string, lower, upper, left, right expr.Expr, combine func(l, r expr.Expr) expr.Expr) (expr.Expr, bool) {
+	l, ok := closedForm(index, lower, upper, left)
+	if !ok {
+		return nil, false
+	}
+	r, ok := closedForm(index, lower, upper, right)
+	if !ok {
+		return nil, false
+	}
+	return combine(l, r), true
+}
+
+// count returns the number of terms, upper - lower + 1, of a non-empty range
+func count(lower, upper expr.Expr) expr.Expr {
+	if isConstant(lower, 1) {
+		return upper
+	}
+	return expr.NewAdd(expr.NewSub(upper, lower), constant(1))
+}
+
+// powerSum returns the sum of i^p over lower..upper for 0 <= p <= 3
+// using Faulhaber's formulas F(upper) - F(lower - 1).
+func powerSum(p int64, lower, upper expr.Expr) (expr.Expr, bool) {
+	var faulhaber func(n expr.Expr) expr.Expr
+	switch p {
+	case 0:
+		return count(lower, upper), true
+	case 1:
+		faulhaber = triangular
+	case 2:
+		// n(n + 1)(2n + 1) / 6
+		faulhaber = func(n expr.Expr) expr.Expr {
+			return expr.NewDiv(
+				expr.NewMul(
+					expr.NewMul(n, expr.NewAdd(n, constant(1))),
+					expr.NewAdd(expr.NewMul(constant(2), n), constant(1)),
+				),
+				constant(6),
+			)
+		}
+	case 3:
+		// (n(n + 1) / 2)^2
+		faulhaber = func(n expr.Expr) expr.Expr {
+			return expr.NewPower(triangular(n), constant(2))
+		}
+	default:
+		return nil, false
+	}
+
+	// F(0) = 0, so sums starting at 0 or 1 need no correction
+	if isConstant(lower, 0) || isConstant(lower, 1) {
+		return faulhaber(upper), true
+	}
+	return expr.NewSub(faulhaber(upper), faulhaber(expr.NewSub(lower, constant(1)))), true
+}
+
+// triangular returns n(n + 1) / 2
+func triangular(n expr.Expr) expr.Expr {
+	return expr.NewDiv(expr.NewMul(n, expr.NewAdd(n, constant(1))), constant(2))
+}
+
+// geometric returns the sum of r^i over lower..upper, (r^(upper+1) - r^lower) / (r - 1)
+// For r = 1 every term is 1 and the sum is the number of terms, so a ratio
+// that is not a constant gets a Piecewise for that case.
+func geometric(r, lower, upper expr.Expr) expr.Expr {
+	ratio := expr.NewDiv(
+		expr.NewSub(
+			expr.NewPower(r, expr.NewAdd(upper, constant(1))),
+			expr.NewPower(r, lower),
+		),
+		expr.NewSub(r, constant(1)),
+	)
+
+	if v, ok := r.Eval(); ok {
+		if real, ok := value.ToReal(v); ok {
+			if real.Float64() == 1 {
+				return count(lower, upper)
+			}
+			return ratio
+		}
+	}
+	return expr.NewPiecewise([]expr.Branch{
+		{Condition: prop.NewEqual(r, constant(1)), Value: count(lower, upper)},
+	}, ratio)
+}
+
+// dependsOn reports whether index occurs free in e
+func dependsOn(e expr.Expr, index string) bool {
+	for _, name := range expr.FreeVariables(e) {
+		if name == index {
+			return true
+		}
+	}
+	return false
+}
+
+// isIndex reports whether e is the index variable
+func isIndex(e expr.Expr, index string) bool {
+	v, ok := e.(*expr.Variable)
+	return ok && v.Name() == index
+}
+
+// constantInt returns the integer held by a constant
+func constantInt(e expr.Expr) (int64, bool) {
+	c, ok := e.(*expr.Constant)
+	if !ok {
+		return 0, false
+	}
+	return value.ToInt64(c.Value())
+}
+
+// isConstant reports whether e is the constant n
+func isConstant(e expr.Expr, n int64) bool {
+	v, ok := constantInt(e)
+	return ok && v == n
+}
+
+func constant(v float64) expr.Expr {
+	return expr.NewConstant(value.NewRealValue(v))
+}
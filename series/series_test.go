@@ -0,0 +1,91 @@
+package series_test
+
+import (
+	"exprtree/expr"
+	"exprtree/latex"
+	"exprtree/series"
+	"exprtree/value"
+	"math"
+	"testing"
+)
+
+func parseSum(t *testing.T, input string) *expr.Sum {
+	t.Helper()
+	result, err := latex.ParseLatex(input)
+	if err != nil {
+		t.Fatalf("ParseLatex failed: %v", err)
+	}
+	sum, ok := result.(*expr.Sum)
+	if !ok {
+		t.Fatalf("expected Sum, got %T", result)
+	}
+	return sum
+}
+
+func TestClosedForm(t *testing.T) {
+	tests := []string{
+		"\\sum_{i=1}^{n} 3",
+		"\\sum_{i=1}^{n} i",
+		"\\sum_{i=0}^{n} (2i + 1)",
+		"\\sum_{k=1}^{n} k^2",
+		"\\sum_{k=1}^{n} k^3",
+		"\\sum_{k=4}^{n} k^2",
+		"\\sum_{i=m}^{n} (i - m) / 2",
+		"\\sum_{i=0}^{n} 2^i",
+		"\\sum_{i=2}^{n} 3 * 0.5^i",
+		"\\sum_{i=1}^{n} (i^2 - x i + 2^i)",
+		"\\sum_{i=0}^{n} x^i",
+		"\\sum_{i=1}^{n} 1^i",
+		"\\sum_{i=1}^{n} (y i + y^i)",
+		"\\sum_{i=5}^{2} i^2",
+		"\\sum_{i=2}^{6} (x^i + 1)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			sum := parseSum(t, input)
+			closed, ok := series.ClosedForm(sum)
+			if !ok {
+				t.Fatalf("no closed form found")
+			}
+			// n = 0 and n = -3 give empty ranges, y = 1 a geometric ratio of 1
+			for _, n := range []int64{-3, 0, 5, 6, 12} {
+				for _, y := range []float64{1, 2} {
+					env := map[string]value.Value{
+						"n": value.NewIntegerValue(n),
+						"m": value.NewIntegerValue(3),
+						"x": value.NewRealValue(1.5),
+						"y": value.NewRealValue(y),
+					}
+					expected, ok1 := expr.EvalWith(sum, env)
+					got, ok2 := expr.EvalWith(closed, env)
+					if !ok1 || !ok2 {
+						t.Fatalf("evaluation failed for n=%d, y=%g", n, y)
+					}
+					e, _ := value.ToReal(expected)
+					g, _ := value.ToReal(got)
+					if math.Abs(e.Float64()-g.Float64()) > 1e-9*math.Max(1, math.Abs(e.Float64())) {
+						t.Errorf("n=%d, y=%g: expected %v, got %v", n, y, e.Float64(), g.Float64())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestClosedFormUnknown(t *testing.T) {
+	tests := []string{
+		"\\sum_{i=1}^{n} i^4",
+		"\\sum_{i=1}^{n} 1 / i",
+		"\\sum_{i=1}^{n} i * 2^i",
+		"\\sum_{i=1}^{n} \\sin i",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if _, ok := series.ClosedForm(parseSum(t, input)); ok {
+				t.Errorf("expected no closed form")
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package value
+
+import (
+	"math"
+	"math/big"
+)
+
+// binaryOp holds the implementation of a binary operation for each value kind
+// Operands of different kinds are promoted to the wider kind before the
+// implementation is called (IntegerKind < RealKind < IntervalKind,
+// RealKind < DualKind).
+type binaryOp struct {
+	integer  func(x, y *big.Int) (Value, bool)
+	real     func(x, y float64) (Value, bool)
+	interval func(x, y *IntervalValue) (Value, bool)
+	dual     func(x, y *DualValue) (Value, bool)
+}
+
+// apply promotes the operands to a common kind and applies the operation
+func (op binaryOp) apply(a, b Value) (Value, bool) {
+	if a == nil || b == nil {
+		return nil, false
+	}
+	kind := commonKind(a, b)
+	if kind != IntegerKind {
+		a, b = promoteInteger(a), promoteInteger(b)
+	}
+
+	switch kind {
+	case IntegerKind:
+		if op.integer == nil {
+			return op.real(a.(*IntegerValue).Float64(), b.(*IntegerValue).Float64())
+		}
+		return op.integer(a.(*IntegerValue).v, b.(*IntegerValue).v)
+	case RealKind:
+		return op.real(a.(*RealValue).v, b.(*RealValue).v)
+	case IntervalKind:
+		x, ok1 := toInterval(a)
+		y, ok2 := toInterval(b)
+		if !ok1 || !ok2 || op.interval == nil {
+			return nil, false
+		}
+		return op.interval(x, y)
+	case DualKind:
+		x, y, ok := toDuals(a, b)
+		if !ok || op.dual == nil {
+			return nil, false
+		}
+		return op.dual(x, y)
+	default:
+		return nil, false
+	}
+}
+
+// commonKind returns the kind both operands are promoted to, or BoolKind if
+// there is none
+func commonKind(a, b Value) ValueKind {
+	ka, kb := a.Kind(), b.Kind()
+	if ka == IntegerKind && kb == IntegerKind {
+		return IntegerKind
+	}
+	// integers behave like reals when mixed with another kind
+	if ka == IntegerKind {
+		ka = RealKind
+	}
+	if kb == IntegerKind {
+		kb = RealKind
+	}
+	switch {
+	case ka == RealKind && kb == RealKind:
+		return RealKind
+	case (ka == IntervalKind || ka == RealKind) && (kb == IntervalKind || kb == RealKind):
+		return IntervalKind
+	case (ka == DualKind || ka == RealKind) && (kb == DualKind || kb == RealKind):
+		return DualKind
+	default:
+		return BoolKind
+	}
+}
+
+// promoteInteger converts an integer value to a real value
+func promoteInteger(v Value) Value {
+	if i, ok := v.(*IntegerValue); ok {
+		return NewRealValue(i.Float64())
+	}
+	return v
+}
+
+var addOp = binaryOp{
+	integer: integerAdd,
+	real: func(x, y float64) (Value, bool) {
+		return NewRealValue(x + y), true
+	},
+	interval: intervalAdd,
+	dual:     dualAdd,
+}
+
+var subOp = binaryOp{
+	integer: integerSub,
+	real: func(x, y float64) (Value, bool) {
+		return NewRealValue(x - y), true
+	},
+	interval: intervalSub,
+	dual:     dualSub,
+}
+
+var mulOp = binaryOp{
+	integer: integerMul,
+	real: func(x, y float64) (Value, bool) {
+		return NewRealValue(x * y), true
+	},
+	interval: intervalMul,
+	dual:     dualMul,
+}
+
+var divOp = binaryOp{
+	integer: integerDiv,
+	real: func(x, y float64) (Value, bool) {
+		if y == 0 {
+			return nil, false
+		}
+		return NewRealValue(x / y), true
+	},
+	interval: intervalDiv,
+	dual:     dualDiv,
+}
+
+var powOp = binaryOp{
+	integer: integerPow,
+	real: func(x, y float64) (Value, bool) {
+		return NewRealValue(math.Pow(x, y)), true
+	},
+	interval: intervalPow,
+	dual:     dualPow,
+}
+
+var rootOp = binaryOp{
+	real: func(x, y float64) (Value, bool) {
+		if y == 0 {
+			return nil, false
+		}
+		return NewRealValue(math.Pow(x, 1.0/y)), true
+	},
+	interval: intervalRoot,
+	dual:     dualRoot,
+}
+
+// Add returns a + b
+func Add(a, b Value) (Value, bool) {
+	return addOp.apply(a, b)
+}
+
+// Sub returns a - b
+func Sub(a, b Value) (Value, bool) {
+	return subOp.apply(a, b)
+}
+
+// Mul returns a * b
+func Mul(a, b Value) (Value, bool) {
+	return mulOp.apply(a, b)
+}
+
+// Div returns a / b
+// Division by a real zero fails.
+func Div(a, b Value) (Value, bool) {
+	return divOp.apply(a, b)
+}
+
+// Pow returns base raised to exponent
+func Pow(base, exponent Value) (Value, bool) {
+	return powOp.apply(base, exponent)
+}
+
+// Root returns the degree-th root of radicand
+// A zero degree fails.
+func Root(radicand, degree Value) (Value, bool) {
+	return rootOp.apply(radicand, degree)
+}
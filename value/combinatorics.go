@@ -0,0 +1,147 @@
+package value
+
+import (
+	"math"
+	"math/big"
+)
+
+// maxExactFactorial bounds the arguments evaluated exactly; larger arguments
+// are evaluated with the Gamma function
+const maxExactFactorial = 100000
+
+// exactCount returns the non-negative integer held by v, if it is small
+// enough for exact combinatorics
+func exactCount(v Value) (int64, bool) {
+	if i, ok := v.(*IntegerValue); ok {
+		if i.v.Sign() < 0 || !i.v.IsInt64() || i.v.Int64() > maxExactFactorial {
+			return 0, false
+		}
+		return i.v.Int64(), true
+	}
+	if !IsNonNegativeIntegerReal(v) {
+		return 0, false
+	}
+	n := v.(*RealValue).v
+	if n > maxExactFactorial {
+		return 0, false
+	}
+	return int64(n), true
+}
+
+// lgammaSigned returns ln|Γ(x)| and the sign of Γ(x), failing at the poles
+func lgammaSigned(x float64) (float64, float64, bool) {
+	if x <= 0 && x == math.Trunc(x) {
+		return 0, 0, false
+	}
+	lg, sign := math.Lgamma(x)
+	return lg, float64(sign), true
+}
+
+// Factorial returns n!
+// Non-negative integers give an exact IntegerValue; other reals use Γ(n+1),
+// which fails at negative integers.
+func Factorial(v Value) (Value, bool) {
+	if n, ok := exactCount(v); ok {
+		return &IntegerValue{v: new(big.Int).MulRange(1, n)}, true
+	}
+	real, ok := ToReal(v)
+	if !ok {
+		return nil, false
+	}
+	lg, sign, ok := lgammaSigned(real.v + 1)
+	if !ok {
+		return nil, false
+	}
+	return NewRealValue(sign * math.Exp(lg)), true
+}
+
+// Binomial returns the binomial coefficient n choose k
+// Non-negative integers give an exact IntegerValue (0 when k > n); other
+// reals use Γ(n+1) / (Γ(k+1) Γ(n-k+1)).
+func Binomial(n, k Value) (Value, bool) {
+	exactN, ok1 := exactCount(n)
+	exactK, ok2 := exactCount(k)
+	if ok1 && ok2 {
+		if exactK > exactN {
+			return NewIntegerValue(0), true
+		}
+		return &IntegerValue{v: new(big.Int).Binomial(exactN, exactK)}, true
+	}
+
+	realN, ok1 := ToReal(n)
+	realK, ok2 := ToReal(k)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	return gammaRatio(realN.v+1, realK.v+1, realN.v-realK.v+1)
+}
+
+// Permutations returns the number of ordered selections of k out of n, n!/(n-k)!
+// Non-negative integers give an exact IntegerValue (0 when k > n); other
+// reals use Γ(n+1) / Γ(n-k+1).
+func Permutations(n, k Value) (Value, bool) {
+	exactN, ok1 := exactCount(n)
+	exactK, ok2 := exactCount(k)
+	if ok1 && ok2 {
+		if exactK > exactN {
+			return NewIntegerValue(0), true
+		}
+		return &IntegerValue{v: new(big.Int).MulRange(exactN-exactK+1, exactN)}, true
+	}
+
+	realN, ok1 := ToReal(n)
+	realK, ok2 := ToReal(k)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	return gammaRatio(realN.v+1, realN.v-realK.v+1)
+}
+
+// gammaRatio returns Γ(numerator) / Γ(d1) Γ(d2) ... computed in log space
+// A pole in a denominator makes the ratio zero; a pole in the numerator fails.
+func gammaRatio(numerator float64, denominators ...float64) (Value, bool) {
+	lg, sign, ok := lgammaSigned(numerator)
+	if !ok {
+		return nil, false
+	}
+	for _, d := range denominators {
+		lgd, signd, ok := lgammaSigned(d)
+		if !ok {
+			return NewRealValue(0), true
+		}
+		lg -= lgd
+		sign *= signd
+	}
+	return NewRealValue(sign * math.Exp(lg)), true
+}
+
+// FactorialFloat64 returns x! as a float64, for evaluators working on plain floats
+func FactorialFloat64(x float64) (float64, bool) {
+	if x > 171 {
+		// Beyond the float64 range; avoids building a huge exact product
+		return math.Inf(1), true
+	}
+	return float64Result(Factorial(NewRealValue(x)))
+}
+
+// BinomialFloat64 returns the binomial coefficient n choose k as a float64
+func BinomialFloat64(n, k float64) (float64, bool) {
+	return float64Result(Binomial(NewRealValue(n), NewRealValue(k)))
+}
+
+// PermutationsFloat64 returns n!/(n-k)! as a float64
+func PermutationsFloat64(n, k float64) (float64, bool) {
+	return float64Result(Permutations(NewRealValue(n), NewRealValue(k)))
+}
+
+// float64Result converts the result of a combinatorial function to a float64
+func float64Result(v Value, ok bool) (float64, bool) {
+	if !ok {
+		return 0, false
+	}
+	real, ok := ToReal(v)
+	if !ok {
+		return 0, false
+	}
+	return real.v, true
+}
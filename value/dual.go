@@ -0,0 +1,137 @@
+package value
+
+import "math"
+
+// DualValue is a dual number v + d·ε with one derivative per seed direction
+// Evaluating an expression on dual numbers yields its value together with
+// its directional derivatives (forward-mode automatic differentiation).
+type DualValue struct {
+	Value
+	v float64
+	d []float64
+}
+
+// NewDualValue creates a dual number with value v and derivatives d
+func NewDualValue(v float64, d ...float64) *DualValue {
+	return &DualValue{
+		v: v,
+		d: append([]float64(nil), d...),
+	}
+}
+
+func (x *DualValue) Kind() ValueKind {
+	return DualKind
+}
+
+// Float64 returns the value part
+func (x *DualValue) Float64() float64 {
+	return x.v
+}
+
+// Derivatives returns a copy of the derivative part
+func (x *DualValue) Derivatives() []float64 {
+	return append([]float64(nil), x.d...)
+}
+
+// Derivative returns the derivative in the i-th seed direction
+func (x *DualValue) Derivative(i int) float64 {
+	return x.d[i]
+}
+
+func (x *DualValue) Eval() (Value, bool) {
+	return x, true
+}
+
+func (x *DualValue) Equals(other any) bool {
+	otherDual, ok := other.(*DualValue)
+	if !ok || x.v != otherDual.v || len(x.d) != len(otherDual.d) {
+		return false
+	}
+	for i := range x.d {
+		if x.d[i] != otherDual.d[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// toDuals converts two real or dual values to duals of the same dimension
+// Reals become constants with zero derivatives.
+func toDuals(a, b Value) (*DualValue, *DualValue, bool) {
+	x, ok1 := a.(*DualValue)
+	y, ok2 := b.(*DualValue)
+	switch {
+	case ok1 && ok2:
+		if len(x.d) != len(y.d) {
+			return nil, nil, false
+		}
+		return x, y, true
+	case ok1:
+		real, ok := b.(*RealValue)
+		if !ok {
+			return nil, nil, false
+		}
+		return x, &DualValue{v: real.v, d: make([]float64, len(x.d))}, true
+	case ok2:
+		real, ok := a.(*RealValue)
+		if !ok {
+			return nil, nil, false
+		}
+		return &DualValue{v: real.v, d: make([]float64, len(y.d))}, y, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// combineDerivatives returns ca·x.d + cb·y.d
+func combineDerivatives(ca float64, x *DualValue, cb float64, y *DualValue) []float64 {
+	d := make([]float64, len(x.d))
+	for i := range d {
+		// skip zero terms so that an infinite or NaN coefficient does not
+		// leak into directions the operand does not depend on
+		if x.d[i] != 0 {
+			d[i] += ca * x.d[i]
+		}
+		if y.d[i] != 0 {
+			d[i] += cb * y.d[i]
+		}
+	}
+	return d
+}
+
+func dualAdd(x, y *DualValue) (Value, bool) {
+	return &DualValue{v: x.v + y.v, d: combineDerivatives(1, x, 1, y)}, true
+}
+
+func dualSub(x, y *DualValue) (Value, bool) {
+	return &DualValue{v: x.v - y.v, d: combineDerivatives(1, x, -1, y)}, true
+}
+
+func dualMul(x, y *DualValue) (Value, bool) {
+	return &DualValue{v: x.v * y.v, d: combineDerivatives(y.v, x, x.v, y)}, true
+}
+
+func dualDiv(x, y *DualValue) (Value, bool) {
+	if y.v == 0 {
+		return nil, false
+	}
+	return &DualValue{v: x.v / y.v, d: combineDerivatives(1/y.v, x, -x.v/(y.v*y.v), y)}, true
+}
+
+// dualPow uses d(x^y) = y·x^(y-1)·dx + x^y·ln(x)·dy
+func dualPow(x, y *DualValue) (Value, bool) {
+	v := math.Pow(x.v, y.v)
+	return &DualValue{v: v, d: combineDerivatives(y.v*math.Pow(x.v, y.v-1), x, v*math.Log(x.v), y)}, true
+}
+
+// dualRoot takes the y-th root of x as x^(1/y)
+func dualRoot(x, y *DualValue) (Value, bool) {
+	if y.v == 0 {
+		return nil, false
+	}
+	d := make([]float64, len(y.d))
+	for i := range d {
+		d[i] = -y.d[i] / (y.v * y.v)
+	}
+	return dualPow(x, &DualValue{v: 1 / y.v, d: d})
+}
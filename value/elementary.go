@@ -0,0 +1,347 @@
+package value
+
+import (
+	"math"
+	"math/big"
+)
+
+// unaryOp holds the implementation of a unary function for each value kind
+type unaryOp struct {
+	// integer is optional; integers are evaluated as reals without it
+	integer  func(x *big.Int) (Value, bool)
+	real     func(x float64) (float64, bool)
+	interval func(x *IntervalValue) (Value, bool)
+	// derivative returns f'(x) given x and f(x) for the chain rule on duals
+	derivative func(x, fx float64) float64
+}
+
+// apply dispatches on the kind of v
+func (op unaryOp) apply(v Value) (Value, bool) {
+	switch x := v.(type) {
+	case *IntegerValue:
+		if op.integer != nil {
+			return op.integer(x.v)
+		}
+		return op.apply(NewRealValue(x.Float64()))
+	case *RealValue:
+		result, ok := op.real(x.v)
+		if !ok {
+			return nil, false
+		}
+		return NewRealValue(result), true
+	case *IntervalValue:
+		if op.interval == nil {
+			return nil, false
+		}
+		return op.interval(x)
+	case *DualValue:
+		fx, ok := op.real(x.v)
+		if !ok {
+			return nil, false
+		}
+		slope := op.derivative(x.v, fx)
+		d := make([]float64, len(x.d))
+		for i := range d {
+			if x.d[i] != 0 {
+				d[i] = slope * x.d[i]
+			}
+		}
+		return &DualValue{v: fx, d: d}, true
+	default:
+		return nil, false
+	}
+}
+
+// total wraps a function defined on every real number
+func total(f func(float64) float64) func(float64) (float64, bool) {
+	return func(x float64) (float64, bool) {
+		return f(x), true
+	}
+}
+
+// restricted wraps a function that is only defined where inDomain holds
+func restricted(f func(float64) float64, inDomain func(float64) bool) func(float64) (float64, bool) {
+	return func(x float64) (float64, bool) {
+		if !inDomain(x) {
+			return 0, false
+		}
+		return f(x), true
+	}
+}
+
+// increasing evaluates a non-decreasing function on an interval clipped to [min, max]
+func increasing(f func(float64) float64, min, max float64) func(*IntervalValue) (Value, bool) {
+	return func(x *IntervalValue) (Value, bool) {
+		lo, hi := math.Max(x.lo, min), math.Min(x.hi, max)
+		if lo > hi {
+			return nil, false
+		}
+		return outward(f(lo), f(hi))
+	}
+}
+
+func inUnitInterval(x float64) bool {
+	return -1 <= x && x <= 1
+}
+
+func isPositive(x float64) bool {
+	return x > 0
+}
+
+var (
+	sinOp = unaryOp{
+		real:       total(math.Sin),
+		interval:   periodic(math.Sin, math.Pi/2),
+		derivative: func(x, fx float64) float64 { return math.Cos(x) },
+	}
+	cosOp = unaryOp{
+		real:       total(math.Cos),
+		interval:   periodic(math.Cos, 0),
+		derivative: func(x, fx float64) float64 { return -math.Sin(x) },
+	}
+	tanOp = unaryOp{
+		real:       total(math.Tan),
+		interval:   intervalTan,
+		derivative: func(x, fx float64) float64 { return 1 + fx*fx },
+	}
+	asinOp = unaryOp{
+		real:       restricted(math.Asin, inUnitInterval),
+		interval:   increasing(math.Asin, -1, 1),
+		derivative: func(x, fx float64) float64 { return 1 / math.Sqrt(1-x*x) },
+	}
+	acosOp = unaryOp{
+		real: restricted(math.Acos, inUnitInterval),
+		interval: func(x *IntervalValue) (Value, bool) {
+			lo, hi := math.Max(x.lo, -1), math.Min(x.hi, 1)
+			if lo > hi {
+				return nil, false
+			}
+			return outward(math.Acos(hi), math.Acos(lo))
+		},
+		derivative: func(x, fx float64) float64 { return -1 / math.Sqrt(1-x*x) },
+	}
+	atanOp = unaryOp{
+		real:       total(math.Atan),
+		interval:   increasing(math.Atan, math.Inf(-1), math.Inf(1)),
+		derivative: func(x, fx float64) float64 { return 1 / (1 + x*x) },
+	}
+	sinhOp = unaryOp{
+		real:       total(math.Sinh),
+		interval:   increasing(math.Sinh, math.Inf(-1), math.Inf(1)),
+		derivative: func(x, fx float64) float64 { return math.Cosh(x) },
+	}
+	coshOp = unaryOp{
+		real: total(math.Cosh),
+		interval: func(x *IntervalValue) (Value, bool) {
+			a, b := math.Cosh(x.lo), math.Cosh(x.hi)
+			if x.ContainsZero() {
+				return outward(1, math.Max(a, b))
+			}
+			return outward(math.Min(a, b), math.Max(a, b))
+		},
+		derivative: func(x, fx float64) float64 { return math.Sinh(x) },
+	}
+	tanhOp = unaryOp{
+		real:       total(math.Tanh),
+		interval:   increasing(math.Tanh, math.Inf(-1), math.Inf(1)),
+		derivative: func(x, fx float64) float64 { return 1 - fx*fx },
+	}
+	expOp = unaryOp{
+		real:       total(math.Exp),
+		interval:   increasing(math.Exp, math.Inf(-1), math.Inf(1)),
+		derivative: func(x, fx float64) float64 { return fx },
+	}
+	lnOp = unaryOp{
+		real: restricted(math.Log, isPositive),
+		interval: func(x *IntervalValue) (Value, bool) {
+			if x.hi <= 0 {
+				return nil, false
+			}
+			if x.lo <= 0 {
+				return outward(math.Inf(-1), math.Log(x.hi))
+			}
+			return outward(math.Log(x.lo), math.Log(x.hi))
+		},
+		derivative: func(x, fx float64) float64 { return 1 / x },
+	}
+)
+
+// periodic evaluates sin or cos on an interval
+// peak is the position of a maximum; the function has period 2π with its
+// minimum half a period after each maximum.
+func periodic(f func(float64) float64, peak float64) func(*IntervalValue) (Value, bool) {
+	return func(x *IntervalValue) (Value, bool) {
+		if x.hi-x.lo >= 2*math.Pi || math.IsInf(x.lo, 0) || math.IsInf(x.hi, 0) {
+			return outward(-1, 1)
+		}
+		lo, hi := math.Min(f(x.lo), f(x.hi)), math.Max(f(x.lo), f(x.hi))
+		if containsPeriodicPoint(x, peak, 2*math.Pi) {
+			hi = 1
+		}
+		if containsPeriodicPoint(x, peak+math.Pi, 2*math.Pi) {
+			lo = -1
+		}
+		return outward(lo, hi)
+	}
+}
+
+// containsPeriodicPoint reports whether x contains point + k·period for some integer k
+func containsPeriodicPoint(x *IntervalValue, point, period float64) bool {
+	k := math.Ceil((x.lo - point) / period)
+	return point+k*period <= x.hi
+}
+
+// intervalTan evaluates tan, which is increasing between its poles
+func intervalTan(x *IntervalValue) (Value, bool) {
+	if x.hi-x.lo >= math.Pi || containsPeriodicPoint(x, math.Pi/2, math.Pi) {
+		return entire()
+	}
+	return outward(math.Tan(x.lo), math.Tan(x.hi))
+}
+
+// Sin returns the sine of v
+func Sin(v Value) (Value, bool) {
+	return sinOp.apply(v)
+}
+
+// Cos returns the cosine of v
+func Cos(v Value) (Value, bool) {
+	return cosOp.apply(v)
+}
+
+// Tan returns the tangent of v
+func Tan(v Value) (Value, bool) {
+	return tanOp.apply(v)
+}
+
+// Asin returns the arcsine of v, defined on [-1, 1]
+func Asin(v Value) (Value, bool) {
+	return asinOp.apply(v)
+}
+
+// Acos returns the arccosine of v, defined on [-1, 1]
+func Acos(v Value) (Value, bool) {
+	return acosOp.apply(v)
+}
+
+// Atan returns the arctangent of v
+func Atan(v Value) (Value, bool) {
+	return atanOp.apply(v)
+}
+
+// Sinh returns the hyperbolic sine of v
+func Sinh(v Value) (Value, bool) {
+	return sinhOp.apply(v)
+}
+
+// Cosh returns the hyperbolic cosine of v
+func Cosh(v Value) (Value, bool) {
+	return coshOp.apply(v)
+}
+
+// Tanh returns the hyperbolic tangent of v
+func Tanh(v Value) (Value, bool) {
+	return tanhOp.apply(v)
+}
+
+// Exp returns e raised to v
+func Exp(v Value) (Value, bool) {
+	return expOp.apply(v)
+}
+
+// Ln returns the natural logarithm of v, defined for positive values
+func Ln(v Value) (Value, bool) {
+	return lnOp.apply(v)
+}
+
+// Log returns the logarithm of v to the given base as ln(v) / ln(base)
+// A base of 1 fails like division by zero.
+func Log(base, v Value) (Value, bool) {
+	lnV, ok := Ln(v)
+	if !ok {
+		return nil, false
+	}
+	lnBase, ok := Ln(base)
+	if !ok {
+		return nil, false
+	}
+	return Div(lnV, lnBase)
+}
+
+var (
+	absOp = unaryOp{
+		integer: func(x *big.Int) (Value, bool) {
+			return &IntegerValue{v: new(big.Int).Abs(x)}, true
+		},
+		real: total(math.Abs),
+		interval: func(x *IntervalValue) (Value, bool) {
+			a, b := math.Abs(x.lo), math.Abs(x.hi)
+			if x.ContainsZero() {
+				return NewIntervalValue(0, math.Max(a, b)), true
+			}
+			return NewIntervalValue(math.Min(a, b), math.Max(a, b)), true
+		},
+		derivative: func(x, fx float64) float64 {
+			// the subgradient 0 is used at the kink
+			switch {
+			case x > 0:
+				return 1
+			case x < 0:
+				return -1
+			default:
+				return 0
+			}
+		},
+	}
+	negOp = unaryOp{
+		integer: func(x *big.Int) (Value, bool) {
+			return &IntegerValue{v: new(big.Int).Neg(x)}, true
+		},
+		real: total(func(x float64) float64 { return -x }),
+		interval: func(x *IntervalValue) (Value, bool) {
+			return NewIntervalValue(-x.hi, -x.lo), true
+		},
+		derivative: func(x, fx float64) float64 { return -1 },
+	}
+	floorOp = unaryOp{
+		integer: exactInteger,
+		real:    total(math.Floor),
+		interval: func(x *IntervalValue) (Value, bool) {
+			return NewIntervalValue(math.Floor(x.lo), math.Floor(x.hi)), true
+		},
+		derivative: func(x, fx float64) float64 { return 0 },
+	}
+	ceilOp = unaryOp{
+		integer: exactInteger,
+		real:    total(math.Ceil),
+		interval: func(x *IntervalValue) (Value, bool) {
+			return NewIntervalValue(math.Ceil(x.lo), math.Ceil(x.hi)), true
+		},
+		derivative: func(x, fx float64) float64 { return 0 },
+	}
+)
+
+// exactInteger returns an integer unchanged (rounding is the identity on integers)
+func exactInteger(x *big.Int) (Value, bool) {
+	return &IntegerValue{v: x}, true
+}
+
+// Abs returns the absolute value of v
+func Abs(v Value) (Value, bool) {
+	return absOp.apply(v)
+}
+
+// Neg returns -v
+func Neg(v Value) (Value, bool) {
+	return negOp.apply(v)
+}
+
+// Floor returns the greatest integer not greater than v
+func Floor(v Value) (Value, bool) {
+	return floorOp.apply(v)
+}
+
+// Ceil returns the least integer not less than v
+func Ceil(v Value) (Value, bool) {
+	return ceilOp.apply(v)
+}
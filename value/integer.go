@@ -0,0 +1,162 @@
+package value
+
+import (
+	"math"
+	"math/big"
+)
+
+// IntegerValue is an exact integer of arbitrary size
+// Integers are promoted to reals when combined with a real operand.
+type IntegerValue struct {
+	Value
+	v *big.Int
+}
+
+// NewIntegerValue creates an integer value from an int64
+func NewIntegerValue(value int64) *IntegerValue {
+	return &IntegerValue{
+		v: big.NewInt(value),
+	}
+}
+
+// NewBigIntegerValue creates an integer value from a big.Int
+// The argument is copied.
+func NewBigIntegerValue(value *big.Int) *IntegerValue {
+	return &IntegerValue{
+		v: new(big.Int).Set(value),
+	}
+}
+
+func (i *IntegerValue) Kind() ValueKind {
+	return IntegerKind
+}
+
+// BigInt returns a copy of the integer
+func (i *IntegerValue) BigInt() *big.Int {
+	return new(big.Int).Set(i.v)
+}
+
+// Float64 returns the nearest float64 to the integer
+func (i *IntegerValue) Float64() float64 {
+	f, _ := new(big.Float).SetInt(i.v).Float64()
+	return f
+}
+
+func (i *IntegerValue) Eval() (Value, bool) {
+	return i, true
+}
+
+func (i *IntegerValue) Equals(other any) bool {
+	otherInteger, ok := other.(*IntegerValue)
+	if !ok {
+		return false
+	}
+	return i.v.Cmp(otherInteger.v) == 0
+}
+
+// ToReal converts a real or integer value to a real value
+func ToReal(v Value) (*RealValue, bool) {
+	switch val := v.(type) {
+	case *RealValue:
+		return val, true
+	case *IntegerValue:
+		return NewRealValue(val.Float64()), true
+	default:
+		return nil, false
+	}
+}
+
+// ToInt64 returns the integer held by an integer value or an integral real
+// value, failing when it does not fit in an int64
+func ToInt64(v Value) (int64, bool) {
+	switch val := v.(type) {
+	case *IntegerValue:
+		if !val.v.IsInt64() {
+			return 0, false
+		}
+		return val.v.Int64(), true
+	case *RealValue:
+		if math.Trunc(val.v) != val.v || math.Abs(val.v) >= 1<<63 {
+			return 0, false
+		}
+		return int64(val.v), true
+	default:
+		return 0, false
+	}
+}
+
+// Compare compares two real or integer values
+// It returns -1, 0 or +1, and ok is false for other kinds or NaN operands.
+func Compare(a, b Value) (int, bool) {
+	x, ok1 := a.(*IntegerValue)
+	y, ok2 := b.(*IntegerValue)
+	if ok1 && ok2 {
+		return x.v.Cmp(y.v), true
+	}
+
+	realA, ok1 := ToReal(a)
+	realB, ok2 := ToReal(b)
+	if !ok1 || !ok2 || math.IsNaN(realA.v) || math.IsNaN(realB.v) {
+		return 0, false
+	}
+	switch {
+	case realA.v < realB.v:
+		return -1, true
+	case realA.v > realB.v:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+// toExactInteger returns the integer held by an integer value or by an
+// integral real value
+func toExactInteger(v Value) (*big.Int, bool) {
+	switch val := v.(type) {
+	case *IntegerValue:
+		return val.v, true
+	case *RealValue:
+		if !IsIntegerReal(val) {
+			return nil, false
+		}
+		return big.NewInt(int64(val.v)), true
+	default:
+		return nil, false
+	}
+}
+
+// maxExactExponentBits bounds the size of exact powers; larger results are
+// computed in floating point instead
+const maxExactExponentBits = 1 << 20
+
+func integerAdd(x, y *big.Int) (Value, bool) {
+	return &IntegerValue{v: new(big.Int).Add(x, y)}, true
+}
+
+func integerSub(x, y *big.Int) (Value, bool) {
+	return &IntegerValue{v: new(big.Int).Sub(x, y)}, true
+}
+
+func integerMul(x, y *big.Int) (Value, bool) {
+	return &IntegerValue{v: new(big.Int).Mul(x, y)}, true
+}
+
+// integerDiv stays exact when y divides x and falls back to a real quotient otherwise
+func integerDiv(x, y *big.Int) (Value, bool) {
+	if y.Sign() == 0 {
+		return nil, false
+	}
+	quotient, remainder := new(big.Int).QuoRem(x, y, new(big.Int))
+	if remainder.Sign() == 0 {
+		return &IntegerValue{v: quotient}, true
+	}
+	return NewRealValue((&IntegerValue{v: x}).Float64() / (&IntegerValue{v: y}).Float64()), true
+}
+
+// integerPow stays exact for non-negative exponents with a moderate result size
+func integerPow(x, y *big.Int) (Value, bool) {
+	if y.Sign() >= 0 && y.IsInt64() && int64(x.BitLen())*y.Int64() <= maxExactExponentBits {
+		return &IntegerValue{v: new(big.Int).Exp(x, y, nil)}, true
+	}
+	return NewRealValue(math.Pow((&IntegerValue{v: x}).Float64(), (&IntegerValue{v: y}).Float64())), true
+}
@@ -0,0 +1,198 @@
+package value
+
+import "math"
+
+// IntervalValue is a closed interval [lo, hi] of real numbers
+// Arithmetic on intervals rounds outward so that the result always
+// contains every value the operation can take on the operands.
+type IntervalValue struct {
+	Value
+	lo, hi float64
+}
+
+// NewIntervalValue creates the interval [lo, hi]
+// The bounds are swapped if lo > hi.
+func NewIntervalValue(lo, hi float64) *IntervalValue {
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return &IntervalValue{
+		lo: lo,
+		hi: hi,
+	}
+}
+
+func (i *IntervalValue) Kind() ValueKind {
+	return IntervalKind
+}
+
+// Lo returns the lower bound
+func (i *IntervalValue) Lo() float64 {
+	return i.lo
+}
+
+// Hi returns the upper bound
+func (i *IntervalValue) Hi() float64 {
+	return i.hi
+}
+
+// Contains reports whether x lies in the interval
+func (i *IntervalValue) Contains(x float64) bool {
+	return i.lo <= x && x <= i.hi
+}
+
+// ContainsZero reports whether 0 lies in the interval
+func (i *IntervalValue) ContainsZero() bool {
+	return i.Contains(0)
+}
+
+func (i *IntervalValue) Eval() (Value, bool) {
+	return i, true
+}
+
+func (i *IntervalValue) Equals(other any) bool {
+	otherInterval, ok := other.(*IntervalValue)
+	if !ok {
+		return false
+	}
+	return i.lo == otherInterval.lo && i.hi == otherInterval.hi
+}
+
+// toInterval converts a real or interval value to an interval
+func toInterval(v Value) (*IntervalValue, bool) {
+	switch val := v.(type) {
+	case *IntervalValue:
+		return val, true
+	case *RealValue:
+		return NewIntervalValue(val.v, val.v), true
+	default:
+		return nil, false
+	}
+}
+
+// outward returns [lo, hi] widened by one ulp on each side
+// NaN bounds mean the operation is undefined somewhere in the operands.
+func outward(lo, hi float64) (Value, bool) {
+	if math.IsNaN(lo) || math.IsNaN(hi) {
+		return nil, false
+	}
+	return NewIntervalValue(math.Nextafter(lo, math.Inf(-1)), math.Nextafter(hi, math.Inf(1))), true
+}
+
+// entire is the interval containing every real number
+func entire() (Value, bool) {
+	return NewIntervalValue(math.Inf(-1), math.Inf(1)), true
+}
+
+// hull returns the smallest and largest of the candidates
+// 0 * ±Inf is taken to be 0, which is its limit inside a bounded interval.
+func hull(candidates ...float64) (float64, float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, c := range candidates {
+		if math.IsNaN(c) {
+			c = 0
+		}
+		lo = math.Min(lo, c)
+		hi = math.Max(hi, c)
+	}
+	return lo, hi
+}
+
+func intervalAdd(x, y *IntervalValue) (Value, bool) {
+	return outward(x.lo+y.lo, x.hi+y.hi)
+}
+
+func intervalSub(x, y *IntervalValue) (Value, bool) {
+	return outward(x.lo-y.hi, x.hi-y.lo)
+}
+
+func intervalMul(x, y *IntervalValue) (Value, bool) {
+	return outward(hull(x.lo*y.lo, x.lo*y.hi, x.hi*y.lo, x.hi*y.hi))
+}
+
+// intervalDiv divides x by y
+// A divisor of exactly [0, 0] fails like real division by zero. A divisor
+// that merely contains zero yields the half-lines or the whole real line
+// covering all quotients with a non-zero divisor.
+func intervalDiv(x, y *IntervalValue) (Value, bool) {
+	if y.lo == 0 && y.hi == 0 {
+		return nil, false
+	}
+	if !y.ContainsZero() {
+		return outward(hull(x.lo/y.lo, x.lo/y.hi, x.hi/y.lo, x.hi/y.hi))
+	}
+	if x.lo == 0 && x.hi == 0 {
+		return NewIntervalValue(0, 0), true
+	}
+
+	inf := math.Inf(1)
+	switch {
+	case y.lo == 0 && x.lo >= 0: // [+, +] / [0, d]
+		return outward(x.lo/y.hi, inf)
+	case y.lo == 0 && x.hi <= 0: // [-, -] / [0, d]
+		return outward(-inf, x.hi/y.hi)
+	case y.hi == 0 && x.lo >= 0: // [+, +] / [c, 0]
+		return outward(-inf, x.lo/y.lo)
+	case y.hi == 0 && x.hi <= 0: // [-, -] / [c, 0]
+		return outward(x.hi/y.lo, inf)
+	default:
+		return entire()
+	}
+}
+
+// intervalPow raises x to the power y
+// Point integer exponents allow negative bases. Other exponents are only
+// defined for non-negative bases, so the base is clipped to [0, hi].
+func intervalPow(x, y *IntervalValue) (Value, bool) {
+	if y.lo == y.hi && y.lo == math.Trunc(y.lo) && !math.IsInf(y.lo, 0) {
+		return intervalIntPow(x, y.lo)
+	}
+
+	if x.hi < 0 {
+		return nil, false
+	}
+	base := NewIntervalValue(math.Max(x.lo, 0), x.hi)
+	// x^y is monotone in x and in y on x >= 0, so the extremes are at the corners
+	return outward(hull(
+		math.Pow(base.lo, y.lo), math.Pow(base.lo, y.hi),
+		math.Pow(base.hi, y.lo), math.Pow(base.hi, y.hi),
+	))
+}
+
+// intervalIntPow raises x to an integer power n
+func intervalIntPow(x *IntervalValue, n float64) (Value, bool) {
+	if n < 0 {
+		positive, ok := intervalIntPow(x, -n)
+		if !ok {
+			return nil, false
+		}
+		return intervalDiv(NewIntervalValue(1, 1), positive.(*IntervalValue))
+	}
+
+	a, b := math.Pow(x.lo, n), math.Pow(x.hi, n)
+	if math.Mod(n, 2) == 1 || x.lo >= 0 {
+		// odd powers and non-negative bases are increasing
+		return outward(a, b)
+	}
+	if x.hi <= 0 {
+		return outward(b, a)
+	}
+	// even power of an interval containing zero
+	return outward(0, math.Max(a, b))
+}
+
+// intervalRoot takes the y-th root of x as x^(1/y)
+func intervalRoot(x, y *IntervalValue) (Value, bool) {
+	if y.ContainsZero() {
+		return nil, false
+	}
+	if y.lo == y.hi {
+		// use the same exponent as real evaluation for point degrees
+		return intervalPow(x, NewIntervalValue(1.0/y.lo, 1.0/y.lo))
+	}
+	exponent, ok := intervalDiv(NewIntervalValue(1, 1), y)
+	if !ok {
+		return nil, false
+	}
+	return intervalPow(x, exponent.(*IntervalValue))
+}
@@ -12,6 +12,15 @@ const (
 
 	// 真偽値
 	BoolKind
+
+	// 区間
+	IntervalKind
+
+	// 二重数（自動微分用）
+	DualKind
+
+	// 多倍長整数
+	IntegerKind
 )
 
 type RealValue struct {